@@ -0,0 +1,252 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+)
+
+const (
+	defaultPasswordResetTTL     = time.Hour
+	defaultEmailVerificationTTL = 48 * time.Hour
+)
+
+// forgotPasswordMessage is returned whether or not the email exists so the
+// endpoint cannot be used to find out who has an account
+const forgotPasswordMessage = "If an account exists for this email, a password reset link has been sent"
+
+// resendVerificationMessage is returned whether or not the email exists
+const resendVerificationMessage = "If this email needs to be verified, a verification link has been sent"
+
+// sendVerificationEmail issues an email verification token for the user and
+// emails its link
+func (ac *AuthController) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	ttl := ac.Settings.EmailVerificationTTL
+	if ttl <= 0 {
+		ttl = defaultEmailVerificationTTL
+	}
+
+	token, err := models.IssueUserToken(ac.DB, user, models.EmailVerificationToken, ttl)
+	if err != nil {
+		return err
+	}
+
+	return sendEmail(ctx, ac.Mailer, user.Email, "", emailVerificationEmail, map[string]string{
+		"Name":      user.Name,
+		"Email":     user.Email,
+		"Link":      frontendLink(ac.BaseURL, "/verify-email", token),
+		"ExpiresAt": time.Now().Add(ttl).UTC().Format(emailDateLayout),
+	})
+}
+
+// ForgotPassword emails a single-use password reset link
+func (ac *AuthController) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Only active accounts can reset their password
+	var user models.User
+	if err := ac.DB.Where("email = ? AND status = ?", req.Email, models.StatusActive).First(&user).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch user",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": forgotPasswordMessage,
+		})
+		return
+	}
+
+	ttl := ac.Settings.PasswordResetTTL
+	if ttl <= 0 {
+		ttl = defaultPasswordResetTTL
+	}
+
+	token, err := models.IssueUserToken(ac.DB, &user, models.PasswordResetToken, ttl)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create reset token",
+		})
+		return
+	}
+
+	if err := sendEmail(c.Request.Context(), ac.Mailer, user.Email, "", passwordResetEmail, map[string]string{
+		"Name":      user.Name,
+		"Link":      frontendLink(ac.BaseURL, "/reset-password", token),
+		"ExpiresAt": time.Now().Add(ttl).UTC().Format(emailDateLayout),
+	}); err != nil {
+		// Failing here would tell that the account exists
+		log.Printf("failed to send password reset email to user %d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": forgotPasswordMessage,
+	})
+}
+
+// ResetPassword sets a new password using a token from ForgotPassword
+func (ac *AuthController) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	err := ac.DB.Transaction(func(tx *gorm.DB) error {
+		userToken, err := models.ConsumeUserToken(tx, req.Token, models.PasswordResetToken)
+		if err != nil {
+			return err
+		}
+
+		user := userToken.User
+		if user.Status != models.StatusActive {
+			return models.ErrInvalidUserToken
+		}
+
+		// Rolling back keeps the token usable for another attempt
+		if fieldErrors := passwordFieldErrors(ac.Passwords, "new_password", req.NewPassword, user.Name, user.Email); len(fieldErrors) > 0 {
+			return &passwordPolicyError{Fields: fieldErrors}
+		}
+
+		if err := user.SetPassword(req.NewPassword); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"password_hash": user.PasswordHash}
+
+		// The reset link was delivered to the address, so it is verified
+		if !user.IsEmailVerified() {
+			updates["email_verified_at"] = time.Now()
+		}
+
+		return tx.Model(&user).Updates(updates).Error
+	})
+	if err != nil {
+		var policyErr *passwordPolicyError
+		if errors.As(err, &policyErr) {
+			respondPasswordPolicy(c, policyErr.Fields)
+		} else if errors.Is(err, models.ErrInvalidUserToken) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid or expired reset token",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to reset password",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password reset successfully",
+	})
+}
+
+// VerifyEmail confirms the email address of a user
+func (ac *AuthController) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var user models.User
+	err := ac.DB.Transaction(func(tx *gorm.DB) error {
+		userToken, err := models.ConsumeUserToken(tx, req.Token, models.EmailVerificationToken)
+		if err != nil {
+			return err
+		}
+
+		user = userToken.User
+		if user.IsEmailVerified() {
+			return nil
+		}
+
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		return tx.Model(&user).Update("email_verified_at", now).Error
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidUserToken) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid or expired verification token",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to verify email",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email verified successfully",
+		"user":    user.ToResponse(),
+	})
+}
+
+// ResendVerification emails a new verification link. It does not require
+// authentication since login may be blocked until the email is verified.
+func (ac *AuthController) ResendVerification(c *gin.Context) {
+	var req models.ResendVerificationRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var user models.User
+	if err := ac.DB.Where("email = ? AND status = ? AND email_verified_at IS NULL", req.Email, models.StatusActive).
+		First(&user).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch user",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": resendVerificationMessage,
+		})
+		return
+	}
+
+	// Failing here would tell that the account exists
+	if err := ac.sendVerificationEmail(c.Request.Context(), &user); err != nil {
+		log.Printf("failed to send verification email to user %d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": resendVerificationMessage,
+	})
+}
@@ -1,8 +1,6 @@
 package controller
 
 import (
-	"crypto/rand"
-	"math/big"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,10 +9,13 @@ import (
 	"gorm.io/gorm"
 
 	"sami/models"
+	"sami/pkg/config"
 )
 
 type AdminController struct {
-	DB *gorm.DB
+	DB          *gorm.DB
+	Invitations *InvitationController
+	Settings    config.AuthConfig
 }
 
 // GetProjectHistory lists all change events for a specific project
@@ -61,17 +62,11 @@ func (ac *AdminController) GetProjectHistory(c *gin.Context) {
 	}
 
 	// Check if user has access to this project
-	hasAccess := project.OwnerID == user.ID || project.Visibility == "public"
-	if !hasAccess {
-		// Check if user is a collaborator
-		var collaborator models.ProjectCollaborator
-		if err := ac.DB.Where("project_id = ? AND user_id = ? AND state = ?",
-			projectID, user.ID, models.StatusActive).First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
-			})
-			return
-		}
+	if !models.GetProjectAccess(ac.DB, &project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
 	}
 
 	// Get query parameters for pagination and filtering
@@ -378,7 +373,9 @@ func (ac *AdminController) UpdateUser(c *gin.Context) {
 	})
 }
 
-// DeleteUser soft deletes a user (admin only)
+// DeleteUser soft deletes a user (admin only). Projects owned by the user are
+// handed to ?transfer_to=<user id>, or else to their longest-standing
+// co-owner; the deletion is refused when a project would be left without owner.
 func (ac *AdminController) DeleteUser(c *gin.Context) {
 	// Get authenticated user
 	userInterface, exists := c.Get("user")
@@ -437,36 +434,78 @@ func (ac *AdminController) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	// Soft delete the user
-	if err := ac.DB.Delete(&userToDelete).Error; err != nil {
+	// Resolve the explicit successor, if any
+	var transferTo uint
+	if param := c.Query("transfer_to"); param != "" {
+		successorID, err := strconv.Atoi(param)
+		if err != nil || successorID == userID {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid transfer_to user ID",
+			})
+			return
+		}
+
+		var successor models.User
+		if err := ac.DB.First(&successor, successorID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Transfer user not found",
+				})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to fetch user",
+				})
+			}
+			return
+		}
+		if successor.Status != models.StatusActive {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Ownership can only be transferred to active users",
+			})
+			return
+		}
+		transferTo = successor.ID
+	}
+
+	// Find a new owner for every project of the user
+	ownedProjects, successors, orphanedProjects, err := models.PlanOwnershipSuccession(ac.DB, userToDelete.ID, transferTo)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete user",
+			"error": "Failed to resolve project co-owners",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "User deleted successfully",
-	})
-}
-
-// generateRandomPassword generates a random password with specified length
-func generateRandomPassword(length int) (string, error) {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-
-	password := make([]byte, length)
-	for i := range password {
-		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
-		if err != nil {
-			return "", err
+	if len(orphanedProjects) > 0 {
+		orphaned := make([]gin.H, 0, len(orphanedProjects))
+		for _, project := range orphanedProjects {
+			orphaned = append(orphaned, gin.H{"id": project.ID, "name": project.Name})
 		}
-		password[i] = charset[num.Int64()]
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    "User owns projects without a co-owner, provide transfer_to",
+			"projects": orphaned,
+		})
+		return
 	}
 
-	return string(password), nil
+	// Force the transfers and soft delete the user
+	err = ac.DB.Transaction(func(tx *gorm.DB) error {
+		return models.DeleteUserWithSuccession(tx, &userToDelete, ownedProjects, successors, authUser.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete user",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "User deleted successfully",
+		"transferred_projects": len(ownedProjects),
+	})
 }
 
-// InviteUser creates a new user with a random password (admin only)
+// InviteUser emails an invitation to create an account (admin only)
 func (ac *AdminController) InviteUser(c *gin.Context) {
 	// Get authenticated user
 	userInterface, exists := c.Get("user")
@@ -494,11 +533,7 @@ func (ac *AdminController) InviteUser(c *gin.Context) {
 	}
 
 	// Parse request body
-	var inviteData struct {
-		Name  string      `json:"name" binding:"required"`
-		Email string      `json:"email" binding:"required,email"`
-		Role  models.Role `json:"role,omitempty"`
-	}
+	var inviteData models.InviteUserRequest
 
 	if err := c.ShouldBindJSON(&inviteData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -534,43 +569,38 @@ func (ac *AdminController) InviteUser(c *gin.Context) {
 		return
 	}
 
-	// Generate random password (12 characters)
-	randomPassword, err := generateRandomPassword(12)
+	// Only one pending invitation per email
+	pending, err := ac.Invitations.hasPendingInvitation(inviteData.Email, nil)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate password",
+			"error": "Failed to check pending invitations",
 		})
 		return
 	}
-
-	// Create new user
-	newUser := models.User{
-		Name:   inviteData.Name,
-		Email:  inviteData.Email,
-		Role:   inviteData.Role,
-		Status: models.StatusActive,
-	}
-
-	// Set hashed password
-	if err := newUser.SetPassword(randomPassword); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to process password",
+	if pending {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "An invitation is already pending for this email",
 		})
 		return
 	}
 
-	// Save to database
-	if err := ac.DB.Create(&newUser).Error; err != nil {
+	// Create invitation and email its link; the user sets their own password
+	invitation := models.Invitation{
+		Email:    inviteData.Email,
+		Name:     inviteData.Name,
+		UserRole: inviteData.Role,
+	}
+	if err := ac.Invitations.createInvitation(c.Request.Context(), &invitation, authUser, ""); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create user",
+			"error": "Failed to send invitation",
 		})
 		return
 	}
 
-	// Return success response with generated password
+	invitation.Inviter = *authUser
+
 	c.JSON(http.StatusCreated, gin.H{
-		"message":  "User invited successfully",
-		"user":     newUser.ToResponse(),
-		"password": randomPassword, // Return password for admin to share
+		"message":    "User invited successfully",
+		"invitation": invitation.ToResponse(),
 	})
 }
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+)
+
+// securitySettings returns the current security settings
+func (ac *AdminController) securitySettings() models.SecuritySettings {
+	return models.SecuritySettings{
+		RequireAdminTwoFactor: models.GetBoolSetting(ac.DB, models.SettingRequireAdminTwoFactor, ac.Settings.RequireAdminTwoFactor),
+	}
+}
+
+// ResetUserTwoFactor removes the 2FA secret and recovery codes of a user who
+// lost their authenticator (admin only)
+func (ac *AdminController) ResetUserTwoFactor(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	authUser, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Check if user is admin
+	if authUser.Role != models.AdminRole {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Admin access required",
+		})
+		return
+	}
+
+	// Get user ID from URL
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	var user models.User
+	if err := ac.DB.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch user",
+			})
+		}
+		return
+	}
+
+	if err := ac.DB.Transaction(func(tx *gorm.DB) error {
+		return models.DeleteUserTwoFactor(tx, user.ID)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to reset two-factor authentication",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication reset successfully",
+		"user":    user.ToResponse(),
+	})
+}
+
+// GetSecuritySettings returns the instance security settings (admin only)
+func (ac *AdminController) GetSecuritySettings(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	authUser, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Check if user is admin
+	if authUser.Role != models.AdminRole {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Admin access required",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings": ac.securitySettings(),
+	})
+}
+
+// UpdateSecuritySettings changes the instance security settings (admin only).
+// Requiring 2FA for admins is refused while the caller has not enrolled, so
+// they cannot lock themselves out.
+func (ac *AdminController) UpdateSecuritySettings(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	authUser, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Check if user is admin
+	if authUser.Role != models.AdminRole {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Admin access required",
+		})
+		return
+	}
+
+	var req models.UpdateSecuritySettingsRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.RequireAdminTwoFactor != nil {
+		if *req.RequireAdminTwoFactor {
+			enabled, err := models.HasTwoFactorEnabled(ac.DB, authUser.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to fetch two-factor settings",
+				})
+				return
+			}
+			if !enabled {
+				c.JSON(http.StatusConflict, gin.H{
+					"error": "Enable two-factor authentication on your account first",
+				})
+				return
+			}
+		}
+
+		if err := models.SetSetting(ac.DB, models.SettingRequireAdminTwoFactor,
+			strconv.FormatBool(*req.RequireAdminTwoFactor), authUser.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update settings",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Settings updated successfully",
+		"settings": ac.securitySettings(),
+	})
+}
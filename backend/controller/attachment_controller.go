@@ -0,0 +1,745 @@
+package controller
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sami/models"
+	"sami/pkg/config"
+	"sami/pkg/storage"
+)
+
+type AttachmentController struct {
+	DB       *gorm.DB
+	Storage  storage.Storage
+	Settings config.AttachmentConfig
+}
+
+// errQuotaExceeded is returned when an upload does not fit in the project
+var errQuotaExceeded = errors.New("project attachment quota exceeded")
+
+// UploadCommentAttachment attaches a file to a comment. The author of the
+// comment and the editors of the project can attach files.
+func (ac *AttachmentController) UploadCommentAttachment(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get comment ID from URL
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid comment ID",
+		})
+		return
+	}
+
+	var comment models.Comment
+	if err := ac.DB.Preload("Project").Where("status = ?", "active").First(&comment, commentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Comment not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch comment",
+			})
+		}
+		return
+	}
+
+	access := models.GetProjectAccess(ac.DB, &comment.Project, user)
+	if !access.CanView() || (comment.UserID != user.ID && !access.CanEdit()) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You can only attach files to your own comments or comments in projects you can edit",
+		})
+		return
+	}
+	if !canComment(c, ac.DB, &comment.Project, user, access) || !threadOpen(c, ac.DB, &comment, access) {
+		return
+	}
+
+	ac.upload(c, user, &comment.Project, &models.Attachment{CommentID: &comment.ID})
+}
+
+// UploadServiceAttachment attaches a file to a service
+func (ac *AttachmentController) UploadServiceAttachment(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get service ID from URL
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid service ID",
+		})
+		return
+	}
+
+	var service models.Service
+	if err := ac.DB.Preload("Project").First(&service, serviceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Service not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch service",
+			})
+		}
+		return
+	}
+
+	if !models.GetProjectAccess(ac.DB, &service.Project, user).CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	ac.upload(c, user, &service.Project, &models.Attachment{ServiceID: &service.ID})
+}
+
+// upload checks the uploaded file against the size, type and quota limits,
+// stores it and records attachment
+func (ac *AttachmentController) upload(c *gin.Context, user *models.User, project *models.Project, attachment *models.Attachment) {
+	maxSize := ac.Settings.MaxSizeMB << 20
+	quota := ac.Settings.ProjectQuotaMB << 20
+
+	// Leave room for the multipart envelope around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("Files are limited to %d MB", ac.Settings.MaxSizeMB),
+			})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "A file is required in the \"file\" field",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+	if fileHeader.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("Files are limited to %d MB", ac.Settings.MaxSizeMB),
+		})
+		return
+	}
+	if fileHeader.Size == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "The file is empty",
+		})
+		return
+	}
+
+	// Fail early when the file cannot fit, before storing it
+	_, used, err := models.ProjectAttachmentUsage(ac.DB, project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check project quota",
+		})
+		return
+	}
+	if used+fileHeader.Size > quota {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("The project attachment quota of %d MB is exceeded", ac.Settings.ProjectQuotaMB),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read the file",
+		})
+		return
+	}
+	defer file.Close()
+
+	// The type is detected from the content, not trusted from the client
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read the file",
+		})
+		return
+	}
+	fileName := models.CleanFileName(fileHeader.Filename)
+	contentType, allowed := models.AttachmentContentType(http.DetectContentType(head[:n]), fileName, ac.Settings.AllowedTypeList())
+	if !allowed {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": fmt.Sprintf("Files of type %s are not allowed", contentType),
+		})
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to read the file",
+		})
+		return
+	}
+
+	key, err := models.NewAttachmentKey(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to store the file",
+		})
+		return
+	}
+
+	hash := sha256.New()
+	if err := ac.Storage.Put(c.Request.Context(), key, io.TeeReader(file, hash), fileHeader.Size, contentType); err != nil {
+		log.Printf("Error storing attachment %s: %v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to store the file",
+		})
+		return
+	}
+
+	attachment.ProjectID = &project.ID
+	attachment.UploadedBy = &user.ID
+	attachment.FileName = fileName
+	attachment.ContentType = contentType
+	attachment.Size = fileHeader.Size
+	attachment.Checksum = hex.EncodeToString(hash.Sum(nil))
+	attachment.StorageKey = key
+
+	// Check the quota again with the project locked, so concurrent uploads
+	// cannot exceed it together
+	err = ac.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Project{}, project.ID).Error; err != nil {
+			return err
+		}
+		_, used, err := models.ProjectAttachmentUsage(tx, project.ID)
+		if err != nil {
+			return err
+		}
+		if used+attachment.Size > quota {
+			return errQuotaExceeded
+		}
+		return tx.Create(attachment).Error
+	})
+	if err != nil {
+		if deleteErr := ac.Storage.Delete(c.Request.Context(), key); deleteErr != nil {
+			log.Printf("Error removing attachment %s: %v", key, deleteErr)
+		}
+		if errors.Is(err, errQuotaExceeded) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("The project attachment quota of %d MB is exceeded", ac.Settings.ProjectQuotaMB),
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to save attachment",
+			})
+		}
+		return
+	}
+
+	attachment.Uploader = user
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "File uploaded successfully",
+		"attachment": attachment.ToResponse(ac.downloadURL(attachment, user.ID)),
+	})
+}
+
+// GetCommentAttachments lists the files attached to a comment
+func (ac *AttachmentController) GetCommentAttachments(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get comment ID from URL
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid comment ID",
+		})
+		return
+	}
+
+	var comment models.Comment
+	if err := ac.DB.Preload("Project").Where("status = ?", "active").First(&comment, commentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Comment not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch comment",
+			})
+		}
+		return
+	}
+
+	if !models.GetProjectAccess(ac.DB, &comment.Project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	ac.respondAttachments(c, user, ac.DB.Where("comment_id = ?", comment.ID))
+}
+
+// GetServiceAttachments lists the files attached to a service
+func (ac *AttachmentController) GetServiceAttachments(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get service ID from URL
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid service ID",
+		})
+		return
+	}
+
+	var service models.Service
+	if err := ac.DB.Preload("Project").First(&service, serviceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Service not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch service",
+			})
+		}
+		return
+	}
+
+	if !models.GetProjectAccess(ac.DB, &service.Project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	ac.respondAttachments(c, user, ac.DB.Where("service_id = ?", service.ID))
+}
+
+func (ac *AttachmentController) respondAttachments(c *gin.Context, user *models.User, query *gorm.DB) {
+	var attachments []models.Attachment
+	if err := query.Preload("Uploader").Order("created_at ASC, id ASC").Find(&attachments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch attachments",
+		})
+		return
+	}
+
+	attachmentResponses := make([]models.AttachmentResponse, 0, len(attachments))
+	for i := range attachments {
+		attachmentResponses = append(attachmentResponses, attachments[i].ToResponse(ac.downloadURL(&attachments[i], user.ID)))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attachments": attachmentResponses,
+	})
+}
+
+// GetProjectAttachmentUsage reports the space used by the attachments of a
+// project and its quota
+func (ac *AttachmentController) GetProjectAttachmentUsage(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	var project models.Project
+	if err := ac.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	if !models.GetProjectAccess(ac.DB, &project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	count, used, err := models.ProjectAttachmentUsage(ac.DB, project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch attachment usage",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"usage": models.AttachmentUsage{
+			ProjectID: project.ID,
+			Count:     count,
+			Used:      used,
+			Quota:     ac.Settings.ProjectQuotaMB << 20,
+		},
+	})
+}
+
+// findAttachment loads an attachment whose parent still exists, and its
+// project. It responds and returns false when there is none.
+func (ac *AttachmentController) findAttachment(c *gin.Context) (*models.Attachment, *models.Project, bool) {
+	// Get attachment ID from URL
+	attachmentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid attachment ID",
+		})
+		return nil, nil, false
+	}
+
+	var attachment models.Attachment
+	if err := ac.DB.Preload("Uploader").Where("NOT ("+models.OrphanedAttachmentsSQL+")").
+		First(&attachment, attachmentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Attachment not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch attachment",
+			})
+		}
+		return nil, nil, false
+	}
+
+	var project models.Project
+	if err := ac.DB.First(&project, *attachment.ProjectID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch project",
+		})
+		return nil, nil, false
+	}
+
+	return &attachment, &project, true
+}
+
+// GetAttachment returns the details of an attachment
+func (ac *AttachmentController) GetAttachment(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	attachment, project, found := ac.findAttachment(c)
+	if !found {
+		return
+	}
+
+	if !models.GetProjectAccess(ac.DB, project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attachment": attachment.ToResponse(ac.downloadURL(attachment, user.ID)),
+	})
+}
+
+// DownloadAttachment sends the file of an attachment to an authenticated
+// user who can view its project
+func (ac *AttachmentController) DownloadAttachment(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	attachment, project, found := ac.findAttachment(c)
+	if !found {
+		return
+	}
+
+	if !models.GetProjectAccess(ac.DB, project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	ac.serve(c, attachment)
+}
+
+// DownloadSignedAttachment sends the file of an attachment through a signed
+// download link, for browsers that cannot send the Authorization header
+// (images, links). Access to the project is checked again for the user the
+// link was made for, so links stop working when access is revoked.
+func (ac *AttachmentController) DownloadSignedAttachment(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Query("user"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid download link",
+		})
+		return
+	}
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid download link",
+		})
+		return
+	}
+	attachmentID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || !hmac.Equal([]byte(c.Query("signature")), []byte(downloadSignature(uint(attachmentID), uint(userID), expires))) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid download link",
+		})
+		return
+	}
+	if time.Now().Unix() > expires {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Download link has expired",
+		})
+		return
+	}
+
+	var user models.User
+	if err := ac.DB.Where("id = ? AND status = ?", userID, models.StatusActive).First(&user).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid download link",
+		})
+		return
+	}
+
+	attachment, project, found := ac.findAttachment(c)
+	if !found {
+		return
+	}
+
+	if !models.GetProjectAccess(ac.DB, project, &user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	ac.serve(c, attachment)
+}
+
+// serve streams the stored file. Only images and PDFs are shown inline,
+// anything else is downloaded.
+func (ac *AttachmentController) serve(c *gin.Context, attachment *models.Attachment) {
+	file, err := ac.Storage.Open(c.Request.Context(), attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "File not found",
+			})
+		} else {
+			log.Printf("Error reading attachment %s: %v", attachment.StorageKey, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to read file",
+			})
+		}
+		return
+	}
+	defer file.Close()
+
+	disposition := "attachment"
+	if strings.HasPrefix(attachment.ContentType, "image/") || attachment.ContentType == "application/pdf" {
+		disposition = "inline"
+	}
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, file, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=300",
+		"ETag":                   `"` + attachment.Checksum + `"`,
+	})
+}
+
+// DeleteAttachment removes an attachment. Uploaders can remove their files,
+// project editors any file.
+func (ac *AttachmentController) DeleteAttachment(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	attachment, project, found := ac.findAttachment(c)
+	if !found {
+		return
+	}
+
+	access := models.GetProjectAccess(ac.DB, project, user)
+	isUploader := attachment.UploadedBy != nil && *attachment.UploadedBy == user.ID
+	if !access.CanView() || (!isUploader && !access.CanEdit()) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You can only delete your own files or files in projects you can edit",
+		})
+		return
+	}
+
+	// Remove the file first so no stored file is left without its record
+	if err := ac.Storage.Delete(c.Request.Context(), attachment.StorageKey); err != nil {
+		log.Printf("Error removing attachment %s: %v", attachment.StorageKey, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete file",
+		})
+		return
+	}
+	if err := ac.DB.Delete(attachment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete attachment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Attachment deleted successfully",
+	})
+}
+
+// downloadURL returns a signed download link of an attachment for a user
+func (ac *AttachmentController) downloadURL(attachment *models.Attachment, userID uint) string {
+	expires := time.Now().Add(ac.Settings.URLTTL).Unix()
+	return fmt.Sprintf("/attachments/%d/content?user=%d&expires=%d&signature=%s",
+		attachment.ID, userID, expires, downloadSignature(attachment.ID, userID, expires))
+}
+
+// downloadSignature signs a download link with the token secret
+func downloadSignature(attachmentID, userID uint, expires int64) string {
+	mac := hmac.New(sha256.New, tokenSecret())
+	fmt.Fprintf(mac, "attachment:%d:%d:%d", attachmentID, userID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
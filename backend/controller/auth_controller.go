@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"log"
 	"net/http"
 	"os"
 	"strconv"
@@ -11,15 +12,39 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
 
+	"sami/internal/middlware"
 	"sami/models"
+	"sami/pkg/config"
+	"sami/pkg/mailer"
+	"sami/pkg/oidc"
+	"sami/pkg/password"
+	"sami/pkg/ratelimit"
 )
 
 type AuthController struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Mailer mailer.Mailer
+	// BaseURL is the frontend address emailed links point to
+	BaseURL  string
+	Settings config.AuthConfig
+	// OIDC is nil unless single sign-on is enabled
+	OIDC         *oidc.Client
+	OIDCSettings config.OIDCConfig
+	// Passwords is the policy new passwords must satisfy
+	Passwords *password.Policy
+	// Lockout locks accounts after failed logins; nil disables it
+	Lockout *ratelimit.Lockout
+	// RateLimit applies per-user limits once AuthMiddleware knows the user
+	RateLimit *middlware.RateLimiter
 }
 
 var jwtSecret = []byte("your-secret-key") // In production, use environment variable
 
+// sessionAudience identifies session tokens. Challenge, invitation and login
+// flow tokens are signed with the same secret, so the middleware only
+// accepts tokens with this audience.
+const sessionAudience = "session"
+
 // Claims represents JWT claims
 type Claims struct {
 	UserID uint   `json:"user_id"`
@@ -41,6 +66,12 @@ func (ac *AuthController) Register(c *gin.Context) {
 		return
 	}
 
+	// Check the password against the policy
+	if fieldErrors := passwordFieldErrors(ac.Passwords, "password", req.Password, req.Name, req.Email); len(fieldErrors) > 0 {
+		respondPasswordPolicy(c, fieldErrors)
+		return
+	}
+
 	// Check if user already exists
 	var existingUser models.User
 	if err := ac.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
@@ -74,9 +105,18 @@ func (ac *AuthController) Register(c *gin.Context) {
 		return
 	}
 
+	// Ask the user to confirm their email address
+	verificationSent := true
+	if err := ac.sendVerificationEmail(c.Request.Context(), &user); err != nil {
+		log.Printf("failed to send verification email to user %d: %v", user.ID, err)
+		verificationSent = false
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "User registered successfully",
-		"user":    user.ToResponse(),
+		"message":                     "User registered successfully",
+		"user":                        user.ToResponse(),
+		"verification_sent":           verificationSent,
+		"email_verification_required": ac.Settings.RequireEmailVerification,
 	})
 }
 
@@ -93,9 +133,15 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
+	// Refuse attempts while the account is locked after failed logins
+	if ac.accountLocked(c, req.Email) {
+		return
+	}
+
 	// Find user by email
 	var user models.User
 	if err := ac.DB.Where("email = ? AND status = ?", req.Email, models.StatusActive).First(&user).Error; err != nil {
+		ac.recordLoginFailure(c, req.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid credentials",
 		})
@@ -104,14 +150,58 @@ func (ac *AuthController) Login(c *gin.Context) {
 
 	// Verify password
 	if !user.CheckPassword(req.Password) {
+		ac.recordLoginFailure(c, req.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid credentials",
 		})
 		return
 	}
 
+	if !ac.checkLogin(c, &user, false) {
+		return
+	}
+
+	ac.resetLoginFailures(c, req.Email)
+	ac.issueSession(c, &user)
+}
+
+// checkLogin applies the checks every login goes through once the user is
+// authenticated: email verification when the policy requires it, then the
+// second factor. It responds and returns false when no session can be
+// issued yet. skipTwoFactor is set when another factor was already checked.
+func (ac *AuthController) checkLogin(c *gin.Context, user *models.User, skipTwoFactor bool) bool {
+	// Block login until the email is verified when the policy requires it
+	if ac.Settings.RequireEmailVerification && !user.IsEmailVerified() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Email address not verified",
+			"code":  "email_not_verified",
+		})
+		return false
+	}
+
+	if skipTwoFactor {
+		return true
+	}
+
+	// Ask for the second factor before issuing a session
+	twoFactorEnabled, err := models.HasTwoFactorEnabled(ac.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch two-factor settings",
+		})
+		return false
+	}
+	if twoFactorEnabled {
+		ac.issueTwoFactorChallenge(c, user)
+		return false
+	}
+	return true
+}
+
+// issueSession responds with a session token for user and records the login
+func (ac *AuthController) issueSession(c *gin.Context, user *models.User) {
 	// Generate JWT token
-	token, err := ac.generateJWT(&user)
+	token, err := ac.generateJWT(user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate token",
@@ -122,7 +212,7 @@ func (ac *AuthController) Login(c *gin.Context) {
 	// Update last login
 	now := time.Now()
 	user.LastLogin = &now
-	ac.DB.Save(&user)
+	ac.DB.Save(user)
 
 	// Successful response
 	response := models.LoginResponse{
@@ -210,8 +300,11 @@ func (ac *AuthController) UpdateProfile(c *gin.Context) {
 	if req.Name != "" {
 		user.Name = req.Name
 	}
-	if req.Email != "" {
+	emailChanged := req.Email != "" && req.Email != user.Email
+	if emailChanged {
+		// The new address has to be verified again
 		user.Email = req.Email
+		user.EmailVerifiedAt = nil
 	}
 	if req.Phone != "" {
 		user.Phone = &req.Phone
@@ -225,6 +318,12 @@ func (ac *AuthController) UpdateProfile(c *gin.Context) {
 		return
 	}
 
+	if emailChanged {
+		if err := ac.sendVerificationEmail(c.Request.Context(), user); err != nil {
+			log.Printf("failed to send verification email to user %d: %v", user.ID, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Profile updated successfully",
 		"user":    user.ToResponse(),
@@ -267,11 +366,9 @@ func (ac *AuthController) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	// Validate new password length
-	if len(req.NewPassword) < 6 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "New password must be at least 6 characters long",
-		})
+	// Check the new password against the policy
+	if fieldErrors := passwordFieldErrors(ac.Passwords, "new_password", req.NewPassword, user.Name, user.Email); len(fieldErrors) > 0 {
+		respondPasswordPolicy(c, fieldErrors)
 		return
 	}
 
@@ -309,6 +406,7 @@ func (ac *AuthController) generateJWT(user *models.User) (string, error) {
 		Email:  user.Email,
 		Role:   string(user.Role),
 		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{sessionAudience},
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -350,7 +448,7 @@ func (ac *AuthController) AuthMiddleware() gin.HandlerFunc {
 
 		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 			return []byte(secret), nil
-		})
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(sessionAudience))
 
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -380,11 +478,37 @@ func (ac *AuthController) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Admins must enroll in 2FA when the policy requires it. They can
+		// still reach the auth routes to do so.
+		if ac.twoFactorRequired(&user) && !strings.HasPrefix(c.FullPath(), "/auth/") {
+			enabled, err := models.HasTwoFactorEnabled(ac.DB, user.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to fetch two-factor settings",
+				})
+				c.Abort()
+				return
+			}
+			if !enabled {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error": "Two-factor authentication must be enabled for your account",
+					"code":  "two_factor_enrollment_required",
+				})
+				c.Abort()
+				return
+			}
+		}
+
 		// Set user in context
 		c.Set("user", &user)
 		c.Set("user_id", strconv.Itoa(int(user.ID)))
 		c.Set("user_role", user.Role)
 
+		// Apply the per-user rate limits now that the user is known
+		if ac.RateLimit != nil && !ac.RateLimit.Allow(c) {
+			return
+		}
+
 		c.Next()
 	}
 }
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -8,12 +9,70 @@ import (
 	"gorm.io/gorm"
 
 	"sami/models"
+	"sami/pkg/pagination"
 )
 
 type CommentController struct {
 	DB *gorm.DB
 }
 
+// commentListSpec describes pagination, sorting and filtering for comment lists
+var commentListSpec = pagination.Spec[models.Comment]{
+	Sorts: map[string]pagination.Sort[models.Comment]{
+		"created_at": {Column: "comments.created_at", Kind: pagination.KindTime, Value: func(c models.Comment) interface{} { return c.CreatedAt }},
+	},
+	DefaultSort: "created_at",
+	DefaultDesc: true,
+	IDColumn:    "comments.id",
+	ID:          func(c models.Comment) uint { return c.ID },
+	Filters: map[string]pagination.Filter{
+		"type":          {Column: "comments.type"},
+		"service_id":    {Column: "comments.service_id", Kind: pagination.KindInt},
+		"dependency_id": {Column: "comments.dependency_id", Kind: pagination.KindInt},
+		"user_id":       {Column: "comments.user_id", Kind: pagination.KindInt},
+		"project_id":    {Column: "comments.project_id", Kind: pagination.KindInt},
+		"issue_state":   {Column: "comments.issue_state"},
+		"assignee_id":   {Column: "comments.assignee_id", Kind: pagination.KindInt},
+		"priority":      {Column: "comments.priority"},
+	},
+	DateRanges: map[string]string{
+		"created": "comments.created_at",
+	},
+	DefaultLimit: 50,
+}
+
+// preloadReplies loads the relationships shown on replies
+func preloadReplies(db *gorm.DB) *gorm.DB {
+	return db.Preload("User").Preload("Mentions").Preload("Reactions")
+}
+
+// sameID reports whether two optional IDs are equal
+func sameID(a, b *uint) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+// respondCommentSubjectError answers an error of models.ValidateCommentSubject
+func respondCommentSubjectError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrCommentSubjectConflict):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A comment can be attached to a service or a dependency, not both",
+		})
+	case errors.Is(err, models.ErrCommentServiceNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Service not found in this project",
+		})
+	case errors.Is(err, models.ErrCommentDependencyNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Dependency not found in this project",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to validate comment subject",
+		})
+	}
+}
+
 // GetProjectComments lists all comments for a specific project
 func (cc *CommentController) GetProjectComments(c *gin.Context) {
 	// Get authenticated user
@@ -58,40 +117,36 @@ func (cc *CommentController) GetProjectComments(c *gin.Context) {
 	}
 
 	// Check if user has access to this project
-	hasAccess := project.OwnerID == user.ID || project.Visibility == "public"
-	if !hasAccess {
-		// Check if user is a collaborator
-		var collaborator models.ProjectCollaborator
-		if err := cc.DB.Where("project_id = ? AND user_id = ? AND state = ?",
-			projectID, user.ID, "active").First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
-			})
-			return
-		}
+	if !models.GetProjectAccess(cc.DB, &project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
 	}
 
-	var comments []models.Comment
-
-	// Get query parameters for filtering
-	serviceID := c.Query("service_id")
-	commentType := c.Query("type")
-
-	// Build query
-	query := cc.DB.Where("project_id = ? AND status = ? AND parent_id IS NULL", projectID, "active")
-
-	if serviceID != "" {
-		query = query.Where("service_id = ?", serviceID)
+	// Parse pagination, sort and filter parameters
+	params, err := pagination.Parse(c.Request.URL.Query(), commentListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
 	}
 
-	if commentType != "" {
-		query = query.Where("type = ?", commentType)
-	}
+	// Build query: the threads of the project, including deleted comments
+	// whose replies are still there
+	query := cc.DB.Where("comments.project_id = ? AND comments.parent_id IS NULL", projectID).
+		Where(models.VisibleCommentSQL)
 
-	// Find comments with relationships and replies
-	if err := query.Preload("User").Preload("Service").
-		Preload("Replies", "status = ?", "active").
-		Preload("Replies.User").Order("created_at DESC").Find(&comments).Error; err != nil {
+	// Find comments with relationships, then their replies at any depth
+	page, err := pagination.Find(query, commentListSpec, params, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("User").Preload("Service").Preload("Dependency.SourceService").Preload("Dependency.TargetService").Preload("Assignee").Preload("Mentions").Preload("Reactions")
+	})
+	if err == nil {
+		err = models.LoadCommentTrees(cc.DB, page.Items, preloadReplies)
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch comments",
 		})
@@ -99,13 +154,14 @@ func (cc *CommentController) GetProjectComments(c *gin.Context) {
 	}
 
 	// Convert to response format
-	var commentResponses []models.CommentResponse
-	for _, comment := range comments {
+	commentResponses := make([]models.CommentResponse, 0, len(page.Items))
+	for _, comment := range page.Items {
 		commentResponses = append(commentResponses, comment.ToResponse())
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"comments": commentResponses,
+		"comments":   commentResponses,
+		"pagination": page.Info,
 	})
 }
 
@@ -163,32 +219,26 @@ func (cc *CommentController) CreateProjectComment(c *gin.Context) {
 		return
 	}
 
-	// Check if user has access to this project
-	hasAccess := project.OwnerID == user.ID || project.Visibility == "public"
-	if !hasAccess {
-		// Check if user is a collaborator
-		var collaborator models.ProjectCollaborator
-		if err := cc.DB.Where("project_id = ? AND user_id = ? AND state = ?",
-			projectID, user.ID, "active").First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
-			})
-			return
-		}
+	// Check if user has access to this project and may comment on it
+	access := models.GetProjectAccess(cc.DB, &project, user)
+	if !access.CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+	if !canComment(c, cc.DB, &project, user, access) {
+		return
 	}
 
-	// Validate service exists if service_id is provided
-	if req.ServiceID != nil {
-		var service models.Service
-		if err := cc.DB.Where("id = ? AND project_id = ?", *req.ServiceID, projectID).First(&service).Error; err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Service not found in this project",
-			})
-			return
-		}
+	// Validate the service or dependency the comment is about
+	if err := models.ValidateCommentSubject(cc.DB, uint(projectID), req.ServiceID, req.DependencyID); err != nil {
+		respondCommentSubjectError(c, err)
+		return
 	}
 
-	// Validate parent comment exists if parent_id is provided
+	// Validate parent comment exists if parent_id is provided. Replies are
+	// about the subject of their thread and need the thread to be open.
 	if req.ParentID != nil {
 		var parentComment models.Comment
 		if err := cc.DB.Where("id = ? AND project_id = ? AND status = ?", *req.ParentID, projectID, "active").First(&parentComment).Error; err != nil {
@@ -197,6 +247,19 @@ func (cc *CommentController) CreateProjectComment(c *gin.Context) {
 			})
 			return
 		}
+
+		if (req.ServiceID != nil && !sameID(req.ServiceID, parentComment.ServiceID)) ||
+			(req.DependencyID != nil && !sameID(req.DependencyID, parentComment.DependencyID)) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Replies are about the service or dependency of their thread",
+			})
+			return
+		}
+		req.ServiceID, req.DependencyID = parentComment.ServiceID, parentComment.DependencyID
+
+		if !threadOpen(c, cc.DB, &parentComment, access) {
+			return
+		}
 	}
 
 	// Set default type if not provided
@@ -206,17 +269,80 @@ func (cc *CommentController) CreateProjectComment(c *gin.Context) {
 
 	// Create new comment
 	comment := models.Comment{
-		ProjectID: uint(projectID),
-		ServiceID: req.ServiceID,
-		UserID:    user.ID,
-		ParentID:  req.ParentID,
-		Content:   req.Content,
-		Type:      req.Type,
-		Status:    "active",
+		ProjectID:    uint(projectID),
+		ServiceID:    req.ServiceID,
+		DependencyID: req.DependencyID,
+		UserID:       user.ID,
+		ParentID:     req.ParentID,
+		Content:      req.Content,
+		Type:         req.Type,
+		Status:       "active",
 	}
 
-	// Save to database
-	if err := cc.DB.Create(&comment).Error; err != nil {
+	// Issues start open, other comments carry no workflow
+	if req.Type == models.CommentTypeIssue {
+		models.OpenIssue(&comment, req.Priority)
+
+		if comment.DueDate, err = models.ParseDueDate(req.DueDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if req.AssigneeID != nil && *req.AssigneeID != 0 {
+			if !cc.checkAssignee(c, &project, *req.AssigneeID) {
+				return
+			}
+			comment.AssigneeID = req.AssigneeID
+		}
+	} else if req.AssigneeID != nil || req.Priority != "" || req.DueDate != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Assignee, priority and due date only apply to issues",
+		})
+		return
+	}
+
+	// Render the Markdown, resolving references to services and projects
+	if comment.ContentHTML, err = models.RenderCommentContent(cc.DB, &project, user, req.Content); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to render comment",
+		})
+		return
+	}
+
+	// Resolve mentions against the project members
+	members, err := models.ProjectMembers(cc.DB, project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch project members",
+		})
+		return
+	}
+	mentioned := models.ResolveMentions(req.Content, members)
+
+	// Save to database, recording the opening of issues, and notify
+	err = cc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		if comment.IsIssue() {
+			if err := tx.Create(&models.IssueTransition{
+				CommentID: comment.ID,
+				UserID:    user.ID,
+				ToState:   models.IssueOpen,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		added, err := models.SetCommentMentions(tx, comment.ID, mentioned)
+		if err != nil {
+			return err
+		}
+		return models.NotifyComment(tx, &comment, &project, added, true)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create comment",
 		})
@@ -224,7 +350,7 @@ func (cc *CommentController) CreateProjectComment(c *gin.Context) {
 	}
 
 	// Load relationships for response
-	cc.DB.Preload("User").Preload("Service").First(&comment, comment.ID)
+	cc.DB.Preload("User").Preload("Service").Preload("Dependency.SourceService").Preload("Dependency.TargetService").Preload("Assignee").Preload("Mentions").Preload("Reactions").First(&comment, comment.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Comment created successfully",
@@ -263,9 +389,8 @@ func (cc *CommentController) GetComment(c *gin.Context) {
 	var comment models.Comment
 
 	// Find comment with all relationships
-	if err := cc.DB.Preload("User").Preload("Service").Preload("Project").
-		Preload("Replies", "status = ?", "active").
-		Preload("Replies.User").Where("status = ?", "active").
+	if err := cc.DB.Preload("User").Preload("Service").Preload("Dependency.SourceService").Preload("Dependency.TargetService").Preload("Project").Preload("Assignee").Preload("Mentions").Preload("Reactions").
+		Where("status = ?", "active").
 		First(&comment, commentID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -280,21 +405,24 @@ func (cc *CommentController) GetComment(c *gin.Context) {
 	}
 
 	// Check if user has access to the project containing this comment
-	hasAccess := comment.Project.OwnerID == user.ID || comment.Project.Visibility == "public"
-	if !hasAccess {
-		// Check if user is a collaborator
-		var collaborator models.ProjectCollaborator
-		if err := cc.DB.Where("project_id = ? AND user_id = ? AND state = ?",
-			comment.ProjectID, user.ID, "active").First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
-			})
-			return
-		}
+	if !models.GetProjectAccess(cc.DB, &comment.Project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	// Load the replies at any depth
+	comments := []models.Comment{comment}
+	if err := models.LoadCommentTrees(cc.DB, comments, preloadReplies); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch replies",
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"comment": comment.ToResponse(),
+		"comment": comments[0].ToResponse(),
 	})
 }
 
@@ -354,19 +482,61 @@ func (cc *CommentController) UpdateComment(c *gin.Context) {
 		return
 	}
 
-	// Check if user is the comment author or project owner
-	if comment.UserID != user.ID && comment.Project.OwnerID != user.ID {
+	// Check if user is the comment author or one of the project owners
+	access := models.GetProjectAccess(cc.DB, &comment.Project, user)
+	if comment.UserID != user.ID && !access.CanManage() {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "You can only edit your own comments or comments in your projects",
 		})
 		return
 	}
 
-	// Update comment content
+	// Comments of locked threads can only be changed by project editors
+	if !threadOpen(c, cc.DB, &comment, access) {
+		return
+	}
+
+	// Muted users cannot edit their comments, nor can anyone once comments
+	// are disabled
+	if !canComment(c, cc.DB, &comment.Project, user, access) {
+		return
+	}
+
+	// Update comment content and its rendered Markdown
 	comment.Content = req.Content
+	if comment.ContentHTML, err = models.RenderCommentContent(cc.DB, &comment.Project, user, req.Content); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to render comment",
+		})
+		return
+	}
 
-	// Save to database
-	if err := cc.DB.Save(&comment).Error; err != nil {
+	// Resolve mentions against the project members
+	members, err := models.ProjectMembers(cc.DB, comment.ProjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch project members",
+		})
+		return
+	}
+	mentioned := models.ResolveMentions(req.Content, members)
+
+	// Keep the previous version, save and notify the users newly mentioned
+	err = cc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.RecordCommentRevision(tx, &comment, req.Content, user); err != nil {
+			return err
+		}
+		if err := tx.Save(&comment).Error; err != nil {
+			return err
+		}
+
+		added, err := models.SetCommentMentions(tx, comment.ID, mentioned)
+		if err != nil {
+			return err
+		}
+		return models.NotifyComment(tx, &comment, &comment.Project, added, false)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update comment",
 		})
@@ -374,7 +544,7 @@ func (cc *CommentController) UpdateComment(c *gin.Context) {
 	}
 
 	// Load relationships for response
-	cc.DB.Preload("User").Preload("Service").First(&comment, comment.ID)
+	cc.DB.Preload("User").Preload("Service").Preload("Dependency.SourceService").Preload("Dependency.TargetService").Preload("Assignee").Preload("Mentions").Preload("Reactions").First(&comment, comment.ID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Comment updated successfully",
@@ -382,8 +552,8 @@ func (cc *CommentController) UpdateComment(c *gin.Context) {
 	})
 }
 
-// DeleteComment performs logical deletion of a comment
-func (cc *CommentController) DeleteComment(c *gin.Context) {
+// GetCommentRevisions lists the earlier versions of a comment, oldest first
+func (cc *CommentController) GetCommentRevisions(c *gin.Context) {
 	// Get authenticated user
 	userInterface, exists := c.Get("user")
 	if !exists {
@@ -411,8 +581,6 @@ func (cc *CommentController) DeleteComment(c *gin.Context) {
 	}
 
 	var comment models.Comment
-
-	// Find comment
 	if err := cc.DB.Preload("Project").Where("status = ?", "active").
 		First(&comment, commentID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -427,32 +595,32 @@ func (cc *CommentController) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	// Check if user is the comment author or project owner
-	if comment.UserID != user.ID && comment.Project.OwnerID != user.ID {
+	// Check if user has access to the project containing this comment
+	if !models.GetProjectAccess(cc.DB, &comment.Project, user).CanView() {
 		c.JSON(http.StatusForbidden, gin.H{
-			"error": "You can only delete your own comments or comments in your projects",
+			"error": "Access denied",
 		})
 		return
 	}
 
-	// Perform logical deletion
-	comment.Status = "deleted"
-
-	// Save to database
-	if err := cc.DB.Save(&comment).Error; err != nil {
+	var revisions []models.CommentRevision
+	if err := cc.DB.Preload("Editor").Where("comment_id = ?", comment.ID).
+		Order("version ASC").Find(&revisions).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete comment",
+			"error": "Failed to fetch comment revisions",
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Comment deleted successfully",
+		"revisions": models.CommentRevisionResponses(&comment, revisions),
 	})
 }
 
-// GetAllUserComments retrieves all comments from projects the user has access to
-func (cc *CommentController) GetAllUserComments(c *gin.Context) {
+// DeleteComment performs logical deletion of a comment. Its replies stay:
+// the comment remains in the thread as a placeholder without content until
+// its replies are deleted too.
+func (cc *CommentController) DeleteComment(c *gin.Context) {
 	// Get authenticated user
 	userInterface, exists := c.Get("user")
 	if !exists {
@@ -470,68 +638,94 @@ func (cc *CommentController) GetAllUserComments(c *gin.Context) {
 		return
 	}
 
-	// Get query parameters for filtering
-	commentType := c.Query("type")
-	projectID := c.Query("project_id")
-
-	// First, get all projects the user has access to
-	var accessibleProjectIDs []uint
-
-	// Query for projects where user is owner or projects that are public
-	var ownedOrPublicProjects []models.Project
-	if err := cc.DB.Where("owner_id = ? OR visibility = ?", user.ID, "public").
-		Select("id").Find(&ownedOrPublicProjects).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch accessible projects",
+	// Get comment ID from URL
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid comment ID",
 		})
 		return
 	}
 
-	for _, project := range ownedOrPublicProjects {
-		accessibleProjectIDs = append(accessibleProjectIDs, project.ID)
+	var comment models.Comment
+
+	// Find comment
+	if err := cc.DB.Preload("Project").Where("status = ?", "active").
+		First(&comment, commentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Comment not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch comment",
+			})
+		}
+		return
 	}
 
-	// Also get projects where user is a collaborator
-	var collaboratorProjectIDs []uint
-	if err := cc.DB.Model(&models.ProjectCollaborator{}).
-		Where("user_id = ? AND state = ?", user.ID, "active").
-		Pluck("project_id", &collaboratorProjectIDs).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch collaborator projects",
+	// Check if user is the comment author or one of the project owners
+	if comment.UserID != user.ID && !models.GetProjectAccess(cc.DB, &comment.Project, user).CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You can only delete your own comments or comments in your projects",
 		})
 		return
 	}
 
-	// Combine all accessible project IDs
-	for _, projectID := range collaboratorProjectIDs {
-		accessibleProjectIDs = append(accessibleProjectIDs, projectID)
-	}
+	// Perform logical deletion
+	comment.Status = "deleted"
 
-	// If no accessible projects, return empty result
-	if len(accessibleProjectIDs) == 0 {
-		c.JSON(http.StatusOK, gin.H{
-			"comments": []models.CommentResponse{},
+	// Save to database
+	if err := cc.DB.Save(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete comment",
 		})
 		return
 	}
 
-	var comments []models.Comment
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment deleted successfully",
+	})
+}
 
-	// Build query for comments
-	query := cc.DB.Where("project_id IN ? AND status = ?", accessibleProjectIDs, "active")
+// GetAllUserComments retrieves all comments from projects the user has access to
+func (cc *CommentController) GetAllUserComments(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
 
-	// Apply filters
-	if commentType != "" {
-		query = query.Where("type = ?", commentType)
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
 	}
 
-	if projectID != "" {
-		query = query.Where("project_id = ?", projectID)
+	// Parse pagination, sort and filter parameters
+	params, err := pagination.Parse(c.Request.URL.Query(), commentListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
 	}
 
+	// Build query for comments in the projects the user has access to
+	query := cc.DB.Where("comments.project_id IN ("+models.AccessibleProjectsSQL+")", map[string]interface{}{"user": user.ID}).
+		Where("comments.status = ?", "active")
+
 	// Find all comments (including replies) with relationships
-	if err := query.Preload("User").Preload("Service").Preload("Project").
-		Order("created_at DESC").Find(&comments).Error; err != nil {
+	page, err := pagination.Find(query, commentListSpec, params, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("User").Preload("Service").Preload("Dependency.SourceService").Preload("Dependency.TargetService").Preload("Project").Preload("Assignee").Preload("Mentions").Preload("Reactions")
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch comments",
 		})
@@ -539,12 +733,13 @@ func (cc *CommentController) GetAllUserComments(c *gin.Context) {
 	}
 
 	// Convert to response format
-	var commentResponses []models.CommentResponse
-	for _, comment := range comments {
+	commentResponses := make([]models.CommentResponse, 0, len(page.Items))
+	for _, comment := range page.Items {
 		commentResponses = append(commentResponses, comment.ToResponse())
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"comments": commentResponses,
+		"comments":   commentResponses,
+		"pagination": page.Info,
 	})
 }
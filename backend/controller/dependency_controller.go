@@ -8,12 +8,38 @@ import (
 	"gorm.io/gorm"
 
 	"sami/models"
+	"sami/pkg/pagination"
 )
 
 type DependencyController struct {
 	DB *gorm.DB
 }
 
+// dependencyListSpec describes pagination, sorting and filtering for GetProjectDependencies.
+var dependencyListSpec = pagination.Spec[models.Dependency]{
+	Sorts: map[string]pagination.Sort[models.Dependency]{
+		"type":       {Column: "COALESCE(dependencies.type, '')", Kind: pagination.KindString, Value: func(d models.Dependency) interface{} { return d.Type }},
+		"created_at": {Column: "dependencies.created_at", Kind: pagination.KindTime, Value: func(d models.Dependency) interface{} { return d.CreatedAt }},
+		"updated_at": {Column: "dependencies.updated_at", Kind: pagination.KindTime, Value: func(d models.Dependency) interface{} { return d.UpdatedAt }},
+	},
+	DefaultSort: "created_at",
+	IDColumn:    "dependencies.id",
+	ID:          func(d models.Dependency) uint { return d.ID },
+	Filters: map[string]pagination.Filter{
+		"type":       {Column: "dependencies.type"},
+		"protocol":   {Column: "dependencies.protocol"},
+		"method":     {Column: "dependencies.method"},
+		"source_id":  {Column: "dependencies.source_id", Kind: pagination.KindInt},
+		"target_id":  {Column: "dependencies.target_id", Kind: pagination.KindInt},
+		"created_by": {Column: "dependencies.created_by", Kind: pagination.KindInt},
+	},
+	DateRanges: map[string]string{
+		"created": "dependencies.created_at",
+		"updated": "dependencies.updated_at",
+	},
+	DefaultLimit: 50,
+}
+
 // GetProjectDependencies lists all dependencies for a specific project
 func (dc *DependencyController) GetProjectDependencies(c *gin.Context) {
 	// Get authenticated user
@@ -58,30 +84,57 @@ func (dc *DependencyController) GetProjectDependencies(c *gin.Context) {
 	}
 
 	// Check if user has access to this project
-	hasAccess := project.OwnerID == user.ID || project.Visibility == "public"
-	if !hasAccess {
-		// Check if user is a collaborator
-		var collaborator models.ProjectCollaborator
-		if err := dc.DB.Where("project_id = ? AND user_id = ? AND state = ?",
-			projectID, user.ID, "active").First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
-			})
-			return
-		}
+	if !models.GetProjectAccess(dc.DB, &project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
 	}
 
-	// Get dependencies for services in this project
-	var dependencies []models.Dependency
-	if err := dc.DB.
-		Preload("SourceService").
-		Preload("TargetService").
-		Preload("Creator").
-		Preload("Updater").
+	// Parse pagination, sort and filter parameters
+	params, err := pagination.Parse(c.Request.URL.Query(), dependencyListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Build query for dependencies between services in this project
+	query := dc.DB.
 		Joins("JOIN services s1 ON dependencies.source_id = s1.id").
 		Joins("JOIN services s2 ON dependencies.target_id = s2.id").
-		Where("s1.project_id = ? AND s2.project_id = ?", projectID, projectID).
-		Find(&dependencies).Error; err != nil {
+		Where("s1.project_id = ? AND s2.project_id = ?", projectID, projectID)
+
+	// Filter by environment: keep edges whose both ends are deployed in the
+	// project environment, fall back to the legacy environment column
+	var environment *models.Environment
+	if name := c.Query("environment"); name != "" {
+		environment, err = models.FindProjectEnvironment(dc.DB, uint(projectID), name)
+		if err == nil {
+			query = query.Where("EXISTS (SELECT 1 FROM service_instances si WHERE si.service_id = s1.id AND si.environment_id = ?)", environment.ID).
+				Where("EXISTS (SELECT 1 FROM service_instances si WHERE si.service_id = s2.id AND si.environment_id = ?)", environment.ID)
+		} else {
+			query = query.Where("s1.environment = ? AND s2.environment = ?", name, name)
+		}
+	}
+
+	// Filter by tags (?tag=a,b keeps edges whose both ends carry all of them)
+	if tags := models.ParseTagFilter(c.Query("tag")); len(tags) > 0 {
+		query = models.FilterByServiceTags(query, "s1.id", tags)
+		query = models.FilterByServiceTags(query, "s2.id", tags)
+	}
+
+	// Get dependencies for services in this project
+	page, err := pagination.Find(query, dependencyListSpec, params, func(db *gorm.DB) *gorm.DB {
+		return db.
+			Preload("SourceService.Instances.Environment").
+			Preload("TargetService.Instances.Environment").
+			Preload("Creator").
+			Preload("Updater")
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch dependencies",
 		})
@@ -89,13 +142,11 @@ func (dc *DependencyController) GetProjectDependencies(c *gin.Context) {
 	}
 
 	// Convert to response format
-	var dependencyResponses []models.DependencyResponse
-	for _, dependency := range dependencies {
-		dependencyResponses = append(dependencyResponses, dependency.ToResponse())
-	}
+	dependencyResponses := models.DependencyResponsesFor(page.Items, environment)
 
 	c.JSON(http.StatusOK, gin.H{
 		"dependencies": dependencyResponses,
+		"pagination":   page.Info,
 	})
 }
 
@@ -143,17 +194,11 @@ func (dc *DependencyController) CreateProjectDependency(c *gin.Context) {
 	}
 
 	// Check if user has access to modify this project
-	hasAccess := project.OwnerID == user.ID
-	if !hasAccess {
-		// Check if user is a collaborator with editor role
-		var collaborator models.ProjectCollaborator
-		if err := dc.DB.Where("project_id = ? AND user_id = ? AND state = ? AND role IN (?)",
-			projectID, user.ID, "active", []string{"owner", "editor"}).First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
-			})
-			return
-		}
+	if !models.GetProjectAccess(dc.DB, &project, user).CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
 	}
 
 	var req models.CreateDependencyRequest
@@ -216,8 +261,15 @@ func (dc *DependencyController) CreateProjectDependency(c *gin.Context) {
 		CreatedBy:   user.ID,
 	}
 
-	// Save to database
-	if err := dc.DB.Create(&dependency).Error; err != nil {
+	// Save to database and record it in the project history
+	err = dc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&dependency).Error; err != nil {
+			return err
+		}
+		dependency.SourceService, dependency.TargetService = sourceService, targetService
+		return models.RecordDependencyHistory(tx, &dependency, user.ID, models.HistoryCreateDependency)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create dependency",
 		})
@@ -296,17 +348,12 @@ func (dc *DependencyController) UpdateDependency(c *gin.Context) {
 		return
 	}
 
-	hasAccess := project.OwnerID == user.ID
-	if !hasAccess {
-		// Check if user is a collaborator with editor role
-		var collaborator models.ProjectCollaborator
-		if err := dc.DB.Where("project_id = ? AND user_id = ? AND state = ? AND role IN (?)",
-			sourceProjectID, user.ID, "active", []string{"owner", "editor"}).First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
-			})
-			return
-		}
+	// Check if user has access to modify this project
+	if !models.GetProjectAccess(dc.DB, &project, user).CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
 	}
 
 	var req models.UpdateDependencyRequest
@@ -404,21 +451,26 @@ func (dc *DependencyController) DeleteDependency(c *gin.Context) {
 		return
 	}
 
-	hasAccess := project.OwnerID == user.ID
-	if !hasAccess {
-		// Check if user is a collaborator with editor role
-		var collaborator models.ProjectCollaborator
-		if err := dc.DB.Where("project_id = ? AND user_id = ? AND state = ? AND role IN (?)",
-			sourceProjectID, user.ID, "active", []string{"owner", "editor"}).First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
-			})
-			return
-		}
+	// Check if user has access to modify this project
+	if !models.GetProjectAccess(dc.DB, &project, user).CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
 	}
 
-	// Delete the dependency
-	if err := dc.DB.Delete(&dependency).Error; err != nil {
+	// Delete the dependency with its comments and record it in the project
+	// history
+	err = dc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.RecordDependencyHistory(tx, &dependency, user.ID, models.HistoryDeleteDependency); err != nil {
+			return err
+		}
+		if err := models.DeleteDependencyComments(tx, []uint{dependency.ID}); err != nil {
+			return err
+		}
+		return tx.Delete(&dependency).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete dependency",
 		})
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	htmltemplate "html/template"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"sami/pkg/mailer"
+)
+
+// emailTemplate pairs the plain text and HTML versions of an email
+type emailTemplate struct {
+	Subject string
+	Text    *template.Template
+	HTML    *htmltemplate.Template
+}
+
+// newEmailTemplate parses the text and HTML bodies of an email
+func newEmailTemplate(name, subject, text, html string) emailTemplate {
+	return emailTemplate{
+		Subject: subject,
+		Text:    template.Must(template.New(name).Parse(text)),
+		HTML:    htmltemplate.Must(htmltemplate.New(name).Parse(html)),
+	}
+}
+
+// sendEmail renders tpl with data and sends it to the given address
+func sendEmail(ctx context.Context, m mailer.Mailer, to string, subject string, tpl emailTemplate, data interface{}) error {
+	var text, html bytes.Buffer
+	if err := tpl.Text.Execute(&text, data); err != nil {
+		return err
+	}
+	if err := tpl.HTML.Execute(&html, data); err != nil {
+		return err
+	}
+
+	if subject == "" {
+		subject = tpl.Subject
+	}
+
+	return m.Send(ctx, mailer.Message{
+		To:      []string{to},
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	})
+}
+
+// frontendLink builds a link to a frontend page carrying a token
+func frontendLink(baseURL, path, token string) string {
+	return strings.TrimRight(baseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+const emailDateLayout = "January 2, 2006 15:04 MST"
+
+var invitationEmail = newEmailTemplate("invitation", "You have been invited to Sami", `Hello{{if .Name}} {{.Name}}{{end}},
+
+{{.Inviter}} invited you to {{if .Project}}collaborate on the project "{{.Project}}" as {{.Role}}{{else}}join Sami{{end}}.
+
+Accept the invitation and choose your password here:
+{{.Link}}
+
+This link expires on {{.ExpiresAt}}. If you were not expecting this invitation you can ignore this email.
+`, `<p>Hello{{if .Name}} {{.Name}}{{end}},</p>
+<p>{{.Inviter}} invited you to {{if .Project}}collaborate on the project <strong>{{.Project}}</strong> as {{.Role}}{{else}}join Sami{{end}}.</p>
+<p><a href="{{.Link}}">Accept the invitation and choose your password</a></p>
+<p>This link expires on {{.ExpiresAt}}. If you were not expecting this invitation you can ignore this email.</p>
+`)
+
+var passwordResetEmail = newEmailTemplate("password_reset", "Reset your Sami password", `Hello{{if .Name}} {{.Name}}{{end}},
+
+Someone asked to reset the password of your Sami account. Choose a new password here:
+{{.Link}}
+
+This link can be used once and expires on {{.ExpiresAt}}. If you did not ask for a password reset you can ignore this email.
+`, `<p>Hello{{if .Name}} {{.Name}}{{end}},</p>
+<p>Someone asked to reset the password of your Sami account.</p>
+<p><a href="{{.Link}}">Choose a new password</a></p>
+<p>This link can be used once and expires on {{.ExpiresAt}}. If you did not ask for a password reset you can ignore this email.</p>
+`)
+
+var emailVerificationEmail = newEmailTemplate("email_verification", "Confirm your email address", `Hello{{if .Name}} {{.Name}}{{end}},
+
+Please confirm that {{.Email}} is your email address:
+{{.Link}}
+
+This link expires on {{.ExpiresAt}}.
+`, `<p>Hello{{if .Name}} {{.Name}}{{end}},</p>
+<p>Please confirm that <strong>{{.Email}}</strong> is your email address.</p>
+<p><a href="{{.Link}}">Confirm email address</a></p>
+<p>This link expires on {{.ExpiresAt}}.</p>
+`)
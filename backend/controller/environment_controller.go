@@ -0,0 +1,632 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+)
+
+type EnvironmentController struct {
+	DB *gorm.DB
+}
+
+// GetProjectEnvironments lists the environments defined for a project
+func (ec *EnvironmentController) GetProjectEnvironments(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	// Check if project exists and user has access
+	var project models.Project
+	if err := ec.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to this project
+	if !models.GetProjectAccess(ec.DB, &project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	var environments []models.Environment
+	if err := ec.DB.Where("project_id = ?", projectID).
+		Order("position ASC, name ASC").Find(&environments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch environments",
+		})
+		return
+	}
+
+	// Convert to response format
+	environmentResponses := make([]models.EnvironmentResponse, 0, len(environments))
+	for _, environment := range environments {
+		environmentResponses = append(environmentResponses, environment.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"environments": environmentResponses,
+	})
+}
+
+// CreateProjectEnvironment defines a new environment for a project
+func (ec *EnvironmentController) CreateProjectEnvironment(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	// Check if project exists and user has access
+	var project models.Project
+	if err := ec.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to modify this project
+	if !models.GetProjectAccess(ec.DB, &project, user).CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	var req models.CreateEnvironmentRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Check if environment name is already used in this project
+	if _, err := models.FindProjectEnvironment(ec.DB, uint(projectID), req.Name); err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Environment with this name already exists",
+		})
+		return
+	}
+
+	// Create new environment
+	environment := models.Environment{
+		ProjectID:   uint(projectID),
+		Name:        req.Name,
+		Description: req.Description,
+		Position:    req.Position,
+	}
+
+	// Save to database
+	if err := ec.DB.Create(&environment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create environment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Environment created successfully",
+		"environment": environment.ToResponse(),
+	})
+}
+
+// UpdateEnvironment updates an environment
+func (ec *EnvironmentController) UpdateEnvironment(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get environment ID from URL
+	environmentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid environment ID",
+		})
+		return
+	}
+
+	// Find environment
+	var environment models.Environment
+	if err := ec.DB.Preload("Project").First(&environment, environmentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Environment not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch environment",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to modify this environment's project
+	if !models.GetProjectAccess(ec.DB, &environment.Project, user).CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	var req models.UpdateEnvironmentRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	oldName := environment.Name
+	if req.Name != "" && req.Name != oldName {
+		// Check if new name is already used in this project
+		if _, err := models.FindProjectEnvironment(ec.DB, environment.ProjectID, req.Name); err == nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Environment with this name already exists",
+			})
+			return
+		}
+		environment.Name = req.Name
+	}
+	if req.Description != "" {
+		environment.Description = req.Description
+	}
+	if req.Position != nil {
+		environment.Position = *req.Position
+	}
+
+	// Save environment and keep services pointing at the renamed environment
+	err = ec.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&environment).Error; err != nil {
+			return err
+		}
+		if environment.Name != oldName {
+			return tx.Model(&models.Service{}).
+				Where("project_id = ? AND environment = ?", environment.ProjectID, oldName).
+				Update("environment", environment.Name).Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update environment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Environment updated successfully",
+		"environment": environment.ToResponse(),
+	})
+}
+
+// DeleteEnvironment deletes an environment and its service instances
+func (ec *EnvironmentController) DeleteEnvironment(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get environment ID from URL
+	environmentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid environment ID",
+		})
+		return
+	}
+
+	// Find environment
+	var environment models.Environment
+	if err := ec.DB.Preload("Project").First(&environment, environmentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Environment not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch environment",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to modify this environment's project
+	if !models.GetProjectAccess(ec.DB, &environment.Project, user).CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	// Refuse to delete an environment still used as a service's environment
+	var servicesInUse int64
+	ec.DB.Model(&models.Service{}).
+		Where("project_id = ? AND environment = ?", environment.ProjectID, environment.Name).
+		Count(&servicesInUse)
+	if servicesInUse > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Environment is still used by services",
+		})
+		return
+	}
+
+	// Delete instances and environment
+	err = ec.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("environment_id = ?", environment.ID).Delete(&models.ServiceInstance{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&environment).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete environment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Environment deleted successfully",
+	})
+}
+
+// GetServiceInstances lists the environments a service is deployed to
+func (ec *EnvironmentController) GetServiceInstances(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get service ID from URL
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid service ID",
+		})
+		return
+	}
+
+	// Find service
+	var service models.Service
+	if err := ec.DB.Preload("Project").Preload("Instances.Environment").
+		First(&service, serviceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Service not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch service",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to this service's project
+	if !models.GetProjectAccess(ec.DB, &service.Project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	// Convert to response format
+	instanceResponses := make([]models.ServiceInstanceResponse, 0, len(service.Instances))
+	for _, instance := range service.Instances {
+		instanceResponses = append(instanceResponses, instance.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"instances": instanceResponses,
+	})
+}
+
+// UpsertServiceInstance creates or updates a service in one environment
+func (ec *EnvironmentController) UpsertServiceInstance(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get service and environment IDs from URL
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid service ID",
+		})
+		return
+	}
+
+	environmentID, err := strconv.Atoi(c.Param("environment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid environment ID",
+		})
+		return
+	}
+
+	// Find service
+	var service models.Service
+	if err := ec.DB.Preload("Project").First(&service, serviceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Service not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch service",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to modify this service's project
+	if !models.GetProjectAccess(ec.DB, &service.Project, user).CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	// Validate that the environment belongs to the service's project
+	var environment models.Environment
+	if err := ec.DB.Where("id = ? AND project_id = ?", environmentID, service.ProjectID).First(&environment).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Environment not found in this project",
+		})
+		return
+	}
+
+	var req models.UpsertServiceInstanceRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Set default status if not provided
+	if req.Status == "" {
+		req.Status = "active"
+	}
+
+	// Find existing instance or start a new one
+	var instance models.ServiceInstance
+	status := http.StatusOK
+	if err := ec.DB.Where("service_id = ? AND environment_id = ?", service.ID, environment.ID).
+		First(&instance).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch service instance",
+			})
+			return
+		}
+		instance = models.ServiceInstance{
+			ServiceID:     service.ID,
+			EnvironmentID: environment.ID,
+		}
+		status = http.StatusCreated
+	}
+
+	instance.Status = req.Status
+	instance.Version = req.Version
+	instance.DeployURL = req.DeployURL
+	instance.Domain = req.Domain
+	instance.UpdatedBy = &user.ID
+
+	// Save to database
+	if err := ec.DB.Save(&instance).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save service instance",
+		})
+		return
+	}
+
+	instance.Environment = environment
+
+	c.JSON(status, gin.H{
+		"message":  "Service instance saved successfully",
+		"instance": instance.ToResponse(),
+	})
+}
+
+// DeleteServiceInstance removes a service from one environment
+func (ec *EnvironmentController) DeleteServiceInstance(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get service and environment IDs from URL
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid service ID",
+		})
+		return
+	}
+
+	environmentID, err := strconv.Atoi(c.Param("environment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid environment ID",
+		})
+		return
+	}
+
+	// Find service
+	var service models.Service
+	if err := ec.DB.Preload("Project").First(&service, serviceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Service not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch service",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to modify this service's project
+	if !models.GetProjectAccess(ec.DB, &service.Project, user).CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	// Delete instance
+	result := ec.DB.Where("service_id = ? AND environment_id = ?", service.ID, environmentID).
+		Delete(&models.ServiceInstance{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete service instance",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Service instance not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Service instance deleted successfully",
+	})
+}
@@ -0,0 +1,600 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sami/models"
+	"sami/pkg/mailer"
+	"sami/pkg/password"
+)
+
+// InvitationController issues email invitations and lets invited people
+// create their account
+type InvitationController struct {
+	DB     *gorm.DB
+	Mailer mailer.Mailer
+	// BaseURL is the frontend address the invitation link points to
+	BaseURL string
+	// TTL is how long an invitation can be accepted
+	TTL time.Duration
+	// Passwords is the policy the password of new accounts must satisfy
+	Passwords *password.Policy
+}
+
+const defaultInvitationTTL = 7 * 24 * time.Hour
+
+// tokenSecret returns the key emailed tokens are signed with
+func tokenSecret() []byte {
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	return jwtSecret
+}
+
+// createInvitation saves an invitation and emails its link. It runs in a
+// transaction so no invitation is left behind when the email cannot be sent.
+func (ic *InvitationController) createInvitation(ctx context.Context, invitation *models.Invitation, inviter *models.User, projectName string) error {
+	ttl := ic.TTL
+	if ttl <= 0 {
+		ttl = defaultInvitationTTL
+	}
+
+	return ic.DB.Transaction(func(tx *gorm.DB) error {
+		nonce, hash, err := models.NewInvitationNonce()
+		if err != nil {
+			return err
+		}
+
+		invitation.TokenHash = hash
+		invitation.Status = models.InvitationPending
+		invitation.InvitedBy = inviter.ID
+		invitation.CreatedAt = time.Now()
+		invitation.ExpiresAt = invitation.CreatedAt.Add(ttl)
+		if err := tx.Create(invitation).Error; err != nil {
+			return err
+		}
+
+		token, err := models.SignInvitationToken(tokenSecret(), invitation, nonce)
+		if err != nil {
+			return err
+		}
+
+		inviterName := inviter.Name
+		if inviterName == "" {
+			inviterName = inviter.Email
+		}
+
+		data := map[string]string{
+			"Name":      invitation.Name,
+			"Inviter":   inviterName,
+			"Project":   projectName,
+			"Role":      invitation.Role,
+			"Link":      frontendLink(ic.BaseURL, "/invitations/accept", token),
+			"ExpiresAt": invitation.ExpiresAt.UTC().Format(emailDateLayout),
+		}
+
+		subject := ""
+		if projectName != "" {
+			subject = "You have been invited to " + projectName + " on Sami"
+		}
+
+		return sendEmail(ctx, ic.Mailer, invitation.Email, subject, invitationEmail, data)
+	})
+}
+
+// hasPendingInvitation reports whether an invitation for the email (and
+// project, if any) is still waiting to be accepted
+func (ic *InvitationController) hasPendingInvitation(email string, projectID *uint) (bool, error) {
+	query := ic.DB.Model(&models.Invitation{}).
+		Where("email = ? AND status = ? AND expires_at > ?", email, models.InvitationPending, time.Now())
+	if projectID != nil {
+		query = query.Where("project_id = ?", *projectID)
+	} else {
+		query = query.Where("project_id IS NULL")
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// findInvitationByToken verifies a token and loads its invitation
+func (ic *InvitationController) findInvitationByToken(token string) (*models.Invitation, error) {
+	id, nonce, err := models.ParseInvitationToken(tokenSecret(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	var invitation models.Invitation
+	if err := ic.DB.Preload("Project").Preload("Inviter").First(&invitation, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, models.ErrInvalidInvitationToken
+		}
+		return nil, err
+	}
+
+	if !invitation.MatchesNonce(nonce) {
+		return nil, models.ErrInvalidInvitationToken
+	}
+
+	return &invitation, nil
+}
+
+// invitationStatusError answers the request when the invitation can no
+// longer be used and reports whether it did
+func invitationStatusError(c *gin.Context, invitation *models.Invitation) bool {
+	switch invitation.EffectiveStatus(time.Now()) {
+	case models.InvitationAccepted:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Invitation has already been accepted",
+		})
+	case models.InvitationRevoked:
+		c.JSON(http.StatusGone, gin.H{
+			"error": "Invitation has been revoked",
+		})
+	case models.InvitationExpired:
+		c.JSON(http.StatusGone, gin.H{
+			"error": "Invitation has expired",
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// GetInvitation shows the invitation a token belongs to (no authentication required)
+func (ic *InvitationController) GetInvitation(c *gin.Context) {
+	invitation, err := ic.findInvitationByToken(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidInvitationToken) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Invitation not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch invitation",
+			})
+		}
+		return
+	}
+
+	if invitationStatusError(c, invitation) {
+		return
+	}
+
+	// Tell the frontend whether to ask for a new or the existing password
+	var existing int64
+	ic.DB.Model(&models.User{}).Where("email = ?", invitation.Email).Count(&existing)
+
+	c.JSON(http.StatusOK, gin.H{
+		"invitation":     invitation.ToResponse(),
+		"account_exists": existing > 0,
+	})
+}
+
+// AcceptInvitation creates the invited account with the chosen password, or
+// checks the password of the existing one, and adds it to the project it was
+// invited to (no authentication required)
+func (ic *InvitationController) AcceptInvitation(c *gin.Context) {
+	var req models.AcceptInvitationRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	invitation, err := ic.findInvitationByToken(req.Token)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidInvitationToken) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid or expired invitation token",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch invitation",
+			})
+		}
+		return
+	}
+
+	if invitationStatusError(c, invitation) {
+		return
+	}
+
+	var user models.User
+	var current models.Invitation
+	err = ic.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the invitation so concurrent accepts and revokes are seen
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, invitation.ID).Error; err != nil {
+			return err
+		}
+		if current.EffectiveStatus(time.Now()) != models.InvitationPending {
+			return errInvitationNotPending
+		}
+
+		lookup := tx.Where("email = ?", invitation.Email).First(&user)
+		switch {
+		case lookup.Error == nil:
+			// Existing accounts prove who they are with their password
+			if !user.CheckPassword(req.Password) {
+				return errInvalidCredentials
+			}
+			if user.Status != models.StatusActive {
+				return errInactiveAccount
+			}
+		case errors.Is(lookup.Error, gorm.ErrRecordNotFound):
+			name := strings.TrimSpace(req.Name)
+			if name == "" {
+				name = invitation.Name
+			}
+
+			// New accounts choose their password here
+			if fieldErrors := passwordFieldErrors(ic.Passwords, "password", req.Password, name, invitation.Email); len(fieldErrors) > 0 {
+				return &passwordPolicyError{Fields: fieldErrors}
+			}
+			// Following the emailed link proves the address
+			now := time.Now()
+			user = models.User{
+				Name:   name,
+				Email:  invitation.Email,
+				Role:   invitation.UserRole,
+				Status: models.StatusActive,
+
+				EmailVerifiedAt: &now,
+			}
+			if user.Role == "" {
+				user.Role = models.UserRole
+			}
+			if err := user.SetPassword(req.Password); err != nil {
+				return err
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		default:
+			return lookup.Error
+		}
+
+		// Land as collaborator with the invited role
+		if invitation.Project != nil && invitation.Project.OwnerID != user.ID {
+			if err := addCollaboratorRole(tx, invitation.Project.ID, user.ID, invitation.Role); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		result := tx.Model(invitation).Where("status = ?", models.InvitationPending).Updates(map[string]interface{}{
+			"status":      models.InvitationAccepted,
+			"accepted_at": now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvitationNotPending
+		}
+		return nil
+	})
+	if err != nil {
+		var policyErr *passwordPolicyError
+		switch {
+		case errors.Is(err, errInvitationNotPending):
+			if !invitationStatusError(c, &current) {
+				c.JSON(http.StatusConflict, gin.H{
+					"error": "Invitation can no longer be accepted",
+				})
+			}
+		case errors.As(err, &policyErr):
+			respondPasswordPolicy(c, policyErr.Fields)
+		case errors.Is(err, errInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "An account already exists for this email, enter its password to accept",
+			})
+		case errors.Is(err, errInactiveAccount):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Account is not active",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to accept invitation",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Invitation accepted successfully",
+		"user":       user.ToResponse(),
+		"project_id": invitation.ProjectID,
+	})
+}
+
+var (
+	errInvalidCredentials = errors.New("invalid credentials")
+	errInactiveAccount    = errors.New("inactive account")
+
+	// errInvitationNotPending is returned when an invitation was accepted or
+	// revoked while it was being accepted
+	errInvitationNotPending = errors.New("invitation is no longer pending")
+)
+
+// addCollaboratorRole grants role on a project, keeping an existing grant if
+// it is already higher
+func addCollaboratorRole(tx *gorm.DB, projectID, userID uint, role string) error {
+	var collaborator models.ProjectCollaborator
+	err := tx.Where("project_id = ? AND user_id = ?", projectID, userID).First(&collaborator).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Create(&models.ProjectCollaborator{
+			ProjectID: projectID,
+			UserID:    userID,
+			Role:      role,
+			State:     "active",
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&models.ProjectCollaborator{}).Where("project_id = ? AND user_id = ?", projectID, userID).Updates(map[string]interface{}{
+		"role":  string(models.MaxProjectRole(models.ProjectRole(collaborator.Role), models.ProjectRole(role))),
+		"state": "active",
+	}).Error
+}
+
+// GetProjectInvitations lists the pending invitations of a project
+func (ic *InvitationController) GetProjectInvitations(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	// Find project
+	var project models.Project
+	if err := ic.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user is owner or admin
+	if !models.GetProjectAccess(ic.DB, &project, user).CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only project owners can see invitations",
+		})
+		return
+	}
+
+	ic.listPendingInvitations(c, ic.DB.Where("project_id = ?", project.ID))
+}
+
+// RevokeProjectInvitation revokes a pending invitation of a project
+func (ic *InvitationController) RevokeProjectInvitation(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	// Find project
+	var project models.Project
+	if err := ic.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user is owner or admin
+	if !models.GetProjectAccess(ic.DB, &project, user).CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only project owners can revoke invitations",
+		})
+		return
+	}
+
+	ic.revokeInvitation(c, ic.DB.Where("project_id = ?", project.ID))
+}
+
+// GetInvitations lists every pending invitation (admin only)
+func (ic *InvitationController) GetInvitations(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Check if user is admin
+	if user.Role != models.AdminRole {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Admin access required",
+		})
+		return
+	}
+
+	ic.listPendingInvitations(c, ic.DB)
+}
+
+// RevokeInvitation revokes any pending invitation (admin only)
+func (ic *InvitationController) RevokeInvitation(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Check if user is admin
+	if user.Role != models.AdminRole {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Admin access required",
+		})
+		return
+	}
+
+	ic.revokeInvitation(c, ic.DB)
+}
+
+// listPendingInvitations answers with the pending invitations matching query
+func (ic *InvitationController) listPendingInvitations(c *gin.Context, query *gorm.DB) {
+	var invitations []models.Invitation
+	if err := query.Preload("Project").Preload("Inviter").
+		Where("status = ? AND expires_at > ?", models.InvitationPending, time.Now()).
+		Order("created_at DESC").Find(&invitations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch invitations",
+		})
+		return
+	}
+
+	// Convert to response format
+	invitationResponses := make([]models.InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		invitationResponses = append(invitationResponses, invitation.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invitations": invitationResponses,
+	})
+}
+
+// revokeInvitation revokes the pending invitation :invitationId within query
+func (ic *InvitationController) revokeInvitation(c *gin.Context, query *gorm.DB) {
+	// Get invitation ID from URL
+	invitationID, err := strconv.Atoi(c.Param("invitationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid invitation ID",
+		})
+		return
+	}
+
+	var invitation models.Invitation
+	if err := query.First(&invitation, invitationID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Invitation not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch invitation",
+			})
+		}
+		return
+	}
+
+	if invitation.Status != models.InvitationPending {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Only pending invitations can be revoked",
+		})
+		return
+	}
+
+	// Only revoke it if it was not accepted in the meantime
+	result := ic.DB.Model(&invitation).Where("status = ?", models.InvitationPending).
+		Update("status", models.InvitationRevoked)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke invitation",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Only pending invitations can be revoked",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invitation revoked successfully",
+	})
+}
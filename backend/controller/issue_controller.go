@@ -0,0 +1,553 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+)
+
+const (
+	defaultIssueBoardLimit = 20
+	maxIssueBoardLimit     = 100
+)
+
+// checkAssignee writes an error response unless the user can be assigned
+// issues of the project: an active user granted access to it
+func (cc *CommentController) checkAssignee(c *gin.Context, project *models.Project, assigneeID uint) bool {
+	var assignee models.User
+	if err := cc.DB.Where("id = ? AND status = ?", assigneeID, models.StatusActive).First(&assignee).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Assignee not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch assignee",
+			})
+		}
+		return false
+	}
+
+	if !models.IsProjectMember(cc.DB, project, &assignee) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Issues can only be assigned to project collaborators",
+		})
+		return false
+	}
+
+	return true
+}
+
+// UpdateIssue changes the state, assignee, priority or due date of an issue.
+// The author, the assignee and project editors can update an issue.
+func (cc *CommentController) UpdateIssue(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get comment ID from URL
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid comment ID",
+		})
+		return
+	}
+
+	var req models.UpdateIssueRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var comment models.Comment
+
+	// Find comment
+	if err := cc.DB.Preload("Project").Where("status = ?", "active").
+		First(&comment, commentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Comment not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch comment",
+			})
+		}
+		return
+	}
+
+	if !comment.IsIssue() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Comment is not an issue",
+		})
+		return
+	}
+
+	// Check if user is the author, the assignee or a project editor
+	access := models.GetProjectAccess(cc.DB, &comment.Project, user)
+	isAssignee := comment.AssigneeID != nil && *comment.AssigneeID == user.ID
+	if comment.UserID != user.ID && !isAssignee && !access.CanEdit() && !access.IsAdmin {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only the author, the assignee or project editors can update an issue",
+		})
+		return
+	}
+
+	// Check the requested transition
+	transition := req.State != "" && req.State != *comment.IssueState
+	if transition && !comment.IssueState.CanTransitionTo(req.State) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Issue cannot move from " + string(*comment.IssueState) + " to " + string(req.State),
+		})
+		return
+	}
+
+	updates := map[string]interface{}{}
+
+	if req.AssigneeID != nil {
+		if *req.AssigneeID == 0 {
+			comment.AssigneeID = nil
+		} else {
+			if !cc.checkAssignee(c, &comment.Project, *req.AssigneeID) {
+				return
+			}
+			comment.AssigneeID = req.AssigneeID
+		}
+		updates["assignee_id"] = comment.AssigneeID
+	}
+
+	if req.Priority != "" {
+		comment.Priority = &req.Priority
+		updates["priority"] = req.Priority
+	}
+
+	if req.DueDate != nil {
+		if comment.DueDate, err = models.ParseDueDate(*req.DueDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		updates["due_date"] = comment.DueDate
+	}
+
+	// Save the changes and record the transition. Columns are updated
+	// directly so the comment is not marked as edited.
+	err = cc.DB.Transaction(func(tx *gorm.DB) error {
+		if transition {
+			if err := models.TransitionIssue(tx, &comment, user.ID, req.State, req.Note); err != nil {
+				return err
+			}
+			updates["issue_state"] = comment.IssueState
+			updates["resolved_at"] = comment.ResolvedAt
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&comment).UpdateColumns(updates).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update issue",
+		})
+		return
+	}
+
+	// Load relationships for response
+	cc.DB.Preload("User").Preload("Service").Preload("Assignee").Preload("Reactions").First(&comment, comment.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Issue updated successfully",
+		"comment": comment.ToResponse(),
+	})
+}
+
+// GetIssueTransitions lists the state changes of an issue, oldest first
+func (cc *CommentController) GetIssueTransitions(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get comment ID from URL
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid comment ID",
+		})
+		return
+	}
+
+	var comment models.Comment
+	if err := cc.DB.Preload("Project").Where("status = ?", "active").
+		First(&comment, commentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Comment not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch comment",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to the project containing this comment
+	if !models.GetProjectAccess(cc.DB, &comment.Project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	var transitions []models.IssueTransition
+	if err := cc.DB.Preload("User").Where("comment_id = ?", comment.ID).
+		Order("created_at ASC, id ASC").Find(&transitions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch issue transitions",
+		})
+		return
+	}
+
+	// Convert to response format
+	transitionResponses := make([]models.IssueTransitionResponse, 0, len(transitions))
+	for _, transition := range transitions {
+		transitionResponses = append(transitionResponses, transition.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transitions": transitionResponses,
+	})
+}
+
+// GetProjectIssueBoard returns the issues of a project grouped by state.
+// ?service_id=, ?assignee_id= and ?priority= narrow the board, ?limit= caps
+// the issues returned per column.
+func (cc *CommentController) GetProjectIssueBoard(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	var project models.Project
+	if err := cc.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to this project
+	if !models.GetProjectAccess(cc.DB, &project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	query := cc.DB.Where("comments.project_id = ?", project.ID)
+	if param := c.Query("service_id"); param != "" {
+		serviceID, err := strconv.Atoi(param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid service ID",
+			})
+			return
+		}
+		query = query.Where("comments.service_id = ?", serviceID)
+	}
+
+	cc.respondIssueBoard(c, query)
+}
+
+// GetServiceIssueBoard returns the issues of a service grouped by state.
+// It takes the same parameters as GetProjectIssueBoard.
+func (cc *CommentController) GetServiceIssueBoard(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get service ID from URL
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid service ID",
+		})
+		return
+	}
+
+	var service models.Service
+	if err := cc.DB.Preload("Project").First(&service, serviceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Service not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch service",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to the project of this service
+	if !models.GetProjectAccess(cc.DB, &service.Project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	cc.respondIssueBoard(c, cc.DB.Where("comments.service_id = ?", service.ID))
+}
+
+// respondIssueBoard writes the board of the issues matched by query, most
+// urgent first in each column
+func (cc *CommentController) respondIssueBoard(c *gin.Context, query *gorm.DB) {
+	limit := defaultIssueBoardLimit
+	if param := c.Query("limit"); param != "" {
+		value, err := strconv.Atoi(param)
+		if err != nil || value < 0 || value > maxIssueBoardLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid limit, must be between 0 and " + strconv.Itoa(maxIssueBoardLimit),
+			})
+			return
+		}
+		limit = value
+	}
+
+	query = query.Where("comments.status = ? AND comments.type = ? AND comments.issue_state IS NOT NULL",
+		"active", models.CommentTypeIssue)
+	if param := c.Query("assignee_id"); param != "" {
+		assigneeID, err := strconv.Atoi(param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid assignee ID",
+			})
+			return
+		}
+		query = query.Where("comments.assignee_id = ?", assigneeID)
+	}
+	if priority := c.Query("priority"); priority != "" {
+		query = query.Where("comments.priority = ?", priority)
+	}
+
+	board := make([]models.IssueBoardColumn, 0, len(models.IssueStates))
+	for _, state := range models.IssueStates {
+		column := models.IssueBoardColumn{State: state, Issues: []models.CommentResponse{}}
+		columnQuery := query.Session(&gorm.Session{}).Model(&models.Comment{}).Where("comments.issue_state = ?", state)
+
+		if err := columnQuery.Count(&column.Count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to count issues",
+			})
+			return
+		}
+
+		if limit > 0 && column.Count > 0 {
+			var issues []models.Comment
+			if err := columnQuery.Preload("User").Preload("Service").Preload("Assignee").Preload("Reactions").
+				Order(models.IssuePriorityOrderSQL).Order("comments.due_date ASC NULLS LAST").
+				Order("comments.created_at ASC").Limit(limit).Find(&issues).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to fetch issues",
+				})
+				return
+			}
+			for _, issue := range issues {
+				column.Issues = append(column.Issues, issue.ToResponse())
+			}
+		}
+
+		board = append(board, column)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"board": board,
+	})
+}
+
+// GetProjectIssueHotspots ranks the services of a project by unresolved
+// issues, to find the parts of the architecture that need attention
+func (cc *CommentController) GetProjectIssueHotspots(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	var project models.Project
+	if err := cc.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to this project
+	if !models.GetProjectAccess(cc.DB, &project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	today := time.Now().Format("2006-01-02")
+	var hotspots []models.IssueHotspot
+	if err := cc.DB.Model(&models.Comment{}).
+		Select(`comments.service_id AS service_id,
+			COUNT(*) AS unresolved,
+			COUNT(*) FILTER (WHERE comments.issue_state = ?) AS open,
+			COUNT(*) FILTER (WHERE comments.issue_state = ?) AS in_progress,
+			COUNT(*) FILTER (WHERE comments.priority = ?) AS critical,
+			COUNT(*) FILTER (WHERE comments.priority = ?) AS high,
+			COUNT(*) FILTER (WHERE comments.due_date < ?) AS overdue,
+			MIN(comments.created_at) AS oldest_open`,
+			models.IssueOpen, models.IssueInProgress, models.IssuePriorityCritical, models.IssuePriorityHigh, today).
+		Where("comments.project_id = ? AND comments.status = ? AND comments.type = ? AND comments.service_id IS NOT NULL",
+			project.ID, "active", models.CommentTypeIssue).
+		Where("comments.issue_state IN ?", []models.IssueState{models.IssueOpen, models.IssueInProgress}).
+		Group("comments.service_id").
+		Order("critical DESC, high DESC, unresolved DESC, comments.service_id ASC").
+		Scan(&hotspots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to compute issue hotspots",
+		})
+		return
+	}
+
+	// Include the services
+	serviceIDs := make([]uint, 0, len(hotspots))
+	for _, hotspot := range hotspots {
+		serviceIDs = append(serviceIDs, hotspot.ServiceID)
+	}
+	var services []models.Service
+	if len(serviceIDs) > 0 {
+		if err := cc.DB.Where("id IN ?", serviceIDs).Find(&services).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch services",
+			})
+			return
+		}
+	}
+	servicesByID := make(map[uint]models.ServiceResponse, len(services))
+	for _, service := range services {
+		servicesByID[service.ID] = service.ToResponse()
+	}
+	for i := range hotspots {
+		if service, ok := servicesByID[hotspots[i].ServiceID]; ok {
+			hotspots[i].Service = &service
+		}
+	}
+
+	if hotspots == nil {
+		hotspots = []models.IssueHotspot{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"hotspots": hotspots,
+	})
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"sami/pkg/ratelimit"
+)
+
+// accountLocked answers 429 when the account is locked for the client after
+// too many failed logins from its address. The lock applies to unknown emails
+// too, so it does not reveal which accounts exist.
+func (ac *AuthController) accountLocked(c *gin.Context, account string) bool {
+	remaining, err := ac.Lockout.Check(c.Request.Context(), ratelimit.AccountKey(account, c.ClientIP()))
+	if err != nil {
+		log.Printf("lockout: %v", err)
+		return false
+	}
+	if remaining <= 0 {
+		return false
+	}
+
+	retryAfter := ratelimit.Seconds(remaining)
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many failed login attempts, try again later",
+		"code":        "account_locked",
+		"retry_after": retryAfter,
+	})
+	return true
+}
+
+// recordLoginFailure counts a failed login towards the lockout
+func (ac *AuthController) recordLoginFailure(c *gin.Context, account string) {
+	locked, err := ac.Lockout.Fail(c.Request.Context(), ratelimit.AccountKey(account, c.ClientIP()))
+	if err != nil {
+		log.Printf("lockout: %v", err)
+		return
+	}
+	if locked > 0 {
+		log.Printf("lockout: %s locked for %s after failed logins from %s", account, locked, c.ClientIP())
+	}
+}
+
+// resetLoginFailures clears the failures of an account once it logged in
+func (ac *AuthController) resetLoginFailures(c *gin.Context, account string) {
+	if err := ac.Lockout.Reset(c.Request.Context(), ratelimit.AccountKey(account, c.ClientIP())); err != nil {
+		log.Printf("lockout: %v", err)
+	}
+}
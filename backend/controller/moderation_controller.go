@@ -0,0 +1,611 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+	"sami/pkg/pagination"
+)
+
+type ModerationController struct {
+	DB *gorm.DB
+}
+
+// reportListSpec describes pagination and filtering of the moderation queue
+var reportListSpec = pagination.Spec[models.CommentReport]{
+	Sorts: map[string]pagination.Sort[models.CommentReport]{
+		"created_at": {Column: "comment_reports.created_at", Kind: pagination.KindTime, Value: func(r models.CommentReport) interface{} { return r.CreatedAt }},
+	},
+	DefaultSort: "created_at",
+	IDColumn:    "comment_reports.id",
+	ID:          func(r models.CommentReport) uint { return r.ID },
+	Filters: map[string]pagination.Filter{
+		"status":      {Column: "comment_reports.status"},
+		"reason":      {Column: "comment_reports.reason"},
+		"comment_id":  {Column: "comment_reports.comment_id", Kind: pagination.KindInt},
+		"reporter_id": {Column: "comment_reports.reporter_id", Kind: pagination.KindInt},
+	},
+	DefaultLimit: 50,
+}
+
+// canComment writes an error response unless the user can write comments
+// and reactions on the project, given the comment settings and moderation
+func canComment(c *gin.Context, db *gorm.DB, project *models.Project, user *models.User, access models.ProjectAccess) bool {
+	denial, err := models.CommentDenial(db, project, user, access)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check comment permissions",
+		})
+		return false
+	}
+	if denial != "" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": denial,
+		})
+		return false
+	}
+	return true
+}
+
+// findManagedProject loads the project in the URL if the user can manage
+// it. It responds and returns false otherwise.
+func (mc *ModerationController) findManagedProject(c *gin.Context, user *models.User) (*models.Project, bool) {
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return nil, false
+	}
+
+	var project models.Project
+	if err := mc.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return nil, false
+	}
+
+	if !models.GetProjectAccess(mc.DB, &project, user).CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only project owners can moderate comments",
+		})
+		return nil, false
+	}
+
+	return &project, true
+}
+
+// UpdateCommentSettings changes who can comment on a project
+func (mc *ModerationController) UpdateCommentSettings(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var req models.UpdateCommentSettingsRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	project, found := mc.findManagedProject(c, user)
+	if !found {
+		return
+	}
+
+	project.CommentPolicy = string(req.CommentPolicy)
+	if err := mc.DB.Model(project).Update("comment_policy", project.CommentPolicy).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update comment settings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Comment settings updated successfully",
+		"comment_policy": project.CommentPolicy,
+	})
+}
+
+// ReportComment reports a comment to the owners of its project
+func (mc *ModerationController) ReportComment(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get comment ID from URL
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid comment ID",
+		})
+		return
+	}
+
+	var req models.ReportCommentRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var comment models.Comment
+	if err := mc.DB.Preload("Project").Where("status = ?", "active").
+		First(&comment, commentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Comment not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch comment",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to the project containing this comment
+	if !models.GetProjectAccess(mc.DB, &comment.Project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	if comment.UserID == user.ID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "You cannot report your own comment",
+		})
+		return
+	}
+
+	// One open report per user and comment
+	var existing int64
+	if err := mc.DB.Model(&models.CommentReport{}).
+		Where("comment_id = ? AND reporter_id = ? AND status = ?", comment.ID, user.ID, models.ReportOpen).
+		Count(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to report comment",
+		})
+		return
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "You have already reported this comment",
+		})
+		return
+	}
+
+	report := models.CommentReport{
+		CommentID:  comment.ID,
+		ProjectID:  comment.ProjectID,
+		ReporterID: user.ID,
+		Reason:     req.Reason,
+		Details:    req.Details,
+		Status:     models.ReportOpen,
+	}
+	if err := mc.DB.Create(&report).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to report comment",
+		})
+		return
+	}
+
+	report.Reporter = *user
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Comment reported successfully",
+		"report":  report.ToResponse(),
+	})
+}
+
+// GetModerationReports lists the reports of the comments of a project,
+// oldest first. ?status=open shows the moderation queue.
+func (mc *ModerationController) GetModerationReports(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	project, found := mc.findManagedProject(c, user)
+	if !found {
+		return
+	}
+
+	// Parse pagination, sort and filter parameters
+	params, err := pagination.Parse(c.Request.URL.Query(), reportListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	query := mc.DB.Where("comment_reports.project_id = ?", project.ID)
+	page, err := pagination.Find(query, reportListSpec, params, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Reporter").Preload("Comment.User")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch reports",
+		})
+		return
+	}
+
+	// Convert to response format
+	reportResponses := make([]models.CommentReportResponse, 0, len(page.Items))
+	for _, report := range page.Items {
+		reportResponses = append(reportResponses, report.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports":    reportResponses,
+		"pagination": page.Info,
+	})
+}
+
+// ResolveReport closes the open reports of a comment, dismissing them or
+// removing the comment
+func (mc *ModerationController) ResolveReport(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get report ID from URL
+	reportID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid report ID",
+		})
+		return
+	}
+
+	var req models.ResolveReportRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var report models.CommentReport
+	if err := mc.DB.First(&report, reportID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Report not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch report",
+			})
+		}
+		return
+	}
+
+	var project models.Project
+	if err := mc.DB.First(&project, report.ProjectID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch project",
+		})
+		return
+	}
+
+	if !models.GetProjectAccess(mc.DB, &project, user).CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only project owners can moderate comments",
+		})
+		return
+	}
+
+	if report.Status != models.ReportOpen {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Report is already resolved",
+		})
+		return
+	}
+
+	status := models.ReportDismissed
+	if req.Action == "remove" {
+		status = models.ReportActioned
+	}
+
+	var resolved int64
+	err = mc.DB.Transaction(func(tx *gorm.DB) error {
+		if req.Action == "remove" {
+			if err := tx.Model(&models.Comment{}).Where("id = ?", report.CommentID).
+				UpdateColumn("status", "deleted").Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&models.CommentReport{}).
+			Where("comment_id = ? AND status = ?", report.CommentID, models.ReportOpen).
+			Updates(map[string]interface{}{
+				"status":      status,
+				"resolved_by": user.ID,
+				"resolved_at": time.Now(),
+				"resolution":  req.Note,
+			})
+		resolved = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to resolve report",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Report resolved successfully",
+		"status":   status,
+		"resolved": resolved,
+	})
+}
+
+// GetRestrictions lists the users muted or banned on a project. Only the
+// restrictions in force are listed unless ?history=true.
+func (mc *ModerationController) GetRestrictions(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	project, found := mc.findManagedProject(c, user)
+	if !found {
+		return
+	}
+
+	var restrictions []models.ProjectRestriction
+	query := mc.DB.Preload("User").Where("project_id = ?", project.ID)
+	if c.Query("history") != "true" {
+		query = query.Where("lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", time.Now())
+	}
+	if err := query.Order("created_at DESC, id DESC").Find(&restrictions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch restrictions",
+		})
+		return
+	}
+
+	// Convert to response format
+	restrictionResponses := make([]models.ProjectRestrictionResponse, 0, len(restrictions))
+	for _, restriction := range restrictions {
+		restrictionResponses = append(restrictionResponses, restriction.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"restrictions": restrictionResponses,
+	})
+}
+
+// CreateRestriction mutes or bans a user on a project, replacing the
+// restriction in force if any
+func (mc *ModerationController) CreateRestriction(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var req models.CreateRestrictionRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "The expiry must be in the future",
+		})
+		return
+	}
+
+	project, found := mc.findManagedProject(c, user)
+	if !found {
+		return
+	}
+
+	var target models.User
+	if err := mc.DB.First(&target, req.UserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch user",
+			})
+		}
+		return
+	}
+
+	if models.GetProjectAccess(mc.DB, project, &target).CanManage() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Project owners cannot be muted or banned",
+		})
+		return
+	}
+
+	restriction := models.ProjectRestriction{
+		ProjectID: project.ID,
+		UserID:    target.ID,
+		Kind:      req.Kind,
+		Reason:    req.Reason,
+		CreatedBy: &user.ID,
+		ExpiresAt: req.ExpiresAt,
+	}
+	err := mc.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := models.LiftRestrictions(tx, project.ID, target.ID, user.ID); err != nil {
+			return err
+		}
+		return tx.Create(&restriction).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save restriction",
+		})
+		return
+	}
+
+	restriction.User = target
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Restriction saved successfully",
+		"restriction": restriction.ToResponse(),
+	})
+}
+
+// LiftRestriction lifts the mute or ban of a user on a project
+func (mc *ModerationController) LiftRestriction(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get user ID from URL
+	targetID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	project, found := mc.findManagedProject(c, user)
+	if !found {
+		return
+	}
+
+	lifted, err := models.LiftRestrictions(mc.DB, project.ID, uint(targetID), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to lift restriction",
+		})
+		return
+	}
+	if lifted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "No restriction in force for this user",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Restriction lifted successfully",
+	})
+}
@@ -0,0 +1,323 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+	"sami/pkg/config"
+	"sami/pkg/pagination"
+)
+
+type NotificationController struct {
+	DB       *gorm.DB
+	Settings config.DigestConfig
+}
+
+// notificationListSpec describes pagination and filtering for the inbox
+var notificationListSpec = pagination.Spec[models.Notification]{
+	Sorts: map[string]pagination.Sort[models.Notification]{
+		"created_at": {Column: "notifications.created_at", Kind: pagination.KindTime, Value: func(n models.Notification) interface{} { return n.CreatedAt }},
+	},
+	DefaultSort: "created_at",
+	DefaultDesc: true,
+	IDColumn:    "notifications.id",
+	ID:          func(n models.Notification) uint { return n.ID },
+	Filters: map[string]pagination.Filter{
+		"type":       {Column: "notifications.type"},
+		"project_id": {Column: "notifications.project_id", Kind: pagination.KindInt},
+	},
+	DefaultLimit: 50,
+}
+
+// inbox restricts a notifications query to the inbox of a user. Notifications
+// about deleted comments are hidden.
+func (nc *NotificationController) inbox(userID uint) *gorm.DB {
+	return nc.DB.Model(&models.Notification{}).
+		Where("notifications.user_id = ?", userID).
+		Where("notifications.comment_id IN (SELECT id FROM comments WHERE status = ?)", "active")
+}
+
+// GetNotifications lists the notifications of the authenticated user, newest
+// first. ?unread=true only returns unread notifications.
+func (nc *NotificationController) GetNotifications(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Parse pagination, sort and filter parameters
+	params, err := pagination.Parse(c.Request.URL.Query(), notificationListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	query := nc.inbox(user.ID)
+	if c.Query("unread") == "true" {
+		query = query.Where("notifications.read_at IS NULL")
+	}
+
+	page, err := pagination.Find(query, notificationListSpec, params, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Actor").Preload("Project").Preload("Comment").Preload("Service")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch notifications",
+		})
+		return
+	}
+
+	// Convert to response format
+	notificationResponses := make([]models.NotificationResponse, 0, len(page.Items))
+	for _, notification := range page.Items {
+		notificationResponses = append(notificationResponses, notification.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notificationResponses,
+		"pagination":    page.Info,
+	})
+}
+
+// GetUnreadCount returns the number of unread notifications of the
+// authenticated user
+func (nc *NotificationController) GetUnreadCount(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var count int64
+	if err := nc.inbox(user.ID).Where("notifications.read_at IS NULL").Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to count notifications",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"unread": count,
+	})
+}
+
+// MarkNotificationRead marks a notification of the authenticated user as read
+func (nc *NotificationController) MarkNotificationRead(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get notification ID from URL
+	notificationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid notification ID",
+		})
+		return
+	}
+
+	var notification models.Notification
+	if err := nc.DB.Where("id = ? AND user_id = ?", notificationID, user.ID).First(&notification).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Notification not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch notification",
+			})
+		}
+		return
+	}
+
+	// Keep the time it was first read
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := nc.DB.Model(&notification).Update("read_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update notification",
+			})
+			return
+		}
+		notification.ReadAt = &now
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Notification marked as read",
+		"notification": notification.ToResponse(),
+	})
+}
+
+// MarkAllNotificationsRead marks every notification of the authenticated
+// user as read. ?project_id= limits it to one project.
+func (nc *NotificationController) MarkAllNotificationsRead(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	query := nc.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", user.ID)
+	if param := c.Query("project_id"); param != "" {
+		projectID, err := strconv.Atoi(param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid project ID",
+			})
+			return
+		}
+		query = query.Where("project_id = ?", projectID)
+	}
+
+	result := query.Update("read_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update notifications",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notifications marked as read",
+		"updated": result.RowsAffected,
+	})
+}
+
+// GetNotificationPreferences returns the notification settings of the
+// authenticated user
+func (nc *NotificationController) GetNotificationPreferences(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	preference, err := models.GetNotificationPreference(nc.DB, user.ID, models.DigestFrequency(nc.Settings.DefaultFrequency))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch notification preferences",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preferences": preference,
+	})
+}
+
+// UpdateNotificationPreferences changes the notification settings of the
+// authenticated user
+func (nc *NotificationController) UpdateNotificationPreferences(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var req models.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	preference, err := models.GetNotificationPreference(nc.DB, user.ID, models.DigestFrequency(nc.Settings.DefaultFrequency))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch notification preferences",
+		})
+		return
+	}
+
+	preference.DigestFrequency = req.DigestFrequency
+	if err := nc.DB.Save(preference).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update notification preferences",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Notification preferences updated successfully",
+		"preferences": preference,
+	})
+}
@@ -0,0 +1,306 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+	"sami/pkg/oidc"
+)
+
+const defaultOIDCFlowTTL = 10 * time.Minute
+
+var (
+	errNoLinkedAccount = errors.New("no account linked to this identity")
+	errEmailTaken      = errors.New("email already used by another account")
+)
+
+// StartOIDCLogin returns the provider URL to send the user to and the flow
+// token to send back with the callback
+func (ac *AuthController) StartOIDCLogin(c *gin.Context) {
+	if ac.OIDC == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Single sign-on is not enabled",
+		})
+		return
+	}
+
+	var flow models.OIDCFlow
+	for _, value := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		random, err := oidc.RandomString()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to start login",
+			})
+			return
+		}
+		*value = random
+	}
+
+	authURL, err := ac.OIDC.AuthCodeURL(c.Request.Context(), flow.State, flow.Nonce, flow.Verifier)
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": "Identity provider unavailable",
+		})
+		return
+	}
+
+	ttl := ac.OIDCSettings.FlowTTL
+	if ttl <= 0 {
+		ttl = defaultOIDCFlowTTL
+	}
+
+	flowToken, err := models.SignOIDCFlow(tokenSecret(), flow, ttl)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to start login",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.OIDCLoginResponse{
+		AuthorizationURL: authURL,
+		FlowToken:        flowToken,
+		ExpiresIn:        int(ttl.Seconds()),
+	})
+}
+
+// OIDCCallback finishes a login: it exchanges the code, verifies the ID
+// token, finds or provisions the user and issues the session token, or the
+// 2FA challenge when the user has enabled TOTP.
+func (ac *AuthController) OIDCCallback(c *gin.Context) {
+	if ac.OIDC == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Single sign-on is not enabled",
+		})
+		return
+	}
+
+	var req models.OIDCCallbackRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	flow, err := models.ParseOIDCFlow(tokenSecret(), req.FlowToken)
+	if err != nil || subtle.ConstantTimeCompare([]byte(flow.State), []byte(req.State)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid or expired login attempt",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	token, err := ac.OIDC.Exchange(ctx, req.Code, flow.Verifier)
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Failed to authenticate with the identity provider",
+		})
+		return
+	}
+
+	claims, err := ac.OIDC.VerifyIDToken(ctx, token.IDToken, flow.Nonce)
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Failed to authenticate with the identity provider",
+		})
+		return
+	}
+
+	user, err := ac.resolveOIDCUser(ctx, claims)
+	if err != nil {
+		switch {
+		case errors.Is(err, errNoLinkedAccount):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "No account is linked to this identity",
+			})
+		case errors.Is(err, errEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "An account already exists for this email",
+			})
+		case errors.Is(err, errInactiveAccount):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Account is not active",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to log in",
+			})
+		}
+		return
+	}
+
+	// The provider replaces the password only: the email policy and the
+	// second factor still apply, unless the provider is trusted for it
+	if !ac.checkLogin(c, user, ac.OIDCSettings.TrustProviderMFA) {
+		return
+	}
+
+	ac.issueSession(c, user)
+}
+
+// resolveOIDCUser finds the user of a provider account. Unknown accounts are
+// linked to the user with the same verified email, or provisioned when
+// enabled. Roles and mapped teams are synced from the groups claim.
+func (ac *AuthController) resolveOIDCUser(ctx context.Context, claims *oidc.Claims) (*models.User, error) {
+	issuer := strings.TrimSuffix(ac.OIDCSettings.IssuerURL, "/")
+	groups := claims.Strings(ac.OIDCSettings.GroupsClaim)
+
+	var user *models.User
+	err := ac.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = models.FindUserByIdentity(tx, issuer, claims.Subject)
+		if err != nil {
+			return err
+		}
+
+		if user == nil {
+			if user, err = ac.linkOIDCUser(tx, issuer, claims); err != nil {
+				return err
+			}
+		}
+
+		if user.Status != models.StatusActive {
+			return errInactiveAccount
+		}
+
+		updates := map[string]interface{}{}
+		if adminGroups := ac.OIDCSettings.AdminGroupList(); len(adminGroups) > 0 {
+			role := models.UserRole
+			if containsAny(groups, adminGroups) {
+				role = models.AdminRole
+			}
+			if user.Role != role {
+				user.Role = role
+				updates["role"] = role
+			}
+		}
+		if !user.IsEmailVerified() && claims.EmailVerified && strings.EqualFold(claims.Email, user.Email) {
+			now := time.Now()
+			user.EmailVerifiedAt = &now
+			updates["email_verified_at"] = now
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(user).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := models.SyncMappedTeams(tx, user.ID, ac.OIDCSettings.TeamMappingList(), groups); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.UserIdentity{}).
+			Where("issuer = ? AND subject = ?", issuer, claims.Subject).
+			Updates(map[string]interface{}{"email": claims.Email, "last_login_at": time.Now()}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// linkOIDCUser links a provider account seen for the first time to a user,
+// creating the user when auto-provisioning is on
+func (ac *AuthController) linkOIDCUser(tx *gorm.DB, issuer string, claims *oidc.Claims) (*models.User, error) {
+	if claims.Email == "" {
+		return nil, errNoLinkedAccount
+	}
+
+	var user models.User
+	lookup := tx.Where("email = ?", claims.Email).First(&user)
+	switch {
+	case lookup.Error == nil:
+		// Only a verified address proves it is the same person
+		if !claims.EmailVerified {
+			return nil, errEmailTaken
+		}
+	case errors.Is(lookup.Error, gorm.ErrRecordNotFound):
+		if !ac.OIDCSettings.AutoProvision {
+			return nil, errNoLinkedAccount
+		}
+
+		name := strings.TrimSpace(claims.Name)
+		if name == "" {
+			name = claims.Email
+		}
+
+		user = models.User{
+			Name:   name,
+			Email:  claims.Email,
+			Role:   models.UserRole,
+			Status: models.StatusActive,
+		}
+		if claims.EmailVerified {
+			now := time.Now()
+			user.EmailVerifiedAt = &now
+		}
+
+		// SSO users log in through the provider; the random password is
+		// never shown but can be replaced with a password reset
+		password, err := randomPassword()
+		if err != nil {
+			return nil, err
+		}
+		if err := user.SetPassword(password); err != nil {
+			return nil, err
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return nil, err
+		}
+	default:
+		return nil, lookup.Error
+	}
+
+	if err := tx.Create(&models.UserIdentity{
+		UserID:  user.ID,
+		Issuer:  issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// containsAny reports whether values and candidates have an item in common
+func containsAny(values, candidates []string) bool {
+	for _, value := range values {
+		for _, candidate := range candidates {
+			if value == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// randomPassword returns an unguessable password for accounts that do not
+// log in with one
+func randomPassword() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
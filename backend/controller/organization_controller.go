@@ -0,0 +1,690 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+)
+
+type OrganizationController struct {
+	DB *gorm.DB
+}
+
+// GetOrganizations lists the organizations the authenticated user belongs to
+func (oc *OrganizationController) GetOrganizations(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var memberships []models.OrganizationMember
+	if err := oc.DB.Preload("Organization").Where("user_id = ?", user.ID).
+		Find(&memberships).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch organizations",
+		})
+		return
+	}
+
+	// Convert to response format, including the user's role in each organization
+	organizationResponses := make([]models.OrganizationResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		response := membership.Organization.ToResponse()
+		response.Role = membership.Role
+		organizationResponses = append(organizationResponses, response)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"organizations": organizationResponses,
+	})
+}
+
+// CreateOrganization creates a new organization with the creator as its admin
+func (oc *OrganizationController) CreateOrganization(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var req models.CreateOrganizationRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Check if organization slug already exists
+	var existingOrganization models.Organization
+	if err := oc.DB.Where("slug = ?", req.Slug).First(&existingOrganization).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Organization with this slug already exists",
+		})
+		return
+	}
+
+	organization := models.Organization{
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Description: req.Description,
+		CreatedBy:   user.ID,
+	}
+
+	// Create organization and its first admin in a transaction
+	err := oc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&organization).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OrganizationMember{
+			OrganizationID: organization.ID,
+			UserID:         user.ID,
+			Role:           models.OrgAdminRole,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create organization",
+		})
+		return
+	}
+
+	response := organization.ToResponse()
+	response.Role = models.OrgAdminRole
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Organization created successfully",
+		"organization": response,
+	})
+}
+
+// GetOrganization retrieves an organization with its teams
+func (oc *OrganizationController) GetOrganization(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get organization ID from URL
+	organizationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid organization ID",
+		})
+		return
+	}
+
+	var organization models.Organization
+	if err := oc.DB.Preload("Teams", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name ASC")
+	}).First(&organization, organizationID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Organization not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch organization",
+			})
+		}
+		return
+	}
+
+	// Check if user is a member of this organization
+	role := models.GetOrganizationRole(oc.DB, organization.ID, user.ID)
+	if role == "" && user.Role != models.AdminRole {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	response := organization.ToResponse()
+	response.Role = role
+
+	teamResponses := make([]models.TeamResponse, 0, len(organization.Teams))
+	for _, team := range organization.Teams {
+		teamResponses = append(teamResponses, team.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"organization": response,
+		"teams":        teamResponses,
+	})
+}
+
+// UpdateOrganization updates an organization
+func (oc *OrganizationController) UpdateOrganization(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get organization ID from URL
+	organizationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid organization ID",
+		})
+		return
+	}
+
+	var req models.UpdateOrganizationRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var organization models.Organization
+	if err := oc.DB.First(&organization, organizationID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Organization not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch organization",
+			})
+		}
+		return
+	}
+
+	// Check if user is an organization admin
+	if !models.CanManageOrganization(oc.DB, organization.ID, user) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Organization admin access required",
+		})
+		return
+	}
+
+	// Update fields if provided
+	if req.Name != "" {
+		organization.Name = req.Name
+	}
+	if req.Description != nil {
+		organization.Description = *req.Description
+	}
+
+	if err := oc.DB.Save(&organization).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update organization",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Organization updated successfully",
+		"organization": organization.ToResponse(),
+	})
+}
+
+// DeleteOrganization deletes an organization that no longer owns projects
+func (oc *OrganizationController) DeleteOrganization(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get organization ID from URL
+	organizationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid organization ID",
+		})
+		return
+	}
+
+	var organization models.Organization
+	if err := oc.DB.First(&organization, organizationID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Organization not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch organization",
+			})
+		}
+		return
+	}
+
+	// Check if user is an organization admin
+	if !models.CanManageOrganization(oc.DB, organization.ID, user) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Organization admin access required",
+		})
+		return
+	}
+
+	// Refuse to delete organizations that still own projects
+	var projectCount int64
+	oc.DB.Model(&models.Project{}).Where("organization_id = ?", organization.ID).Count(&projectCount)
+	if projectCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Organization still owns projects",
+		})
+		return
+	}
+
+	// Delete organization; members, teams and team grants cascade
+	if err := oc.DB.Delete(&organization).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete organization",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Organization deleted successfully",
+	})
+}
+
+// GetOrganizationMembers lists the members of an organization
+func (oc *OrganizationController) GetOrganizationMembers(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get organization ID from URL
+	organizationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid organization ID",
+		})
+		return
+	}
+
+	// Check if user is a member of this organization
+	if models.GetOrganizationRole(oc.DB, uint(organizationID), user.ID) == "" && user.Role != models.AdminRole {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	var members []models.OrganizationMember
+	if err := oc.DB.Preload("User").Where("organization_id = ?", organizationID).
+		Order("joined_at ASC").Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch members",
+		})
+		return
+	}
+
+	// Convert to response format
+	memberResponses := make([]models.OrganizationMemberResponse, 0, len(members))
+	for _, member := range members {
+		memberResponses = append(memberResponses, member.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"members": memberResponses,
+	})
+}
+
+// AddOrganizationMember adds an existing user to an organization
+func (oc *OrganizationController) AddOrganizationMember(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get organization ID from URL
+	organizationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid organization ID",
+		})
+		return
+	}
+
+	var req models.AddOrganizationMemberRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var organization models.Organization
+	if err := oc.DB.First(&organization, organizationID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Organization not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch organization",
+			})
+		}
+		return
+	}
+
+	// Check if user is an organization admin
+	if !models.CanManageOrganization(oc.DB, organization.ID, user) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Organization admin access required",
+		})
+		return
+	}
+
+	// Find user to add
+	var targetUser models.User
+	if err := oc.DB.Where("email = ? AND deleted_at IS NULL", req.Email).First(&targetUser).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	// Check if user is already a member
+	if models.GetOrganizationRole(oc.DB, organization.ID, targetUser.ID) != "" {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "User is already a member of this organization",
+		})
+		return
+	}
+
+	// Set default role if not provided
+	if req.Role == "" {
+		req.Role = models.OrgMemberRole
+	}
+
+	member := models.OrganizationMember{
+		OrganizationID: organization.ID,
+		UserID:         targetUser.ID,
+		Role:           req.Role,
+	}
+
+	if err := oc.DB.Create(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to add member",
+		})
+		return
+	}
+
+	member.User = targetUser
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Member added successfully",
+		"member":  member.ToResponse(),
+	})
+}
+
+// UpdateOrganizationMember changes the role of an organization member
+func (oc *OrganizationController) UpdateOrganizationMember(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get organization and user IDs from URL
+	organizationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid organization ID",
+		})
+		return
+	}
+
+	memberID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	var req models.UpdateOrganizationMemberRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Check if user is an organization admin
+	if !models.CanManageOrganization(oc.DB, uint(organizationID), user) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Organization admin access required",
+		})
+		return
+	}
+
+	var member models.OrganizationMember
+	if err := oc.DB.Where("organization_id = ? AND user_id = ?", organizationID, memberID).
+		First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Member not found",
+		})
+		return
+	}
+
+	// Keep at least one admin in the organization
+	if member.Role == models.OrgAdminRole && req.Role != models.OrgAdminRole && isLastOrganizationAdmin(oc.DB, member) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Organization must keep at least one admin",
+		})
+		return
+	}
+
+	if err := oc.DB.Model(&models.OrganizationMember{}).Where("organization_id = ? AND user_id = ?", organizationID, memberID).
+		Update("role", req.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update member",
+		})
+		return
+	}
+
+	oc.DB.Preload("User").Where("organization_id = ? AND user_id = ?", organizationID, memberID).First(&member)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member updated successfully",
+		"member":  member.ToResponse(),
+	})
+}
+
+// RemoveOrganizationMember removes a user from an organization and its teams
+func (oc *OrganizationController) RemoveOrganizationMember(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get organization and user IDs from URL
+	organizationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid organization ID",
+		})
+		return
+	}
+
+	memberID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	// Members can leave on their own, admins can remove anyone
+	if uint(memberID) != user.ID && !models.CanManageOrganization(oc.DB, uint(organizationID), user) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Organization admin access required",
+		})
+		return
+	}
+
+	var member models.OrganizationMember
+	if err := oc.DB.Where("organization_id = ? AND user_id = ?", organizationID, memberID).
+		First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Member not found",
+		})
+		return
+	}
+
+	// Keep at least one admin in the organization
+	if member.Role == models.OrgAdminRole && isLastOrganizationAdmin(oc.DB, member) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Organization must keep at least one admin",
+		})
+		return
+	}
+
+	err = oc.DB.Transaction(func(tx *gorm.DB) error {
+		// Remove the user from every team of the organization
+		if err := tx.Where("user_id = ? AND team_id IN (SELECT id FROM teams WHERE organization_id = ?)",
+			memberID, organizationID).Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("organization_id = ? AND user_id = ?", organizationID, memberID).
+			Delete(&models.OrganizationMember{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to remove member",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member removed successfully",
+	})
+}
+
+// isLastOrganizationAdmin reports whether member is the only admin left
+func isLastOrganizationAdmin(db *gorm.DB, member models.OrganizationMember) bool {
+	var adminCount int64
+	db.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND role = ?", member.OrganizationID, models.OrgAdminRole).
+		Count(&adminCount)
+	return adminCount <= 1
+}
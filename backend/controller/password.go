@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"sami/models"
+	"sami/pkg/password"
+)
+
+// fallbackPasswordPolicy keeps the historical minimum when no policy is
+// configured
+var fallbackPasswordPolicy = &password.Policy{MinLength: 6}
+
+// passwordPolicyError carries the requirements a new password does not meet
+// out of a transaction
+type passwordPolicyError struct {
+	Fields []models.FieldError
+}
+
+func (e *passwordPolicyError) Error() string {
+	return "password does not meet the policy"
+}
+
+// passwordFieldErrors checks a new password against the policy. personal
+// holds the user's name and email.
+func passwordFieldErrors(policy *password.Policy, field, pw string, personal ...string) []models.FieldError {
+	if policy == nil {
+		policy = fallbackPasswordPolicy
+	}
+
+	var fieldErrors []models.FieldError
+	for _, problem := range policy.Check(pw, personal...) {
+		fieldErrors = append(fieldErrors, models.FieldError{
+			Field:   field,
+			Message: "Password " + problem,
+		})
+	}
+	return fieldErrors
+}
+
+// respondPasswordPolicy answers 400 with the requirements the password misses
+func respondPasswordPolicy(c *gin.Context, fieldErrors []models.FieldError) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  "Password does not meet the requirements",
+		"fields": fieldErrors,
+	})
+}
+
+// GetPasswordPolicy returns the requirements for new passwords so clients
+// can show them before submitting
+func (ac *AuthController) GetPasswordPolicy(c *gin.Context) {
+	policy := ac.Passwords
+	if policy == nil {
+		policy = fallbackPasswordPolicy
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policy": policy,
+	})
+}
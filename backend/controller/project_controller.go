@@ -9,10 +9,54 @@ import (
 	"gorm.io/gorm"
 
 	"sami/models"
+	"sami/pkg/pagination"
 )
 
 type ProjectController struct {
-	DB *gorm.DB
+	DB          *gorm.DB
+	Invitations *InvitationController
+}
+
+// projectListSpec describes pagination, sorting and filtering for GetProjects
+var projectListSpec = pagination.Spec[models.Project]{
+	Sorts: map[string]pagination.Sort[models.Project]{
+		"name":       {Column: "projects.name", Kind: pagination.KindString, Value: func(p models.Project) interface{} { return p.Name }},
+		"created_at": {Column: "projects.created_at", Kind: pagination.KindTime, Value: func(p models.Project) interface{} { return p.CreatedAt }},
+		"updated_at": {Column: "projects.updated_at", Kind: pagination.KindTime, Value: func(p models.Project) interface{} { return p.UpdatedAt }},
+	},
+	DefaultSort: "created_at",
+	DefaultDesc: true,
+	IDColumn:    "projects.id",
+	ID:          func(p models.Project) uint { return p.ID },
+	Filters: map[string]pagination.Filter{
+		"status":          {Column: "projects.status"},
+		"visibility":      {Column: "projects.visibility"},
+		"owner_id":        {Column: "projects.owner_id", Kind: pagination.KindInt},
+		"organization_id": {Column: "projects.organization_id", Kind: pagination.KindInt},
+	},
+	DateRanges: map[string]string{
+		"created": "projects.created_at",
+		"updated": "projects.updated_at",
+	},
+	DefaultLimit: 50,
+}
+
+// collaboratorListSpec describes pagination, sorting and filtering for GetProjectCollaborators
+var collaboratorListSpec = pagination.Spec[models.ProjectCollaborator]{
+	Sorts: map[string]pagination.Sort[models.ProjectCollaborator]{
+		"joined_at": {Column: "project_collaborators.joined_at", Kind: pagination.KindTime, Value: func(pc models.ProjectCollaborator) interface{} { return pc.JoinedAt }},
+		"role":      {Column: "project_collaborators.role", Kind: pagination.KindString, Value: func(pc models.ProjectCollaborator) interface{} { return pc.Role }},
+	},
+	DefaultSort: "joined_at",
+	IDColumn:    "project_collaborators.user_id",
+	ID:          func(pc models.ProjectCollaborator) uint { return pc.UserID },
+	Filters: map[string]pagination.Filter{
+		"role": {Column: "project_collaborators.role"},
+	},
+	DateRanges: map[string]string{
+		"joined": "project_collaborators.joined_at",
+	},
+	DefaultLimit: 50,
 }
 
 // GetProjects lists all visible projects for the authenticated user
@@ -34,30 +78,42 @@ func (pc *ProjectController) GetProjects(c *gin.Context) {
 		return
 	}
 
-	var projects []models.Project
+	// Parse pagination, sort and filter parameters
+	params, err := pagination.Parse(c.Request.URL.Query(), projectListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Query projects: owned by user, public, or shared with the user directly,
+	// through one of their teams or an organization they administer
+	query := models.AccessibleProjects(pc.DB, user.ID)
+
+	// Filter by tags (?tag=a,b matches projects carrying all of them)
+	query = models.FilterByProjectTags(query, "projects.id", models.ParseTagFilter(c.Query("tag")))
 
-	// Query projects: owned by user OR public projects OR user is a collaborator
-	if err := pc.DB.Where("owner_id = ? OR visibility = ? OR id IN (SELECT project_id FROM project_collaborators WHERE user_id = ? AND state = ?)",
-		user.ID, "public", user.ID, "active").Find(&projects).Error; err != nil {
+	page, err := pagination.Find(query, projectListSpec, params, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Owner").Preload("Tags")
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch projects",
 		})
 		return
 	}
 
-	// Load owner relationships for response
-	for i := range projects {
-		pc.DB.Model(&projects[i]).Association("Owner").Find(&projects[i].Owner)
-	}
-
 	// Convert to response format
-	var projectResponses []models.ProjectResponse
-	for _, project := range projects {
+	projectResponses := make([]models.ProjectResponse, 0, len(page.Items))
+	for _, project := range page.Items {
 		projectResponses = append(projectResponses, project.ToResponse())
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"projects": projectResponses,
+		"projects":   projectResponses,
+		"pagination": page.Info,
 	})
 }
 
@@ -105,6 +161,26 @@ func (pc *ProjectController) CreateProject(c *gin.Context) {
 		req.Visibility = "private"
 	}
 
+	// Projects can only be created in organizations the user belongs to
+	if req.OrganizationID != nil {
+		if models.GetOrganizationRole(pc.DB, *req.OrganizationID, user.ID) == "" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "You are not a member of this organization",
+			})
+			return
+		}
+	}
+
+	// Resolve tags, creating the ones that do not exist yet
+	tags, err := models.ResolveTags(pc.DB, req.Tags, user.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid tags",
+			"details": err.Error(),
+		})
+		return
+	}
+
 	// Create new project
 	project := models.Project{
 		Name:        req.Name,
@@ -113,6 +189,9 @@ func (pc *ProjectController) CreateProject(c *gin.Context) {
 		OwnerID:     user.ID,
 		Visibility:  req.Visibility,
 		Status:      "active",
+		Tags:        tags,
+
+		OrganizationID: req.OrganizationID,
 	}
 
 	// Save to database
@@ -124,7 +203,7 @@ func (pc *ProjectController) CreateProject(c *gin.Context) {
 	}
 
 	// Load owner relationship
-	pc.DB.Preload("Owner").First(&project, project.ID)
+	pc.DB.Preload("Owner").Preload("Tags").First(&project, project.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Project created successfully",
@@ -163,7 +242,7 @@ func (pc *ProjectController) GetProject(c *gin.Context) {
 	var project models.Project
 
 	// Find project with all relationships
-	if err := pc.DB.Preload("Owner").Preload("Collaborators.User").
+	if err := pc.DB.Preload("Owner").Preload("Collaborators.User").Preload("Teams.Team").Preload("Tags").
 		First(&project, projectID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -178,21 +257,17 @@ func (pc *ProjectController) GetProject(c *gin.Context) {
 	}
 
 	// Check if user has access to this project
-	hasAccess := project.OwnerID == user.ID || project.Visibility == "public"
-	if !hasAccess {
-		// Check if user is a collaborator
-		var collaborator models.ProjectCollaborator
-		if err := pc.DB.Where("project_id = ? AND user_id = ? AND state = ?",
-			projectID, user.ID, "active").First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
-			})
-			return
-		}
+	access := models.GetProjectAccess(pc.DB, &project, user)
+	if !access.CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"project": project.ToResponse(),
+		"access":  access,
 	})
 }
 
@@ -251,13 +326,26 @@ func (pc *ProjectController) UpdateProject(c *gin.Context) {
 	}
 
 	// Check if user is owner or admin
-	if project.OwnerID != user.ID && user.Role != "admin" {
+	if !models.GetProjectAccess(pc.DB, &project, user).CanManage() {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "Only project owner can update project",
 		})
 		return
 	}
 
+	// Resolve tags, creating the ones that do not exist yet
+	var tags []models.Tag
+	if req.Tags != nil {
+		tags, err = models.ResolveTags(pc.DB, req.Tags, user.ID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid tags",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
 	// Update fields if provided
 	if req.Name != "" {
 		project.Name = req.Name
@@ -272,6 +360,28 @@ func (pc *ProjectController) UpdateProject(c *gin.Context) {
 		project.Status = req.Status
 	}
 
+	// Move the project to another organization; team grants do not follow
+	movedOrganization := false
+	if req.OrganizationID != nil {
+		current := uint(0)
+		if project.OrganizationID != nil {
+			current = *project.OrganizationID
+		}
+		if *req.OrganizationID != current {
+			if *req.OrganizationID != 0 && models.GetOrganizationRole(pc.DB, *req.OrganizationID, user.ID) == "" {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error": "You are not a member of this organization",
+				})
+				return
+			}
+			project.OrganizationID = nil
+			if *req.OrganizationID != 0 {
+				project.OrganizationID = req.OrganizationID
+			}
+			movedOrganization = true
+		}
+	}
+
 	// Save changes
 	if err := pc.DB.Save(&project).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -280,8 +390,36 @@ func (pc *ProjectController) UpdateProject(c *gin.Context) {
 		return
 	}
 
+	if movedOrganization {
+		if err := pc.DB.Where("project_id = ?", project.ID).Delete(&models.ProjectTeam{}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to remove team grants",
+			})
+			return
+		}
+
+		// Teams of the previous organization no longer own the project's services
+		if err := pc.DB.Model(&models.Service{}).Where("project_id = ? AND owner_team_id IS NOT NULL", project.ID).
+			Update("owner_team_id", nil).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to remove team ownership",
+			})
+			return
+		}
+	}
+
+	// Replace tags if provided
+	if req.Tags != nil {
+		if err := pc.DB.Model(&project).Association("Tags").Replace(tags); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update project tags",
+			})
+			return
+		}
+	}
+
 	// Load owner relationship
-	pc.DB.Preload("Owner").First(&project, project.ID)
+	pc.DB.Preload("Owner").Preload("Tags").First(&project, project.ID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Project updated successfully",
@@ -333,7 +471,7 @@ func (pc *ProjectController) DeleteProject(c *gin.Context) {
 	}
 
 	// Check if user is owner or admin
-	if project.OwnerID != user.ID && user.Role != "admin" {
+	if !models.GetProjectAccess(pc.DB, &project, user).CanManage() {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "Only project owner can delete project",
 		})
@@ -398,23 +536,29 @@ func (pc *ProjectController) GetProjectCollaborators(c *gin.Context) {
 	}
 
 	// Check if user has access to this project
-	hasAccess := project.OwnerID == user.ID || project.Visibility == "public"
-	if !hasAccess {
-		// Check if user is a collaborator
-		var collaborator models.ProjectCollaborator
-		if err := pc.DB.Where("project_id = ? AND user_id = ? AND state = ?",
-			projectID, user.ID, "active").First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
-			})
-			return
-		}
+	if !models.GetProjectAccess(pc.DB, &project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	// Parse pagination, sort and filter parameters
+	params, err := pagination.Parse(c.Request.URL.Query(), collaboratorListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
 	}
 
 	// Get all collaborators
-	var collaborators []models.ProjectCollaborator
-	if err := pc.DB.Preload("User").Where("project_id = ? AND state = ?",
-		projectID, "active").Find(&collaborators).Error; err != nil {
+	query := pc.DB.Where("project_id = ? AND state = ?", projectID, "active")
+	page, err := pagination.Find(query, collaboratorListSpec, params, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("User")
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch collaborators",
 		})
@@ -422,13 +566,14 @@ func (pc *ProjectController) GetProjectCollaborators(c *gin.Context) {
 	}
 
 	// Convert to response format
-	var collaboratorResponses []models.CollaboratorResponse
-	for _, collab := range collaborators {
+	collaboratorResponses := make([]models.CollaboratorResponse, 0, len(page.Items))
+	for _, collab := range page.Items {
 		collaboratorResponses = append(collaboratorResponses, collab.ToResponse())
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"collaborators": collaboratorResponses,
+		"pagination":    page.Info,
 	})
 }
 
@@ -487,20 +632,23 @@ func (pc *ProjectController) AddProjectCollaborator(c *gin.Context) {
 	}
 
 	// Check if user is owner or admin
-	if project.OwnerID != user.ID && user.Role != "admin" {
+	if !models.GetProjectAccess(pc.DB, &project, user).CanManage() {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "Only project owner can add collaborators",
 		})
 		return
 	}
 
-	// Check if target user exists by email
+	// Set default role if not provided
+	if req.Role == "" {
+		req.Role = "editor"
+	}
+
+	// Check if target user exists by email, invite them otherwise
 	var targetUser models.User
 	if err := pc.DB.Where("email = ?", req.Email).First(&targetUser).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "User not found with this email",
-			})
+			pc.inviteCollaborator(c, &project, user, req)
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Failed to fetch user",
@@ -509,6 +657,14 @@ func (pc *ProjectController) AddProjectCollaborator(c *gin.Context) {
 		return
 	}
 
+	// The primary owner cannot also be a collaborator
+	if targetUser.ID == project.OwnerID {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "User already owns this project",
+		})
+		return
+	}
+
 	// Check if user is already a collaborator
 	var existingCollab models.ProjectCollaborator
 	if err := pc.DB.Where("project_id = ? AND user_id = ?",
@@ -519,11 +675,6 @@ func (pc *ProjectController) AddProjectCollaborator(c *gin.Context) {
 		return
 	}
 
-	// Set default role if not provided
-	if req.Role == "" {
-		req.Role = "editor"
-	}
-
 	// Create collaborator
 	collaborator := models.ProjectCollaborator{
 		ProjectID: uint(projectID),
@@ -549,6 +700,45 @@ func (pc *ProjectController) AddProjectCollaborator(c *gin.Context) {
 	})
 }
 
+// inviteCollaborator emails an invitation to join the project to someone
+// without an account
+func (pc *ProjectController) inviteCollaborator(c *gin.Context, project *models.Project, user *models.User, req models.AddCollaboratorRequest) {
+	pending, err := pc.Invitations.hasPendingInvitation(req.Email, &project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check pending invitations",
+		})
+		return
+	}
+	if pending {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "An invitation is already pending for this email",
+		})
+		return
+	}
+
+	invitation := models.Invitation{
+		Email:     req.Email,
+		ProjectID: &project.ID,
+		Role:      req.Role,
+		UserRole:  models.UserRole,
+	}
+	if err := pc.Invitations.createInvitation(c.Request.Context(), &invitation, user, project.Name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to send invitation",
+		})
+		return
+	}
+
+	invitation.Project = project
+	invitation.Inviter = *user
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "Invitation sent successfully",
+		"invitation": invitation.ToResponse(),
+	})
+}
+
 // RemoveProjectCollaborator removes a collaborator from a project
 func (pc *ProjectController) RemoveProjectCollaborator(c *gin.Context) {
 	// Get authenticated user
@@ -602,7 +792,7 @@ func (pc *ProjectController) RemoveProjectCollaborator(c *gin.Context) {
 	}
 
 	// Check if user is owner or admin
-	if project.OwnerID != user.ID && user.Role != "admin" {
+	if !models.GetProjectAccess(pc.DB, &project, user).CanManage() {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "Only project owner can remove collaborators",
 		})
@@ -667,7 +857,7 @@ func (pc *ProjectController) GetPublicProjectBySlug(c *gin.Context) {
 	var project models.Project
 
 	// Find project with owner relationship
-	if err := pc.DB.Preload("Owner").Where("slug = ?", slug).First(&project).Error; err != nil {
+	if err := pc.DB.Preload("Owner").Preload("Tags").Where("slug = ?", slug).First(&project).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Project not found",
@@ -732,7 +922,7 @@ func (pc *ProjectController) BulkSaveProjectData(c *gin.Context) {
 
 	// Validate project access
 	var project models.Project
-	if err := pc.DB.Where("id = ? AND owner_id = ?", projectID, user.ID).First(&project).Error; err != nil {
+	if err := pc.DB.First(&project, projectID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Project not found",
@@ -745,6 +935,21 @@ func (pc *ProjectController) BulkSaveProjectData(c *gin.Context) {
 		return
 	}
 
+	// Bulk save is reserved to project owners, including collaborator owners
+	access := models.GetProjectAccess(pc.DB, &project, user)
+	if !access.CanView() && !access.IsAdmin {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Project not found",
+		})
+		return
+	}
+	if !access.CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only project owners can bulk save project data",
+		})
+		return
+	}
+
 	// Start transaction
 	tx := pc.DB.Begin()
 	if tx.Error != nil {
@@ -767,6 +972,15 @@ func (pc *ProjectController) BulkSaveProjectData(c *gin.Context) {
 	result, err := models.ExecuteBulkSave(tx, uint(projectID), user.ID, req)
 	if err != nil {
 		tx.Rollback()
+		var metadataErr *models.MetadataValidationError
+		if errors.As(err, &metadataErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid metadata",
+				"service": metadataErr.Service,
+				"fields":  metadataErr.Fields,
+			})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Bulk save failed",
 			"details": err.Error(),
@@ -787,3 +1001,231 @@ func (pc *ProjectController) BulkSaveProjectData(c *gin.Context) {
 		"result":  result,
 	})
 }
+
+// ExportProjectGraph exports the service graph of a project, optionally for a single environment
+// and restricted to services carrying the tags given in ?tag=
+func (pc *ProjectController) ExportProjectGraph(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	// Find project with owner relationship
+	var project models.Project
+	if err := pc.DB.Preload("Owner").Preload("Tags").First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to this project
+	if !models.GetProjectAccess(pc.DB, &project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	// Resolve environment if provided
+	var environment *models.Environment
+	if name := c.Query("environment"); name != "" {
+		environment, err = models.FindProjectEnvironment(pc.DB, project.ID, name)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Environment not found",
+			})
+			return
+		}
+	}
+
+	filter := models.GraphFilter{
+		Environment: environment,
+		Tags:        models.ParseTagFilter(c.Query("tag")),
+	}
+
+	graph, err := models.BuildProjectGraph(pc.DB, &project, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to export project",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"graph": graph,
+	})
+}
+
+// GetProjectMetadataSchema returns the custom service attribute schema of a project
+func (pc *ProjectController) GetProjectMetadataSchema(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	// Find project
+	var project models.Project
+	if err := pc.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to this project
+	if !models.GetProjectAccess(pc.DB, &project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"metadata_schema": project.MetadataSchema,
+	})
+}
+
+// UpdateProjectMetadataSchema replaces the custom service attribute schema of a project
+func (pc *ProjectController) UpdateProjectMetadataSchema(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	var req models.UpdateMetadataSchemaRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Find project
+	var project models.Project
+	if err := pc.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user is owner or admin
+	if !models.GetProjectAccess(pc.DB, &project, user).CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only project owner can update the metadata schema",
+		})
+		return
+	}
+
+	schema := models.MetadataSchema{
+		Fields:          req.Fields,
+		AllowAdditional: req.AllowAdditional,
+	}
+
+	// Validate schema definition
+	if fieldErrors := schema.Check(); len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Invalid metadata schema",
+			"fields": fieldErrors,
+		})
+		return
+	}
+
+	// Save schema
+	if err := pc.DB.Model(&project).Update("metadata_schema", schema).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update metadata schema",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Metadata schema updated successfully",
+		"metadata_schema": schema,
+	})
+}
@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+	"sami/pkg/config"
+)
+
+type PublicController struct {
+	DB       *gorm.DB
+	Settings config.PublicConfig
+}
+
+// publicView returns the public view settings of a project, or the server
+// defaults when it has none
+func (pc *PublicController) publicView(project *models.Project) models.PublicView {
+	if project.PublicView != nil {
+		return *project.PublicView
+	}
+	return models.PublicView{HiddenFields: pc.Settings.HiddenFieldList()}
+}
+
+// respondCached writes body as JSON with caching headers. Clients that
+// already hold the same version get 304 Not Modified without a body.
+func (pc *PublicController) respondCached(c *gin.Context, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(pc.Settings.CacheMaxAge.Seconds())))
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPublicGraph returns the read-only graph of a public project to anyone,
+// without authentication. Service fields hidden by the project settings are
+// left out. ?include=comments adds the project comments when the project
+// publishes them, and ?tag= keeps only services carrying the given tags.
+func (pc *PublicController) GetPublicGraph(c *gin.Context) {
+	// Get slug from URL
+	slug := c.Param("slug")
+	if slug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Slug is required",
+		})
+		return
+	}
+
+	var project models.Project
+	if err := pc.DB.Preload("Tags").Where("slug = ?", slug).First(&project).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if project is public
+	if project.Visibility != "public" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "This project is private",
+		})
+		return
+	}
+
+	view := pc.publicView(&project)
+	includeComments := c.Query("include") == "comments"
+	if includeComments && !view.Comments {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Comments are not public on this project",
+		})
+		return
+	}
+
+	graph, err := models.BuildPublicGraph(pc.DB, &project, view, models.ParseTagFilter(c.Query("tag")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch project graph",
+		})
+		return
+	}
+
+	if includeComments {
+		graph.Comments, err = models.LoadPublicComments(pc.DB, project.ID, pc.Settings.CommentLimit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch comments",
+			})
+			return
+		}
+	}
+
+	pc.respondCached(c, graph)
+}
+
+// UpdatePublicView sets which service fields are hidden from the public view
+// of a project, and whether its comments are public
+func (pc *PublicController) UpdatePublicView(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	var req models.UpdatePublicViewRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Find project
+	var project models.Project
+	if err := pc.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user is owner or admin
+	if !models.GetProjectAccess(pc.DB, &project, user).CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only project owner can update the public view",
+		})
+		return
+	}
+
+	view := models.PublicView{
+		HiddenFields: req.HiddenFields,
+		Comments:     req.Comments,
+	}
+
+	// Validate hidden fields
+	if fieldErrors := view.Check(); len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Invalid public view",
+			"fields": fieldErrors,
+		})
+		return
+	}
+
+	// Save settings
+	if err := pc.DB.Model(&project).Update("public_view", view).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update public view",
+		})
+		return
+	}
+	project.PublicView = &view
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Public view updated successfully",
+		"project": project.ToResponse(),
+	})
+}
@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sami/models"
+)
+
+// findReactableComment loads an active comment the user can view. It
+// responds and returns false when there is none.
+func (cc *CommentController) findReactableComment(c *gin.Context, user *models.User) (*models.Comment, bool) {
+	// Get comment ID from URL
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid comment ID",
+		})
+		return nil, false
+	}
+
+	var comment models.Comment
+	if err := cc.DB.Preload("Project").Where("status = ?", "active").
+		First(&comment, commentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Comment not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch comment",
+			})
+		}
+		return nil, false
+	}
+
+	// Check if user has access to the project containing this comment
+	if !models.GetProjectAccess(cc.DB, &comment.Project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return nil, false
+	}
+
+	return &comment, true
+}
+
+// respondReactionCounts writes the reaction counts of a comment
+func (cc *CommentController) respondReactionCounts(c *gin.Context, status int, message string, commentID uint) {
+	var reactions []models.CommentReaction
+	if err := cc.DB.Where("comment_id = ?", commentID).Find(&reactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch reactions",
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"message":   message,
+		"reactions": models.SummarizeReactions(reactions),
+	})
+}
+
+// GetCommentReactions lists who reacted to a comment with which emoji,
+// oldest first
+func (cc *CommentController) GetCommentReactions(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	comment, found := cc.findReactableComment(c, user)
+	if !found {
+		return
+	}
+
+	var reactions []models.CommentReaction
+	if err := cc.DB.Preload("User").Where("comment_id = ?", comment.ID).
+		Order("created_at ASC, id ASC").Find(&reactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch reactions",
+		})
+		return
+	}
+
+	// Convert to response format
+	reactionResponses := make([]models.ReactionResponse, 0, len(reactions))
+	for _, reaction := range reactions {
+		reactionResponses = append(reactionResponses, reaction.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reactions": reactionResponses,
+	})
+}
+
+// AddCommentReaction reacts to a comment with an emoji. Reacting twice with
+// the same emoji has no effect.
+func (cc *CommentController) AddCommentReaction(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var req models.ReactionRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	emoji, valid := models.NormalizeEmoji(req.Emoji)
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Reactions must be a single emoji",
+		})
+		return
+	}
+
+	comment, found := cc.findReactableComment(c, user)
+	if !found {
+		return
+	}
+	access := models.GetProjectAccess(cc.DB, &comment.Project, user)
+	if !canComment(c, cc.DB, &comment.Project, user, access) || !threadOpen(c, cc.DB, comment, access) {
+		return
+	}
+
+	reaction := models.CommentReaction{
+		CommentID: comment.ID,
+		UserID:    user.ID,
+		Emoji:     emoji,
+	}
+	result := cc.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to add reaction",
+		})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		cc.respondReactionCounts(c, http.StatusOK, "Reaction already added", comment.ID)
+		return
+	}
+	cc.respondReactionCounts(c, http.StatusCreated, "Reaction added successfully", comment.ID)
+}
+
+// RemoveCommentReaction removes a reaction of the user from a comment
+func (cc *CommentController) RemoveCommentReaction(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	emoji, _ := models.NormalizeEmoji(c.Param("emoji"))
+
+	comment, found := cc.findReactableComment(c, user)
+	if !found {
+		return
+	}
+
+	result := cc.DB.Where("comment_id = ? AND user_id = ? AND emoji = ?", comment.ID, user.ID, emoji).
+		Delete(&models.CommentReaction{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to remove reaction",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Reaction not found",
+		})
+		return
+	}
+
+	cc.respondReactionCounts(c, http.StatusOK, "Reaction removed successfully", comment.ID)
+}
@@ -0,0 +1,1165 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+	"sami/pkg/config"
+)
+
+const scimContentType = "application/scim+json"
+
+// SCIMController serves the SCIM 2.0 API identity providers provision users
+// and groups through. Groups are the teams of the organization the
+// provisioning token is bound to.
+type SCIMController struct {
+	DB       *gorm.DB
+	Settings config.SCIMConfig
+}
+
+var errSCIMUniqueness = errors.New("uniqueness")
+
+// scimError writes a SCIM error response
+func scimError(c *gin.Context, status int, scimType, detail string) {
+	c.Header("Content-Type", scimContentType)
+	c.AbortWithStatusJSON(status, models.SCIMError{
+		Schemas:  []string{models.SCIMErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+// scimJSON writes a SCIM response
+func scimJSON(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", scimContentType)
+	c.JSON(status, body)
+}
+
+// scimBaseURL returns the address resource locations are built from
+func scimBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host + "/scim/v2"
+}
+
+// scimToken returns the provisioning token of the request
+func scimToken(c *gin.Context) *models.ProvisioningToken {
+	token, _ := c.Get("scim_token")
+	provisioningToken, _ := token.(*models.ProvisioningToken)
+	return provisioningToken
+}
+
+// scimPage reads the startIndex and count parameters. Both are clamped
+// rather than rejected, as RFC 7644 asks.
+func (sc *SCIMController) scimPage(c *gin.Context) (startIndex, count int) {
+	maxResults := sc.Settings.MaxResults
+	if maxResults <= 0 {
+		maxResults = 200
+	}
+
+	startIndex, err := strconv.Atoi(c.DefaultQuery("startIndex", "1"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	count, err = strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(maxResults)))
+	if err != nil || count > maxResults {
+		count = maxResults
+	}
+	if count < 0 {
+		count = 0
+	}
+	return startIndex, count
+}
+
+// deactivatedStatus returns the status given to users the identity provider
+// marks inactive
+func (sc *SCIMController) deactivatedStatus() models.Status {
+	if status := models.Status(sc.Settings.DeactivatedStatus); status == models.StatusSuspended {
+		return status
+	}
+	return models.StatusInactive
+}
+
+// TokenMiddleware authenticates requests with a provisioning token
+func (sc *SCIMController) TokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if authHeader == "" || tokenString == authHeader {
+			scimError(c, http.StatusUnauthorized, "", "Provisioning token required")
+			return
+		}
+
+		var token models.ProvisioningToken
+		if err := sc.DB.Where("token_hash = ? AND revoked_at IS NULL", models.HashToken(tokenString)).
+			First(&token).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				scimError(c, http.StatusUnauthorized, "", "Invalid provisioning token")
+			} else {
+				scimError(c, http.StatusInternalServerError, "", "Failed to verify provisioning token")
+			}
+			return
+		}
+
+		// Recording every use would write on each request, a minute is enough
+		now := time.Now()
+		if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > time.Minute {
+			sc.DB.Model(&token).Update("last_used_at", now)
+		}
+
+		c.Set("scim_token", &token)
+		c.Next()
+	}
+}
+
+// GetServiceProviderConfig describes the features the server supports
+func (sc *SCIMController) GetServiceProviderConfig(c *gin.Context) {
+	maxResults := sc.Settings.MaxResults
+	if maxResults <= 0 {
+		maxResults = 200
+	}
+
+	scimJSON(c, http.StatusOK, gin.H{
+		"schemas":        []string{models.SCIMServiceConfSchema},
+		"patch":          gin.H{"supported": true},
+		"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         gin.H{"supported": true, "maxResults": maxResults},
+		"changePassword": gin.H{"supported": false},
+		"sort":           gin.H{"supported": false},
+		"etag":           gin.H{"supported": false},
+		"authenticationSchemes": []gin.H{{
+			"type":        "oauthbearertoken",
+			"name":        "Provisioning token",
+			"description": "Bearer token created by a SAMI administrator",
+		}},
+	})
+}
+
+// GetResourceTypes lists the resource types the server exposes
+func (sc *SCIMController) GetResourceTypes(c *gin.Context) {
+	resources := []interface{}{
+		gin.H{
+			"schemas":  []string{models.SCIMResourceSchema},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   models.SCIMUserSchema,
+		},
+		gin.H{
+			"schemas":  []string{models.SCIMResourceSchema},
+			"id":       "Group",
+			"name":     "Group",
+			"endpoint": "/Groups",
+			"schema":   models.SCIMGroupSchema,
+		},
+	}
+
+	scimJSON(c, http.StatusOK, models.SCIMListResponse{
+		Schemas:      []string{models.SCIMListSchema},
+		TotalResults: int64(len(resources)),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// toSCIMUsers converts users to their SCIM representation, loading their
+// external IDs and their teams in the organization of the token
+func (sc *SCIMController) toSCIMUsers(c *gin.Context, users []models.User) ([]models.SCIMUser, error) {
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+
+	externalIDs := make(map[uint]string)
+	teams := make(map[uint][]models.Team)
+	if len(ids) > 0 {
+		var identities []models.UserIdentity
+		if err := sc.DB.Where("issuer = ? AND user_id IN ?", models.SCIMIssuer, ids).
+			Find(&identities).Error; err != nil {
+			return nil, err
+		}
+		for _, identity := range identities {
+			externalIDs[identity.UserID] = identity.Subject
+		}
+
+		if token := scimToken(c); token != nil && token.OrganizationID != nil {
+			var members []models.TeamMember
+			if err := sc.DB.Preload("Team").
+				Joins("JOIN teams ON teams.id = team_members.team_id").
+				Where("teams.organization_id = ? AND team_members.user_id IN ?", *token.OrganizationID, ids).
+				Order("teams.name ASC").Find(&members).Error; err != nil {
+				return nil, err
+			}
+			for _, member := range members {
+				teams[member.UserID] = append(teams[member.UserID], member.Team)
+			}
+		}
+	}
+
+	baseURL := scimBaseURL(c)
+	result := make([]models.SCIMUser, 0, len(users))
+	for i := range users {
+		result = append(result, users[i].ToSCIM(externalIDs[users[i].ID], teams[users[i].ID], baseURL))
+	}
+	return result, nil
+}
+
+// scimUsers returns a query on the users the token provisions: the members
+// of its organization, or every user for a token without one
+func (sc *SCIMController) scimUsers(c *gin.Context) *gorm.DB {
+	query := sc.DB.Model(&models.User{})
+	if token := scimToken(c); token != nil && token.OrganizationID != nil {
+		query = query.Where("users.id IN (?)", sc.DB.Model(&models.OrganizationMember{}).
+			Select("user_id").Where("organization_id = ?", *token.OrganizationID))
+	}
+	return query
+}
+
+// findSCIMUser loads the user of the :id parameter among the users of the
+// token, writing the error response when there is none
+func (sc *SCIMController) findSCIMUser(c *gin.Context) *models.User {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		scimError(c, http.StatusNotFound, "", "User not found")
+		return nil
+	}
+
+	var user models.User
+	if err := sc.scimUsers(c).Where("users.id = ?", userID).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			scimError(c, http.StatusNotFound, "", "User not found")
+		} else {
+			scimError(c, http.StatusInternalServerError, "", "Failed to fetch user")
+		}
+		return nil
+	}
+	return &user
+}
+
+// findManagedSCIMUser loads the user of the :id parameter for a change.
+// Tokens bound to an organization cannot change administrators, who belong
+// to the whole instance.
+func (sc *SCIMController) findManagedSCIMUser(c *gin.Context) *models.User {
+	user := sc.findSCIMUser(c)
+	if user == nil {
+		return nil
+	}
+
+	if token := scimToken(c); token.OrganizationID != nil && user.Role == models.AdminRole {
+		scimError(c, http.StatusForbidden, "", "Administrators cannot be provisioned by an organization token")
+		return nil
+	}
+	return user
+}
+
+// ListUsers lists users, optionally filtered by userName, emails or
+// externalId
+func (sc *SCIMController) ListUsers(c *gin.Context) {
+	filter, err := models.ParseSCIMFilter(c.Query("filter"))
+	if err != nil {
+		scimError(c, http.StatusBadRequest, "invalidFilter", "Only `attribute eq \"value\"` filters are supported")
+		return
+	}
+
+	query := sc.scimUsers(c)
+	if filter != nil {
+		switch filter.Attribute {
+		case "username", "emails", "emails.value":
+			query = query.Where("LOWER(users.email) = LOWER(?)", filter.Value)
+		case "externalid":
+			query = query.Where("users.id IN (?)", sc.DB.Model(&models.UserIdentity{}).
+				Select("user_id").Where("issuer = ? AND subject = ?", models.SCIMIssuer, filter.Value))
+		case "id":
+			query = query.Where("users.id = ?", filter.Value)
+		default:
+			scimError(c, http.StatusBadRequest, "invalidFilter", "Unsupported filter attribute "+filter.Attribute)
+			return
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to count users")
+		return
+	}
+
+	startIndex, count := sc.scimPage(c)
+	var users []models.User
+	if count > 0 {
+		if err := query.Order("users.id ASC").Offset(startIndex - 1).Limit(count).Find(&users).Error; err != nil {
+			scimError(c, http.StatusInternalServerError, "", "Failed to fetch users")
+			return
+		}
+	}
+
+	scimUsers, err := sc.toSCIMUsers(c, users)
+	if err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to fetch users")
+		return
+	}
+
+	resources := make([]interface{}, 0, len(scimUsers))
+	for _, user := range scimUsers {
+		resources = append(resources, user)
+	}
+
+	scimJSON(c, http.StatusOK, models.SCIMListResponse{
+		Schemas:      []string{models.SCIMListSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// GetUser returns a user
+func (sc *SCIMController) GetUser(c *gin.Context) {
+	user := sc.findSCIMUser(c)
+	if user == nil {
+		return
+	}
+
+	scimUsers, err := sc.toSCIMUsers(c, []models.User{*user})
+	if err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to fetch user")
+		return
+	}
+
+	scimJSON(c, http.StatusOK, scimUsers[0])
+}
+
+// CreateUser provisions a user. The account gets a random password: users
+// provisioned this way sign in through single sign-on.
+func (sc *SCIMController) CreateUser(c *gin.Context) {
+	var req models.SCIMUser
+	if err := c.ShouldBindJSON(&req); err != nil {
+		scimError(c, http.StatusBadRequest, "invalidSyntax", "Invalid request body")
+		return
+	}
+
+	email := req.Email()
+	if !strings.Contains(email, "@") {
+		scimError(c, http.StatusBadRequest, "invalidValue", "userName or emails must hold an email address")
+		return
+	}
+
+	password, err := randomPassword()
+	if err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to create user")
+		return
+	}
+
+	now := time.Now()
+	user := models.User{
+		Email:           email,
+		Role:            models.UserRole,
+		Status:          models.StatusActive,
+		EmailVerifiedAt: &now,
+	}
+	if user.Name = req.FullName(); user.Name == "" {
+		user.Name, _, _ = strings.Cut(email, "@")
+	}
+	if req.Active != nil && !*req.Active {
+		user.Status = sc.deactivatedStatus()
+	}
+	if err := user.SetPassword(password); err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to create user")
+		return
+	}
+
+	token := scimToken(c)
+	err = sc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := sc.checkUserUniqueness(tx, 0, email, req.ExternalID); err != nil {
+			return err
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		if err := models.SetSCIMExternalID(tx, &user, req.ExternalID); err != nil {
+			return err
+		}
+
+		// Provisioned users join the organization of the token
+		if token.OrganizationID != nil {
+			return tx.Create(&models.OrganizationMember{
+				OrganizationID: *token.OrganizationID,
+				UserID:         user.ID,
+				Role:           models.OrgMemberRole,
+			}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errSCIMUniqueness) {
+			scimError(c, http.StatusConflict, "uniqueness", "A user with this userName or externalId already exists")
+		} else {
+			scimError(c, http.StatusInternalServerError, "", "Failed to create user")
+		}
+		return
+	}
+
+	scimUsers, err := sc.toSCIMUsers(c, []models.User{user})
+	if err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to fetch user")
+		return
+	}
+
+	c.Header("Location", scimUsers[0].Meta.Location)
+	scimJSON(c, http.StatusCreated, scimUsers[0])
+}
+
+// checkUserUniqueness fails with errSCIMUniqueness when another user has the
+// email or the external ID
+func (sc *SCIMController) checkUserUniqueness(tx *gorm.DB, userID uint, email, externalID string) error {
+	var count int64
+	if err := tx.Model(&models.User{}).Where("LOWER(email) = LOWER(?) AND id <> ?", email, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errSCIMUniqueness
+	}
+
+	if externalID != "" {
+		if err := tx.Model(&models.UserIdentity{}).
+			Where("issuer = ? AND subject = ? AND user_id <> ?", models.SCIMIssuer, externalID, userID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errSCIMUniqueness
+		}
+	}
+
+	return nil
+}
+
+// ReplaceUser replaces the attributes of a user
+func (sc *SCIMController) ReplaceUser(c *gin.Context) {
+	user := sc.findManagedSCIMUser(c)
+	if user == nil {
+		return
+	}
+
+	var req models.SCIMUser
+	if err := c.ShouldBindJSON(&req); err != nil {
+		scimError(c, http.StatusBadRequest, "invalidSyntax", "Invalid request body")
+		return
+	}
+
+	sc.saveUser(c, user, &req)
+}
+
+// PatchUser applies PATCH operations to a user. Identity providers
+// deactivate users this way.
+func (sc *SCIMController) PatchUser(c *gin.Context) {
+	user := sc.findManagedSCIMUser(c)
+	if user == nil {
+		return
+	}
+
+	var req models.SCIMPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		scimError(c, http.StatusBadRequest, "invalidSyntax", "Invalid request body")
+		return
+	}
+
+	scimUsers, err := sc.toSCIMUsers(c, []models.User{*user})
+	if err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to fetch user")
+		return
+	}
+
+	current := scimUsers[0]
+	if err := current.ApplyPatch(req.Operations); err != nil {
+		scimError(c, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+
+	sc.saveUser(c, user, &current)
+}
+
+// saveUser stores the attributes of a SCIM user and writes the updated user
+func (sc *SCIMController) saveUser(c *gin.Context, user *models.User, req *models.SCIMUser) {
+	email := req.Email()
+	if !strings.Contains(email, "@") {
+		scimError(c, http.StatusBadRequest, "invalidValue", "userName or emails must hold an email address")
+		return
+	}
+
+	if email != user.Email {
+		// The new address has to be verified again
+		user.Email = email
+		user.EmailVerifiedAt = nil
+	}
+	if name := req.FullName(); name != "" {
+		user.Name = name
+	}
+
+	// Users suspended by an admin stay so when the provider reactivates them
+	if req.Active != nil {
+		if !*req.Active && user.Status == models.StatusActive {
+			user.Status = sc.deactivatedStatus()
+		} else if *req.Active && user.Status == sc.deactivatedStatus() {
+			user.Status = models.StatusActive
+		}
+	}
+
+	err := sc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := sc.checkUserUniqueness(tx, user.ID, email, req.ExternalID); err != nil {
+			return err
+		}
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		return models.SetSCIMExternalID(tx, user, req.ExternalID)
+	})
+	if err != nil {
+		if errors.Is(err, errSCIMUniqueness) {
+			scimError(c, http.StatusConflict, "uniqueness", "A user with this userName or externalId already exists")
+		} else {
+			scimError(c, http.StatusInternalServerError, "", "Failed to update user")
+		}
+		return
+	}
+
+	scimUsers, err := sc.toSCIMUsers(c, []models.User{*user})
+	if err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to fetch user")
+		return
+	}
+
+	scimJSON(c, http.StatusOK, scimUsers[0])
+}
+
+// DeleteUser deletes a user, handing their projects over the way an admin
+// deletion does. When a project has no co-owner to inherit it, the user is
+// deactivated instead and the conflict is reported for an admin to resolve.
+func (sc *SCIMController) DeleteUser(c *gin.Context) {
+	user := sc.findManagedSCIMUser(c)
+	if user == nil {
+		return
+	}
+
+	ownedProjects, successors, orphanedProjects, err := models.PlanOwnershipSuccession(sc.DB, user.ID, 0)
+	if err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to resolve project co-owners")
+		return
+	}
+
+	if len(orphanedProjects) > 0 {
+		if user.Status == models.StatusActive {
+			if err := sc.DB.Model(user).Update("status", sc.deactivatedStatus()).Error; err != nil {
+				scimError(c, http.StatusInternalServerError, "", "Failed to deactivate user")
+				return
+			}
+		}
+
+		names := make([]string, 0, len(orphanedProjects))
+		for _, project := range orphanedProjects {
+			names = append(names, project.Name)
+		}
+		scimError(c, http.StatusConflict, "", fmt.Sprintf(
+			"User owns projects without a co-owner (%s). The user was deactivated, an admin must delete them.",
+			strings.Join(names, ", ")))
+		return
+	}
+
+	token := scimToken(c)
+	err = sc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		return models.DeleteUserWithSuccession(tx, user, ownedProjects, successors, token.CreatedBy)
+	})
+	if err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to delete user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// scimOrganizationID returns the organization groups are synced into,
+// writing the error response when the token has none
+func scimOrganizationID(c *gin.Context) (uint, bool) {
+	token := scimToken(c)
+	if token == nil || token.OrganizationID == nil {
+		scimError(c, http.StatusForbidden, "", "Groups require a provisioning token bound to an organization")
+		return 0, false
+	}
+	return *token.OrganizationID, true
+}
+
+// toSCIMGroups converts teams to their SCIM representation
+func (sc *SCIMController) toSCIMGroups(c *gin.Context, teams []models.Team, withMembers bool) ([]models.SCIMGroup, error) {
+	members := make(map[uint][]models.User)
+	if withMembers && len(teams) > 0 {
+		ids := make([]uint, 0, len(teams))
+		for _, team := range teams {
+			ids = append(ids, team.ID)
+		}
+
+		var teamMembers []models.TeamMember
+		if err := sc.DB.Preload("User").Where("team_id IN ?", ids).
+			Order("user_id ASC").Find(&teamMembers).Error; err != nil {
+			return nil, err
+		}
+		for _, member := range teamMembers {
+			members[member.TeamID] = append(members[member.TeamID], member.User)
+		}
+	}
+
+	baseURL := scimBaseURL(c)
+	result := make([]models.SCIMGroup, 0, len(teams))
+	for i := range teams {
+		result = append(result, teams[i].ToSCIM(members[teams[i].ID], baseURL))
+	}
+	return result, nil
+}
+
+// findSCIMGroup loads the team of the :id parameter in the organization of
+// the token, writing the error response when there is none
+func (sc *SCIMController) findSCIMGroup(c *gin.Context) *models.Team {
+	organizationID, ok := scimOrganizationID(c)
+	if !ok {
+		return nil
+	}
+
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		scimError(c, http.StatusNotFound, "", "Group not found")
+		return nil
+	}
+
+	var team models.Team
+	if err := sc.DB.Where("id = ? AND organization_id = ?", teamID, organizationID).First(&team).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			scimError(c, http.StatusNotFound, "", "Group not found")
+		} else {
+			scimError(c, http.StatusInternalServerError, "", "Failed to fetch group")
+		}
+		return nil
+	}
+	return &team
+}
+
+// checkMembers writes an error response unless every ID is a user the
+// token provisions
+func (sc *SCIMController) checkMembers(c *gin.Context, ids []uint) bool {
+	unique := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	if len(unique) == 0 {
+		return true
+	}
+
+	var count int64
+	if err := sc.scimUsers(c).Where("users.id IN ?", ids).Count(&count).Error; err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to fetch members")
+		return false
+	}
+	if int(count) != len(unique) {
+		scimError(c, http.StatusBadRequest, "invalidValue", "Unknown member")
+		return false
+	}
+	return true
+}
+
+// ListGroups lists the teams of the organization, optionally filtered by
+// displayName or externalId
+func (sc *SCIMController) ListGroups(c *gin.Context) {
+	organizationID, ok := scimOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	filter, err := models.ParseSCIMFilter(c.Query("filter"))
+	if err != nil {
+		scimError(c, http.StatusBadRequest, "invalidFilter", "Only `attribute eq \"value\"` filters are supported")
+		return
+	}
+
+	query := sc.DB.Model(&models.Team{}).Where("organization_id = ?", organizationID)
+	if filter != nil {
+		switch filter.Attribute {
+		case "displayname":
+			query = query.Where("name = ?", filter.Value)
+		case "externalid":
+			query = query.Where("external_id = ?", filter.Value)
+		case "id":
+			query = query.Where("id = ?", filter.Value)
+		default:
+			scimError(c, http.StatusBadRequest, "invalidFilter", "Unsupported filter attribute "+filter.Attribute)
+			return
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to count groups")
+		return
+	}
+
+	startIndex, count := sc.scimPage(c)
+	var teams []models.Team
+	if count > 0 {
+		if err := query.Order("id ASC").Offset(startIndex - 1).Limit(count).Find(&teams).Error; err != nil {
+			scimError(c, http.StatusInternalServerError, "", "Failed to fetch groups")
+			return
+		}
+	}
+
+	// Providers skip members when they only look a group up
+	withMembers := !strings.Contains(strings.ToLower(c.Query("excludedAttributes")), "members")
+	groups, err := sc.toSCIMGroups(c, teams, withMembers)
+	if err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to fetch groups")
+		return
+	}
+
+	resources := make([]interface{}, 0, len(groups))
+	for _, group := range groups {
+		resources = append(resources, group)
+	}
+
+	scimJSON(c, http.StatusOK, models.SCIMListResponse{
+		Schemas:      []string{models.SCIMListSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// GetGroup returns a team
+func (sc *SCIMController) GetGroup(c *gin.Context) {
+	team := sc.findSCIMGroup(c)
+	if team == nil {
+		return
+	}
+
+	withMembers := !strings.Contains(strings.ToLower(c.Query("excludedAttributes")), "members")
+	groups, err := sc.toSCIMGroups(c, []models.Team{*team}, withMembers)
+	if err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to fetch group")
+		return
+	}
+
+	scimJSON(c, http.StatusOK, groups[0])
+}
+
+// CreateGroup creates a team from a group, with a slug derived from its name
+func (sc *SCIMController) CreateGroup(c *gin.Context) {
+	organizationID, ok := scimOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	var req models.SCIMGroup
+	if err := c.ShouldBindJSON(&req); err != nil {
+		scimError(c, http.StatusBadRequest, "invalidSyntax", "Invalid request body")
+		return
+	}
+	if name := strings.TrimSpace(req.DisplayName); len(name) < 2 || len(name) > 100 {
+		scimError(c, http.StatusBadRequest, "invalidValue", "displayName must be between 2 and 100 characters")
+		return
+	}
+
+	memberIDs, ok := sc.groupMemberIDs(c, req.Members)
+	if !ok {
+		return
+	}
+
+	team := models.Team{
+		OrganizationID: organizationID,
+		Name:           strings.TrimSpace(req.DisplayName),
+	}
+	if req.ExternalID != "" {
+		team.ExternalID = &req.ExternalID
+	}
+
+	err := sc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkGroupUniqueness(tx, organizationID, 0, req.ExternalID); err != nil {
+			return err
+		}
+
+		slug, err := models.UniqueTeamSlug(tx, organizationID, team.Name)
+		if err != nil {
+			return err
+		}
+		team.Slug = slug
+
+		if err := tx.Create(&team).Error; err != nil {
+			return err
+		}
+		return models.AddTeamMembers(tx, &team, memberIDs)
+	})
+	if err != nil {
+		if errors.Is(err, errSCIMUniqueness) {
+			scimError(c, http.StatusConflict, "uniqueness", "A group with this externalId already exists")
+		} else {
+			scimError(c, http.StatusInternalServerError, "", "Failed to create group")
+		}
+		return
+	}
+
+	groups, err := sc.toSCIMGroups(c, []models.Team{team}, true)
+	if err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to fetch group")
+		return
+	}
+
+	c.Header("Location", groups[0].Meta.Location)
+	scimJSON(c, http.StatusCreated, groups[0])
+}
+
+// groupMemberIDs reads and checks the members of a group
+func (sc *SCIMController) groupMemberIDs(c *gin.Context, refs []models.SCIMMemberRef) ([]uint, bool) {
+	ids := make([]uint, 0, len(refs))
+	for _, ref := range refs {
+		id, err := strconv.ParseUint(ref.Value, 10, 64)
+		if err != nil || id == 0 {
+			scimError(c, http.StatusBadRequest, "invalidValue", "Invalid member ID "+ref.Value)
+			return nil, false
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, sc.checkMembers(c, ids)
+}
+
+// checkGroupUniqueness fails with errSCIMUniqueness when another team of the
+// organization has the external ID
+func checkGroupUniqueness(tx *gorm.DB, organizationID, teamID uint, externalID string) error {
+	if externalID == "" {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(&models.Team{}).
+		Where("organization_id = ? AND external_id = ? AND id <> ?", organizationID, externalID, teamID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errSCIMUniqueness
+	}
+	return nil
+}
+
+// ReplaceGroup replaces the name and the members of a team. The slug is
+// kept so links to the team stay valid.
+func (sc *SCIMController) ReplaceGroup(c *gin.Context) {
+	team := sc.findSCIMGroup(c)
+	if team == nil {
+		return
+	}
+
+	var req models.SCIMGroup
+	if err := c.ShouldBindJSON(&req); err != nil {
+		scimError(c, http.StatusBadRequest, "invalidSyntax", "Invalid request body")
+		return
+	}
+
+	memberIDs, ok := sc.groupMemberIDs(c, req.Members)
+	if !ok {
+		return
+	}
+
+	patch := &models.SCIMGroupPatch{
+		DisplayName:    &req.DisplayName,
+		ExternalID:     &req.ExternalID,
+		ReplaceMembers: true,
+		Members:        memberIDs,
+	}
+	sc.saveGroup(c, team, patch)
+}
+
+// PatchGroup applies PATCH operations to a team, usually to add or remove
+// members
+func (sc *SCIMController) PatchGroup(c *gin.Context) {
+	team := sc.findSCIMGroup(c)
+	if team == nil {
+		return
+	}
+
+	var req models.SCIMPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		scimError(c, http.StatusBadRequest, "invalidSyntax", "Invalid request body")
+		return
+	}
+
+	patch, err := models.ParseSCIMGroupPatch(req.Operations)
+	if err != nil {
+		scimError(c, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+
+	if !sc.checkMembers(c, append(append([]uint{}, patch.Members...), patch.Add...)) {
+		return
+	}
+
+	sc.saveGroup(c, team, patch)
+}
+
+// saveGroup applies a patch to a team and writes the updated group
+func (sc *SCIMController) saveGroup(c *gin.Context, team *models.Team, patch *models.SCIMGroupPatch) {
+	if patch.DisplayName != nil {
+		name := strings.TrimSpace(*patch.DisplayName)
+		if len(name) < 2 || len(name) > 100 {
+			scimError(c, http.StatusBadRequest, "invalidValue", "displayName must be between 2 and 100 characters")
+			return
+		}
+		team.Name = name
+	}
+	if patch.ExternalID != nil {
+		if *patch.ExternalID == "" {
+			team.ExternalID = nil
+		} else {
+			team.ExternalID = patch.ExternalID
+		}
+	}
+
+	err := sc.DB.Transaction(func(tx *gorm.DB) error {
+		if patch.ExternalID != nil {
+			if err := checkGroupUniqueness(tx, team.OrganizationID, team.ID, *patch.ExternalID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Save(team).Error; err != nil {
+			return err
+		}
+		return models.ApplySCIMGroupPatch(tx, team, patch)
+	})
+	if err != nil {
+		if errors.Is(err, errSCIMUniqueness) {
+			scimError(c, http.StatusConflict, "uniqueness", "A group with this externalId already exists")
+		} else {
+			scimError(c, http.StatusInternalServerError, "", "Failed to update group")
+		}
+		return
+	}
+
+	groups, err := sc.toSCIMGroups(c, []models.Team{*team}, true)
+	if err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to fetch group")
+		return
+	}
+
+	scimJSON(c, http.StatusOK, groups[0])
+}
+
+// DeleteGroup deletes a team. Its memberships and project grants go with it.
+func (sc *SCIMController) DeleteGroup(c *gin.Context) {
+	team := sc.findSCIMGroup(c)
+	if team == nil {
+		return
+	}
+
+	if err := sc.DB.Delete(team).Error; err != nil {
+		scimError(c, http.StatusInternalServerError, "", "Failed to delete group")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetProvisioningTokens lists the provisioning tokens (admin only)
+func (sc *SCIMController) GetProvisioningTokens(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	authUser, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Check if user is admin
+	if authUser.Role != models.AdminRole {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Admin access required",
+		})
+		return
+	}
+
+	var tokens []models.ProvisioningToken
+	if err := sc.DB.Preload("Organization").Order("created_at DESC").Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch provisioning tokens",
+		})
+		return
+	}
+
+	// Convert to response format
+	tokenResponses := make([]models.ProvisioningTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		tokenResponses = append(tokenResponses, token.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": tokenResponses,
+	})
+}
+
+// CreateProvisioningToken creates a provisioning token (admin only). The
+// token is only shown in this response.
+func (sc *SCIMController) CreateProvisioningToken(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	authUser, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Check if user is admin
+	if authUser.Role != models.AdminRole {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Admin access required",
+		})
+		return
+	}
+
+	var req models.CreateProvisioningTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Check that the organization exists
+	if req.OrganizationID != nil {
+		var organization models.Organization
+		if err := sc.DB.First(&organization, *req.OrganizationID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Organization not found",
+				})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to fetch organization",
+				})
+			}
+			return
+		}
+	}
+
+	secret, hash, err := models.NewProvisioningToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create provisioning token",
+		})
+		return
+	}
+
+	token := models.ProvisioningToken{
+		Name:           req.Name,
+		TokenHash:      hash,
+		OrganizationID: req.OrganizationID,
+		CreatedBy:      authUser.ID,
+	}
+	if err := sc.DB.Create(&token).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create provisioning token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":            "Provisioning token created, copy it now as it will not be shown again",
+		"token":              secret,
+		"provisioning_token": token.ToResponse(),
+	})
+}
+
+// RevokeProvisioningToken revokes a provisioning token (admin only)
+func (sc *SCIMController) RevokeProvisioningToken(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	authUser, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Check if user is admin
+	if authUser.Role != models.AdminRole {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Admin access required",
+		})
+		return
+	}
+
+	tokenID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid token ID",
+		})
+		return
+	}
+
+	result := sc.DB.Model(&models.ProvisioningToken{}).
+		Where("id = ? AND revoked_at IS NULL", tokenID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke provisioning token",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Provisioning token not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Provisioning token revoked",
+	})
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+)
+
+type SearchController struct {
+	DB *gorm.DB
+}
+
+// Search runs a full-text search over projects, services, dependencies and comments
+func (sc *SearchController) Search(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get search query
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Search query is required",
+		})
+		return
+	}
+
+	// Get query parameters for pagination and filtering
+	limit := 20 // Default limit
+	if l := c.Query("limit"); l != "" {
+		if parsedLimit, err := strconv.Atoi(l); err == nil && parsedLimit > 0 && parsedLimit <= 100 {
+			limit = parsedLimit
+		}
+	}
+
+	offset := 0
+	if o := c.Query("offset"); o != "" {
+		if parsedOffset, err := strconv.Atoi(o); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	opts := models.SearchOptions{
+		Query:  query,
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	// Filter by entity types (?types=service,comment)
+	if t := c.Query("types"); t != "" {
+		for _, name := range strings.Split(t, ",") {
+			searchType := models.SearchType(strings.TrimSpace(name))
+			if !searchType.IsValid() {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Invalid type. Must be one of: project, service, dependency, comment",
+				})
+				return
+			}
+			opts.Types = append(opts.Types, searchType)
+		}
+	}
+
+	// Restrict to a single project
+	if p := c.Query("project_id"); p != "" {
+		projectID, err := strconv.Atoi(p)
+		if err != nil || projectID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid project ID",
+			})
+			return
+		}
+		id := uint(projectID)
+		opts.ProjectID = &id
+	}
+
+	results, total, err := models.Search(sc.DB, user.ID, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to search",
+		})
+		return
+	}
+
+	if results == nil {
+		results = []models.SearchResult{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
@@ -3,17 +3,43 @@ package controller
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"sami/models"
+	"sami/pkg/pagination"
 )
 
 type ServiceController struct {
 	DB *gorm.DB
 }
 
+// serviceListSpec describes pagination, sorting and filtering for GetProjectServices.
+var serviceListSpec = pagination.Spec[models.Service]{
+	Sorts: map[string]pagination.Sort[models.Service]{
+		"name":       {Column: "services.name", Kind: pagination.KindString, Value: func(s models.Service) interface{} { return s.Name }},
+		"type":       {Column: "services.type", Kind: pagination.KindString, Value: func(s models.Service) interface{} { return s.Type }},
+		"created_at": {Column: "services.created_at", Kind: pagination.KindTime, Value: func(s models.Service) interface{} { return s.CreatedAt }},
+		"updated_at": {Column: "services.updated_at", Kind: pagination.KindTime, Value: func(s models.Service) interface{} { return s.UpdatedAt }},
+	},
+	DefaultSort: "created_at",
+	IDColumn:    "services.id",
+	ID:          func(s models.Service) uint { return s.ID },
+	Filters: map[string]pagination.Filter{
+		"status":     {Column: "services.status"},
+		"type":       {Column: "services.type"},
+		"language":   {Column: "services.language"},
+		"created_by": {Column: "services.created_by", Kind: pagination.KindInt},
+	},
+	DateRanges: map[string]string{
+		"created": "services.created_at",
+		"updated": "services.updated_at",
+	},
+	DefaultLimit: 50,
+}
+
 // GetProjectServices lists all services for a specific project
 func (sc *ServiceController) GetProjectServices(c *gin.Context) {
 	// Get authenticated user
@@ -58,23 +84,69 @@ func (sc *ServiceController) GetProjectServices(c *gin.Context) {
 	}
 
 	// Check if user has access to this project
-	hasAccess := project.OwnerID == user.ID || project.Visibility == "public"
-	if !hasAccess {
-		// Check if user is a collaborator
-		var collaborator models.ProjectCollaborator
-		if err := sc.DB.Where("project_id = ? AND user_id = ? AND state = ?",
-			projectID, user.ID, "active").First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
+	if !models.GetProjectAccess(sc.DB, &project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	// Parse pagination, sort and filter parameters
+	params, err := pagination.Parse(c.Request.URL.Query(), serviceListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Build query
+	query := sc.DB.Where("services.project_id = ?", projectID)
+
+	// Filter by environment: keep the services deployed to it and render
+	// them through their instance there
+	var environment *models.Environment
+	if name := c.Query("environment"); name != "" {
+		environment, err = models.FindProjectEnvironment(sc.DB, uint(projectID), name)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{
+					"error": "Environment not found",
+				})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to fetch environment",
+				})
+			}
+			return
+		}
+		query = query.Where("EXISTS (SELECT 1 FROM service_instances si WHERE si.service_id = services.id AND si.environment_id = ?)", environment.ID)
+	}
+
+	// Filter by metadata values (?metadata.<key>=<value>)
+	for param, values := range c.Request.URL.Query() {
+		key, found := strings.CutPrefix(param, "metadata.")
+		if !found {
+			continue
+		}
+		if !models.IsValidMetadataKey(key) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid metadata filter: " + param,
 			})
 			return
 		}
+		query = query.Where("services.metadata ->> CAST(? AS text) IN ?", key, values)
 	}
 
+	// Filter by tags (?tag=a,b matches services carrying all of them)
+	query = models.FilterByServiceTags(query, "services.id", models.ParseTagFilter(c.Query("tag")))
+
 	// Get services for the project
-	var services []models.Service
-	if err := sc.DB.Preload("Creator").Preload("Updater").
-		Where("project_id = ?", projectID).Find(&services).Error; err != nil {
+	page, err := pagination.Find(query, serviceListSpec, params, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Creator").Preload("Updater").Preload("Instances.Environment").Preload("Tags")
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch services",
 		})
@@ -82,13 +154,11 @@ func (sc *ServiceController) GetProjectServices(c *gin.Context) {
 	}
 
 	// Convert to response format
-	var serviceResponses []models.ServiceResponse
-	for _, service := range services {
-		serviceResponses = append(serviceResponses, service.ToResponse())
-	}
+	serviceResponses := models.ServiceResponsesFor(page.Items, environment)
 
 	c.JSON(http.StatusOK, gin.H{
-		"services": serviceResponses,
+		"services":   serviceResponses,
+		"pagination": page.Info,
 	})
 }
 
@@ -136,17 +206,11 @@ func (sc *ServiceController) CreateProjectService(c *gin.Context) {
 	}
 
 	// Check if user has access to modify this project
-	hasAccess := project.OwnerID == user.ID
-	if !hasAccess {
-		// Check if user is a collaborator with editor role
-		var collaborator models.ProjectCollaborator
-		if err := sc.DB.Where("project_id = ? AND user_id = ? AND state = ? AND role IN (?)",
-			projectID, user.ID, "active", []string{"owner", "editor"}).First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
-			})
-			return
-		}
+	if !models.GetProjectAccess(sc.DB, &project, user).CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
 	}
 
 	var req models.CreateServiceRequest
@@ -165,7 +229,49 @@ func (sc *ServiceController) CreateProjectService(c *gin.Context) {
 		req.Status = "active"
 	}
 	if req.Environment == "" {
-		req.Environment = "production"
+		environment, err := models.DefaultServiceEnvironment(sc.DB, uint(projectID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch environments",
+			})
+			return
+		}
+		req.Environment = environment
+	}
+
+	// Validate environment against the project's environments
+	if err := models.ValidateServiceEnvironment(sc.DB, uint(projectID), req.Environment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid environment",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Validate metadata against the project's schema
+	fieldErrors, err := models.ValidateServiceMetadata(sc.DB, uint(projectID), req.Metadata)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to validate metadata",
+		})
+		return
+	}
+	if len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Invalid metadata",
+			"fields": fieldErrors,
+		})
+		return
+	}
+
+	// Resolve tags, creating the ones that do not exist yet
+	tags, err := models.ResolveTags(sc.DB, req.Tags, user.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid tags",
+			"details": err.Error(),
+		})
+		return
 	}
 
 	// Create new service
@@ -187,10 +293,17 @@ func (sc *ServiceController) CreateProjectService(c *gin.Context) {
 		PosY:          req.PosY,
 		Notes:         req.Notes,
 		CreatedBy:     user.ID,
+		Tags:          tags,
 	}
 
-	// Save to database
-	if err := sc.DB.Create(&service).Error; err != nil {
+	// Save to database and record it in the project history
+	err = sc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&service).Error; err != nil {
+			return err
+		}
+		return models.RecordServiceHistory(tx, &service, user.ID, models.HistoryCreateService)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create service",
 		})
@@ -198,7 +311,7 @@ func (sc *ServiceController) CreateProjectService(c *gin.Context) {
 	}
 
 	// Load relationships
-	sc.DB.Preload("Creator").Preload("Updater").First(&service, service.ID)
+	sc.DB.Preload("Creator").Preload("Updater").Preload("Tags").First(&service, service.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Service created successfully",
@@ -237,8 +350,8 @@ func (sc *ServiceController) GetService(c *gin.Context) {
 	var service models.Service
 
 	// Find service with all relationships
-	if err := sc.DB.Preload("Creator").Preload("Updater").Preload("Project").
-		First(&service, serviceID).Error; err != nil {
+	if err := sc.DB.Preload("Creator").Preload("Updater").Preload("Project").Preload("Instances.Environment").Preload("Tags").
+		Preload("OwnerTeam").Preload("Owners").First(&service, serviceID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Service not found",
@@ -252,17 +365,11 @@ func (sc *ServiceController) GetService(c *gin.Context) {
 	}
 
 	// Check if user has access to this service's project
-	hasAccess := service.Project.OwnerID == user.ID || service.Project.Visibility == "public"
-	if !hasAccess {
-		// Check if user is a collaborator
-		var collaborator models.ProjectCollaborator
-		if err := sc.DB.Where("project_id = ? AND user_id = ? AND state = ?",
-			service.ProjectID, user.ID, "active").First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
-			})
-			return
-		}
+	if !models.GetProjectAccess(sc.DB, &service.Project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -314,17 +421,11 @@ func (sc *ServiceController) UpdateService(c *gin.Context) {
 	}
 
 	// Check if user has access to modify this service's project
-	hasAccess := service.Project.OwnerID == user.ID
-	if !hasAccess {
-		// Check if user is a collaborator with editor role
-		var collaborator models.ProjectCollaborator
-		if err := sc.DB.Where("project_id = ? AND user_id = ? AND state = ? AND role IN (?)",
-			service.ProjectID, user.ID, "active", []string{"owner", "editor"}).First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
-			})
-			return
-		}
+	if !models.GetProjectAccess(sc.DB, &service.Project, user).CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
 	}
 
 	var req models.UpdateServiceRequest
@@ -338,6 +439,46 @@ func (sc *ServiceController) UpdateService(c *gin.Context) {
 		return
 	}
 
+	// Validate environment against the project's environments
+	if err := models.ValidateServiceEnvironment(sc.DB, service.ProjectID, req.Environment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid environment",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Validate metadata against the project's schema
+	if req.Metadata != nil {
+		fieldErrors, err := models.ValidateServiceMetadata(sc.DB, service.ProjectID, req.Metadata)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to validate metadata",
+			})
+			return
+		}
+		if len(fieldErrors) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":  "Invalid metadata",
+				"fields": fieldErrors,
+			})
+			return
+		}
+	}
+
+	// Resolve tags, creating the ones that do not exist yet
+	var tags []models.Tag
+	if req.Tags != nil {
+		tags, err = models.ResolveTags(sc.DB, req.Tags, user.ID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid tags",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
 	// Update only provided fields
 	updates := make(map[string]interface{})
 
@@ -394,8 +535,18 @@ func (sc *ServiceController) UpdateService(c *gin.Context) {
 		return
 	}
 
+	// Replace tags if provided
+	if req.Tags != nil {
+		if err := sc.DB.Model(&service).Association("Tags").Replace(tags); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update service tags",
+			})
+			return
+		}
+	}
+
 	// Reload service with relationships
-	sc.DB.Preload("Creator").Preload("Updater").First(&service, service.ID)
+	sc.DB.Preload("Creator").Preload("Updater").Preload("Tags").First(&service, service.ID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Service updated successfully",
@@ -447,21 +598,25 @@ func (sc *ServiceController) DeleteService(c *gin.Context) {
 	}
 
 	// Check if user has access to delete this service's project
-	hasAccess := service.Project.OwnerID == user.ID
-	if !hasAccess {
-		// Check if user is a collaborator with owner/editor role
-		var collaborator models.ProjectCollaborator
-		if err := sc.DB.Where("project_id = ? AND user_id = ? AND state = ? AND role IN (?)",
-			service.ProjectID, user.ID, "active", []string{"owner", "editor"}).First(&collaborator).Error; err != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied",
-			})
-			return
-		}
+	if !models.GetProjectAccess(sc.DB, &service.Project, user).CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
 	}
 
-	// Delete service
-	if err := sc.DB.Delete(&service).Error; err != nil {
+	// Delete service and record it in the project history. Its dependencies
+	// are deleted with it, along with their comments.
+	err = sc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.RecordServiceHistory(tx, &service, user.ID, models.HistoryDeleteService); err != nil {
+			return err
+		}
+		if err := models.DeleteServiceDependencyComments(tx, []uint{service.ID}); err != nil {
+			return err
+		}
+		return tx.Delete(&service).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete service",
 		})
@@ -0,0 +1,383 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+	"sami/pkg/pagination"
+)
+
+// ownershipListSpec describes pagination for the cross-project ownership
+// reports. Unlike serviceListSpec these are paginated by default.
+var ownershipListSpec = func() pagination.Spec[models.Service] {
+	spec := serviceListSpec
+	spec.DefaultLimit = 50
+	spec.Filters = map[string]pagination.Filter{
+		"project_id": {Column: "services.project_id", Kind: pagination.KindInt},
+	}
+	for name, filter := range serviceListSpec.Filters {
+		spec.Filters[name] = filter
+	}
+	return spec
+}()
+
+// GetOwnedServices lists the services owned by the authenticated user across
+// all projects they can see. ?scope=me only returns services owned personally,
+// ?scope=team only those owned through one of the user's teams.
+func (sc *ServiceController) GetOwnedServices(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Parse pagination, sort and filter parameters
+	params, err := pagination.Parse(c.Request.URL.Query(), ownershipListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	query := sc.DB.Where("services.project_id IN ("+models.AccessibleProjectsSQL+")", map[string]interface{}{"user": user.ID})
+
+	switch c.DefaultQuery("scope", "all") {
+	case "all":
+		query = models.OwnedServices(query, user.ID)
+	case "me":
+		query = models.DirectlyOwnedServices(query, user.ID)
+	case "team":
+		query = models.TeamOwnedServices(query, user.ID)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid scope. Must be one of: all, me, team",
+		})
+		return
+	}
+
+	page, err := pagination.Find(query, ownershipListSpec, params, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Tags").Preload("OwnerTeam").Preload("Owners")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch services",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"services":   models.ServiceResponsesFor(page.Items, nil),
+		"pagination": page.Info,
+	})
+}
+
+// GetUnownedServices reports the services without an owning team or owner
+// users across all projects the user can see. ?project_id= narrows the report
+// to one project.
+func (sc *ServiceController) GetUnownedServices(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Parse pagination, sort and filter parameters
+	params, err := pagination.Parse(c.Request.URL.Query(), ownershipListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	query := sc.DB.Where("services.project_id IN ("+models.AccessibleProjectsSQL+")", map[string]interface{}{"user": user.ID})
+	query = models.UnownedServices(query)
+
+	page, err := pagination.Find(query, ownershipListSpec, params, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Tags")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch services",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"services":   models.ServiceResponsesFor(page.Items, nil),
+		"pagination": page.Info,
+	})
+}
+
+// GetServiceOwnership returns the ownership and on-call data of a service
+// along with the users notifications about it are routed to
+func (sc *ServiceController) GetServiceOwnership(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get service ID from URL
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid service ID",
+		})
+		return
+	}
+
+	var service models.Service
+	if err := sc.DB.Preload("Project").Preload("OwnerTeam").Preload("Owners").First(&service, serviceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Service not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch service",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to this service's project
+	if !models.GetProjectAccess(sc.DB, &service.Project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	// Resolve notification recipients
+	recipientIDs, err := models.ServiceOwnerUserIDs(sc.DB, service.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to resolve service owners",
+		})
+		return
+	}
+
+	var recipients []models.User
+	if len(recipientIDs) > 0 {
+		if err := sc.DB.Where("id IN ?", recipientIDs).Order("email").Find(&recipients).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to resolve service owners",
+			})
+			return
+		}
+	}
+
+	recipientResponses := make([]models.UserResponse, 0, len(recipients))
+	for _, recipient := range recipients {
+		recipientResponses = append(recipientResponses, recipient.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ownership":  service.ToOwnershipResponse(),
+		"recipients": recipientResponses,
+	})
+}
+
+// UpdateServiceOwnership replaces the owning team, owner users, escalation
+// contact and runbook links of a service
+func (sc *ServiceController) UpdateServiceOwnership(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get service ID from URL
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid service ID",
+		})
+		return
+	}
+
+	var req models.UpdateServiceOwnershipRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var service models.Service
+	if err := sc.DB.Preload("Project").First(&service, serviceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Service not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch service",
+			})
+		}
+		return
+	}
+
+	// Check if user has edit access to this service's project
+	if !models.GetProjectAccess(sc.DB, &service.Project, user).CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	// Validate runbook links
+	if fieldErrors := req.Runbooks.Check(); len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Invalid runbook links",
+			"fields": fieldErrors,
+		})
+		return
+	}
+
+	// The owning team must belong to the project's organization
+	if req.OwnerTeamID != nil && *req.OwnerTeamID == 0 {
+		req.OwnerTeamID = nil
+	}
+	if req.OwnerTeamID != nil {
+		var team models.Team
+		if err := sc.DB.First(&team, *req.OwnerTeamID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Team not found",
+				})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to fetch team",
+				})
+			}
+			return
+		}
+		if service.Project.OrganizationID == nil || team.OrganizationID != *service.Project.OrganizationID {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Team must belong to the project's organization",
+			})
+			return
+		}
+	}
+
+	// Owner users must be able to see the project
+	ownerIDs := make([]uint, 0, len(req.OwnerIDs))
+	seen := make(map[uint]bool)
+	for _, id := range req.OwnerIDs {
+		if !seen[id] {
+			seen[id] = true
+			ownerIDs = append(ownerIDs, id)
+		}
+	}
+	var owners []models.User
+	if len(ownerIDs) > 0 {
+		if err := sc.DB.Where("id IN ?", ownerIDs).Find(&owners).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch owners",
+			})
+			return
+		}
+		if len(owners) != len(ownerIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "One or more owners not found",
+			})
+			return
+		}
+		for i := range owners {
+			if !models.GetProjectAccess(sc.DB, &service.Project, &owners[i]).CanView() {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Owner " + owners[i].Email + " does not have access to this project",
+				})
+				return
+			}
+		}
+	}
+
+	// Save ownership
+	err = sc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&service).Updates(map[string]interface{}{
+			"owner_team_id":      req.OwnerTeamID,
+			"escalation_contact": req.EscalationContact,
+			"runbooks":           req.Runbooks,
+			"updated_by":         user.ID,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&service).Association("Owners").Replace(owners)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update service ownership",
+		})
+		return
+	}
+
+	// Reload ownership data
+	if err := sc.DB.Preload("OwnerTeam").Preload("Owners").First(&service, service.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch updated service",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Service ownership updated successfully",
+		"ownership": service.ToOwnershipResponse(),
+	})
+}
@@ -0,0 +1,323 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+	"sami/pkg/pagination"
+)
+
+type TagController struct {
+	DB *gorm.DB
+}
+
+// tagListSpec describes pagination and sorting for GetTags
+var tagListSpec = pagination.Spec[models.Tag]{
+	Sorts: map[string]pagination.Sort[models.Tag]{
+		"name":       {Column: "tags.name", Kind: pagination.KindString, Value: func(t models.Tag) interface{} { return t.Name }},
+		"created_at": {Column: "tags.created_at", Kind: pagination.KindTime, Value: func(t models.Tag) interface{} { return t.CreatedAt }},
+	},
+	DefaultSort: "name",
+	IDColumn:    "tags.id",
+	ID:          func(t models.Tag) uint { return t.ID },
+	Filters: map[string]pagination.Filter{
+		"color":      {Column: "tags.color"},
+		"created_by": {Column: "tags.created_by", Kind: pagination.KindInt},
+	},
+}
+
+// GetTags lists the available tags. ?q= matches tags by name prefix.
+func (tc *TagController) GetTags(c *gin.Context) {
+	// Parse pagination, sort and filter parameters
+	params, err := pagination.Parse(c.Request.URL.Query(), tagListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	query := tc.DB.Model(&models.Tag{})
+	if prefix := models.NormalizeTagName(c.Query("q")); prefix != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+		query = query.Where("tags.name LIKE ?", escaped+"%")
+	}
+
+	page, err := pagination.Find(query, tagListSpec, params, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch tags",
+		})
+		return
+	}
+
+	// Convert to response format
+	tagResponses := make([]models.TagResponse, 0, len(page.Items))
+	for _, tag := range page.Items {
+		tagResponses = append(tagResponses, tag.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tags":       tagResponses,
+		"pagination": page.Info,
+	})
+}
+
+// CreateTag creates a new tag
+func (tc *TagController) CreateTag(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var req models.CreateTagRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	name := models.NormalizeTagName(req.Name)
+	if !models.IsValidTagName(name) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tag name must start with a letter or digit and contain only lowercase letters, digits, '.', '_', ':' and '-'",
+		})
+		return
+	}
+
+	// Set default color if not provided
+	if req.Color == "" {
+		req.Color = models.DefaultTagColor
+	}
+	if !models.IsValidTagColor(req.Color) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tag color must be a hex color like #1f2937",
+		})
+		return
+	}
+
+	// Check if tag already exists
+	var existingTag models.Tag
+	if err := tc.DB.Where("name = ?", name).First(&existingTag).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Tag with this name already exists",
+		})
+		return
+	}
+
+	tag := models.Tag{
+		Name:        name,
+		Color:       req.Color,
+		Description: req.Description,
+		CreatedBy:   user.ID,
+	}
+
+	// Save to database
+	if err := tc.DB.Create(&tag).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create tag",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Tag created successfully",
+		"tag":     tag.ToResponse(),
+	})
+}
+
+// UpdateTag renames or recolors a tag (admin only)
+func (tc *TagController) UpdateTag(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get tag ID from URL
+	tagID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid tag ID",
+		})
+		return
+	}
+
+	var req models.UpdateTagRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Find tag
+	var tag models.Tag
+	if err := tc.DB.First(&tag, tagID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Tag not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch tag",
+			})
+		}
+		return
+	}
+
+	// Tags are shared by every project, only admins can change them
+	if user.Role != models.AdminRole {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only administrators can update tags",
+		})
+		return
+	}
+
+	// Update fields if provided
+	if req.Name != "" {
+		name := models.NormalizeTagName(req.Name)
+		if !models.IsValidTagName(name) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Tag name must start with a letter or digit and contain only lowercase letters, digits, '.', '_', ':' and '-'",
+			})
+			return
+		}
+		if name != tag.Name {
+			var existingTag models.Tag
+			if err := tc.DB.Where("name = ?", name).First(&existingTag).Error; err == nil {
+				c.JSON(http.StatusConflict, gin.H{
+					"error": "Tag with this name already exists",
+				})
+				return
+			}
+		}
+		tag.Name = name
+	}
+	if req.Color != "" {
+		if !models.IsValidTagColor(req.Color) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Tag color must be a hex color like #1f2937",
+			})
+			return
+		}
+		tag.Color = req.Color
+	}
+	if req.Description != nil {
+		tag.Description = *req.Description
+	}
+
+	// Save changes
+	if err := tc.DB.Save(&tag).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update tag",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag updated successfully",
+		"tag":     tag.ToResponse(),
+	})
+}
+
+// DeleteTag deletes a tag and removes it from every project and service
+// (admin only)
+func (tc *TagController) DeleteTag(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get tag ID from URL
+	tagID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid tag ID",
+		})
+		return
+	}
+
+	// Find tag
+	var tag models.Tag
+	if err := tc.DB.First(&tag, tagID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Tag not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch tag",
+			})
+		}
+		return
+	}
+
+	// Tags are shared by every project, only admins can change them
+	if user.Role != models.AdminRole {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only administrators can delete tags",
+		})
+		return
+	}
+
+	// Delete tag; project_tags and service_tags rows cascade
+	if err := tc.DB.Delete(&tag).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete tag",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag deleted successfully",
+	})
+}
@@ -0,0 +1,916 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+)
+
+type TeamController struct {
+	DB *gorm.DB
+}
+
+// GetOrganizationTeams lists the teams of an organization
+func (tc *TeamController) GetOrganizationTeams(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get organization ID from URL
+	organizationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid organization ID",
+		})
+		return
+	}
+
+	// Check if user is a member of this organization
+	if models.GetOrganizationRole(tc.DB, uint(organizationID), user.ID) == "" && user.Role != models.AdminRole {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	var teams []models.Team
+	if err := tc.DB.Preload("Members.User").Where("organization_id = ?", organizationID).
+		Order("name ASC").Find(&teams).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch teams",
+		})
+		return
+	}
+
+	// Convert to response format
+	teamResponses := make([]models.TeamResponse, 0, len(teams))
+	for _, team := range teams {
+		teamResponses = append(teamResponses, team.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"teams": teamResponses,
+	})
+}
+
+// CreateTeam creates a new team in an organization
+func (tc *TeamController) CreateTeam(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get organization ID from URL
+	organizationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid organization ID",
+		})
+		return
+	}
+
+	var req models.CreateTeamRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var organization models.Organization
+	if err := tc.DB.First(&organization, organizationID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Organization not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch organization",
+			})
+		}
+		return
+	}
+
+	// Check if user is an organization admin
+	if !models.CanManageOrganization(tc.DB, organization.ID, user) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Organization admin access required",
+		})
+		return
+	}
+
+	// Check if team slug already exists in this organization
+	var existingTeam models.Team
+	if err := tc.DB.Where("organization_id = ? AND slug = ?", organization.ID, req.Slug).
+		First(&existingTeam).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Team with this slug already exists",
+		})
+		return
+	}
+
+	team := models.Team{
+		OrganizationID: organization.ID,
+		Name:           req.Name,
+		Slug:           req.Slug,
+		Description:    req.Description,
+	}
+
+	if err := tc.DB.Create(&team).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create team",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Team created successfully",
+		"team":    team.ToResponse(),
+	})
+}
+
+// GetTeam retrieves a team with its members
+func (tc *TeamController) GetTeam(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get team ID from URL
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid team ID",
+		})
+		return
+	}
+
+	var team models.Team
+	if err := tc.DB.Preload("Members.User").First(&team, teamID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Team not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch team",
+			})
+		}
+		return
+	}
+
+	// Check if user is a member of the team's organization
+	if models.GetOrganizationRole(tc.DB, team.OrganizationID, user.ID) == "" && user.Role != models.AdminRole {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	// List the projects this team has access to
+	var grants []models.ProjectTeam
+	tc.DB.Preload("Project").Where("team_id = ?", team.ID).Find(&grants)
+
+	projects := make([]gin.H, 0, len(grants))
+	for _, grant := range grants {
+		projects = append(projects, gin.H{
+			"project_id": grant.ProjectID,
+			"name":       grant.Project.Name,
+			"slug":       grant.Project.Slug,
+			"role":       grant.Role,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"team":     team.ToResponse(),
+		"projects": projects,
+	})
+}
+
+// UpdateTeam updates a team
+func (tc *TeamController) UpdateTeam(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get team ID from URL
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid team ID",
+		})
+		return
+	}
+
+	var req models.UpdateTeamRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var team models.Team
+	if err := tc.DB.First(&team, teamID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Team not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch team",
+			})
+		}
+		return
+	}
+
+	// Check if user is an organization admin
+	if !models.CanManageOrganization(tc.DB, team.OrganizationID, user) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Organization admin access required",
+		})
+		return
+	}
+
+	// Update fields if provided
+	if req.Name != "" {
+		team.Name = req.Name
+	}
+	if req.Description != nil {
+		team.Description = *req.Description
+	}
+
+	if err := tc.DB.Save(&team).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update team",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team updated successfully",
+		"team":    team.ToResponse(),
+	})
+}
+
+// DeleteTeam deletes a team and its project grants
+func (tc *TeamController) DeleteTeam(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get team ID from URL
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid team ID",
+		})
+		return
+	}
+
+	var team models.Team
+	if err := tc.DB.First(&team, teamID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Team not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch team",
+			})
+		}
+		return
+	}
+
+	// Check if user is an organization admin
+	if !models.CanManageOrganization(tc.DB, team.OrganizationID, user) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Organization admin access required",
+		})
+		return
+	}
+
+	// Delete team; members and project grants cascade, owned services become unowned
+	if err := tc.DB.Delete(&team).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete team",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team deleted successfully",
+	})
+}
+
+// AddTeamMember adds an organization member to a team
+func (tc *TeamController) AddTeamMember(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get team ID from URL
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid team ID",
+		})
+		return
+	}
+
+	var req models.AddTeamMemberRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var team models.Team
+	if err := tc.DB.First(&team, teamID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Team not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch team",
+			})
+		}
+		return
+	}
+
+	// Check if user is an organization admin
+	if !models.CanManageOrganization(tc.DB, team.OrganizationID, user) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Organization admin access required",
+		})
+		return
+	}
+
+	// Find user to add
+	var targetUser models.User
+	if err := tc.DB.Where("email = ? AND deleted_at IS NULL", req.Email).First(&targetUser).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	// Teams only contain members of their organization
+	if models.GetOrganizationRole(tc.DB, team.OrganizationID, targetUser.ID) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User is not a member of this organization",
+		})
+		return
+	}
+
+	// Check if user is already in the team
+	var existingMember models.TeamMember
+	if err := tc.DB.Where("team_id = ? AND user_id = ?", team.ID, targetUser.ID).
+		First(&existingMember).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "User is already a member of this team",
+		})
+		return
+	}
+
+	member := models.TeamMember{
+		TeamID: team.ID,
+		UserID: targetUser.ID,
+	}
+
+	if err := tc.DB.Create(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to add team member",
+		})
+		return
+	}
+
+	member.User = targetUser
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Team member added successfully",
+		"member":  member.ToResponse(),
+	})
+}
+
+// RemoveTeamMember removes a user from a team
+func (tc *TeamController) RemoveTeamMember(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get team and user IDs from URL
+	teamID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid team ID",
+		})
+		return
+	}
+
+	memberID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return
+	}
+
+	var team models.Team
+	if err := tc.DB.First(&team, teamID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Team not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch team",
+			})
+		}
+		return
+	}
+
+	// Members can leave on their own, admins can remove anyone
+	if uint(memberID) != user.ID && !models.CanManageOrganization(tc.DB, team.OrganizationID, user) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Organization admin access required",
+		})
+		return
+	}
+
+	result := tc.DB.Where("team_id = ? AND user_id = ?", team.ID, memberID).Delete(&models.TeamMember{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to remove team member",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Team member not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team member removed successfully",
+	})
+}
+
+// GetProjectTeams lists the teams granted access to a project
+func (tc *TeamController) GetProjectTeams(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	var project models.Project
+	if err := tc.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user has access to this project
+	if !models.GetProjectAccess(tc.DB, &project, user).CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	var grants []models.ProjectTeam
+	if err := tc.DB.Preload("Team").Where("project_id = ?", project.ID).
+		Order("granted_at ASC").Find(&grants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch project teams",
+		})
+		return
+	}
+
+	// Convert to response format
+	grantResponses := make([]models.ProjectTeamResponse, 0, len(grants))
+	for _, grant := range grants {
+		grantResponses = append(grantResponses, grant.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"teams": grantResponses,
+	})
+}
+
+// GrantProjectTeam grants a team of the project's organization access to the project
+func (tc *TeamController) GrantProjectTeam(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	var req models.GrantProjectTeamRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var project models.Project
+	if err := tc.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user can manage project access
+	if !models.GetProjectAccess(tc.DB, &project, user).CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only project owner can grant team access",
+		})
+		return
+	}
+
+	// Only teams of the project's organization can be granted
+	var team models.Team
+	if err := tc.DB.First(&team, req.TeamID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Team not found",
+		})
+		return
+	}
+	if project.OrganizationID == nil || *project.OrganizationID != team.OrganizationID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Team does not belong to the project's organization",
+		})
+		return
+	}
+
+	// Check if team already has access
+	var existingGrant models.ProjectTeam
+	if err := tc.DB.Where("project_id = ? AND team_id = ?", project.ID, team.ID).
+		First(&existingGrant).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Team already has access to this project",
+		})
+		return
+	}
+
+	// Set default role if not provided
+	if req.Role == "" {
+		req.Role = models.ProjectViewerRole
+	}
+
+	grant := models.ProjectTeam{
+		ProjectID: project.ID,
+		TeamID:    team.ID,
+		Role:      req.Role,
+		GrantedBy: user.ID,
+	}
+
+	if err := tc.DB.Create(&grant).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to grant team access",
+		})
+		return
+	}
+
+	grant.Team = team
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Team access granted successfully",
+		"team":    grant.ToResponse(),
+	})
+}
+
+// UpdateProjectTeam changes the role granted to a team on a project
+func (tc *TeamController) UpdateProjectTeam(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project and team IDs from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	teamID, err := strconv.Atoi(c.Param("team_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid team ID",
+		})
+		return
+	}
+
+	var req models.UpdateProjectTeamRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var project models.Project
+	if err := tc.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user can manage project access
+	if !models.GetProjectAccess(tc.DB, &project, user).CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only project owner can change team access",
+		})
+		return
+	}
+
+	result := tc.DB.Model(&models.ProjectTeam{}).
+		Where("project_id = ? AND team_id = ?", project.ID, teamID).
+		Update("role", req.Role)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update team access",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Team does not have access to this project",
+		})
+		return
+	}
+
+	var grant models.ProjectTeam
+	tc.DB.Preload("Team").Where("project_id = ? AND team_id = ?", project.ID, teamID).First(&grant)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team access updated successfully",
+		"team":    grant.ToResponse(),
+	})
+}
+
+// RevokeProjectTeam removes a team's access to a project
+func (tc *TeamController) RevokeProjectTeam(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project and team IDs from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	teamID, err := strconv.Atoi(c.Param("team_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid team ID",
+		})
+		return
+	}
+
+	var project models.Project
+	if err := tc.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user can manage project access
+	if !models.GetProjectAccess(tc.DB, &project, user).CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only project owner can revoke team access",
+		})
+		return
+	}
+
+	result := tc.DB.Where("project_id = ? AND team_id = ?", project.ID, teamID).Delete(&models.ProjectTeam{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke team access",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Team does not have access to this project",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team access revoked successfully",
+	})
+}
@@ -0,0 +1,360 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+	"sami/pkg/pagination"
+)
+
+// threadListSpec describes pagination of the comments of a thread, listed
+// depth first
+var threadListSpec = pagination.Spec[models.Comment]{
+	Sorts: map[string]pagination.Sort[models.Comment]{
+		"path": {Column: "comments.path", Kind: pagination.KindString, Value: func(c models.Comment) interface{} { return c.Path }},
+	},
+	DefaultSort: "path",
+	IDColumn:    "comments.id",
+	ID:          func(c models.Comment) uint { return c.ID },
+	Filters: map[string]pagination.Filter{
+		"user_id": {Column: "comments.user_id", Kind: pagination.KindInt},
+		"depth":   {Column: "comments.depth", Kind: pagination.KindInt},
+	},
+	DefaultLimit: 50,
+}
+
+// findThread loads the root comment of the thread of the comment in the URL
+// and the access of the user to its project. It responds and returns false
+// when the thread is not found or the user cannot view it.
+func (cc *CommentController) findThread(c *gin.Context, user *models.User) (*models.Comment, models.ProjectAccess, bool) {
+	// Get comment ID from URL
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid comment ID",
+		})
+		return nil, models.ProjectAccess{}, false
+	}
+
+	var comment models.Comment
+	if err := cc.DB.Where("status = ?", "active").First(&comment, commentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Comment not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch comment",
+			})
+		}
+		return nil, models.ProjectAccess{}, false
+	}
+
+	root, err := models.ThreadRoot(cc.DB, &comment)
+	if err == nil {
+		err = cc.DB.Preload("Project").First(root, root.ID).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch thread",
+		})
+		return nil, models.ProjectAccess{}, false
+	}
+
+	// Check if user has access to the project containing this thread
+	access := models.GetProjectAccess(cc.DB, &root.Project, user)
+	if !access.CanView() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return nil, models.ProjectAccess{}, false
+	}
+
+	return root, access, true
+}
+
+// respondThread writes the root comment of a thread after a change
+func (cc *CommentController) respondThread(c *gin.Context, message string, root *models.Comment) {
+	// Load relationships for response
+	cc.DB.Preload("User").Preload("Service").Preload("Dependency.SourceService").Preload("Dependency.TargetService").Preload("Assignee").Preload("Mentions").Preload("Reactions").First(root, root.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"comment": root.ToResponse(),
+	})
+}
+
+// GetCommentThread lists the comments of the thread of a comment depth
+// first, root included, with their depth and parent. Large threads are read
+// page by page; ?depth= narrows the list to one level.
+func (cc *CommentController) GetCommentThread(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	root, _, found := cc.findThread(c, user)
+	if !found {
+		return
+	}
+
+	// Parse pagination and filter parameters
+	params, err := pagination.Parse(c.Request.URL.Query(), threadListSpec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	query := cc.DB.Where("comments.thread_id = ?", root.ID).Where(models.VisibleCommentSQL)
+	page, err := pagination.Find(query, threadListSpec, params, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("User").Preload("Assignee").Preload("Mentions").Preload("Reactions")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch thread",
+		})
+		return
+	}
+
+	// Convert to response format
+	commentResponses := make([]models.CommentResponse, 0, len(page.Items))
+	for _, comment := range page.Items {
+		commentResponses = append(commentResponses, comment.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thread":     root.ThreadResponse(),
+		"comments":   commentResponses,
+		"pagination": page.Info,
+	})
+}
+
+// LockThread locks or unlocks a thread. Only project editors can reply to
+// or edit the comments of a locked thread.
+func (cc *CommentController) LockThread(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var req models.LockThreadRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	root, access, found := cc.findThread(c, user)
+	if !found {
+		return
+	}
+
+	if !access.CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only project owners can lock threads",
+		})
+		return
+	}
+
+	updates := map[string]interface{}{"locked_at": nil, "locked_by": nil}
+	message := "Thread unlocked successfully"
+	if *req.Locked {
+		updates = map[string]interface{}{"locked_at": time.Now(), "locked_by": user.ID}
+		message = "Thread locked successfully"
+	}
+
+	// Update the columns only, so the comment is not marked edited
+	if err := cc.DB.Model(root).UpdateColumns(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update thread",
+		})
+		return
+	}
+
+	cc.respondThread(c, message, root)
+}
+
+// ResolveThread marks a thread resolved, or open again. The author of the
+// thread and project editors can resolve it. The state of the issues in the
+// thread is not changed.
+func (cc *CommentController) ResolveThread(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var req models.ResolveThreadRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	root, access, found := cc.findThread(c, user)
+	if !found {
+		return
+	}
+
+	if root.UserID != user.ID && !access.CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You can only resolve your own threads or threads in projects you can edit",
+		})
+		return
+	}
+
+	updates := map[string]interface{}{"thread_resolved_at": nil, "thread_resolved_by": nil}
+	message := "Thread reopened successfully"
+	if *req.Resolved {
+		updates = map[string]interface{}{"thread_resolved_at": time.Now(), "thread_resolved_by": user.ID}
+		message = "Thread resolved successfully"
+	}
+
+	// Update the columns only, so the comment is not marked edited
+	if err := cc.DB.Model(root).UpdateColumns(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update thread",
+		})
+		return
+	}
+
+	cc.respondThread(c, message, root)
+}
+
+// threadOpen writes an error response unless the user can add to the thread
+// of the comment: locked threads are left to project editors
+func threadOpen(c *gin.Context, db *gorm.DB, comment *models.Comment, access models.ProjectAccess) bool {
+	root, err := models.ThreadRoot(db, comment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch thread",
+		})
+		return false
+	}
+	if root.IsLocked() && !access.CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "This thread is locked",
+		})
+		return false
+	}
+	return true
+}
+
+// MoveThread moves a whole thread to another service or dependency of the
+// project, or to the project itself
+func (cc *CommentController) MoveThread(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var req models.MoveThreadRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.ServiceID != nil && req.DependencyID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A thread can be moved to a service or a dependency, not both",
+		})
+		return
+	}
+
+	root, access, found := cc.findThread(c, user)
+	if !found {
+		return
+	}
+
+	if !access.CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	// Validate the new subject belongs to the project of the thread
+	if err := models.ValidateCommentSubject(cc.DB, root.ProjectID, req.ServiceID, req.DependencyID); err != nil {
+		respondCommentSubjectError(c, err)
+		return
+	}
+
+	if err := models.MoveThread(cc.DB, root, req.ServiceID, req.DependencyID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to move thread",
+		})
+		return
+	}
+
+	// Clear the old subject before reloading it
+	root.Service, root.Dependency = nil, nil
+	cc.respondThread(c, "Thread moved successfully", root)
+}
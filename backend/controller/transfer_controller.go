@@ -0,0 +1,447 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+)
+
+// CreateProjectTransfer offers the primary ownership of a project to another
+// user. The transfer only takes effect once the recipient accepts it.
+func (pc *ProjectController) CreateProjectTransfer(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	var req models.CreateTransferRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Find project
+	var project models.Project
+	if err := pc.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Only the primary owner or an admin can give the project away
+	if !models.GetProjectAccess(pc.DB, &project, user).CanTransfer() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only the project owner can transfer ownership",
+		})
+		return
+	}
+
+	// Find recipient by email
+	var recipient models.User
+	if err := pc.DB.Where("email = ?", req.Email).First(&recipient).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found with this email",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch user",
+			})
+		}
+		return
+	}
+
+	if recipient.ID == project.OwnerID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User already owns this project",
+		})
+		return
+	}
+
+	if recipient.Status != models.StatusActive {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Ownership can only be transferred to active users",
+		})
+		return
+	}
+
+	// Only one transfer can be pending at a time
+	var pending int64
+	if err := pc.DB.Model(&models.ProjectTransfer{}).
+		Where("project_id = ? AND status = ? AND expires_at > ?", project.ID, models.TransferPending, time.Now()).
+		Count(&pending).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check pending transfers",
+		})
+		return
+	}
+	if pending > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "A transfer is already pending for this project",
+		})
+		return
+	}
+
+	// Previous owner stays on the project as co-owner by default
+	if req.PreviousOwnerRole == "" {
+		req.PreviousOwnerRole = string(models.ProjectOwnerRole)
+	}
+
+	transfer := models.ProjectTransfer{
+		ProjectID:         project.ID,
+		FromUserID:        project.OwnerID,
+		ToUserID:          recipient.ID,
+		PreviousOwnerRole: req.PreviousOwnerRole,
+		Status:            models.TransferPending,
+		RequestedBy:       user.ID,
+		CreatedAt:         time.Now(),
+		ExpiresAt:         time.Now().Add(models.TransferExpiry),
+	}
+
+	// Save to database
+	if err := pc.DB.Create(&transfer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create transfer",
+		})
+		return
+	}
+
+	// Load relationships for response
+	pc.DB.Preload("Project").Preload("FromUser").Preload("ToUser").First(&transfer, transfer.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Ownership transfer requested successfully",
+		"transfer": transfer.ToResponse(),
+	})
+}
+
+// GetProjectTransfer returns the pending ownership transfer of a project
+func (pc *ProjectController) GetProjectTransfer(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	// Find project
+	var project models.Project
+	if err := pc.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Check if user is one of the owners or admin
+	if !models.GetProjectAccess(pc.DB, &project, user).CanManage() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+		})
+		return
+	}
+
+	var transfer models.ProjectTransfer
+	if err := pc.DB.Preload("Project").Preload("FromUser").Preload("ToUser").
+		Where("project_id = ? AND status = ? AND expires_at > ?", project.ID, models.TransferPending, time.Now()).
+		First(&transfer).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "No pending transfer",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch transfer",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transfer": transfer.ToResponse(),
+	})
+}
+
+// CancelProjectTransfer withdraws the pending ownership transfer of a project
+func (pc *ProjectController) CancelProjectTransfer(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get project ID from URL
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID",
+		})
+		return
+	}
+
+	// Find project
+	var project models.Project
+	if err := pc.DB.First(&project, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Project not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch project",
+			})
+		}
+		return
+	}
+
+	// Only the primary owner or an admin can withdraw the transfer
+	if !models.GetProjectAccess(pc.DB, &project, user).CanTransfer() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only the project owner can cancel a transfer",
+		})
+		return
+	}
+
+	result := pc.DB.Model(&models.ProjectTransfer{}).
+		Where("project_id = ? AND status = ?", project.ID, models.TransferPending).
+		Updates(map[string]interface{}{"status": models.TransferCancelled, "responded_at": time.Now()})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to cancel transfer",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "No pending transfer",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Transfer cancelled successfully",
+	})
+}
+
+// GetIncomingTransfers lists the pending transfers offered to the authenticated user
+func (pc *ProjectController) GetIncomingTransfers(c *gin.Context) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var transfers []models.ProjectTransfer
+	if err := pc.DB.Preload("Project").Preload("FromUser").Preload("ToUser").
+		Where("to_user_id = ? AND status = ? AND expires_at > ?", user.ID, models.TransferPending, time.Now()).
+		Order("created_at DESC").Find(&transfers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch transfers",
+		})
+		return
+	}
+
+	// Convert to response format
+	transferResponses := make([]models.TransferResponse, 0, len(transfers))
+	for _, transfer := range transfers {
+		transferResponses = append(transferResponses, transfer.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transfers": transferResponses,
+	})
+}
+
+// AcceptTransfer makes the authenticated user the primary owner of the project
+func (pc *ProjectController) AcceptTransfer(c *gin.Context) {
+	pc.respondToTransfer(c, models.TransferAccepted)
+}
+
+// DeclineTransfer refuses an ownership transfer
+func (pc *ProjectController) DeclineTransfer(c *gin.Context) {
+	pc.respondToTransfer(c, models.TransferDeclined)
+}
+
+// respondToTransfer records the recipient's answer to a pending transfer
+func (pc *ProjectController) respondToTransfer(c *gin.Context, status models.TransferStatus) {
+	// Get authenticated user
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	// Get transfer ID from URL
+	transferID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid transfer ID",
+		})
+		return
+	}
+
+	var transfer models.ProjectTransfer
+	if err := pc.DB.Preload("Project").First(&transfer, transferID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Transfer not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch transfer",
+			})
+		}
+		return
+	}
+
+	// Only the recipient can answer
+	if transfer.ToUserID != user.ID {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Transfer not found",
+		})
+		return
+	}
+
+	now := time.Now()
+	if transfer.Status != models.TransferPending || transfer.IsExpired(now) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Transfer is no longer pending",
+		})
+		return
+	}
+
+	// The owner may have changed since the transfer was requested
+	if status == models.TransferAccepted && transfer.Project.OwnerID != transfer.FromUserID {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Project owner has changed since the transfer was requested",
+		})
+		return
+	}
+
+	err = pc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&transfer).Updates(map[string]interface{}{
+			"status":       status,
+			"responded_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		if status != models.TransferAccepted {
+			return nil
+		}
+		return models.TransferProjectOwnership(tx, &transfer.Project, user.ID, transfer.PreviousOwnerRole)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update transfer",
+		})
+		return
+	}
+
+	// Load relationships for response
+	pc.DB.Preload("Project").Preload("FromUser").Preload("ToUser").First(&transfer, transfer.ID)
+
+	message := "Transfer declined"
+	if status == models.TransferAccepted {
+		message = "Transfer accepted, you now own this project"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  message,
+		"transfer": transfer.ToResponse(),
+	})
+}
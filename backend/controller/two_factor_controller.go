@@ -0,0 +1,543 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"sami/models"
+	"sami/pkg/ratelimit"
+	"sami/pkg/totp"
+)
+
+const (
+	defaultTwoFactorChallengeTTL = 5 * time.Minute
+	defaultTwoFactorMaxAttempts  = 5
+	defaultTwoFactorLockout      = 15 * time.Minute
+)
+
+// errInvalidTwoFactorCode is returned when neither a TOTP code nor a
+// recovery code matches
+var errInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+// twoFactorRequired reports whether the user may not go without 2FA
+func (ac *AuthController) twoFactorRequired(user *models.User) bool {
+	return models.IsTwoFactorRequired(ac.DB, user, ac.Settings.RequireAdminTwoFactor)
+}
+
+// issueTwoFactorChallenge answers a login whose password was verified with a
+// challenge token to exchange for a session once the code is checked
+func (ac *AuthController) issueTwoFactorChallenge(c *gin.Context, user *models.User) {
+	ttl := ac.Settings.TwoFactorChallengeTTL
+	if ttl <= 0 {
+		ttl = defaultTwoFactorChallengeTTL
+	}
+
+	token, err := models.SignTwoFactorChallenge(tokenSecret(), user, ttl)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresIn:         int(ttl.Seconds()),
+	})
+}
+
+// verifyTOTP checks a TOTP code and consumes its time step so it cannot be
+// replayed
+func verifyTOTP(tx *gorm.DB, twoFactor *models.UserTwoFactor, code string) error {
+	step := totp.Validate(twoFactor.Secret, code, time.Now())
+	if step < 0 {
+		return errInvalidTwoFactorCode
+	}
+
+	used, err := models.UseTOTPStep(tx, twoFactor.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code
+func verifySecondFactor(tx *gorm.DB, twoFactor *models.UserTwoFactor, code string) error {
+	if err := verifyTOTP(tx, twoFactor, code); !errors.Is(err, errInvalidTwoFactorCode) {
+		return err
+	}
+
+	used, err := models.UseRecoveryCode(tx, twoFactor.UserID, code)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// GetTwoFactorStatus returns the 2FA state of the authenticated user
+func (ac *AuthController) GetTwoFactorStatus(c *gin.Context) {
+	// Get user from context (set by middleware)
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	twoFactor, err := models.GetUserTwoFactor(ac.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch two-factor settings",
+		})
+		return
+	}
+
+	response := models.TwoFactorStatusResponse{
+		Required: ac.twoFactorRequired(user),
+	}
+	if twoFactor != nil && twoFactor.IsEnabled() {
+		response.Enabled = true
+		response.EnabledAt = twoFactor.EnabledAt
+		if response.RecoveryCodesRemaining, err = models.CountRecoveryCodes(ac.DB, user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to count recovery codes",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"two_factor": response,
+	})
+}
+
+// SetupTwoFactor starts enrollment by generating a secret. It only takes
+// effect once a code from the authenticator is confirmed with EnableTwoFactor.
+func (ac *AuthController) SetupTwoFactor(c *gin.Context) {
+	// Get user from context (set by middleware)
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	twoFactor, err := models.GetUserTwoFactor(ac.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch two-factor settings",
+		})
+		return
+	}
+	if twoFactor != nil && twoFactor.IsEnabled() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Two-factor authentication is already enabled",
+		})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate secret",
+		})
+		return
+	}
+
+	// Restarting enrollment replaces the previous unconfirmed secret
+	if err := ac.DB.Save(&models.UserTwoFactor{
+		UserID:    user.ID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save two-factor settings",
+		})
+		return
+	}
+
+	issuer := ac.Settings.TwoFactorIssuer
+	if issuer == "" {
+		issuer = "Sami"
+	}
+
+	c.JSON(http.StatusOK, models.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(issuer, user.Email, secret),
+	})
+}
+
+// EnableTwoFactor confirms enrollment with a code from the authenticator and
+// returns the recovery codes. They are only shown this once.
+func (ac *AuthController) EnableTwoFactor(c *gin.Context) {
+	// Get user from context (set by middleware)
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	twoFactor, err := models.GetUserTwoFactor(ac.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch two-factor settings",
+		})
+		return
+	}
+	if twoFactor == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Two-factor setup has not been started",
+		})
+		return
+	}
+	if twoFactor.IsEnabled() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Two-factor authentication is already enabled",
+		})
+		return
+	}
+
+	var codes []string
+	err = ac.DB.Transaction(func(tx *gorm.DB) error {
+		if err := verifyTOTP(tx, twoFactor, req.Code); err != nil {
+			return err
+		}
+
+		if err := tx.Model(twoFactor).Update("enabled_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		codes, err = models.GenerateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errInvalidTwoFactorCode) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid verification code",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to enable two-factor authentication",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTwoFactor turns 2FA off. It needs the password and a current code,
+// and is refused when the user's role requires 2FA.
+func (ac *AuthController) DisableTwoFactor(c *gin.Context) {
+	// Get user from context (set by middleware)
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var req models.DisableTwoFactorRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if ac.twoFactorRequired(user) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Two-factor authentication is required for your role",
+		})
+		return
+	}
+
+	twoFactor, err := models.GetUserTwoFactor(ac.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch two-factor settings",
+		})
+		return
+	}
+	if twoFactor == nil || !twoFactor.IsEnabled() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Two-factor authentication is not enabled",
+		})
+		return
+	}
+
+	if !user.CheckPassword(req.Password) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Current password is incorrect",
+		})
+		return
+	}
+
+	err = ac.DB.Transaction(func(tx *gorm.DB) error {
+		if err := verifySecondFactor(tx, twoFactor, req.Code); err != nil {
+			return err
+		}
+		return models.DeleteUserTwoFactor(tx, user.ID)
+	})
+	if err != nil {
+		if errors.Is(err, errInvalidTwoFactorCode) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid verification code",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to disable two-factor authentication",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user after
+// checking a current TOTP code
+func (ac *AuthController) RegenerateRecoveryCodes(c *gin.Context) {
+	// Get user from context (set by middleware)
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	user, ok := userInterface.(*models.User)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	twoFactor, err := models.GetUserTwoFactor(ac.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch two-factor settings",
+		})
+		return
+	}
+	if twoFactor == nil || !twoFactor.IsEnabled() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Two-factor authentication is not enabled",
+		})
+		return
+	}
+
+	var codes []string
+	err = ac.DB.Transaction(func(tx *gorm.DB) error {
+		if err := verifyTOTP(tx, twoFactor, req.Code); err != nil {
+			return err
+		}
+
+		codes, err = models.GenerateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errInvalidTwoFactorCode) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid verification code",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to regenerate recovery codes",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// LoginTwoFactor completes a login started with Login by checking the second
+// factor, and issues the session token
+func (ac *AuthController) LoginTwoFactor(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+
+	// Validate JSON input
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userID, err := models.ParseTwoFactorChallenge(tokenSecret(), req.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired challenge token",
+		})
+		return
+	}
+
+	// The account may have been deactivated since the password was checked
+	var user models.User
+	if err := ac.DB.Where("id = ? AND status = ?", userID, models.StatusActive).First(&user).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid credentials",
+		})
+		return
+	}
+
+	// Code guesses count towards the same lockout as passwords
+	if ac.accountLocked(c, user.Email) {
+		return
+	}
+
+	twoFactor, err := models.GetUserTwoFactor(ac.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch two-factor settings",
+		})
+		return
+	}
+	if twoFactor == nil || !twoFactor.IsEnabled() {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired challenge token",
+		})
+		return
+	}
+
+	// Codes are limited per user even without rate limiting, as new
+	// challenges can be requested with the password alone
+	if remaining := twoFactor.LockedFor(time.Now()); remaining > 0 {
+		retryAfter := ratelimit.Seconds(remaining)
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many invalid verification codes, try again later",
+			"code":        "two_factor_locked",
+			"retry_after": retryAfter,
+		})
+		return
+	}
+
+	if err := ac.DB.Transaction(func(tx *gorm.DB) error {
+		return verifySecondFactor(tx, twoFactor, req.Code)
+	}); err != nil {
+		if errors.Is(err, errInvalidTwoFactorCode) {
+			ac.recordTwoFactorFailure(user.ID)
+			ac.recordLoginFailure(c, user.Email)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid verification code",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to verify code",
+			})
+		}
+		return
+	}
+
+	if twoFactor.FailedAttempts > 0 {
+		if err := models.ResetTwoFactorFailures(ac.DB, user.ID); err != nil {
+			log.Printf("failed to reset two-factor attempts of user %d: %v", user.ID, err)
+		}
+	}
+	ac.resetLoginFailures(c, user.Email)
+	ac.issueSession(c, &user)
+}
+
+// recordTwoFactorFailure counts a wrong login code towards the lock of the
+// user's second factor
+func (ac *AuthController) recordTwoFactorFailure(userID uint) {
+	maxAttempts := ac.Settings.TwoFactorMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultTwoFactorMaxAttempts
+	}
+	lockout := ac.Settings.TwoFactorLockout
+	if lockout <= 0 {
+		lockout = defaultTwoFactorLockout
+	}
+
+	if err := models.RecordTwoFactorFailure(ac.DB, userID, maxAttempts, lockout); err != nil {
+		log.Printf("failed to record two-factor attempt of user %d: %v", userID, err)
+	}
+}
@@ -33,7 +33,8 @@ CREATE TABLE projects (
     created_at          TIMESTAMP NOT NULL DEFAULT NOW(),
     updated_at          TIMESTAMP NOT NULL DEFAULT NOW(),
     deleted_at          TIMESTAMP,
-    status              VARCHAR(20) DEFAULT 'active'       -- 'active' | 'archived'
+    status              VARCHAR(20) DEFAULT 'active',      -- 'active' | 'archived'
+    metadata_schema     JSONB                              -- typed fields allowed in services.metadata
 );
 
 CREATE INDEX idx_projects_owner ON projects(owner_id);
@@ -62,7 +63,7 @@ CREATE TABLE services (
     status            VARCHAR(20) DEFAULT 'active',         -- 'active' | 'inactive'
     version           VARCHAR(50),
     language          VARCHAR(50),
-    environment       VARCHAR(20) DEFAULT 'production',     -- 'production' | 'development'
+    environment       VARCHAR(50) DEFAULT 'production',     -- name of a project environment
     deploy_url        VARCHAR(255),
     domain            VARCHAR(255),
     git_repo          VARCHAR(255),
@@ -149,7 +150,491 @@ CREATE INDEX idx_comments_service ON comments(service_id);
 CREATE INDEX idx_comments_parent ON comments(parent_id);
 
 -- ========================================
--- 9. Initial Admin Seeder
+-- 9. Environments (project-defined)
+-- ========================================
+CREATE TABLE environments (
+    id              SERIAL PRIMARY KEY,
+    project_id      INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name            VARCHAR(50) NOT NULL,                    -- staging, qa, prod-eu, etc.
+    description     TEXT,
+    position        INTEGER DEFAULT 0,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (project_id, name)
+);
+
+-- ========================================
+-- 10. Service Instances (service per environment)
+-- ========================================
+CREATE TABLE service_instances (
+    id                SERIAL PRIMARY KEY,
+    service_id        INTEGER NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    environment_id    INTEGER NOT NULL REFERENCES environments(id) ON DELETE CASCADE,
+    status            VARCHAR(20) DEFAULT 'active',         -- 'active' | 'inactive'
+    version           VARCHAR(50),
+    deploy_url        VARCHAR(255),
+    domain            VARCHAR(255),
+    updated_by        INTEGER REFERENCES users(id),
+    created_at        TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (service_id, environment_id)
+);
+
+CREATE INDEX idx_service_instances_environment ON service_instances(environment_id);
+
+-- ========================================
+-- 11. Full-text search
+-- ========================================
+ALTER TABLE projects ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B')
+) STORED;
+
+ALTER TABLE services ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(language, '') || ' ' || coalesce(domain, '')), 'C') ||
+    setweight(to_tsvector('english', coalesce(notes, '')), 'D')
+) STORED;
+
+ALTER TABLE dependencies ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('english', coalesce(description, ''))
+) STORED;
+
+ALTER TABLE comments ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('english', content)
+) STORED;
+
+CREATE INDEX idx_projects_search ON projects USING GIN (search_vector);
+CREATE INDEX idx_services_search ON services USING GIN (search_vector);
+CREATE INDEX idx_dependencies_search ON dependencies USING GIN (search_vector);
+CREATE INDEX idx_comments_search ON comments USING GIN (search_vector);
+
+-- ========================================
+-- 12. Tags (labels for projects and services)
+-- ========================================
+CREATE TABLE tags (
+    id              SERIAL PRIMARY KEY,
+    name            VARCHAR(50) UNIQUE NOT NULL,             -- pci, tier-1, team-payments, etc.
+    color           VARCHAR(7) NOT NULL DEFAULT '#6b7280',
+    description     TEXT,
+    created_by      INTEGER NOT NULL REFERENCES users(id),
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE project_tags (
+    project_id      INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    tag_id          INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (project_id, tag_id)
+);
+
+CREATE TABLE service_tags (
+    service_id      INTEGER NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    tag_id          INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (service_id, tag_id)
+);
+
+CREATE INDEX idx_project_tags_tag ON project_tags(tag_id);
+CREATE INDEX idx_service_tags_tag ON service_tags(tag_id);
+
+-- ========================================
+-- 13. Organizations and teams
+-- ========================================
+CREATE TABLE organizations (
+    id              SERIAL PRIMARY KEY,
+    name            VARCHAR(100) NOT NULL,
+    slug            VARCHAR(100) UNIQUE NOT NULL,
+    description     TEXT,
+    created_by      INTEGER NOT NULL REFERENCES users(id),
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE organization_members (
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id         INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role            VARCHAR(20) DEFAULT 'member',            -- 'admin' | 'member' (not the global user role)
+    joined_at       TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE TABLE teams (
+    id              SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name            VARCHAR(100) NOT NULL,
+    slug            VARCHAR(100) NOT NULL,
+    description     TEXT,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (organization_id, slug)
+);
+
+CREATE TABLE team_members (
+    team_id         INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id         INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at       TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (team_id, user_id)
+);
+
+ALTER TABLE projects ADD COLUMN organization_id INTEGER REFERENCES organizations(id) ON DELETE SET NULL;
+
+CREATE TABLE project_teams (
+    project_id      INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    team_id         INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    role            VARCHAR(50) DEFAULT 'viewer',            -- 'owner' | 'editor' | 'viewer'
+    granted_by      INTEGER NOT NULL REFERENCES users(id),
+    granted_at      TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (project_id, team_id)
+);
+
+CREATE INDEX idx_projects_organization ON projects(organization_id);
+CREATE INDEX idx_team_members_user ON team_members(user_id);
+CREATE INDEX idx_project_teams_team ON project_teams(team_id);
+
+-- ========================================
+-- 14. Service ownership
+-- ========================================
+ALTER TABLE services ADD COLUMN owner_team_id INTEGER REFERENCES teams(id) ON DELETE SET NULL;
+ALTER TABLE services ADD COLUMN escalation_contact VARCHAR(255);
+ALTER TABLE services ADD COLUMN runbooks JSONB;                -- [{"title": "...", "url": "https://..."}]
+
+CREATE TABLE service_owners (
+    service_id      INTEGER NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    user_id         INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (service_id, user_id)
+);
+
+CREATE INDEX idx_services_owner_team ON services(owner_team_id);
+CREATE INDEX idx_service_owners_user ON service_owners(user_id);
+
+-- ========================================
+-- 15. Project ownership transfers
+-- ========================================
+CREATE TABLE project_transfers (
+    id                  SERIAL PRIMARY KEY,
+    project_id          INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    from_user_id        INTEGER NOT NULL,                  -- no FK: kept after the previous owner is deleted
+    to_user_id          INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    previous_owner_role VARCHAR(50),                       -- 'owner' | 'editor' | 'viewer' | 'none'
+    status              VARCHAR(20) DEFAULT 'pending',     -- 'pending' | 'accepted' | 'declined' | 'cancelled' | 'forced'
+    requested_by        INTEGER NOT NULL,                  -- owner or admin who started the transfer
+    created_at          TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at          TIMESTAMP NOT NULL,
+    responded_at        TIMESTAMP
+);
+
+CREATE INDEX idx_project_transfers_project ON project_transfers(project_id, status);
+CREATE INDEX idx_project_transfers_to_user ON project_transfers(to_user_id, status);
+
+-- ========================================
+-- 16. Invitations (email invites for people without an account)
+-- ========================================
+CREATE TABLE invitations (
+    id              SERIAL PRIMARY KEY,
+    email           VARCHAR(255) NOT NULL,
+    name            VARCHAR(100),
+    project_id      INTEGER REFERENCES projects(id) ON DELETE CASCADE, -- NULL for admin invites to the instance
+    role            VARCHAR(50),                          -- project role: 'owner' | 'editor' | 'viewer'
+    user_role       VARCHAR(50) DEFAULT 'user',           -- role of the created account
+    token_hash      VARCHAR(64) NOT NULL,                 -- SHA-256 of the token nonce, never the token itself
+    status          VARCHAR(20) DEFAULT 'pending',        -- 'pending' | 'accepted' | 'revoked'
+    invited_by      INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at      TIMESTAMP NOT NULL,
+    accepted_at     TIMESTAMP
+);
+
+CREATE INDEX idx_invitations_email ON invitations(email, status);
+CREATE INDEX idx_invitations_project ON invitations(project_id, status);
+
+-- ========================================
+-- 17. Email verification and password reset
+-- ========================================
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+-- Accounts created before verification existed are trusted
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+CREATE TABLE user_tokens (
+    id              SERIAL PRIMARY KEY,
+    user_id         INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose         VARCHAR(30) NOT NULL,                 -- 'password_reset' | 'email_verification'
+    token_hash      VARCHAR(64) UNIQUE NOT NULL,          -- SHA-256 of the emailed token
+    email           VARCHAR(255) NOT NULL,                -- address the token was sent to
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at      TIMESTAMP NOT NULL,
+    used_at         TIMESTAMP                             -- single use: set once consumed or superseded
+);
+
+CREATE INDEX idx_user_tokens_user ON user_tokens(user_id, purpose);
+
+-- ========================================
+-- 18. Two-factor authentication and settings
+-- ========================================
+CREATE TABLE user_two_factor (
+    user_id         INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret          VARCHAR(64) NOT NULL,                 -- base32 TOTP secret (RFC 6238)
+    enabled_at      TIMESTAMP,                            -- NULL while enrollment is not confirmed
+    last_used_step  BIGINT NOT NULL DEFAULT 0,            -- time step of the last accepted code (replay protection)
+    failed_attempts INTEGER NOT NULL DEFAULT 0,           -- wrong login codes since the last accepted one
+    locked_until    TIMESTAMP,                            -- login codes are refused until then
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE recovery_codes (
+    id              SERIAL PRIMARY KEY,
+    user_id         INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash       VARCHAR(64) NOT NULL,                 -- SHA-256 of the normalized code
+    used_at         TIMESTAMP
+);
+
+CREATE INDEX idx_recovery_codes_user ON recovery_codes(user_id);
+
+CREATE TABLE settings (
+    key             VARCHAR(100) PRIMARY KEY,             -- e.g. 'security.require_admin_2fa'
+    value           TEXT NOT NULL,
+    updated_by      INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- ========================================
+-- 19. Single sign-on identities (OpenID Connect)
+-- ========================================
+CREATE TABLE user_identities (
+    id              SERIAL PRIMARY KEY,
+    user_id         INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer          VARCHAR(255) NOT NULL,                -- OpenID provider issuer URL
+    subject         VARCHAR(255) NOT NULL,                -- 'sub' claim, stable at the provider
+    email           VARCHAR(255),                         -- email claim at the last login
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_login_at   TIMESTAMP,
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX idx_user_identities_user ON user_identities(user_id);
+
+-- ========================================
+-- 20. SCIM provisioning
+-- ========================================
+CREATE TABLE provisioning_tokens (
+    id              SERIAL PRIMARY KEY,
+    name            VARCHAR(100) NOT NULL,
+    token_hash      VARCHAR(64) NOT NULL UNIQUE,          -- SHA-256 of the token
+    organization_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE, -- groups are synced into its teams
+    created_by      INTEGER NOT NULL REFERENCES users(id),
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_used_at    TIMESTAMP,
+    revoked_at      TIMESTAMP
+);
+
+-- Group ID at the identity provider for SCIM-managed teams
+ALTER TABLE teams ADD COLUMN external_id VARCHAR(255);
+CREATE UNIQUE INDEX idx_teams_external_id ON teams(organization_id, external_id) WHERE external_id IS NOT NULL;
+
+-- ========================================
+-- 21. Issue workflow (issue comments)
+-- ========================================
+ALTER TABLE comments ADD COLUMN issue_state VARCHAR(20);        -- open | in_progress | resolved | wontfix, issues only
+ALTER TABLE comments ADD COLUMN assignee_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE comments ADD COLUMN priority VARCHAR(20);           -- low | medium | high | critical
+ALTER TABLE comments ADD COLUMN due_date DATE;
+ALTER TABLE comments ADD COLUMN resolved_at TIMESTAMP;
+
+-- Existing issues start open
+UPDATE comments SET issue_state = 'open', priority = 'medium' WHERE type = 'issue';
+
+CREATE INDEX idx_comments_issue_board ON comments(project_id, issue_state) WHERE issue_state IS NOT NULL;
+CREATE INDEX idx_comments_assignee ON comments(assignee_id) WHERE assignee_id IS NOT NULL;
+
+CREATE TABLE issue_transitions (
+    id              SERIAL PRIMARY KEY,
+    comment_id      INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    user_id         INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_state      VARCHAR(20),                           -- empty when the issue was opened
+    to_state        VARCHAR(20) NOT NULL,
+    note            TEXT,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_issue_transitions_comment ON issue_transitions(comment_id);
+
+-- ========================================
+-- 22. Mentions and notifications
+-- ========================================
+CREATE TABLE comment_mentions (
+    comment_id      INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    user_id         INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (comment_id, user_id)
+);
+
+CREATE TABLE notifications (
+    id              SERIAL PRIMARY KEY,
+    user_id         INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,   -- recipient
+    actor_id        INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,   -- comment author
+    type            VARCHAR(30) NOT NULL,                  -- mention | reply | service_comment
+    project_id      INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    comment_id      INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    service_id      INTEGER REFERENCES services(id) ON DELETE CASCADE,
+    read_at         TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_notifications_inbox ON notifications(user_id, created_at DESC);
+CREATE INDEX idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
+
+-- ========================================
+-- 23. Email digests
+-- ========================================
+CREATE TABLE notification_preferences (
+    user_id          INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    digest_frequency VARCHAR(20) CHECK (digest_frequency IN ('never', 'daily', 'weekly')), -- NULL: configured default
+    last_digest_at   TIMESTAMP,
+    updated_at       TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- ========================================
+-- 24. Comments on dependencies
+-- ========================================
+-- Comments are marked deleted with their dependency, the link is cleared
+ALTER TABLE comments ADD COLUMN dependency_id INTEGER REFERENCES dependencies(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_comments_dependency ON comments(dependency_id) WHERE dependency_id IS NOT NULL;
+
+-- ========================================
+-- 25. Markdown comments
+-- ========================================
+-- Rendered and sanitized HTML of comments; NULL for comments written before,
+-- which are rendered when read
+ALTER TABLE comments ADD COLUMN content_html TEXT;
+
+-- ========================================
+-- 26. Attachments
+-- ========================================
+-- Files attached to comments and services. The references are cleared when
+-- a parent is deleted so the stored files can be removed by the cleanup job.
+CREATE TABLE attachments (
+    id           SERIAL PRIMARY KEY,
+    project_id   INTEGER REFERENCES projects(id) ON DELETE SET NULL,
+    comment_id   INTEGER REFERENCES comments(id) ON DELETE SET NULL,
+    service_id   INTEGER REFERENCES services(id) ON DELETE SET NULL,
+    uploaded_by  INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    file_name    VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size         BIGINT NOT NULL CHECK (size >= 0),
+    checksum     VARCHAR(64) NOT NULL,
+    storage_key  VARCHAR(255) NOT NULL UNIQUE,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_attachments_project ON attachments(project_id);
+CREATE INDEX idx_attachments_comment ON attachments(comment_id) WHERE comment_id IS NOT NULL;
+CREATE INDEX idx_attachments_service ON attachments(service_id) WHERE service_id IS NOT NULL;
+
+-- ========================================
+-- 27. Comment revisions and reactions
+-- ========================================
+-- Earlier versions of edited comments
+CREATE TABLE comment_revisions (
+    id          SERIAL PRIMARY KEY,
+    comment_id  INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    version     INTEGER NOT NULL,
+    content     TEXT NOT NULL,
+    edited_by   INTEGER REFERENCES users(id) ON DELETE SET NULL,  -- whose edit replaced this version
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (comment_id, version)
+);
+
+CREATE TABLE comment_reactions (
+    id          SERIAL PRIMARY KEY,
+    comment_id  INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    user_id     INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    emoji       VARCHAR(32) NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT idx_comment_reactions_unique UNIQUE (comment_id, user_id, emoji)
+);
+
+-- ========================================
+-- 28. Comment threads
+-- ========================================
+-- Placement of comments in their thread: the root comment, the depth below
+-- it and the path of zero-padded IDs from the root, which orders a thread
+-- depth first
+ALTER TABLE comments ADD COLUMN thread_id INTEGER REFERENCES comments(id) ON DELETE CASCADE;
+ALTER TABLE comments ADD COLUMN depth INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN path TEXT;
+
+-- Thread state, on root comments
+ALTER TABLE comments ADD COLUMN locked_at TIMESTAMP;
+ALTER TABLE comments ADD COLUMN locked_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE comments ADD COLUMN thread_resolved_at TIMESTAMP;
+ALTER TABLE comments ADD COLUMN thread_resolved_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+-- Place the existing comments
+WITH RECURSIVE tree AS (
+    SELECT id, id AS thread_id, 0 AS depth, LPAD(id::TEXT, 10, '0') AS path
+    FROM comments WHERE parent_id IS NULL
+    UNION ALL
+    SELECT c.id, tree.thread_id, tree.depth + 1, tree.path || '/' || LPAD(c.id::TEXT, 10, '0')
+    FROM comments c JOIN tree ON c.parent_id = tree.id
+)
+UPDATE comments SET thread_id = tree.thread_id, depth = tree.depth, path = tree.path
+FROM tree WHERE comments.id = tree.id;
+
+CREATE INDEX idx_comments_thread ON comments(thread_id, path);
+
+-- ========================================
+-- 29. Comment moderation
+-- ========================================
+-- Who can comment on a project: nobody, members only or anyone who can view
+-- it. Project owners can always comment.
+ALTER TABLE projects ADD COLUMN comment_policy VARCHAR(20) NOT NULL DEFAULT 'anyone'
+    CHECK (comment_policy IN ('disabled', 'members', 'anyone'));
+
+-- Reports of comments, reviewed by the project owners
+CREATE TABLE comment_reports (
+    id          SERIAL PRIMARY KEY,
+    comment_id  INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    project_id  INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    reporter_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason      VARCHAR(20) NOT NULL CHECK (reason IN ('spam', 'abuse', 'off_topic', 'other')),
+    details     TEXT,
+    status      VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'actioned')),
+    resolved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    resolution  TEXT,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_comment_reports_queue ON comment_reports(project_id, status, created_at);
+CREATE INDEX idx_comment_reports_comment ON comment_reports(comment_id);
+
+-- Mutes and bans of users on a project. Lifted restrictions are kept as
+-- history.
+CREATE TABLE project_restrictions (
+    id          SERIAL PRIMARY KEY,
+    project_id  INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id     INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind        VARCHAR(20) NOT NULL CHECK (kind IN ('mute', 'ban')),
+    reason      TEXT NOT NULL,
+    created_by  INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at  TIMESTAMP,
+    lifted_by   INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    lifted_at   TIMESTAMP
+);
+
+CREATE INDEX idx_project_restrictions_user ON project_restrictions(project_id, user_id);
+
+-- ========================================
+-- 30. Public project view
+-- ========================================
+-- Service fields hidden from anonymous visitors of a public project and
+-- whether its comments are public. NULL uses the server defaults.
+ALTER TABLE projects ADD COLUMN public_view JSONB;
+
+-- ========================================
+-- 31. Initial Admin Seeder
 -- ========================================
 --DO
 --$$
@@ -0,0 +1,72 @@
+// Package attachments removes the files of attachments whose parent was
+// deleted.
+package attachments
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sami/models"
+	"sami/pkg/storage"
+)
+
+// batchSize is the number of attachments removed per query
+const batchSize = 100
+
+// Cleaner removes orphaned attachments. Deleting a project, service or user
+// only clears the references of their attachments and deleting a comment
+// marks it deleted, so the stored files are removed here rather than in
+// every code path that deletes a parent.
+type Cleaner struct {
+	DB       *gorm.DB
+	Storage  storage.Storage
+	Interval time.Duration
+}
+
+// Run cleans up every Interval until ctx is cancelled
+func (c *Cleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.RunOnce(ctx); err != nil {
+			log.Printf("Error cleaning up attachments: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce removes the orphaned attachments and returns how many were
+// removed. A row is only deleted once its file is gone, so a failure is
+// retried on the next run.
+func (c *Cleaner) RunOnce(ctx context.Context) (int, error) {
+	removed := 0
+	for {
+		attachments, err := models.FindOrphanedAttachments(c.DB, batchSize)
+		if err != nil {
+			return removed, err
+		}
+
+		for _, attachment := range attachments {
+			if err := c.Storage.Delete(ctx, attachment.StorageKey); err != nil {
+				return removed, err
+			}
+			if err := c.DB.Delete(&attachment).Error; err != nil {
+				return removed, err
+			}
+			removed++
+		}
+
+		if len(attachments) < batchSize || ctx.Err() != nil {
+			return removed, ctx.Err()
+		}
+	}
+}
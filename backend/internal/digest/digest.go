@@ -0,0 +1,229 @@
+// Package digest sends users a periodic email summarizing the activity of
+// their projects.
+package digest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sami/models"
+	"sami/pkg/config"
+	"sami/pkg/mailer"
+)
+
+// Sender builds and sends the digests that are due
+type Sender struct {
+	DB      *gorm.DB
+	Mailer  mailer.Mailer
+	BaseURL string
+
+	Hour             int
+	Weekday          time.Weekday
+	DefaultFrequency models.DigestFrequency
+	CheckInterval    time.Duration
+}
+
+// NewSender creates a sender from the digest configuration
+func NewSender(db *gorm.DB, m mailer.Mailer, baseURL string, cfg config.DigestConfig) (*Sender, error) {
+	if cfg.Hour < 0 || cfg.Hour > 23 {
+		return nil, fmt.Errorf("invalid digest hour %d", cfg.Hour)
+	}
+	weekday, ok := models.ParseWeekday(cfg.Weekday)
+	if !ok {
+		return nil, fmt.Errorf("invalid digest weekday %q", cfg.Weekday)
+	}
+	frequency := models.DigestFrequency(cfg.DefaultFrequency)
+	if !frequency.IsValid() {
+		return nil, fmt.Errorf("invalid digest frequency %q", cfg.DefaultFrequency)
+	}
+	if cfg.CheckInterval <= 0 {
+		return nil, fmt.Errorf("invalid digest check interval %s", cfg.CheckInterval)
+	}
+
+	return &Sender{
+		DB:               db,
+		Mailer:           m,
+		BaseURL:          baseURL,
+		Hour:             cfg.Hour,
+		Weekday:          weekday,
+		DefaultFrequency: frequency,
+		CheckInterval:    cfg.CheckInterval,
+	}, nil
+}
+
+// Run sends due digests every CheckInterval until ctx is cancelled
+func (s *Sender) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunOnce(ctx, time.Now()); err != nil {
+			log.Printf("Error sending digests: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends the digests due at now and returns how many were sent. A
+// user whose digest is empty gets no email but is considered served.
+func (s *Sender) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	var users []models.User
+	if err := s.DB.Where("status = ?", models.StatusActive).Order("id ASC").Find(&users).Error; err != nil {
+		return 0, err
+	}
+
+	var preferences []models.NotificationPreference
+	if err := s.DB.Find(&preferences).Error; err != nil {
+		return 0, err
+	}
+	byUser := make(map[uint]models.NotificationPreference, len(preferences))
+	for _, preference := range preferences {
+		byUser[preference.UserID] = preference
+	}
+
+	sent := 0
+	for i := range users {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+
+		user := &users[i]
+		preference := byUser[user.ID]
+		frequency := preference.DigestFrequency
+		if frequency == "" {
+			frequency = s.DefaultFrequency
+		}
+		if frequency == models.DigestNever {
+			continue
+		}
+
+		scheduled := models.LastDigestSchedule(now, frequency, s.Hour, s.Weekday)
+		if preference.LastDigestAt != nil && !preference.LastDigestAt.Before(scheduled) {
+			continue
+		}
+
+		// Cover the time since the last digest, at most one period
+		since := scheduled.Add(-frequency.Period())
+		if preference.LastDigestAt != nil && preference.LastDigestAt.After(since) {
+			since = *preference.LastDigestAt
+		}
+
+		digest, err := models.BuildDigest(s.DB, user, frequency, since, now)
+		if err != nil {
+			return sent, fmt.Errorf("building digest of user %d: %w", user.ID, err)
+		}
+
+		if !digest.IsEmpty() {
+			msg, err := s.Render(digest)
+			if err != nil {
+				return sent, err
+			}
+			if err := s.Mailer.Send(ctx, msg); err != nil {
+				log.Printf("Error sending digest to user %d: %v", user.ID, err)
+				continue
+			}
+			sent++
+		}
+
+		if err := models.MarkDigestSent(s.DB, user.ID, now); err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// Render builds the email of a digest
+func (s *Sender) Render(digest *models.Digest) (mailer.Message, error) {
+	data := digestData{Digest: digest, BaseURL: strings.TrimRight(s.BaseURL, "/")}
+
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return mailer.Message{}, err
+	}
+	if err := htmlTemplate.Execute(&html, data); err != nil {
+		return mailer.Message{}, err
+	}
+
+	return mailer.Message{
+		To:      []string{digest.User.Email},
+		Subject: fmt.Sprintf("Your %s Sami digest", digest.Frequency),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// digestData is passed to the digest templates
+type digestData struct {
+	*models.Digest
+	BaseURL string
+}
+
+// ProjectLink returns the frontend page of a project
+func (d digestData) ProjectLink(projectID uint) string {
+	return fmt.Sprintf("%s/dashboard/projects/%d", d.BaseURL, projectID)
+}
+
+const digestDateLayout = "January 2, 2006"
+
+var templateFuncs = map[string]interface{}{
+	"date": func(t time.Time) string { return t.Format(digestDateLayout) },
+}
+
+var textTemplate = template.Must(template.New("digest").Funcs(templateFuncs).Parse(`Hello {{.User.Name}},
+
+Here is what happened in your projects between {{date .Since}} and {{date .Until}}.
+{{range .Projects}}
+== {{.Name}} ==
+{{$.ProjectLink .ID}}
+{{if .NewServices}}
+New services:
+{{range .NewServices}}  - {{.Title}} (added by {{.Actor}})
+{{end}}{{end}}{{if .RemovedDependencies}}
+Removed dependencies:
+{{range .RemovedDependencies}}  - {{.Title}} (removed by {{.Actor}})
+{{end}}{{end}}{{if .NewIssues}}
+New issues:
+{{range .NewIssues}}  - {{if .Service}}[{{.Service}}] {{end}}{{.Excerpt}} ({{.Author}}{{if .Priority}}, {{.Priority}}{{end}})
+{{end}}{{end}}{{end}}{{if .Mentions}}
+== Mentions ==
+{{range .Mentions}}  - {{.Author}} in {{.Project}}: {{.Excerpt}}
+    {{$.ProjectLink .ProjectID}}
+{{end}}{{end}}
+You receive this {{.Frequency}} digest because of your notification settings in Sami.
+`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("digest").Funcs(templateFuncs).Parse(`<p>Hello {{.User.Name}},</p>
+<p>Here is what happened in your projects between {{date .Since}} and {{date .Until}}.</p>
+{{range .Projects}}<h2><a href="{{$.ProjectLink .ID}}">{{.Name}}</a></h2>
+{{if .NewServices}}<h3>New services</h3>
+<ul>
+{{range .NewServices}}<li><strong>{{.Title}}</strong> added by {{.Actor}}</li>
+{{end}}</ul>
+{{end}}{{if .RemovedDependencies}}<h3>Removed dependencies</h3>
+<ul>
+{{range .RemovedDependencies}}<li><strong>{{.Title}}</strong> removed by {{.Actor}}</li>
+{{end}}</ul>
+{{end}}{{if .NewIssues}}<h3>New issues</h3>
+<ul>
+{{range .NewIssues}}<li>{{if .Service}}<strong>{{.Service}}</strong>: {{end}}{{.Excerpt}} <em>{{.Author}}{{if .Priority}}, {{.Priority}}{{end}}</em></li>
+{{end}}</ul>
+{{end}}{{end}}{{if .Mentions}}<h2>Mentions</h2>
+<ul>
+{{range .Mentions}}<li>{{.Author}} in <a href="{{$.ProjectLink .ProjectID}}">{{.Project}}</a>: {{.Excerpt}}</li>
+{{end}}</ul>
+{{end}}<p>You receive this {{.Frequency}} digest because of your notification settings in Sami.</p>
+`))
@@ -0,0 +1,80 @@
+package digest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"sami/models"
+	"sami/pkg/config"
+	"sami/pkg/mailer"
+)
+
+func TestRender(t *testing.T) {
+	outbox := mailer.NewMemoryOutbox()
+	sender, err := NewSender(nil, outbox, "https://sami.example.com/", config.DigestConfig{
+		Hour: 8, Weekday: "monday", DefaultFrequency: "weekly", CheckInterval: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := &models.Digest{
+		User:      models.User{Name: "Jane Doe", Email: "jane@example.com"},
+		Frequency: models.DigestWeekly,
+		Since:     time.Date(2024, 5, 6, 8, 0, 0, 0, time.UTC),
+		Until:     time.Date(2024, 5, 13, 8, 0, 0, 0, time.UTC),
+		Projects: []models.DigestProject{{
+			ID:                  3,
+			Name:                "Payments",
+			NewServices:         []models.DigestChange{{Title: "ledger", Actor: "Bob"}},
+			RemovedDependencies: []models.DigestChange{{Title: "api → <legacy>", Actor: "Bob"}},
+			NewIssues:           []models.DigestIssue{{Service: "ledger", Excerpt: "Timeouts", Priority: models.IssuePriority("high"), Author: "Ann"}},
+		}},
+		Mentions: []models.DigestMention{{ProjectID: 4, Project: "Search", Excerpt: "@jane can you look?", Author: "Ann"}},
+	}
+
+	msg, err := sender.Render(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := outbox.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+
+	sent, ok := outbox.Last()
+	if !ok {
+		t.Fatal("expected a message in the outbox")
+	}
+	if sent.To[0] != "jane@example.com" || sent.Subject != "Your weekly Sami digest" {
+		t.Errorf("unexpected message %q to %v", sent.Subject, sent.To)
+	}
+	for _, want := range []string{"May 6, 2024", "== Payments ==", "https://sami.example.com/dashboard/projects/3",
+		"ledger (added by Bob)", "api → <legacy>", "[ledger] Timeouts (Ann, high)", "Ann in Search", "/dashboard/projects/4"} {
+		if !strings.Contains(sent.Text, want) {
+			t.Errorf("text body misses %q:\n%s", want, sent.Text)
+		}
+	}
+	for _, want := range []string{`<a href="https://sami.example.com/dashboard/projects/3">Payments</a>`, "api → &lt;legacy&gt;", "<h2>Mentions</h2>"} {
+		if !strings.Contains(sent.HTML, want) {
+			t.Errorf("HTML body misses %q:\n%s", want, sent.HTML)
+		}
+	}
+}
+
+func TestNewSenderValidatesConfig(t *testing.T) {
+	valid := config.DigestConfig{Hour: 8, Weekday: "monday", DefaultFrequency: "daily", CheckInterval: time.Minute}
+	for _, mutate := range []func(*config.DigestConfig){
+		func(c *config.DigestConfig) { c.Hour = 24 },
+		func(c *config.DigestConfig) { c.Weekday = "someday" },
+		func(c *config.DigestConfig) { c.DefaultFrequency = "hourly" },
+		func(c *config.DigestConfig) { c.CheckInterval = 0 },
+	} {
+		cfg := valid
+		mutate(&cfg)
+		if _, err := NewSender(nil, mailer.NewMemoryOutbox(), "", cfg); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+}
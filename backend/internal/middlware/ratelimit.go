@@ -0,0 +1,130 @@
+package middlware
+
+import (
+	"log"
+	"net/http"
+
+	"sami/pkg/config"
+	"sami/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimiter limits requests by the first rule matching the route. Rules
+// sharing a name share a counter.
+type RateLimiter struct {
+	Store ratelimit.Store
+	// Scope separates the counters of limiters using the same store
+	Scope string
+	Rules []ratelimit.Rule
+	// Key identifies the client; requests with an empty key are not limited
+	Key func(c *gin.Context) string
+}
+
+// ClientIP keys requests by client address
+func ClientIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// UserID keys requests by the authenticated user
+func UserID(c *gin.Context) string {
+	return c.GetString("user_id")
+}
+
+// Handler returns the limiter as a middleware
+func (l *RateLimiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !l.Allow(c) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// Allow counts the request and reports whether it may proceed. Otherwise it
+// answers 429 and aborts. Store errors let requests through rather than
+// taking the API down with the store.
+func (l *RateLimiter) Allow(c *gin.Context) bool {
+	path := c.FullPath()
+	if path == "" {
+		path = c.Request.URL.Path
+	}
+
+	rule, ok := ratelimit.Match(l.Rules, path)
+	if !ok || !rule.Limit.Enabled() {
+		return true
+	}
+
+	key := l.Key(c)
+	if key == "" {
+		return true
+	}
+
+	result, err := ratelimit.Allow(c.Request.Context(), l.Store, l.Scope+":"+rule.Name+":"+key, rule.Limit)
+	if err != nil {
+		log.Printf("rate limit: %v", err)
+		return true
+	}
+
+	ratelimit.SetHeaders(c.Writer.Header(), result)
+	if !result.Allowed {
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many requests",
+			"retry_after": ratelimit.Seconds(result.Reset),
+		})
+		return false
+	}
+
+	return true
+}
+
+// IPRateLimiter limits clients by address: credential endpoints share a
+// strict limit, every other route a generous one
+func IPRateLimiter(cfg config.RateLimitConfig, store ratelimit.Store) (*RateLimiter, error) {
+	auth, err := ratelimit.ParseLimit(cfg.AuthPerIP)
+	if err != nil {
+		return nil, err
+	}
+	api, err := ratelimit.ParseLimit(cfg.APIPerIP)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []ratelimit.Rule
+	for _, prefix := range []string{
+		"/auth/login",
+		"/auth/register",
+		"/auth/password/",
+		"/auth/email/",
+		"/auth/oidc/",
+		"/invitations/accept",
+	} {
+		rules = append(rules, ratelimit.Rule{Name: "auth", Prefix: prefix, Limit: auth})
+	}
+	rules = append(rules, ratelimit.Rule{Name: "api", Prefix: "/", Limit: api})
+
+	return &RateLimiter{Store: store, Scope: "ip", Rules: rules, Key: ClientIP}, nil
+}
+
+// UserRateLimiter limits authenticated users, with a separate limit for
+// bulk saves since each rewrites a whole project
+func UserRateLimiter(cfg config.RateLimitConfig, store ratelimit.Store) (*RateLimiter, error) {
+	bulkSave, err := ratelimit.ParseLimit(cfg.BulkSavePerUser)
+	if err != nil {
+		return nil, err
+	}
+	api, err := ratelimit.ParseLimit(cfg.APIPerUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimiter{
+		Store: store,
+		Scope: "user",
+		Rules: []ratelimit.Rule{
+			{Name: "bulk-save", Prefix: "/projects/:id/bulk-save", Limit: bulkSave},
+			{Name: "api", Prefix: "/", Limit: api},
+		},
+		Key: UserID,
+	}, nil
+}
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"sami/controller"
+	"sami/internal/attachments"
+	"sami/internal/digest"
 	"sami/internal/middlware"
 	"sami/models"
 	"sami/pkg/config"
 	"sami/pkg/database"
+	"sami/pkg/mailer"
+	"sami/pkg/oidc"
+	"sami/pkg/password"
+	"sami/pkg/ratelimit"
+	"sami/pkg/storage"
 	"sami/routes"
 
 	"github.com/gin-gonic/gin"
@@ -38,19 +46,97 @@ func main() {
 		log.Fatalf("Error migrating database: %v", err)
 	}
 
+	// Configure mailer
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		log.Fatalf("Error configuring mailer: %v", err)
+	}
+
+	// Configure password policy
+	passwords, err := password.New(cfg.Password)
+	if err != nil {
+		log.Fatalf("Error configuring password policy: %v", err)
+	}
+
+	// Configure rate limiting
+	var ipLimiter, userLimiter *middlware.RateLimiter
+	var lockout *ratelimit.Lockout
+	if cfg.RateLimit.Enabled {
+		limitStore, err := ratelimit.NewStore(cfg.RateLimit, cfg.Redis)
+		if err != nil {
+			log.Fatalf("Error configuring rate limiting: %v", err)
+		}
+		if ipLimiter, err = middlware.IPRateLimiter(cfg.RateLimit, limitStore); err != nil {
+			log.Fatalf("Error configuring rate limiting: %v", err)
+		}
+		if userLimiter, err = middlware.UserRateLimiter(cfg.RateLimit, limitStore); err != nil {
+			log.Fatalf("Error configuring rate limiting: %v", err)
+		}
+		lockout = ratelimit.NewLockout(cfg.RateLimit, limitStore)
+	}
+
+	// Send activity digests in the background
+	if cfg.Digest.Enabled {
+		digests, err := digest.NewSender(db, mail, cfg.App.BaseURL, cfg.Digest)
+		if err != nil {
+			log.Fatalf("Error configuring digests: %v", err)
+		}
+		go digests.Run(context.Background())
+	}
+
+	// Store attachments and remove the files of deleted parents
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatalf("Error configuring storage: %v", err)
+	}
+	if cfg.Attachments.CleanupInterval > 0 {
+		cleaner := &attachments.Cleaner{DB: db, Storage: store, Interval: cfg.Attachments.CleanupInterval}
+		go cleaner.Run(context.Background())
+	}
+
 	// Configure Gin
 	r := gin.Default()
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxyList()); err != nil {
+		log.Fatalf("Error configuring trusted proxies: %v", err)
+	}
 
 	// CORS Middleware
 	r.Use(middlware.Cors(cfg.Server))
 
+	// Per-IP rate limits, before authentication
+	if ipLimiter != nil {
+		r.Use(ipLimiter.Handler())
+	}
+
 	// Pass DB instance to controllers
-	authController := &controller.AuthController{DB: db}
-	projectController := &controller.ProjectController{DB: db}
+	invitationController := &controller.InvitationController{DB: db, Mailer: mail, BaseURL: cfg.App.BaseURL, TTL: cfg.App.InvitationTTL, Passwords: passwords}
+	authController := &controller.AuthController{DB: db, Mailer: mail, BaseURL: cfg.App.BaseURL, Settings: cfg.Auth, OIDCSettings: cfg.OIDC,
+		Passwords: passwords, Lockout: lockout, RateLimit: userLimiter}
+	if cfg.OIDC.Enabled {
+		authController.OIDC = oidc.NewClient(oidc.Config{
+			IssuerURL:    cfg.OIDC.IssuerURL,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.ScopeList(),
+			CacheTTL:     cfg.OIDC.CacheTTL,
+		})
+	}
+	projectController := &controller.ProjectController{DB: db, Invitations: invitationController}
 	serviceController := &controller.ServiceController{DB: db}
 	dependencyController := &controller.DependencyController{DB: db}
 	commentController := &controller.CommentController{DB: db}
-	adminController := &controller.AdminController{DB: db}
+	adminController := &controller.AdminController{DB: db, Invitations: invitationController, Settings: cfg.Auth}
+	environmentController := &controller.EnvironmentController{DB: db}
+	searchController := &controller.SearchController{DB: db}
+	tagController := &controller.TagController{DB: db}
+	organizationController := &controller.OrganizationController{DB: db}
+	teamController := &controller.TeamController{DB: db}
+	notificationController := &controller.NotificationController{DB: db, Settings: cfg.Digest}
+	scimController := &controller.SCIMController{DB: db, Settings: cfg.SCIM}
+	attachmentController := &controller.AttachmentController{DB: db, Storage: store, Settings: cfg.Attachments}
+	moderationController := &controller.ModerationController{DB: db}
+	publicController := &controller.PublicController{DB: db, Settings: cfg.Public}
 
 	// Setup routes
 	routes.SetupAuthRoutes(r, authController)
@@ -59,6 +145,16 @@ func main() {
 	routes.SetupDependencyRoutes(r, dependencyController, authController)
 	routes.SetupCommentRoutes(r, commentController, authController)
 	routes.SetupAdminRoutes(r, adminController, authController)
+	routes.SetupEnvironmentRoutes(r, environmentController, authController)
+	routes.SetupSearchRoutes(r, searchController, authController)
+	routes.SetupTagRoutes(r, tagController, authController)
+	routes.SetupOrganizationRoutes(r, organizationController, teamController, authController)
+	routes.SetupInvitationRoutes(r, invitationController, authController)
+	routes.SetupNotificationRoutes(r, notificationController, authController)
+	routes.SetupSCIMRoutes(r, scimController, authController)
+	routes.SetupAttachmentRoutes(r, attachmentController, authController)
+	routes.SetupModerationRoutes(r, moderationController, authController)
+	routes.SetupPublicRoutes(r, publicController, authController)
 
 	// Start server
 	if err = r.Run(fmt.Sprintf(":%d", cfg.Server.Port)); err != nil {
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// ProjectRole represents the role of a user on a project
+type ProjectRole string
+
+const (
+	ProjectOwnerRole  ProjectRole = "owner"
+	ProjectEditorRole ProjectRole = "editor"
+	ProjectViewerRole ProjectRole = "viewer"
+)
+
+var projectRoleRanks = map[ProjectRole]int{
+	ProjectViewerRole: 1,
+	ProjectEditorRole: 2,
+	ProjectOwnerRole:  3,
+}
+
+func (r ProjectRole) IsValid() bool {
+	return projectRoleRanks[r] > 0
+}
+
+// AtLeast reports whether r grants at least the permissions of other
+func (r ProjectRole) AtLeast(other ProjectRole) bool {
+	return projectRoleRanks[r] >= projectRoleRanks[other]
+}
+
+// MaxProjectRole returns the most permissive of the given roles
+func MaxProjectRole(roles ...ProjectRole) ProjectRole {
+	var max ProjectRole
+	for _, role := range roles {
+		if projectRoleRanks[role] > projectRoleRanks[max] {
+			max = role
+		}
+	}
+	return max
+}
+
+// accessibleProjectsCondition matches the projects a user (@user) can see:
+// owned, public unless they are banned from it, shared directly, shared with
+// one of their teams, or belonging to an organization they administer
+const accessibleProjectsCondition = `owner_id = @user OR (visibility = 'public' AND id NOT IN (` + bannedProjectsSQL + `))
+	OR id IN (SELECT project_id FROM project_collaborators WHERE user_id = @user AND state = 'active')
+	OR id IN (SELECT pt.project_id FROM project_teams pt JOIN team_members tm ON tm.team_id = pt.team_id WHERE tm.user_id = @user)
+	OR organization_id IN (SELECT organization_id FROM organization_members WHERE user_id = @user AND role = 'admin')`
+
+// AccessibleProjectsSQL selects the IDs of the projects a user can see. It
+// takes the user ID as the named parameter @user.
+const AccessibleProjectsSQL = `SELECT id FROM projects WHERE ` + accessibleProjectsCondition
+
+// AccessibleProjects restricts a projects query to the projects a user can see
+func AccessibleProjects(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Where("projects.id IN ("+AccessibleProjectsSQL+")", map[string]interface{}{"user": userID})
+}
+
+// ProjectAccess represents the effective permissions of a user on a project
+type ProjectAccess struct {
+	// Role combines ownership, direct collaborator and team grants, and
+	// organization administration
+	Role ProjectRole `json:"role"`
+	// IsOwner is set for the primary owner (Project.OwnerID) only
+	IsOwner    bool `json:"is_owner"`
+	IsOrgAdmin bool `json:"is_org_admin"`
+	IsAdmin    bool `json:"is_admin"`
+}
+
+// CanView reports whether the user can read the project
+func (a ProjectAccess) CanView() bool {
+	return a.Role != ""
+}
+
+// CanEdit reports whether the user can change services, dependencies and
+// environments of the project
+func (a ProjectAccess) CanEdit() bool {
+	return a.Role.AtLeast(ProjectEditorRole)
+}
+
+// CanManage reports whether the user can change the project settings and
+// who has access to it. Every owner has these powers, whether primary owner,
+// collaborator or team owner, or organization admin.
+func (a ProjectAccess) CanManage() bool {
+	return a.Role == ProjectOwnerRole || a.IsAdmin
+}
+
+// CanTransfer reports whether the user can hand the primary ownership of the
+// project to someone else
+func (a ProjectAccess) CanTransfer() bool {
+	return a.IsOwner || a.IsAdmin
+}
+
+// GetProjectAccess resolves the effective access of a user to a project.
+// Lookup errors are treated as no grant.
+func GetProjectAccess(db *gorm.DB, project *Project, user *User) ProjectAccess {
+	access := ProjectAccess{
+		IsOwner: project.OwnerID == user.ID,
+		IsAdmin: user.Role == AdminRole,
+	}
+
+	if project.OrganizationID != nil {
+		access.IsOrgAdmin = GetOrganizationRole(db, *project.OrganizationID, user.ID) == OrgAdminRole
+	}
+
+	if access.IsOwner || access.IsOrgAdmin {
+		access.Role = ProjectOwnerRole
+		return access
+	}
+
+	// Public projects are readable by everyone but the users banned from them
+	var roles []ProjectRole
+	if project.Visibility == "public" {
+		var banned int64
+		db.Model(&ProjectRestriction{}).Where("project_id = ? AND user_id = ? AND kind = ?", project.ID, user.ID, RestrictionBan).
+			Where(activeRestrictionCondition).Count(&banned)
+		if banned == 0 {
+			roles = append(roles, ProjectViewerRole)
+		}
+	}
+
+	// Direct collaborator grant
+	var collaborator ProjectCollaborator
+	if err := db.Where("project_id = ? AND user_id = ? AND state = ?",
+		project.ID, user.ID, "active").First(&collaborator).Error; err == nil {
+		roles = append(roles, ProjectRole(collaborator.Role))
+	}
+
+	// Grants through the user's teams
+	var teamRoles []ProjectRole
+	db.Model(&ProjectTeam{}).
+		Joins("JOIN team_members tm ON tm.team_id = project_teams.team_id").
+		Where("project_teams.project_id = ? AND tm.user_id = ?", project.ID, user.ID).
+		Pluck("project_teams.role", &teamRoles)
+	roles = append(roles, teamRoles...)
+
+	access.Role = MaxProjectRole(roles...)
+	return access
+}
@@ -0,0 +1,57 @@
+package models
+
+import "testing"
+
+func TestMaxProjectRole(t *testing.T) {
+	tests := []struct {
+		roles    []ProjectRole
+		expected ProjectRole
+	}{
+		{nil, ""},
+		{[]ProjectRole{ProjectViewerRole}, ProjectViewerRole},
+		{[]ProjectRole{ProjectViewerRole, ProjectEditorRole}, ProjectEditorRole},
+		{[]ProjectRole{ProjectOwnerRole, ProjectEditorRole, ProjectViewerRole}, ProjectOwnerRole},
+		{[]ProjectRole{"unknown", ProjectViewerRole}, ProjectViewerRole},
+	}
+
+	for _, tt := range tests {
+		if got := MaxProjectRole(tt.roles...); got != tt.expected {
+			t.Errorf("MaxProjectRole(%v) = %q, expected %q", tt.roles, got, tt.expected)
+		}
+	}
+}
+
+func TestProjectAccess(t *testing.T) {
+	viewer := ProjectAccess{Role: ProjectViewerRole}
+	if !viewer.CanView() || viewer.CanEdit() || viewer.CanManage() {
+		t.Errorf("unexpected permissions for viewer: %+v", viewer)
+	}
+
+	editor := ProjectAccess{Role: ProjectEditorRole}
+	if !editor.CanView() || !editor.CanEdit() || editor.CanManage() {
+		t.Errorf("unexpected permissions for editor: %+v", editor)
+	}
+
+	orgAdmin := ProjectAccess{Role: ProjectOwnerRole, IsOrgAdmin: true}
+	if !orgAdmin.CanEdit() || !orgAdmin.CanManage() {
+		t.Errorf("unexpected permissions for org admin: %+v", orgAdmin)
+	}
+
+	// Collaborator owners have the same powers as the primary owner, except
+	// handing over the primary ownership
+	coOwner := ProjectAccess{Role: ProjectOwnerRole}
+	if !coOwner.CanEdit() || !coOwner.CanManage() || coOwner.CanTransfer() {
+		t.Errorf("unexpected permissions for co-owner: %+v", coOwner)
+	}
+
+	owner := ProjectAccess{Role: ProjectOwnerRole, IsOwner: true}
+	if !owner.CanManage() || !owner.CanTransfer() {
+		t.Errorf("unexpected permissions for owner: %+v", owner)
+	}
+
+	// The global admin role manages projects but does not grant read access by itself
+	admin := ProjectAccess{IsAdmin: true}
+	if admin.CanView() || !admin.CanManage() {
+		t.Errorf("unexpected permissions for admin: %+v", admin)
+	}
+}
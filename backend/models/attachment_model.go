@@ -0,0 +1,192 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Attachment represents a file attached to a comment or a service
+type Attachment struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ProjectID   *uint     `json:"project_id"`
+	CommentID   *uint     `json:"comment_id,omitempty"`
+	ServiceID   *uint     `json:"service_id,omitempty"`
+	UploadedBy  *uint     `json:"uploaded_by"`
+	Uploader    *User     `json:"-" gorm:"foreignKey:UploadedBy"`
+	FileName    string    `json:"file_name" gorm:"not null;size:255"`
+	ContentType string    `json:"content_type" gorm:"not null;size:100"`
+	Size        int64     `json:"size" gorm:"not null"`
+	Checksum    string    `json:"checksum" gorm:"not null;size:64"` // SHA-256, hex
+	StorageKey  string    `json:"-" gorm:"not null;unique;size:255"`
+	CreatedAt   time.Time `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// AttachmentResponse represents attachment response
+type AttachmentResponse struct {
+	ID          uint          `json:"id"`
+	ProjectID   uint          `json:"project_id"`
+	CommentID   *uint         `json:"comment_id,omitempty"`
+	ServiceID   *uint         `json:"service_id,omitempty"`
+	FileName    string        `json:"file_name"`
+	ContentType string        `json:"content_type"`
+	Size        int64         `json:"size"`
+	Checksum    string        `json:"checksum"`
+	Uploader    *UserResponse `json:"uploader,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	// DownloadURL is a link for the requesting user that works without the
+	// Authorization header, until it expires
+	DownloadURL string `json:"download_url"`
+}
+
+// AttachmentUsage reports the space used by the attachments of a project
+type AttachmentUsage struct {
+	ProjectID uint  `json:"project_id"`
+	Count     int64 `json:"count"`
+	Used      int64 `json:"used"`
+	Quota     int64 `json:"quota"`
+}
+
+// TableName specifies the table name for Attachment
+func (Attachment) TableName() string {
+	return "attachments"
+}
+
+// BeforeCreate runs before creating an attachment
+func (a *Attachment) BeforeCreate(tx *gorm.DB) error {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+// ToResponse converts attachment to AttachmentResponse
+func (a *Attachment) ToResponse(downloadURL string) AttachmentResponse {
+	response := AttachmentResponse{
+		ID:          a.ID,
+		CommentID:   a.CommentID,
+		ServiceID:   a.ServiceID,
+		FileName:    a.FileName,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Checksum:    a.Checksum,
+		CreatedAt:   a.CreatedAt,
+		DownloadURL: downloadURL,
+	}
+	if a.ProjectID != nil {
+		response.ProjectID = *a.ProjectID
+	}
+
+	// Include uploader if loaded
+	if a.Uploader != nil && a.Uploader.ID != 0 {
+		uploader := a.Uploader.ToResponse()
+		response.Uploader = &uploader
+	}
+
+	return response
+}
+
+// NewAttachmentKey returns a random storage key in the folder of a project.
+// The file name is not part of the key so it never reaches the storage.
+func NewAttachmentKey(projectID uint) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("projects/%d/%s", projectID, hex.EncodeToString(b)), nil
+}
+
+// CleanFileName keeps the base name of an uploaded file without control
+// characters or quotes, at most 255 bytes long
+func CleanFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' || r == '/' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." {
+		name = "file"
+	}
+	for len(name) > 255 {
+		runes := []rune(name)
+		name = string(runes[:len(runes)-1])
+	}
+	return name
+}
+
+// genericTypes are detected for content that may be more specific types
+var genericTypes = map[string]bool{
+	"text/plain":               true,
+	"application/octet-stream": true,
+}
+
+// AttachmentContentType decides the type of an upload from its detected
+// type. Content detected as plain text or unknown binary takes the type of
+// its extension, so runbooks in Markdown are text/markdown. It returns false
+// when the type is not allowed.
+func AttachmentContentType(detected, fileName string, allowed []string) (string, bool) {
+	contentType := baseMediaType(detected)
+	if genericTypes[contentType] {
+		if byExtension := baseMediaType(mime.TypeByExtension(strings.ToLower(filepath.Ext(fileName)))); byExtension != "" {
+			// Text must stay text: a binary cannot claim to be Markdown
+			if contentType != "text/plain" || strings.HasPrefix(byExtension, "text/") || byExtension == "application/json" {
+				contentType = byExtension
+			}
+		}
+		if contentType == "text/plain" && strings.EqualFold(filepath.Ext(fileName), ".md") {
+			contentType = "text/markdown"
+		}
+	}
+
+	for _, t := range allowed {
+		if strings.EqualFold(t, contentType) {
+			return contentType, true
+		}
+	}
+	return contentType, false
+}
+
+func baseMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(mediaType)
+}
+
+// ProjectAttachmentUsage returns the number and total size of the
+// attachments of a project
+func ProjectAttachmentUsage(db *gorm.DB, projectID uint) (int64, int64, error) {
+	var usage struct {
+		Count int64
+		Used  int64
+	}
+	err := db.Model(&Attachment{}).Select("COUNT(*) AS count, COALESCE(SUM(size), 0) AS used").
+		Where("project_id = ?", projectID).Scan(&usage).Error
+	return usage.Count, usage.Used, err
+}
+
+// OrphanedAttachmentsSQL selects attachments whose parent is gone: the
+// project, comment or service was deleted, or the comment was marked deleted.
+// Their rows outlive the parent (the foreign keys are set to NULL) so the
+// stored files can be removed.
+const OrphanedAttachmentsSQL = `project_id IS NULL
+	OR (comment_id IS NULL AND service_id IS NULL)
+	OR comment_id IN (SELECT id FROM comments WHERE status = 'deleted')`
+
+// FindOrphanedAttachments returns up to limit attachments to clean up
+func FindOrphanedAttachments(db *gorm.DB, limit int) ([]Attachment, error) {
+	var attachments []Attachment
+	err := db.Where(OrphanedAttachmentsSQL).Order("id ASC").Limit(limit).Find(&attachments).Error
+	return attachments, err
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttachmentContentType(t *testing.T) {
+	allowed := []string{"image/png", "application/pdf", "text/plain", "text/markdown", "application/json"}
+	tests := []struct {
+		detected string
+		fileName string
+		want     string
+		ok       bool
+	}{
+		{"image/png", "diagram.png", "image/png", true},
+		{"image/png", "diagram.pdf", "image/png", true},
+		{"application/pdf", "spec.pdf", "application/pdf", true},
+		{"text/plain; charset=utf-8", "notes.txt", "text/plain", true},
+		{"text/plain; charset=utf-8", "runbook.md", "text/markdown", true},
+		{"text/plain; charset=utf-8", "config.json", "application/json", true},
+		{"text/html; charset=utf-8", "page.html", "text/html", false},
+		{"text/plain; charset=utf-8", "page.html", "text/html", false},
+		{"application/octet-stream", "tool.exe", "application/octet-stream", false},
+		{"application/octet-stream", "image.png", "image/png", true},
+	}
+	for _, test := range tests {
+		got, ok := AttachmentContentType(test.detected, test.fileName, allowed)
+		if got != test.want || ok != test.ok {
+			t.Errorf("%s %s: got %s %v, want %s %v", test.detected, test.fileName, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestCleanFileName(t *testing.T) {
+	tests := map[string]string{
+		"runbook.md":               "runbook.md",
+		"../../etc/passwd":         "passwd",
+		`C:\Users\ops\diagram.png`: "diagram.png",
+		"say \"hi\".txt":           "say hi.txt",
+		"line\nbreak.txt":          "linebreak.txt",
+		"  ":                       "file",
+		"dir/":                     "dir",
+	}
+	for name, want := range tests {
+		if got := CleanFileName(name); got != want {
+			t.Errorf("CleanFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	if got := CleanFileName(strings.Repeat("é", 200)); len(got) > 255 {
+		t.Errorf("name of %d bytes was kept", len(got))
+	}
+}
@@ -56,13 +56,15 @@ type User struct {
 	UpdatedAt    time.Time  `json:"updated_at" gorm:"not null;default:now()"`
 	LastLogin    *time.Time `json:"last_login"`
 	DeletedAt    *time.Time `json:"deleted_at"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
 }
 
 // RegisterRequest represents registration data
 type RegisterRequest struct {
 	Name     string `json:"name" binding:"required,min=2,max=100"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
 // LoginRequest represents login data
@@ -83,6 +85,8 @@ type UserResponse struct {
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 	LastLogin *time.Time `json:"last_login"`
+
+	EmailVerified bool `json:"email_verified"`
 }
 
 // LoginResponse represents login response
@@ -101,7 +105,7 @@ type UpdateProfileRequest struct {
 // ChangePasswordRequest represents password change data
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" binding:"required"`
-	NewPassword     string `json:"new_password" binding:"required,min=6"`
+	NewPassword     string `json:"new_password" binding:"required"`
 }
 
 // SetPassword sets the hashed password
@@ -133,9 +137,16 @@ func (u *User) ToResponse() UserResponse {
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
 		LastLogin: u.LastLogin,
+
+		EmailVerified: u.IsEmailVerified(),
 	}
 }
 
+// IsEmailVerified reports whether the user confirmed their email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
 // TableName specifies the table name in the database
 func (User) TableName() string {
 	return "users"
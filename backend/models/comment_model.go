@@ -1,27 +1,64 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+
+	"sami/pkg/markdown"
 )
 
 // Comment represents the comment structure in the database
 type Comment struct {
-	ID        uint       `json:"id" gorm:"primaryKey"`
-	ProjectID uint       `json:"project_id" gorm:"not null"`
-	Project   Project    `json:"-" gorm:"foreignKey:ProjectID"`
-	ServiceID *uint      `json:"service_id,omitempty" gorm:"nullable"`
-	Service   *Service   `json:"-" gorm:"foreignKey:ServiceID"`
-	UserID    uint       `json:"user_id" gorm:"not null"`
-	User      User       `json:"-" gorm:"foreignKey:UserID"`
-	ParentID  *uint      `json:"parent_id,omitempty" gorm:"nullable"`
-	Parent    *Comment   `json:"-" gorm:"foreignKey:ParentID"`
-	Content   string     `json:"content" gorm:"type:text;not null"`
-	Type      string     `json:"type" gorm:"default:general;size:20"`
-	Status    string     `json:"status" gorm:"default:active;size:20"`
-	CreatedAt time.Time  `json:"created_at" gorm:"not null;default:now()"`
-	EditedAt  *time.Time `json:"edited_at,omitempty" gorm:"nullable"`
+	ID        uint     `json:"id" gorm:"primaryKey"`
+	ProjectID uint     `json:"project_id" gorm:"not null"`
+	Project   Project  `json:"-" gorm:"foreignKey:ProjectID"`
+	ServiceID *uint    `json:"service_id,omitempty" gorm:"nullable"`
+	Service   *Service `json:"-" gorm:"foreignKey:ServiceID"`
+
+	// Dependency the comment is about, instead of a service
+	DependencyID *uint       `json:"dependency_id,omitempty"`
+	Dependency   *Dependency `json:"-" gorm:"foreignKey:DependencyID"`
+
+	UserID   uint     `json:"user_id" gorm:"not null"`
+	User     User     `json:"-" gorm:"foreignKey:UserID"`
+	ParentID *uint    `json:"parent_id,omitempty" gorm:"nullable"`
+	Parent   *Comment `json:"-" gorm:"foreignKey:ParentID"`
+	Content  string   `json:"content" gorm:"type:text;not null"`
+	// ContentHTML is the rendered and sanitized Markdown of Content
+	ContentHTML string     `json:"content_html" gorm:"type:text"`
+	Type        string     `json:"type" gorm:"default:general;size:20"`
+	Status      string     `json:"status" gorm:"default:active;size:20"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	EditedAt    *time.Time `json:"edited_at,omitempty" gorm:"nullable"`
+
+	// Placement in the thread, see ThreadPath
+	ThreadID *uint  `json:"thread_id,omitempty"`
+	Depth    int    `json:"depth" gorm:"not null;default:0"`
+	Path     string `json:"-" gorm:"type:text"`
+
+	// Thread state, set on root comments only
+	LockedAt         *time.Time `json:"locked_at,omitempty"`
+	LockedBy         *uint      `json:"locked_by,omitempty"`
+	ThreadResolvedAt *time.Time `json:"thread_resolved_at,omitempty"`
+	ThreadResolvedBy *uint      `json:"thread_resolved_by,omitempty"`
+
+	// Issue workflow, set on issue comments only
+	IssueState *IssueState    `json:"issue_state,omitempty" gorm:"size:20"`
+	AssigneeID *uint          `json:"assignee_id,omitempty"`
+	Assignee   *User          `json:"-" gorm:"foreignKey:AssigneeID"`
+	Priority   *IssuePriority `json:"priority,omitempty" gorm:"size:20"`
+	DueDate    *time.Time     `json:"due_date,omitempty" gorm:"type:date"`
+	ResolvedAt *time.Time     `json:"resolved_at,omitempty"`
+
+	// Users mentioned in the content
+	Mentions []User `json:"-" gorm:"many2many:comment_mentions"`
+
+	// Emoji reactions of users
+	Reactions []CommentReaction `json:"-" gorm:"foreignKey:CommentID"`
 
 	// Replies relationship for nested comments
 	Replies []Comment `json:"-" gorm:"foreignKey:ParentID"`
@@ -29,10 +66,16 @@ type Comment struct {
 
 // CreateCommentRequest represents comment creation data
 type CreateCommentRequest struct {
-	ServiceID *uint  `json:"service_id,omitempty"`
-	ParentID  *uint  `json:"parent_id,omitempty"`
-	Content   string `json:"content" binding:"required,min=1,max=5000"`
-	Type      string `json:"type" binding:"omitempty,oneof=general issue improvement"`
+	ServiceID    *uint  `json:"service_id,omitempty"`
+	DependencyID *uint  `json:"dependency_id,omitempty"`
+	ParentID     *uint  `json:"parent_id,omitempty"`
+	Content      string `json:"content" binding:"required,min=1,max=5000"`
+	Type         string `json:"type" binding:"omitempty,oneof=general issue improvement"`
+
+	// Issue workflow, for issue comments only
+	AssigneeID *uint         `json:"assignee_id,omitempty"`
+	Priority   IssuePriority `json:"priority" binding:"omitempty,oneof=low medium high critical"`
+	DueDate    string        `json:"due_date"`
 }
 
 // UpdateCommentRequest represents comment update data
@@ -42,34 +85,55 @@ type UpdateCommentRequest struct {
 
 // CommentResponse represents comment response
 type CommentResponse struct {
-	ID        uint              `json:"id"`
-	ProjectID uint              `json:"project_id"`
-	ServiceID *uint             `json:"service_id,omitempty"`
-	Service   *ServiceResponse  `json:"service,omitempty"`
-	UserID    uint              `json:"user_id"`
-	User      UserResponse      `json:"user"`
-	ParentID  *uint             `json:"parent_id,omitempty"`
-	Content   string            `json:"content"`
-	Type      string            `json:"type"`
-	Status    string            `json:"status"`
-	CreatedAt time.Time         `json:"created_at"`
-	EditedAt  *time.Time        `json:"edited_at,omitempty"`
-	Replies   []CommentResponse `json:"replies,omitempty"`
+	ID           uint                `json:"id"`
+	ProjectID    uint                `json:"project_id"`
+	ServiceID    *uint               `json:"service_id,omitempty"`
+	Service      *ServiceResponse    `json:"service,omitempty"`
+	DependencyID *uint               `json:"dependency_id,omitempty"`
+	Dependency   *DependencyResponse `json:"dependency,omitempty"`
+	UserID       uint                `json:"user_id"`
+	User         UserResponse        `json:"user"`
+	ParentID     *uint               `json:"parent_id,omitempty"`
+	ThreadID     *uint               `json:"thread_id,omitempty"`
+	Depth        int                 `json:"depth"`
+	Thread       *ThreadResponse     `json:"thread,omitempty"`
+	Content      string              `json:"content"`
+	ContentHTML  string              `json:"content_html"`
+	Type         string              `json:"type"`
+	Status       string              `json:"status"`
+	CreatedAt    time.Time           `json:"created_at"`
+	EditedAt     *time.Time          `json:"edited_at,omitempty"`
+	Issue        *IssueResponse      `json:"issue,omitempty"`
+	Mentions     []UserResponse      `json:"mentions,omitempty"`
+	Reactions    []ReactionCount     `json:"reactions,omitempty"`
+	Replies      []CommentResponse   `json:"replies,omitempty"`
 }
 
 // ToResponse converts comment to CommentResponse
 func (c *Comment) ToResponse() CommentResponse {
 	response := CommentResponse{
-		ID:        c.ID,
-		ProjectID: c.ProjectID,
-		ServiceID: c.ServiceID,
-		UserID:    c.UserID,
-		ParentID:  c.ParentID,
-		Content:   c.Content,
-		Type:      c.Type,
-		Status:    c.Status,
-		CreatedAt: c.CreatedAt,
-		EditedAt:  c.EditedAt,
+		ID:           c.ID,
+		ProjectID:    c.ProjectID,
+		ServiceID:    c.ServiceID,
+		DependencyID: c.DependencyID,
+		UserID:       c.UserID,
+		ParentID:     c.ParentID,
+		ThreadID:     c.ThreadID,
+		Depth:        c.Depth,
+		Thread:       c.ThreadResponse(),
+		Content:      c.Content,
+		ContentHTML:  c.RenderedContent(),
+		Type:         c.Type,
+		Status:       c.Status,
+		CreatedAt:    c.CreatedAt,
+		EditedAt:     c.EditedAt,
+		Issue:        c.IssueResponse(),
+	}
+
+	// Deleted comments kept for their replies show no content
+	if c.Status == "deleted" {
+		response.Content = ""
+		response.ContentHTML = ""
 	}
 
 	// Include user if loaded
@@ -77,12 +141,26 @@ func (c *Comment) ToResponse() CommentResponse {
 		response.User = c.User.ToResponse()
 	}
 
+	// Include mentioned users if loaded
+	for _, user := range c.Mentions {
+		response.Mentions = append(response.Mentions, user.ToResponse())
+	}
+
+	// Count reactions if loaded
+	response.Reactions = SummarizeReactions(c.Reactions)
+
 	// Include service if loaded
 	if c.Service != nil && c.Service.ID != 0 {
 		serviceResponse := c.Service.ToResponse()
 		response.Service = &serviceResponse
 	}
 
+	// Include dependency if loaded
+	if c.Dependency != nil && c.Dependency.ID != 0 {
+		dependencyResponse := c.Dependency.ToResponse()
+		response.Dependency = &dependencyResponse
+	}
+
 	// Convert replies if loaded
 	for _, reply := range c.Replies {
 		response.Replies = append(response.Replies, reply.ToResponse())
@@ -91,6 +169,73 @@ func (c *Comment) ToResponse() CommentResponse {
 	return response
 }
 
+// RenderedContent returns the HTML of the comment. Comments written before
+// Markdown was supported are rendered on the fly, without references.
+func (c *Comment) RenderedContent() string {
+	if c.ContentHTML == "" && c.Content != "" {
+		return markdown.RenderSafe(c.Content, nil).HTML
+	}
+	return c.ContentHTML
+}
+
+// RenderCommentContent renders the Markdown of a comment written by author
+// in project. #service-name references resolve to services of the project,
+// by name or slug, and !project-slug references to projects the author can
+// view. Other references stay plain text.
+func RenderCommentContent(db *gorm.DB, project *Project, author *User, content string) (string, error) {
+	var services []Service
+	servicesLoaded := false
+	var lookupErr error
+
+	resolve := func(kind markdown.ReferenceKind, name string) *markdown.Reference {
+		if lookupErr != nil {
+			return nil
+		}
+		name = strings.ToLower(name)
+
+		switch kind {
+		case markdown.ServiceReference:
+			if !servicesLoaded {
+				if lookupErr = db.Select("id", "name").Where("project_id = ?", project.ID).Find(&services).Error; lookupErr != nil {
+					return nil
+				}
+				servicesLoaded = true
+			}
+			for _, service := range services {
+				if strings.ToLower(service.Name) == name || Slugify(service.Name) == name {
+					return &markdown.Reference{
+						Kind: kind,
+						Name: service.Name,
+						ID:   service.ID,
+						URL:  fmt.Sprintf("/dashboard/projects/%d/services?service=%d", project.ID, service.ID),
+					}
+				}
+			}
+
+		case markdown.ProjectReference:
+			var referenced Project
+			if lookupErr = db.Where("LOWER(slug) = ?", name).Limit(1).Find(&referenced).Error; lookupErr != nil || referenced.ID == 0 {
+				return nil
+			}
+			if referenced.ID == project.ID || GetProjectAccess(db, &referenced, author).CanView() {
+				return &markdown.Reference{
+					Kind: kind,
+					Name: referenced.Name,
+					ID:   referenced.ID,
+					URL:  fmt.Sprintf("/dashboard/projects/%d", referenced.ID),
+				}
+			}
+		}
+		return nil
+	}
+
+	result := markdown.RenderSafe(content, resolve)
+	if lookupErr != nil {
+		return "", lookupErr
+	}
+	return result.HTML, nil
+}
+
 // TableName specifies the table name for Comment
 func (Comment) TableName() string {
 	return "comments"
@@ -110,3 +255,91 @@ func (c *Comment) BeforeUpdate(tx *gorm.DB) error {
 	c.EditedAt = &now
 	return nil
 }
+
+// Errors returned by ValidateCommentSubject
+var (
+	ErrCommentSubjectConflict    = errors.New("a comment can be about a service or a dependency, not both")
+	ErrCommentServiceNotFound    = errors.New("service not found in this project")
+	ErrCommentDependencyNotFound = errors.New("dependency not found in this project")
+)
+
+// ValidateCommentSubject checks that comments of the project can be about
+// the given service or dependency. Both nil means the project itself.
+func ValidateCommentSubject(db *gorm.DB, projectID uint, serviceID, dependencyID *uint) error {
+	return validateCommentSubject(serviceID, dependencyID,
+		func(id uint) (bool, error) {
+			return exists(db.Model(&Service{}).Where("id = ? AND project_id = ?", id, projectID))
+		},
+		func(id uint) (bool, error) {
+			return exists(db.Model(&Dependency{}).
+				Where("id = ? AND source_id IN (SELECT id FROM services WHERE project_id = ?)", id, projectID))
+		})
+}
+
+// validateCommentSubject applies the rules of ValidateCommentSubject, with
+// lookups reporting whether a service or a dependency is in the project
+func validateCommentSubject(serviceID, dependencyID *uint, serviceInProject, dependencyInProject func(uint) (bool, error)) error {
+	if serviceID != nil && dependencyID != nil {
+		return ErrCommentSubjectConflict
+	}
+
+	if serviceID != nil {
+		found, err := serviceInProject(*serviceID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrCommentServiceNotFound
+		}
+	}
+
+	if dependencyID != nil {
+		found, err := dependencyInProject(*dependencyID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrCommentDependencyNotFound
+		}
+	}
+
+	return nil
+}
+
+// exists reports whether the query matches a row
+func exists(query *gorm.DB) (bool, error) {
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// DeleteDependencyComments marks the comments about the given dependencies,
+// and the replies to them, as deleted. It is called before the dependencies
+// are deleted, as their discussion has nowhere to be shown afterwards.
+func DeleteDependencyComments(tx *gorm.DB, dependencyIDs []uint) error {
+	if len(dependencyIDs) == 0 {
+		return nil
+	}
+	return deleteAnchoredComments(tx, dependencyIDs)
+}
+
+// DeleteServiceDependencyComments marks the comments about the dependencies
+// of the given services as deleted. Those dependencies go with the services.
+func DeleteServiceDependencyComments(tx *gorm.DB, serviceIDs []uint) error {
+	if len(serviceIDs) == 0 {
+		return nil
+	}
+	return deleteAnchoredComments(tx, tx.Model(&Dependency{}).Select("id").
+		Where("source_id IN ? OR target_id IN ?", serviceIDs, serviceIDs))
+}
+
+// deleteAnchoredComments marks the active comments whose dependency is in
+// dependencies (IDs or a subquery) as deleted, along with their threads
+func deleteAnchoredComments(tx *gorm.DB, dependencies interface{}) error {
+	return tx.Model(&Comment{}).
+		Where("status = ?", "active").
+		Where("dependency_id IN (?) OR thread_id IN (SELECT thread_id FROM comments WHERE dependency_id IN (?))", dependencies, dependencies).
+		UpdateColumn("status", "deleted").Error
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateCommentSubject(t *testing.T) {
+	id := func(v uint) *uint { return &v }
+	errLookup := errors.New("lookup failed")
+
+	// Service 3 and dependency 4 belong to the project, 8 and 9 do not;
+	// 13 cannot be looked up
+	lookup := func(inProject uint, looked *[]uint) func(uint) (bool, error) {
+		return func(subjectID uint) (bool, error) {
+			*looked = append(*looked, subjectID)
+			if subjectID == 13 {
+				return false, errLookup
+			}
+			return subjectID == inProject, nil
+		}
+	}
+
+	cases := []struct {
+		name         string
+		serviceID    *uint
+		dependencyID *uint
+		err          error
+		lookups      int
+	}{
+		{"project itself", nil, nil, nil, 0},
+		{"service and dependency", id(3), id(4), ErrCommentSubjectConflict, 0},
+		{"service of the project", id(3), nil, nil, 1},
+		{"service of another project", id(8), nil, ErrCommentServiceNotFound, 1},
+		{"dependency of the project", nil, id(4), nil, 1},
+		{"dependency of another project", nil, id(9), ErrCommentDependencyNotFound, 1},
+		{"failed service lookup", id(13), nil, errLookup, 1},
+		{"failed dependency lookup", nil, id(13), errLookup, 1},
+	}
+
+	for _, tc := range cases {
+		var services, dependencies []uint
+		err := validateCommentSubject(tc.serviceID, tc.dependencyID, lookup(3, &services), lookup(4, &dependencies))
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.err, err)
+		}
+		if got := len(services) + len(dependencies); got != tc.lookups {
+			t.Errorf("%s: expected %d lookups, got services %v and dependencies %v", tc.name, tc.lookups, services, dependencies)
+		}
+	}
+}
+
+func TestDeleteAnchoredCommentsWithoutAnchors(t *testing.T) {
+	// Nothing to delete means no query, so no database is needed
+	if err := DeleteDependencyComments(nil, nil); err != nil {
+		t.Errorf("expected no error without dependencies, got %v", err)
+	}
+	if err := DeleteServiceDependencyComments(nil, []uint{}); err != nil {
+		t.Errorf("expected no error without services, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CommentRevision keeps a version of a comment replaced by an edit. Version
+// 1 is the content the comment was created with. EditedBy is the user whose
+// edit replaced the version, at CreatedAt.
+type CommentRevision struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"comment_id" gorm:"not null"`
+	Version   int       `json:"version" gorm:"not null"`
+	Content   string    `json:"content" gorm:"type:text;not null"`
+	EditedBy  *uint     `json:"edited_by"`
+	Editor    *User     `json:"-" gorm:"foreignKey:EditedBy"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// CommentRevisionResponse represents comment revision response
+type CommentRevisionResponse struct {
+	ID          uint          `json:"id"`
+	Version     int           `json:"version"`
+	Content     string        `json:"content"`
+	ContentHTML string        `json:"content_html"`
+	EditedBy    *uint         `json:"edited_by,omitempty"`
+	Editor      *UserResponse `json:"editor,omitempty"`
+	// WrittenAt is when the version was written, EditedAt when it was
+	// replaced
+	WrittenAt time.Time `json:"written_at"`
+	EditedAt  time.Time `json:"edited_at"`
+}
+
+// TableName specifies the table name for CommentRevision
+func (CommentRevision) TableName() string {
+	return "comment_revisions"
+}
+
+// BeforeCreate runs before creating a comment revision
+func (r *CommentRevision) BeforeCreate(tx *gorm.DB) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+// RecordCommentRevision keeps the current content of a comment before it is
+// replaced by an edit of editor. Edits that leave the content unchanged are
+// not recorded. The comment is locked so concurrent edits are numbered in
+// turn.
+func RecordCommentRevision(tx *gorm.DB, comment *Comment, newContent string, editor *User) error {
+	var previous Comment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "content").First(&previous, comment.ID).Error; err != nil {
+		return err
+	}
+	if previous.Content == newContent {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(&CommentRevision{}).Where("comment_id = ?", comment.ID).Count(&count).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&CommentRevision{
+		CommentID: comment.ID,
+		Version:   int(count) + 1,
+		Content:   previous.Content,
+		EditedBy:  &editor.ID,
+	}).Error
+}
+
+// CommentRevisionResponses converts the revisions of a comment, oldest
+// first, and dates each version
+func CommentRevisionResponses(comment *Comment, revisions []CommentRevision) []CommentRevisionResponse {
+	responses := make([]CommentRevisionResponse, 0, len(revisions))
+	writtenAt := comment.CreatedAt
+	for _, revision := range revisions {
+		response := CommentRevisionResponse{
+			ID:          revision.ID,
+			Version:     revision.Version,
+			Content:     revision.Content,
+			ContentHTML: (&Comment{Content: revision.Content}).RenderedContent(),
+			EditedBy:    revision.EditedBy,
+			WrittenAt:   writtenAt,
+			EditedAt:    revision.CreatedAt,
+		}
+
+		// Include editor if loaded
+		if revision.Editor != nil && revision.Editor.ID != 0 {
+			editor := revision.Editor.ToResponse()
+			response.Editor = &editor
+		}
+
+		responses = append(responses, response)
+		writtenAt = revision.CreatedAt
+	}
+	return responses
+}
@@ -0,0 +1,264 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DigestFrequency represents how often a user receives the activity digest
+type DigestFrequency string
+
+const (
+	DigestNever  DigestFrequency = "never"
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+func (f DigestFrequency) IsValid() bool {
+	return f == DigestNever || f == DigestDaily || f == DigestWeekly
+}
+
+// Period returns the time a digest of this frequency covers
+func (f DigestFrequency) Period() time.Duration {
+	if f == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// NotificationPreference holds the notification settings of a user. Users
+// without a row, or who never chose a frequency, get the configured default.
+type NotificationPreference struct {
+	UserID          uint            `json:"user_id" gorm:"primaryKey"`
+	DigestFrequency DigestFrequency `json:"digest_frequency" gorm:"size:20"`
+	LastDigestAt    *time.Time      `json:"last_digest_at"`
+	UpdatedAt       time.Time       `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// UpdateNotificationPreferencesRequest represents notification settings
+// update data
+type UpdateNotificationPreferencesRequest struct {
+	DigestFrequency DigestFrequency `json:"digest_frequency" binding:"required,oneof=never daily weekly"`
+}
+
+// TableName specifies the table name for NotificationPreference
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+// BeforeSave runs before saving notification preferences
+func (p *NotificationPreference) BeforeSave(tx *gorm.DB) error {
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+// GetNotificationPreference returns the settings of a user, with the default
+// frequency when they never changed it
+func GetNotificationPreference(db *gorm.DB, userID uint, def DigestFrequency) (*NotificationPreference, error) {
+	preference := NotificationPreference{UserID: userID}
+	if err := db.Where("user_id = ?", userID).Limit(1).Find(&preference).Error; err != nil {
+		return nil, err
+	}
+	if preference.DigestFrequency == "" {
+		preference.DigestFrequency = def
+	}
+	return &preference, nil
+}
+
+// MarkDigestSent records when the last digest of a user was sent, without
+// touching the frequency they chose
+func MarkDigestSent(db *gorm.DB, userID uint, at time.Time) error {
+	return db.Exec(`INSERT INTO notification_preferences (user_id, last_digest_at, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET last_digest_at = EXCLUDED.last_digest_at`, userID, at, at).Error
+}
+
+// LastDigestSchedule returns the latest time at or before now a digest of
+// the given frequency was due: every day at hour, or every week on weekday at
+// hour. A user is due when their last digest is older.
+func LastDigestSchedule(now time.Time, frequency DigestFrequency, hour int, weekday time.Weekday) time.Time {
+	scheduled := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if scheduled.After(now) {
+		scheduled = scheduled.AddDate(0, 0, -1)
+	}
+	if frequency == DigestWeekly {
+		back := (int(scheduled.Weekday()) - int(weekday) + 7) % 7
+		scheduled = scheduled.AddDate(0, 0, -back)
+	}
+	return scheduled
+}
+
+// MemberProjectsSQL selects the IDs of the projects a user (@user) was
+// granted access to, leaving out the public projects they only visit
+const MemberProjectsSQL = `SELECT id FROM projects WHERE owner_id = @user
+	OR id IN (SELECT project_id FROM project_collaborators WHERE user_id = @user AND state = 'active')
+	OR id IN (SELECT pt.project_id FROM project_teams pt JOIN team_members tm ON tm.team_id = pt.team_id WHERE tm.user_id = @user)
+	OR organization_id IN (SELECT organization_id FROM organization_members WHERE user_id = @user AND role = 'admin')`
+
+// DigestChange is a change listed in a digest
+type DigestChange struct {
+	Title string
+	Actor string
+	At    time.Time
+}
+
+// DigestIssue is an issue opened during the period of a digest
+type DigestIssue struct {
+	CommentID uint
+	Service   string
+	Excerpt   string
+	Priority  IssuePriority
+	Author    string
+	At        time.Time
+}
+
+// DigestMention is a comment mentioning the user
+type DigestMention struct {
+	CommentID uint
+	ProjectID uint
+	Project   string
+	Excerpt   string
+	Author    string
+	At        time.Time
+}
+
+// DigestProject gathers the activity of one project
+type DigestProject struct {
+	ID                  uint
+	Name                string
+	NewServices         []DigestChange
+	RemovedDependencies []DigestChange
+	NewIssues           []DigestIssue
+}
+
+// Digest is the activity of the projects of a user over a period. Changes
+// made by the user themselves are left out.
+type Digest struct {
+	User      User
+	Frequency DigestFrequency
+	Since     time.Time
+	Until     time.Time
+	Projects  []DigestProject
+	Mentions  []DigestMention
+}
+
+// IsEmpty reports whether there is nothing to tell the user
+func (d *Digest) IsEmpty() bool {
+	return len(d.Projects) == 0 && len(d.Mentions) == 0
+}
+
+// BuildDigest collects the activity a user missed between since and until
+func BuildDigest(db *gorm.DB, user *User, frequency DigestFrequency, since, until time.Time) (*Digest, error) {
+	digest := &Digest{User: *user, Frequency: frequency, Since: since, Until: until}
+	projectIDs := db.Raw(MemberProjectsSQL, map[string]interface{}{"user": user.ID})
+
+	var projects []Project
+	if err := db.Where("id IN (?)", projectIDs).Order("name ASC").Find(&projects).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*DigestProject, len(projects))
+	for _, project := range projects {
+		byID[project.ID] = &DigestProject{ID: project.ID, Name: project.Name}
+	}
+
+	// Service and dependency changes come from the change history
+	var history []ChangeHistory
+	if err := db.Preload("User").
+		Where("project_id IN (?) AND user_id <> ? AND timestamp >= ? AND timestamp < ?", projectIDs, user.ID, since, until).
+		Where("action IN ?", []string{HistoryCreateService, HistoryDeleteDependency}).
+		Order("timestamp ASC, id ASC").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	for _, entry := range history {
+		project := byID[entry.ProjectID]
+		if project == nil {
+			continue
+		}
+
+		change := DigestChange{Actor: entry.User.Name, At: entry.Timestamp}
+		switch entry.Action {
+		case HistoryCreateService:
+			var details ServiceHistoryDetails
+			if err := json.Unmarshal(entry.Details, &details); err != nil {
+				continue
+			}
+			change.Title = details.Name
+			project.NewServices = append(project.NewServices, change)
+		case HistoryDeleteDependency:
+			var details DependencyHistoryDetails
+			if err := json.Unmarshal(entry.Details, &details); err != nil {
+				continue
+			}
+			change.Title = details.Source + " → " + details.Target
+			project.RemovedDependencies = append(project.RemovedDependencies, change)
+		}
+	}
+
+	var issues []Comment
+	if err := db.Preload("User").Preload("Service").
+		Where("project_id IN (?) AND user_id <> ? AND created_at >= ? AND created_at < ?", projectIDs, user.ID, since, until).
+		Where("type = ? AND status = ?", CommentTypeIssue, "active").
+		Order("created_at ASC, id ASC").Find(&issues).Error; err != nil {
+		return nil, err
+	}
+	for _, issue := range issues {
+		project := byID[issue.ProjectID]
+		if project == nil {
+			continue
+		}
+
+		item := DigestIssue{
+			CommentID: issue.ID,
+			Excerpt:   excerpt(issue.Content, notificationExcerptLength),
+			Author:    issue.User.Name,
+			At:        issue.CreatedAt,
+		}
+		if issue.Priority != nil {
+			item.Priority = *issue.Priority
+		}
+		if issue.Service != nil {
+			item.Service = issue.Service.Name
+		}
+		project.NewIssues = append(project.NewIssues, item)
+	}
+
+	for _, project := range projects {
+		activity := byID[project.ID]
+		if len(activity.NewServices)+len(activity.RemovedDependencies)+len(activity.NewIssues) > 0 {
+			digest.Projects = append(digest.Projects, *activity)
+		}
+	}
+
+	// Mentions come from the inbox, whichever project they are in
+	var mentions []Notification
+	if err := db.Preload("Actor").Preload("Project").Preload("Comment").
+		Where("user_id = ? AND type = ? AND created_at >= ? AND created_at < ?", user.ID, NotificationMention, since, until).
+		Where("comment_id IN (SELECT id FROM comments WHERE status = ?)", "active").
+		Order("created_at ASC, id ASC").Find(&mentions).Error; err != nil {
+		return nil, err
+	}
+	for _, mention := range mentions {
+		digest.Mentions = append(digest.Mentions, DigestMention{
+			CommentID: mention.CommentID,
+			ProjectID: mention.ProjectID,
+			Project:   mention.Project.Name,
+			Excerpt:   excerpt(mention.Comment.Content, notificationExcerptLength),
+			Author:    mention.Actor.Name,
+			At:        mention.CreatedAt,
+		})
+	}
+
+	return digest, nil
+}
+
+// ParseWeekday parses an English weekday name such as "monday"
+func ParseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), strings.TrimSpace(name)) {
+			return day, true
+		}
+	}
+	return time.Sunday, false
+}
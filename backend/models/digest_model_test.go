@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLastDigestSchedule(t *testing.T) {
+	// Wednesday
+	now := time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		now       time.Time
+		frequency DigestFrequency
+		weekday   time.Weekday
+		want      time.Time
+	}{
+		{now, DigestDaily, time.Monday, time.Date(2024, 5, 15, 8, 0, 0, 0, time.UTC)},
+		{now.Add(-3 * time.Hour), DigestDaily, time.Monday, time.Date(2024, 5, 14, 8, 0, 0, 0, time.UTC)},
+		{now, DigestWeekly, time.Monday, time.Date(2024, 5, 13, 8, 0, 0, 0, time.UTC)},
+		{now, DigestWeekly, time.Wednesday, time.Date(2024, 5, 15, 8, 0, 0, 0, time.UTC)},
+		{now.Add(-3 * time.Hour), DigestWeekly, time.Wednesday, time.Date(2024, 5, 8, 8, 0, 0, 0, time.UTC)},
+		{now, DigestWeekly, time.Thursday, time.Date(2024, 5, 9, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		got := LastDigestSchedule(test.now, test.frequency, 8, test.weekday)
+		if !got.Equal(test.want) {
+			t.Errorf("%s %s on %s: got %s, want %s", test.now, test.frequency, test.weekday, got, test.want)
+		}
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	if day, ok := ParseWeekday(" Friday"); !ok || day != time.Friday {
+		t.Errorf("got %s %v", day, ok)
+	}
+	if _, ok := ParseWeekday("fri"); ok {
+		t.Error("expected abbreviations to be rejected")
+	}
+}
@@ -0,0 +1,255 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// legacyEnvironments are the environment names accepted for projects that
+// have not defined their own environments yet
+var legacyEnvironments = map[string]bool{
+	"production":  true,
+	"development": true,
+}
+
+// Environment represents a project-defined deployment environment
+type Environment struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ProjectID   uint      `json:"project_id" gorm:"not null;uniqueIndex:idx_environments_project_name"`
+	Project     Project   `json:"-" gorm:"foreignKey:ProjectID"`
+	Name        string    `json:"name" gorm:"not null;size:50;uniqueIndex:idx_environments_project_name"`
+	Description string    `json:"description" gorm:"type:text"`
+	Position    int       `json:"position" gorm:"default:0"`
+	CreatedAt   time.Time `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// ServiceInstance represents a service deployed in a specific environment
+type ServiceInstance struct {
+	ID            uint        `json:"id" gorm:"primaryKey"`
+	ServiceID     uint        `json:"service_id" gorm:"not null;uniqueIndex:idx_service_instances_service_env"`
+	Service       Service     `json:"-" gorm:"foreignKey:ServiceID"`
+	EnvironmentID uint        `json:"environment_id" gorm:"not null;uniqueIndex:idx_service_instances_service_env"`
+	Environment   Environment `json:"-" gorm:"foreignKey:EnvironmentID"`
+	Status        string      `json:"status" gorm:"default:active;size:20"`
+	Version       string      `json:"version" gorm:"size:50"`
+	DeployURL     string      `json:"deploy_url" gorm:"size:255"`
+	Domain        string      `json:"domain" gorm:"size:255"`
+	UpdatedBy     *uint       `json:"updated_by"`
+	CreatedAt     time.Time   `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt     time.Time   `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// CreateEnvironmentRequest represents environment creation data
+type CreateEnvironmentRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=50"`
+	Description string `json:"description"`
+	Position    int    `json:"position"`
+}
+
+// UpdateEnvironmentRequest represents environment update data
+type UpdateEnvironmentRequest struct {
+	Name        string `json:"name" binding:"omitempty,min=1,max=50"`
+	Description string `json:"description"`
+	Position    *int   `json:"position"`
+}
+
+// UpsertServiceInstanceRequest represents the data of a service in one environment
+type UpsertServiceInstanceRequest struct {
+	Status    string `json:"status" binding:"omitempty,oneof=active inactive"`
+	Version   string `json:"version" binding:"max=50"`
+	DeployURL string `json:"deploy_url" binding:"max=255"`
+	Domain    string `json:"domain" binding:"max=255"`
+}
+
+// EnvironmentResponse represents environment response
+type EnvironmentResponse struct {
+	ID          uint      `json:"id"`
+	ProjectID   uint      `json:"project_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Position    int       `json:"position"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ServiceInstanceResponse represents service instance response
+type ServiceInstanceResponse struct {
+	ID            uint      `json:"id"`
+	ServiceID     uint      `json:"service_id"`
+	EnvironmentID uint      `json:"environment_id"`
+	Environment   string    `json:"environment,omitempty"`
+	Status        string    `json:"status"`
+	Version       string    `json:"version"`
+	DeployURL     string    `json:"deploy_url"`
+	Domain        string    `json:"domain"`
+	UpdatedBy     *uint     `json:"updated_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ToResponse converts environment to EnvironmentResponse
+func (e *Environment) ToResponse() EnvironmentResponse {
+	return EnvironmentResponse{
+		ID:          e.ID,
+		ProjectID:   e.ProjectID,
+		Name:        e.Name,
+		Description: e.Description,
+		Position:    e.Position,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
+
+// ToResponse converts service instance to ServiceInstanceResponse
+func (si *ServiceInstance) ToResponse() ServiceInstanceResponse {
+	response := ServiceInstanceResponse{
+		ID:            si.ID,
+		ServiceID:     si.ServiceID,
+		EnvironmentID: si.EnvironmentID,
+		Status:        si.Status,
+		Version:       si.Version,
+		DeployURL:     si.DeployURL,
+		Domain:        si.Domain,
+		UpdatedBy:     si.UpdatedBy,
+		CreatedAt:     si.CreatedAt,
+		UpdatedAt:     si.UpdatedAt,
+	}
+
+	// Include environment name if loaded
+	if si.Environment.ID != 0 {
+		response.Environment = si.Environment.Name
+	}
+
+	return response
+}
+
+// TableName specifies the table name for Environment
+func (Environment) TableName() string {
+	return "environments"
+}
+
+// TableName specifies the table name for ServiceInstance
+func (ServiceInstance) TableName() string {
+	return "service_instances"
+}
+
+// BeforeCreate runs before creating an environment
+func (e *Environment) BeforeCreate(tx *gorm.DB) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	if e.UpdatedAt.IsZero() {
+		e.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// BeforeUpdate runs before updating an environment
+func (e *Environment) BeforeUpdate(tx *gorm.DB) error {
+	e.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeCreate runs before creating a service instance
+func (si *ServiceInstance) BeforeCreate(tx *gorm.DB) error {
+	if si.CreatedAt.IsZero() {
+		si.CreatedAt = time.Now()
+	}
+	if si.UpdatedAt.IsZero() {
+		si.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// BeforeUpdate runs before updating a service instance
+func (si *ServiceInstance) BeforeUpdate(tx *gorm.DB) error {
+	si.UpdatedAt = time.Now()
+	return nil
+}
+
+// FindProjectEnvironment looks up an environment of a project by name
+func FindProjectEnvironment(db *gorm.DB, projectID uint, name string) (*Environment, error) {
+	var environment Environment
+	if err := db.Where("project_id = ? AND name = ?", projectID, name).First(&environment).Error; err != nil {
+		return nil, err
+	}
+	return &environment, nil
+}
+
+// ValidateServiceEnvironment checks that an environment name is allowed for
+// services of a project. Projects without environments of their own keep
+// accepting the legacy production/development values.
+func ValidateServiceEnvironment(db *gorm.DB, projectID uint, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	var count int64
+	if err := db.Model(&Environment{}).Where("project_id = ?", projectID).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count == 0 {
+		if !legacyEnvironments[name] {
+			return fmt.Errorf("environment %q is not defined for this project", name)
+		}
+		return nil
+	}
+
+	if _, err := FindProjectEnvironment(db, projectID, name); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("environment %q is not defined for this project", name)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// DefaultServiceEnvironment returns the environment given to new services
+// that do not name one: the first environment of the project by position,
+// or the legacy production value for projects without environments
+func DefaultServiceEnvironment(db *gorm.DB, projectID uint) (string, error) {
+	var environments []Environment
+	if err := db.Where("project_id = ?", projectID).Order("position ASC, id ASC").
+		Limit(1).Find(&environments).Error; err != nil {
+		return "", err
+	}
+	if len(environments) == 0 {
+		return "production", nil
+	}
+	return environments[0].Name, nil
+}
+
+// InstanceFor returns the loaded instance of the service in an environment
+func (s *Service) InstanceFor(environmentID uint) *ServiceInstance {
+	for i := range s.Instances {
+		if s.Instances[i].EnvironmentID == environmentID {
+			return &s.Instances[i]
+		}
+	}
+	return nil
+}
+
+// ToEnvironmentResponse converts a service to the ServiceResponse it has in
+// the given environment. The second value is false when the service is not
+// deployed there.
+func (s *Service) ToEnvironmentResponse(environment *Environment) (ServiceResponse, bool) {
+	instance := s.InstanceFor(environment.ID)
+	if instance == nil {
+		return ServiceResponse{}, false
+	}
+
+	response := s.ToResponse()
+	response.Environment = environment.Name
+	response.Status = instance.Status
+	response.Version = instance.Version
+	response.DeployURL = instance.DeployURL
+	response.Domain = instance.Domain
+	response.Instances = nil
+
+	return response, true
+}
@@ -0,0 +1,83 @@
+package models
+
+import "testing"
+
+func TestToEnvironmentResponse(t *testing.T) {
+	staging := &Environment{ID: 2, Name: "staging"}
+	service := Service{
+		ID:          1,
+		Name:        "api",
+		Environment: "production",
+		Status:      "active",
+		Version:     "2.0.0",
+		DeployURL:   "https://api.example.com",
+		Instances: []ServiceInstance{
+			{EnvironmentID: 2, Status: "inactive", Version: "2.1.0-rc1", DeployURL: "https://staging.example.com", Domain: "staging.example.com"},
+		},
+	}
+
+	response, ok := service.ToEnvironmentResponse(staging)
+	if !ok {
+		t.Fatal("expected the service to be deployed in staging")
+	}
+	if response.Environment != "staging" || response.Status != "inactive" || response.Version != "2.1.0-rc1" ||
+		response.DeployURL != "https://staging.example.com" || response.Domain != "staging.example.com" {
+		t.Errorf("instance values not applied: %+v", response)
+	}
+	if response.Name != "api" || response.Instances != nil {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	if _, ok := service.ToEnvironmentResponse(&Environment{ID: 3, Name: "qa"}); ok {
+		t.Error("expected no response for an environment without instance")
+	}
+}
+
+func TestGraphResponsesForEnvironment(t *testing.T) {
+	staging := &Environment{ID: 2, Name: "staging"}
+	deployed := Service{ID: 1, Name: "api", Instances: []ServiceInstance{{EnvironmentID: 2, Version: "1.1"}}}
+	alsoDeployed := Service{ID: 2, Name: "db", Instances: []ServiceInstance{{EnvironmentID: 2, Version: "15"}}}
+	notDeployed := Service{ID: 3, Name: "worker"}
+	services := []Service{deployed, alsoDeployed, notDeployed}
+	dependencies := []Dependency{
+		{ID: 10, SourceID: 1, TargetID: 2, SourceService: deployed, TargetService: alsoDeployed},
+		{ID: 11, SourceID: 3, TargetID: 2, SourceService: notDeployed, TargetService: alsoDeployed},
+	}
+
+	cases := []struct {
+		name         string
+		environment  *Environment
+		services     []uint
+		dependencies []uint
+	}{
+		{"all environments", nil, []uint{1, 2, 3}, []uint{10, 11}},
+		{"staging", staging, []uint{1, 2}, []uint{10}},
+	}
+	for _, tc := range cases {
+		serviceResponses := ServiceResponsesFor(services, tc.environment)
+		if len(serviceResponses) != len(tc.services) {
+			t.Fatalf("%s: expected services %v, got %+v", tc.name, tc.services, serviceResponses)
+		}
+		for i, id := range tc.services {
+			if serviceResponses[i].ID != id {
+				t.Errorf("%s: expected service %d at %d, got %d", tc.name, id, i, serviceResponses[i].ID)
+			}
+		}
+
+		dependencyResponses := DependencyResponsesFor(dependencies, tc.environment)
+		if len(dependencyResponses) != len(tc.dependencies) {
+			t.Fatalf("%s: expected dependencies %v, got %+v", tc.name, tc.dependencies, dependencyResponses)
+		}
+		for i, id := range tc.dependencies {
+			if dependencyResponses[i].ID != id {
+				t.Errorf("%s: expected dependency %d at %d, got %d", tc.name, id, i, dependencyResponses[i].ID)
+			}
+		}
+	}
+
+	// Edges in an environment carry the services as deployed there
+	edge := DependencyResponsesFor(dependencies, staging)[0]
+	if edge.SourceService.Version != "1.1" || edge.TargetService.Environment != "staging" {
+		t.Errorf("edge services not converted for staging: %+v", edge)
+	}
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// ProjectGraph represents the services and dependencies of a project,
+// optionally rendered for a single environment
+type ProjectGraph struct {
+	Project      ProjectResponse      `json:"project"`
+	Environment  *EnvironmentResponse `json:"environment,omitempty"`
+	Services     []ServiceResponse    `json:"services"`
+	Dependencies []DependencyResponse `json:"dependencies"`
+}
+
+// ServiceResponsesFor converts services to responses. When an environment is
+// given only the services deployed there are returned, carrying the
+// environment-specific fields of their instance.
+func ServiceResponsesFor(services []Service, environment *Environment) []ServiceResponse {
+	responses := make([]ServiceResponse, 0, len(services))
+	for _, service := range services {
+		if environment == nil {
+			responses = append(responses, service.ToResponse())
+			continue
+		}
+		if response, ok := service.ToEnvironmentResponse(environment); ok {
+			responses = append(responses, response)
+		}
+	}
+	return responses
+}
+
+// DependencyResponsesFor converts dependencies to responses. When an
+// environment is given only edges whose source and target are both deployed
+// there are kept.
+func DependencyResponsesFor(dependencies []Dependency, environment *Environment) []DependencyResponse {
+	responses := make([]DependencyResponse, 0, len(dependencies))
+	for _, dependency := range dependencies {
+		if environment == nil {
+			responses = append(responses, dependency.ToResponse())
+			continue
+		}
+
+		source, sourceOK := dependency.SourceService.ToEnvironmentResponse(environment)
+		target, targetOK := dependency.TargetService.ToEnvironmentResponse(environment)
+		if !sourceOK || !targetOK {
+			continue
+		}
+
+		response := dependency.ToResponse()
+		response.SourceService = source
+		response.TargetService = target
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+// GraphFilter restricts the part of a project graph that is exported
+type GraphFilter struct {
+	Environment *Environment
+	// Tags keeps only services carrying all of the given tags, and the
+	// dependencies between them
+	Tags []string
+}
+
+// BuildProjectGraph loads the service graph of a project
+func BuildProjectGraph(db *gorm.DB, project *Project, filter GraphFilter) (*ProjectGraph, error) {
+	var services []Service
+	servicesQuery := FilterByServiceTags(db.Where("project_id = ?", project.ID), "services.id", filter.Tags)
+	if err := servicesQuery.Preload("Instances.Environment").Preload("Tags").
+		Order("id").Find(&services).Error; err != nil {
+		return nil, err
+	}
+
+	var dependencies []Dependency
+	dependenciesQuery := db.
+		Joins("JOIN services s1 ON dependencies.source_id = s1.id").
+		Joins("JOIN services s2 ON dependencies.target_id = s2.id").
+		Where("s1.project_id = ? AND s2.project_id = ?", project.ID, project.ID)
+	dependenciesQuery = FilterByServiceTags(dependenciesQuery, "s1.id", filter.Tags)
+	dependenciesQuery = FilterByServiceTags(dependenciesQuery, "s2.id", filter.Tags)
+	if err := dependenciesQuery.Preload("SourceService.Instances.Environment").
+		Preload("TargetService.Instances.Environment").
+		Order("dependencies.id").Find(&dependencies).Error; err != nil {
+		return nil, err
+	}
+
+	environment := filter.Environment
+	graph := &ProjectGraph{
+		Project:      project.ToResponse(),
+		Services:     ServiceResponsesFor(services, environment),
+		Dependencies: DependencyResponsesFor(dependencies, environment),
+	}
+
+	if environment != nil {
+		environmentResponse := environment.ToResponse()
+		graph.Environment = &environmentResponse
+	}
+
+	return graph, nil
+}
@@ -7,6 +7,31 @@ import (
 	"gorm.io/gorm"
 )
 
+// History actions recorded on projects
+const (
+	HistoryCreateService    = "create_service"
+	HistoryDeleteService    = "delete_service"
+	HistoryCreateDependency = "create_dependency"
+	HistoryDeleteDependency = "delete_dependency"
+)
+
+// ServiceHistoryDetails describes the service of a history entry. The name
+// is kept as the service may be deleted.
+type ServiceHistoryDetails struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// DependencyHistoryDetails describes the dependency of a history entry
+type DependencyHistoryDetails struct {
+	ID       uint   `json:"id"`
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Type     string `json:"type,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
 // ChangeHistory represents the change_history table structure
 type ChangeHistory struct {
 	ID        uint            `json:"id" gorm:"primaryKey"`
@@ -90,3 +115,29 @@ func CreateHistoryEntry(db *gorm.DB, projectID uint, serviceID *uint, userID uin
 
 	return db.Create(&history).Error
 }
+
+// RecordServiceHistory records the creation or deletion of a service.
+// Deletions are not linked to the service as its row is going away.
+func RecordServiceHistory(db *gorm.DB, service *Service, userID uint, action string) error {
+	serviceID := &service.ID
+	if action == HistoryDeleteService {
+		serviceID = nil
+	}
+	return CreateHistoryEntry(db, service.ProjectID, serviceID, userID, action, ServiceHistoryDetails{
+		ID:   service.ID,
+		Name: service.Name,
+		Type: service.Type,
+	})
+}
+
+// RecordDependencyHistory records the creation or deletion of a dependency.
+// The source and target services must be loaded.
+func RecordDependencyHistory(db *gorm.DB, dependency *Dependency, userID uint, action string) error {
+	return CreateHistoryEntry(db, dependency.SourceService.ProjectID, nil, userID, action, DependencyHistoryDetails{
+		ID:       dependency.ID,
+		Source:   dependency.SourceService.Name,
+		Target:   dependency.TargetService.Name,
+		Type:     dependency.Type,
+		Protocol: dependency.Protocol,
+	})
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidOIDCFlow is returned for flow tokens that are malformed, expired
+// or not signed by us
+var ErrInvalidOIDCFlow = errors.New("invalid OIDC flow token")
+
+// UserIdentity links a user to an account at an OpenID provider. The
+// subject is stable at the provider, unlike the email.
+type UserIdentity struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null"`
+	User        User       `json:"-" gorm:"foreignKey:UserID"`
+	Issuer      string     `json:"issuer" gorm:"not null;size:255;uniqueIndex:idx_user_identities_subject"`
+	Subject     string     `json:"subject" gorm:"not null;size:255;uniqueIndex:idx_user_identities_subject"`
+	Email       string     `json:"email" gorm:"size:255"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// OIDCCallbackRequest represents the redirect back from the provider,
+// forwarded by the frontend with the flow token it got from the login start
+type OIDCCallbackRequest struct {
+	Code      string `json:"code" binding:"required"`
+	State     string `json:"state" binding:"required"`
+	FlowToken string `json:"flow_token" binding:"required"`
+}
+
+// OIDCLoginResponse starts a login at the provider
+type OIDCLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	FlowToken        string `json:"flow_token"`
+	ExpiresIn        int    `json:"expires_in"`
+}
+
+// OIDCFlow holds what the callback needs to finish a login. It travels with
+// the frontend as a signed token so the server keeps no session state.
+type OIDCFlow struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// oidcFlowClaims are the claims of a flow token
+type oidcFlowClaims struct {
+	OIDCFlow
+	jwt.RegisteredClaims
+}
+
+// TableName specifies the table name for UserIdentity
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// SignOIDCFlow creates the flow token returned when a login starts
+func SignOIDCFlow(secret []byte, flow OIDCFlow, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := oidcFlowClaims{
+		OIDCFlow: flow,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{"oidc-flow"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+}
+
+// ParseOIDCFlow verifies a flow token and returns its flow
+func ParseOIDCFlow(secret []byte, token string) (*OIDCFlow, error) {
+	claims := &oidcFlowClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience("oidc-flow"))
+	if err != nil || claims.State == "" || claims.Verifier == "" {
+		return nil, ErrInvalidOIDCFlow
+	}
+
+	return &claims.OIDCFlow, nil
+}
+
+// FindUserByIdentity returns the user linked to a provider account, or nil
+func FindUserByIdentity(db *gorm.DB, issuer, subject string) (*User, error) {
+	var identity UserIdentity
+	if err := db.Preload("User").Where("issuer = ? AND subject = ?", issuer, subject).
+		Limit(1).Find(&identity).Error; err != nil {
+		return nil, err
+	}
+	if identity.ID == 0 {
+		return nil, nil
+	}
+	return &identity.User, nil
+}
+
+// MappedTeams splits the teams managed through group mappings into the ones
+// the user belongs to given their groups and the ones they must leave.
+// mappings maps a group to an "org-slug/team-slug" team.
+func MappedTeams(mappings map[string]string, groups []string) (join, leave []string) {
+	member := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		member[group] = true
+	}
+
+	// A team mapped from several groups is kept when any of them matches
+	wanted := make(map[string]bool)
+	for group, team := range mappings {
+		if member[group] {
+			wanted[team] = true
+		} else if !wanted[team] {
+			wanted[team] = false
+		}
+	}
+
+	for team, ok := range wanted {
+		if ok {
+			join = append(join, team)
+		} else {
+			leave = append(leave, team)
+		}
+	}
+	sort.Strings(join)
+	sort.Strings(leave)
+	return join, leave
+}
+
+// SyncMappedTeams applies group mappings to the team memberships of a user.
+// Joining a team also adds the user to its organization as a member. Teams
+// that do not exist are skipped.
+func SyncMappedTeams(tx *gorm.DB, userID uint, mappings map[string]string, groups []string) error {
+	join, leave := MappedTeams(mappings, groups)
+
+	for _, key := range join {
+		team, err := findTeamBySlugs(tx, key)
+		if err != nil || team == nil {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&OrganizationMember{
+			OrganizationID: team.OrganizationID,
+			UserID:         userID,
+			Role:           OrgMemberRole,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&TeamMember{
+			TeamID: team.ID,
+			UserID: userID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, key := range leave {
+		team, err := findTeamBySlugs(tx, key)
+		if err != nil || team == nil {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := tx.Where("team_id = ? AND user_id = ?", team.ID, userID).Delete(&TeamMember{}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findTeamBySlugs finds a team from an "org-slug/team-slug" key
+func findTeamBySlugs(db *gorm.DB, key string) (*Team, error) {
+	orgSlug, teamSlug, ok := strings.Cut(key, "/")
+	if !ok {
+		return nil, nil
+	}
+
+	var team Team
+	if err := db.Joins("JOIN organizations ON organizations.id = teams.organization_id").
+		Where("organizations.slug = ? AND teams.slug = ?", orgSlug, teamSlug).
+		Limit(1).Find(&team).Error; err != nil {
+		return nil, err
+	}
+	if team.ID == 0 {
+		return nil, nil
+	}
+	return &team, nil
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOIDCFlowToken(t *testing.T) {
+	secret := []byte("test-secret")
+	flow := OIDCFlow{State: "state", Nonce: "nonce", Verifier: "verifier"}
+
+	token, err := SignOIDCFlow(secret, flow, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseOIDCFlow(secret, token)
+	if err != nil || *parsed != flow {
+		t.Fatalf("unexpected parse result: %+v, %v", parsed, err)
+	}
+
+	if _, err := ParseOIDCFlow([]byte("other-secret"), token); err != ErrInvalidOIDCFlow {
+		t.Errorf("expected invalid token error, got %v", err)
+	}
+
+	expired, _ := SignOIDCFlow(secret, flow, -time.Minute)
+	if _, err := ParseOIDCFlow(secret, expired); err != ErrInvalidOIDCFlow {
+		t.Errorf("expected expired token to be rejected, got %v", err)
+	}
+}
+
+func TestMappedTeams(t *testing.T) {
+	mappings := map[string]string{
+		"sre":       "acme/sre",
+		"oncall":    "acme/sre",
+		"platform":  "acme/platform",
+		"frontend":  "acme/web",
+		"designers": "acme/web",
+	}
+
+	join, leave := MappedTeams(mappings, []string{"oncall", "designers", "unmapped"})
+	if !reflect.DeepEqual(join, []string{"acme/sre", "acme/web"}) {
+		t.Errorf("unexpected teams to join: %v", join)
+	}
+	if !reflect.DeepEqual(leave, []string{"acme/platform"}) {
+		t.Errorf("unexpected teams to leave: %v", leave)
+	}
+
+	join, leave = MappedTeams(mappings, nil)
+	if len(join) != 0 || len(leave) != 3 {
+		t.Errorf("expected to leave every mapped team, got %v / %v", join, leave)
+	}
+}
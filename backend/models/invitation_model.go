@@ -0,0 +1,182 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// InvitationStatus represents the state of an invitation
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationRevoked  InvitationStatus = "revoked"
+	InvitationExpired  InvitationStatus = "expired"
+)
+
+// ErrInvalidInvitationToken is returned for tokens that are malformed, not
+// signed by us or do not match the invitation
+var ErrInvalidInvitationToken = errors.New("invalid invitation token")
+
+// Invitation represents an invitation sent by email to someone without an
+// account. Project invitations land the person as a collaborator.
+type Invitation struct {
+	ID         uint             `json:"id" gorm:"primaryKey"`
+	Email      string           `json:"email" gorm:"not null;size:255"`
+	Name       string           `json:"name" gorm:"size:100"`
+	ProjectID  *uint            `json:"project_id"`
+	Project    *Project         `json:"-" gorm:"foreignKey:ProjectID"`
+	Role       string           `json:"role" gorm:"size:50"`
+	UserRole   Role             `json:"user_role" gorm:"default:user;size:50"`
+	TokenHash  string           `json:"-" gorm:"not null;size:64"`
+	Status     InvitationStatus `json:"status" gorm:"default:pending;size:20"`
+	InvitedBy  uint             `json:"invited_by" gorm:"not null"`
+	Inviter    User             `json:"-" gorm:"foreignKey:InvitedBy"`
+	CreatedAt  time.Time        `json:"created_at" gorm:"not null;default:now()"`
+	ExpiresAt  time.Time        `json:"expires_at" gorm:"not null"`
+	AcceptedAt *time.Time       `json:"accepted_at"`
+}
+
+// AcceptInvitationRequest represents the data to accept an invitation. For
+// new accounts the password is the one the person chooses; for existing
+// accounts it must be their current password.
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Name     string `json:"name" binding:"omitempty,min=2,max=100"`
+	Password string `json:"password" binding:"required"`
+}
+
+// InviteUserRequest represents an admin invitation to join the instance
+type InviteUserRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+	Role  Role   `json:"role,omitempty"`
+}
+
+// InvitationResponse represents an invitation response
+type InvitationResponse struct {
+	ID          uint             `json:"id"`
+	Email       string           `json:"email"`
+	Name        string           `json:"name"`
+	ProjectID   *uint            `json:"project_id"`
+	ProjectName string           `json:"project_name,omitempty"`
+	Role        string           `json:"role,omitempty"`
+	UserRole    Role             `json:"user_role"`
+	Status      InvitationStatus `json:"status"`
+	InvitedBy   UserResponse     `json:"invited_by"`
+	CreatedAt   time.Time        `json:"created_at"`
+	ExpiresAt   time.Time        `json:"expires_at"`
+	AcceptedAt  *time.Time       `json:"accepted_at"`
+}
+
+// EffectiveStatus reports expired for pending invitations past their expiry
+func (i *Invitation) EffectiveStatus(now time.Time) InvitationStatus {
+	if i.Status == InvitationPending && now.After(i.ExpiresAt) {
+		return InvitationExpired
+	}
+	return i.Status
+}
+
+// ToResponse converts invitation to InvitationResponse
+func (i *Invitation) ToResponse() InvitationResponse {
+	response := InvitationResponse{
+		ID:         i.ID,
+		Email:      i.Email,
+		Name:       i.Name,
+		ProjectID:  i.ProjectID,
+		Role:       i.Role,
+		UserRole:   i.UserRole,
+		Status:     i.EffectiveStatus(time.Now()),
+		CreatedAt:  i.CreatedAt,
+		ExpiresAt:  i.ExpiresAt,
+		AcceptedAt: i.AcceptedAt,
+	}
+
+	// Include relations if loaded
+	if i.Project != nil {
+		response.ProjectName = i.Project.Name
+	}
+	if i.Inviter.ID != 0 {
+		response.InvitedBy = i.Inviter.ToResponse()
+	}
+
+	return response
+}
+
+// TableName specifies the table name for Invitation
+func (Invitation) TableName() string {
+	return "invitations"
+}
+
+// invitationClaims are the claims of an invitation token. The nonce is only
+// stored hashed so a database leak does not expose usable tokens.
+type invitationClaims struct {
+	Nonce string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// NewInvitationNonce generates the random part of an invitation token and
+// the hash stored on the invitation
+func NewInvitationNonce() (nonce, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	nonce = base64.RawURLEncoding.EncodeToString(b)
+	return nonce, HashToken(nonce), nil
+}
+
+// HashToken returns the hex encoded SHA-256 of a secret token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SignInvitationToken creates the signed token sent by email. It carries the
+// invitation ID, the nonce and the expiry.
+func SignInvitationToken(secret []byte, invitation *Invitation, nonce string) (string, error) {
+	claims := invitationClaims{
+		Nonce: nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(invitation.ID), 10),
+			Audience:  jwt.ClaimStrings{"invitation"},
+			IssuedAt:  jwt.NewNumericDate(invitation.CreatedAt),
+			ExpiresAt: jwt.NewNumericDate(invitation.ExpiresAt),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+}
+
+// ParseInvitationToken verifies the signature and expiry of an invitation
+// token and returns the invitation ID and nonce
+func ParseInvitationToken(secret []byte, token string) (uint, string, error) {
+	claims := &invitationClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience("invitation"))
+	if err != nil {
+		return 0, "", ErrInvalidInvitationToken
+	}
+
+	id, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || id == 0 || claims.Nonce == "" {
+		return 0, "", ErrInvalidInvitationToken
+	}
+
+	return uint(id), claims.Nonce, nil
+}
+
+// MatchesNonce reports whether nonce is the one the invitation was issued with
+func (i *Invitation) MatchesNonce(nonce string) bool {
+	return subtle.ConstantTimeCompare([]byte(i.TokenHash), []byte(HashToken(nonce))) == 1
+}
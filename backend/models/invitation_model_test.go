@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInvitationToken(t *testing.T) {
+	secret := []byte("test-secret")
+	nonce, hash, err := NewInvitationNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invitation := &Invitation{ID: 42, TokenHash: hash, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	token, err := SignInvitationToken(secret, invitation, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, parsedNonce, err := ParseInvitationToken(secret, token)
+	if err != nil || id != 42 || !invitation.MatchesNonce(parsedNonce) {
+		t.Fatalf("unexpected parse result: %d, %q, %v", id, parsedNonce, err)
+	}
+
+	// Tokens signed with another key are rejected
+	if _, _, err := ParseInvitationToken([]byte("other-secret"), token); err != ErrInvalidInvitationToken {
+		t.Errorf("expected invalid token error, got %v", err)
+	}
+
+	// Expired tokens are rejected
+	invitation.ExpiresAt = time.Now().Add(-time.Minute)
+	expired, _ := SignInvitationToken(secret, invitation, nonce)
+	if _, _, err := ParseInvitationToken(secret, expired); err != ErrInvalidInvitationToken {
+		t.Errorf("expected expired token to be rejected, got %v", err)
+	}
+
+	// A token issued for a previous nonce does not match
+	if invitation.MatchesNonce("another-nonce") {
+		t.Error("expected nonce mismatch")
+	}
+}
+
+func TestInvitationEffectiveStatus(t *testing.T) {
+	now := time.Now()
+	invitation := Invitation{Status: InvitationPending, ExpiresAt: now.Add(-time.Second)}
+	if got := invitation.EffectiveStatus(now); got != InvitationExpired {
+		t.Errorf("expected expired, got %s", got)
+	}
+
+	invitation.Status = InvitationAccepted
+	if got := invitation.EffectiveStatus(now); got != InvitationAccepted {
+		t.Errorf("expected accepted, got %s", got)
+	}
+}
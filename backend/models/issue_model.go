@@ -0,0 +1,258 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IssueState represents the lifecycle state of an issue comment
+type IssueState string
+
+const (
+	IssueOpen       IssueState = "open"
+	IssueInProgress IssueState = "in_progress"
+	IssueResolved   IssueState = "resolved"
+	IssueWontFix    IssueState = "wontfix"
+)
+
+// IssueStates lists the states in board order
+var IssueStates = []IssueState{IssueOpen, IssueInProgress, IssueResolved, IssueWontFix}
+
+// issueTransitions lists the states each state can move to. Closed issues
+// must be reopened before work resumes on them.
+var issueTransitions = map[IssueState][]IssueState{
+	IssueOpen:       {IssueInProgress, IssueResolved, IssueWontFix},
+	IssueInProgress: {IssueOpen, IssueResolved, IssueWontFix},
+	IssueResolved:   {IssueOpen},
+	IssueWontFix:    {IssueOpen},
+}
+
+func (s IssueState) IsValid() bool {
+	_, ok := issueTransitions[s]
+	return ok
+}
+
+// IsClosed reports whether no more work is expected on the issue
+func (s IssueState) IsClosed() bool {
+	return s == IssueResolved || s == IssueWontFix
+}
+
+// CanTransitionTo reports whether an issue can move from s to next
+func (s IssueState) CanTransitionTo(next IssueState) bool {
+	for _, state := range issueTransitions[s] {
+		if state == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IssuePriority represents how urgent an issue is
+type IssuePriority string
+
+const (
+	IssuePriorityLow      IssuePriority = "low"
+	IssuePriorityMedium   IssuePriority = "medium"
+	IssuePriorityHigh     IssuePriority = "high"
+	IssuePriorityCritical IssuePriority = "critical"
+)
+
+// IssuePriorityOrderSQL orders issues from the most to the least urgent
+const IssuePriorityOrderSQL = `CASE comments.priority WHEN 'critical' THEN 0 WHEN 'high' THEN 1 WHEN 'medium' THEN 2 ELSE 3 END`
+
+// CommentTypeIssue is the comment type the issue workflow applies to
+const CommentTypeIssue = "issue"
+
+const dueDateLayout = "2006-01-02"
+
+// ErrInvalidDueDate is returned for due dates not written as YYYY-MM-DD
+var ErrInvalidDueDate = errors.New("due date must be formatted as YYYY-MM-DD")
+
+// IssueTransition records a state change of an issue
+type IssueTransition struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	CommentID uint       `json:"comment_id" gorm:"not null"`
+	UserID    uint       `json:"user_id" gorm:"not null"`
+	User      User       `json:"-" gorm:"foreignKey:UserID"`
+	FromState IssueState `json:"from_state" gorm:"size:20"`
+	ToState   IssueState `json:"to_state" gorm:"not null;size:20"`
+	Note      string     `json:"note" gorm:"type:text"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// UpdateIssueRequest represents issue workflow update data. An assignee_id
+// of 0 unassigns the issue and an empty due_date clears it.
+type UpdateIssueRequest struct {
+	State      IssueState    `json:"state" binding:"omitempty,oneof=open in_progress resolved wontfix"`
+	AssigneeID *uint         `json:"assignee_id"`
+	Priority   IssuePriority `json:"priority" binding:"omitempty,oneof=low medium high critical"`
+	DueDate    *string       `json:"due_date"`
+	Note       string        `json:"note" binding:"max=1000"`
+}
+
+// IssueResponse represents the workflow data of an issue comment
+type IssueResponse struct {
+	State      IssueState    `json:"state"`
+	AssigneeID *uint         `json:"assignee_id"`
+	Assignee   *UserResponse `json:"assignee,omitempty"`
+	Priority   IssuePriority `json:"priority"`
+	DueDate    *string       `json:"due_date"`
+	Overdue    bool          `json:"overdue"`
+	ResolvedAt *time.Time    `json:"resolved_at"`
+}
+
+// IssueTransitionResponse represents issue transition response
+type IssueTransitionResponse struct {
+	ID        uint         `json:"id"`
+	UserID    uint         `json:"user_id"`
+	User      UserResponse `json:"user"`
+	FromState IssueState   `json:"from_state"`
+	ToState   IssueState   `json:"to_state"`
+	Note      string       `json:"note"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// IssueBoardColumn holds the issues of a project in one state
+type IssueBoardColumn struct {
+	State  IssueState        `json:"state"`
+	Count  int64             `json:"count"`
+	Issues []CommentResponse `json:"issues"`
+}
+
+// IssueHotspot counts the unresolved issues of a service
+type IssueHotspot struct {
+	ServiceID  uint             `json:"service_id"`
+	Service    *ServiceResponse `json:"service,omitempty"`
+	Open       int64            `json:"open"`
+	InProgress int64            `json:"in_progress"`
+	Critical   int64            `json:"critical"`
+	High       int64            `json:"high"`
+	Overdue    int64            `json:"overdue"`
+	OldestOpen *time.Time       `json:"oldest_open"`
+	Unresolved int64            `json:"unresolved"`
+}
+
+// TableName specifies the table name for IssueTransition
+func (IssueTransition) TableName() string {
+	return "issue_transitions"
+}
+
+// ToResponse converts issue transition to IssueTransitionResponse
+func (t *IssueTransition) ToResponse() IssueTransitionResponse {
+	response := IssueTransitionResponse{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		FromState: t.FromState,
+		ToState:   t.ToState,
+		Note:      t.Note,
+		CreatedAt: t.CreatedAt,
+	}
+
+	// Include user if loaded
+	if t.User.ID != 0 {
+		response.User = t.User.ToResponse()
+	}
+
+	return response
+}
+
+// BeforeCreate runs before creating an issue transition
+func (t *IssueTransition) BeforeCreate(tx *gorm.DB) error {
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+// IsIssue reports whether the issue workflow applies to the comment
+func (c *Comment) IsIssue() bool {
+	return c.Type == CommentTypeIssue && c.IssueState != nil
+}
+
+// IssueResponse returns the workflow data of an issue comment, or nil
+func (c *Comment) IssueResponse() *IssueResponse {
+	if !c.IsIssue() {
+		return nil
+	}
+
+	response := &IssueResponse{
+		State:      *c.IssueState,
+		AssigneeID: c.AssigneeID,
+		ResolvedAt: c.ResolvedAt,
+	}
+	if c.Priority != nil {
+		response.Priority = *c.Priority
+	}
+	if c.DueDate != nil {
+		dueDate := c.DueDate.Format(dueDateLayout)
+		response.DueDate = &dueDate
+		response.Overdue = !response.State.IsClosed() && dueDate < time.Now().Format(dueDateLayout)
+	}
+
+	// Include assignee if loaded
+	if c.Assignee != nil && c.Assignee.ID != 0 {
+		assignee := c.Assignee.ToResponse()
+		response.Assignee = &assignee
+	}
+
+	return response
+}
+
+// ParseDueDate parses a YYYY-MM-DD due date. An empty date is no date.
+func ParseDueDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse(dueDateLayout, value)
+	if err != nil {
+		return nil, ErrInvalidDueDate
+	}
+	return &date, nil
+}
+
+// OpenIssue starts the workflow of a new issue comment
+func OpenIssue(comment *Comment, priority IssuePriority) {
+	state := IssueOpen
+	if priority == "" {
+		priority = IssuePriorityMedium
+	}
+	comment.IssueState = &state
+	comment.Priority = &priority
+}
+
+// TransitionIssue moves an issue to a new state and records the transition.
+// resolved_at follows the state so boards can tell when an issue was closed.
+func TransitionIssue(tx *gorm.DB, comment *Comment, userID uint, next IssueState, note string) error {
+	var from IssueState
+	if comment.IssueState != nil {
+		from = *comment.IssueState
+	}
+
+	if next.IsClosed() {
+		now := time.Now()
+		comment.ResolvedAt = &now
+	} else {
+		comment.ResolvedAt = nil
+	}
+	comment.IssueState = &next
+
+	return tx.Create(&IssueTransition{
+		CommentID: comment.ID,
+		UserID:    userID,
+		FromState: from,
+		ToState:   next,
+		Note:      note,
+	}).Error
+}
+
+// IsProjectMember reports whether a user was granted access to a project, as
+// opposed to only seeing it because it is public
+func IsProjectMember(db *gorm.DB, project *Project, user *User) bool {
+	private := *project
+	private.Visibility = "private"
+	return GetProjectAccess(db, &private, user).CanView()
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIssueStateTransitions(t *testing.T) {
+	tests := []struct {
+		from, to IssueState
+		allowed  bool
+	}{
+		{IssueOpen, IssueInProgress, true},
+		{IssueOpen, IssueWontFix, true},
+		{IssueInProgress, IssueResolved, true},
+		{IssueInProgress, IssueOpen, true},
+		{IssueResolved, IssueOpen, true},
+		{IssueResolved, IssueInProgress, false},
+		{IssueWontFix, IssueResolved, false},
+		{IssueOpen, IssueState("closed"), false},
+	}
+	for _, test := range tests {
+		if got := test.from.CanTransitionTo(test.to); got != test.allowed {
+			t.Errorf("%s -> %s: got %v, want %v", test.from, test.to, got, test.allowed)
+		}
+	}
+
+	if IssueState("closed").IsValid() || !IssueWontFix.IsValid() {
+		t.Error("unexpected state validity")
+	}
+}
+
+func TestIssueResponse(t *testing.T) {
+	comment := Comment{Type: "general"}
+	if comment.IssueResponse() != nil {
+		t.Fatal("expected no issue data on a general comment")
+	}
+
+	comment.Type = CommentTypeIssue
+	OpenIssue(&comment, "")
+	yesterday := time.Now().AddDate(0, 0, -1)
+	comment.DueDate = &yesterday
+
+	issue := comment.IssueResponse()
+	if issue == nil || issue.State != IssueOpen || issue.Priority != IssuePriorityMedium {
+		t.Fatalf("unexpected issue data: %+v", issue)
+	}
+	if !issue.Overdue || *issue.DueDate != yesterday.Format("2006-01-02") {
+		t.Errorf("expected issue to be overdue: %+v", issue)
+	}
+
+	resolved := IssueResolved
+	comment.IssueState = &resolved
+	if comment.IssueResponse().Overdue {
+		t.Error("closed issues are never overdue")
+	}
+}
+
+func TestParseDueDate(t *testing.T) {
+	date, err := ParseDueDate("2026-03-01")
+	if err != nil || date.Format("2006-01-02") != "2026-03-01" {
+		t.Errorf("unexpected parse result: %v, %v", date, err)
+	}
+	if date, err := ParseDueDate(" "); date != nil || err != nil {
+		t.Errorf("expected no date, got %v, %v", date, err)
+	}
+	if _, err := ParseDueDate("01/03/2026"); err != ErrInvalidDueDate {
+		t.Errorf("expected invalid date error, got %v", err)
+	}
+}
@@ -0,0 +1,281 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// MetadataFieldType represents the type of a custom service attribute
+type MetadataFieldType string
+
+const (
+	MetadataString MetadataFieldType = "string"
+	MetadataEnum   MetadataFieldType = "enum"
+	MetadataURL    MetadataFieldType = "url"
+	MetadataNumber MetadataFieldType = "number"
+	MetadataBool   MetadataFieldType = "bool"
+	MetadataUser   MetadataFieldType = "user"
+)
+
+var validMetadataFieldTypes = map[MetadataFieldType]bool{
+	MetadataString: true,
+	MetadataEnum:   true,
+	MetadataURL:    true,
+	MetadataNumber: true,
+	MetadataBool:   true,
+	MetadataUser:   true,
+}
+
+func (t MetadataFieldType) IsValid() bool {
+	return validMetadataFieldTypes[t]
+}
+
+var metadataKeyPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{0,49}$`)
+
+// MetadataField describes one typed attribute of Service.Metadata
+type MetadataField struct {
+	Key         string            `json:"key"`
+	Label       string            `json:"label,omitempty"`
+	Type        MetadataFieldType `json:"type"`
+	Required    bool              `json:"required"`
+	Options     []string          `json:"options,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+// MetadataSchema represents the project-defined schema for Service.Metadata
+type MetadataSchema struct {
+	Fields          []MetadataField `json:"fields"`
+	AllowAdditional bool            `json:"allow_additional"`
+}
+
+// FieldError represents a validation error on a single field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// MetadataValidationError is returned when service metadata does not match
+// the project's schema
+type MetadataValidationError struct {
+	Service string       `json:"service"`
+	Fields  []FieldError `json:"fields"`
+}
+
+func (e *MetadataValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+	return fmt.Sprintf("invalid metadata for service %q: %s", e.Service, strings.Join(messages, "; "))
+}
+
+// UpdateMetadataSchemaRequest represents metadata schema update data
+type UpdateMetadataSchemaRequest struct {
+	Fields          []MetadataField `json:"fields"`
+	AllowAdditional bool            `json:"allow_additional"`
+}
+
+// Value implements driver.Valuer so the schema is stored as jsonb
+func (s MetadataSchema) Value() (driver.Value, error) {
+	if s.IsEmpty() && !s.AllowAdditional {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
+// Scan implements sql.Scanner so the schema can be read from jsonb
+func (s *MetadataSchema) Scan(value interface{}) error {
+	if value == nil {
+		*s = MetadataSchema{}
+		return nil
+	}
+
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported metadata schema value: %T", value)
+	}
+
+	return json.Unmarshal(data, s)
+}
+
+// IsEmpty reports whether the schema defines no fields, in which case
+// metadata is not validated
+func (s MetadataSchema) IsEmpty() bool {
+	return len(s.Fields) == 0
+}
+
+// IsValidMetadataKey reports whether key can be used as a metadata attribute name
+func IsValidMetadataKey(key string) bool {
+	return metadataKeyPattern.MatchString(key)
+}
+
+// Field returns the schema field with the given key
+func (s MetadataSchema) Field(key string) (MetadataField, bool) {
+	for _, field := range s.Fields {
+		if field.Key == key {
+			return field, true
+		}
+	}
+	return MetadataField{}, false
+}
+
+// Check validates the schema definition itself
+func (s MetadataSchema) Check() []FieldError {
+	var errs []FieldError
+	seen := make(map[string]bool)
+
+	for i, field := range s.Fields {
+		name := fmt.Sprintf("fields[%d]", i)
+
+		if !IsValidMetadataKey(field.Key) {
+			errs = append(errs, FieldError{Field: name + ".key", Message: "must start with a letter and contain only letters, digits and underscores (max 50)"})
+		} else if seen[field.Key] {
+			errs = append(errs, FieldError{Field: name + ".key", Message: fmt.Sprintf("duplicate key %q", field.Key)})
+		}
+		seen[field.Key] = true
+
+		if !field.Type.IsValid() {
+			errs = append(errs, FieldError{Field: name + ".type", Message: "must be one of: string, enum, url, number, bool, user"})
+		}
+
+		if field.Type == MetadataEnum && len(field.Options) == 0 {
+			errs = append(errs, FieldError{Field: name + ".options", Message: "enum fields require at least one option"})
+		}
+		if field.Type != MetadataEnum && len(field.Options) > 0 {
+			errs = append(errs, FieldError{Field: name + ".options", Message: "options are only allowed on enum fields"})
+		}
+	}
+
+	return errs
+}
+
+// Validate checks metadata against the schema. userExists is used to
+// resolve user reference fields.
+func (s MetadataSchema) Validate(metadata interface{}, userExists func(id uint) bool) []FieldError {
+	if s.IsEmpty() {
+		return nil
+	}
+
+	values := map[string]interface{}{}
+	if metadata != nil {
+		object, ok := metadata.(map[string]interface{})
+		if !ok {
+			return []FieldError{{Field: "metadata", Message: "must be an object"}}
+		}
+		values = object
+	}
+
+	var errs []FieldError
+
+	for _, field := range s.Fields {
+		value, present := values[field.Key]
+		if !present || value == nil {
+			if field.Required {
+				errs = append(errs, FieldError{Field: "metadata." + field.Key, Message: "is required"})
+			}
+			continue
+		}
+
+		if message := field.validateValue(value, userExists); message != "" {
+			errs = append(errs, FieldError{Field: "metadata." + field.Key, Message: message})
+		}
+	}
+
+	if !s.AllowAdditional {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if _, ok := s.Field(key); !ok {
+				errs = append(errs, FieldError{Field: "metadata." + key, Message: "is not defined in the project schema"})
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateValue returns an error message when value does not match the field type
+func (f MetadataField) validateValue(value interface{}, userExists func(id uint) bool) string {
+	switch f.Type {
+	case MetadataString:
+		if _, ok := value.(string); !ok {
+			return "must be a string"
+		}
+
+	case MetadataEnum:
+		str, ok := value.(string)
+		if !ok {
+			return "must be a string"
+		}
+		for _, option := range f.Options {
+			if option == str {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(f.Options, ", ")
+
+	case MetadataURL:
+		str, ok := value.(string)
+		if !ok {
+			return "must be a string"
+		}
+		parsed, err := url.ParseRequestURI(str)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return "must be an http or https URL"
+		}
+
+	case MetadataNumber:
+		if _, ok := value.(float64); !ok {
+			return "must be a number"
+		}
+
+	case MetadataBool:
+		if _, ok := value.(bool); !ok {
+			return "must be a boolean"
+		}
+
+	case MetadataUser:
+		number, ok := value.(float64)
+		if !ok || number <= 0 || number != math.Trunc(number) {
+			return "must be a user ID"
+		}
+		if userExists != nil && !userExists(uint(number)) {
+			return "user not found"
+		}
+	}
+
+	return ""
+}
+
+// ValidateServiceMetadata validates metadata against the schema of a project
+func ValidateServiceMetadata(db *gorm.DB, projectID uint, metadata interface{}) ([]FieldError, error) {
+	var project Project
+	if err := db.Select("id", "metadata_schema").First(&project, projectID).Error; err != nil {
+		return nil, err
+	}
+
+	userExists := func(id uint) bool {
+		var count int64
+		db.Model(&User{}).Where("id = ? AND deleted_at IS NULL", id).Count(&count)
+		return count > 0
+	}
+
+	return project.MetadataSchema.Validate(metadata, userExists), nil
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestMetadataSchema_Check(t *testing.T) {
+	schema := MetadataSchema{
+		Fields: []MetadataField{
+			{Key: "tier", Type: MetadataEnum, Options: []string{"1", "2"}},
+			{Key: "tier", Type: MetadataString},
+			{Key: "1bad", Type: MetadataString},
+			{Key: "owner", Type: "person"},
+			{Key: "level", Type: MetadataEnum},
+		},
+	}
+
+	errs := schema.Check()
+	if len(errs) != 4 {
+		t.Fatalf("expected 4 schema errors, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestMetadataSchema_Validate(t *testing.T) {
+	schema := MetadataSchema{
+		Fields: []MetadataField{
+			{Key: "tier", Type: MetadataEnum, Required: true, Options: []string{"tier-1", "tier-2"}},
+			{Key: "runbook", Type: MetadataURL},
+			{Key: "replicas", Type: MetadataNumber},
+			{Key: "pci", Type: MetadataBool},
+			{Key: "owner", Type: MetadataUser},
+		},
+	}
+	userExists := func(id uint) bool { return id == 7 }
+
+	valid := map[string]interface{}{
+		"tier":     "tier-1",
+		"runbook":  "https://wiki.example.com/runbook",
+		"replicas": float64(3),
+		"pci":      true,
+		"owner":    float64(7),
+	}
+	if errs := schema.Validate(valid, userExists); len(errs) != 0 {
+		t.Errorf("expected valid metadata, got %v", errs)
+	}
+
+	invalid := map[string]interface{}{
+		"tier":     "tier-9",
+		"runbook":  "wiki/runbook",
+		"replicas": "three",
+		"pci":      "yes",
+		"owner":    float64(8),
+		"extra":    "value",
+	}
+	errs := schema.Validate(invalid, userExists)
+	if len(errs) != 6 {
+		t.Fatalf("expected 6 field errors, got %d: %v", len(errs), errs)
+	}
+
+	if errs := schema.Validate(nil, userExists); len(errs) != 1 || errs[0].Field != "metadata.tier" {
+		t.Errorf("expected missing required field error, got %v", errs)
+	}
+
+	if errs := schema.Validate("not an object", userExists); len(errs) != 1 {
+		t.Errorf("expected object error, got %v", errs)
+	}
+}
+
+func TestMetadataSchema_ValidateEmptySchema(t *testing.T) {
+	var schema MetadataSchema
+	if errs := schema.Validate(map[string]interface{}{"anything": 1}, nil); errs != nil {
+		t.Errorf("expected empty schema to accept any metadata, got %v", errs)
+	}
+}
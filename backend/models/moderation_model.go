@@ -0,0 +1,286 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CommentPolicy decides who can comment on a project
+type CommentPolicy string
+
+const (
+	// CommentsDisabled closes the discussion; existing comments stay readable
+	CommentsDisabled CommentPolicy = "disabled"
+	// CommentsMembers lets collaborators and team members comment, but not
+	// the users who only read a public project
+	CommentsMembers CommentPolicy = "members"
+	// CommentsAnyone lets everyone who can view the project comment
+	CommentsAnyone CommentPolicy = "anyone"
+)
+
+// IsValid reports whether p is a known policy
+func (p CommentPolicy) IsValid() bool {
+	return p == CommentsDisabled || p == CommentsMembers || p == CommentsAnyone
+}
+
+// ReportStatus represents the state of a comment report
+type ReportStatus string
+
+const (
+	ReportOpen      ReportStatus = "open"
+	ReportDismissed ReportStatus = "dismissed"
+	ReportActioned  ReportStatus = "actioned"
+)
+
+// RestrictionKind represents a moderation measure against a user
+type RestrictionKind string
+
+const (
+	// RestrictionMute stops a user from commenting and reacting
+	RestrictionMute RestrictionKind = "mute"
+	// RestrictionBan also stops a user from reading a public project
+	RestrictionBan RestrictionKind = "ban"
+)
+
+// CommentReport is a report of a comment to the owners of its project
+type CommentReport struct {
+	ID         uint         `json:"id" gorm:"primaryKey"`
+	CommentID  uint         `json:"comment_id" gorm:"not null"`
+	Comment    Comment      `json:"-" gorm:"foreignKey:CommentID"`
+	ProjectID  uint         `json:"project_id" gorm:"not null"`
+	ReporterID uint         `json:"reporter_id" gorm:"not null"`
+	Reporter   User         `json:"-" gorm:"foreignKey:ReporterID"`
+	Reason     string       `json:"reason" gorm:"not null;size:20"`
+	Details    string       `json:"details" gorm:"type:text"`
+	Status     ReportStatus `json:"status" gorm:"not null;default:open;size:20"`
+	ResolvedBy *uint        `json:"resolved_by"`
+	ResolvedAt *time.Time   `json:"resolved_at"`
+	Resolution string       `json:"resolution" gorm:"type:text"`
+	CreatedAt  time.Time    `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// ProjectRestriction mutes or bans a user on a project. It applies until it
+// is lifted or expires.
+type ProjectRestriction struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	ProjectID uint            `json:"project_id" gorm:"not null"`
+	UserID    uint            `json:"user_id" gorm:"not null"`
+	User      User            `json:"-" gorm:"foreignKey:UserID"`
+	Kind      RestrictionKind `json:"kind" gorm:"not null;size:20"`
+	Reason    string          `json:"reason" gorm:"type:text;not null"`
+	CreatedBy *uint           `json:"created_by"`
+	CreatedAt time.Time       `json:"created_at" gorm:"not null;default:now()"`
+	ExpiresAt *time.Time      `json:"expires_at"`
+	LiftedBy  *uint           `json:"lifted_by"`
+	LiftedAt  *time.Time      `json:"lifted_at"`
+}
+
+// UpdateCommentSettingsRequest represents project comment settings data
+type UpdateCommentSettingsRequest struct {
+	CommentPolicy CommentPolicy `json:"comment_policy" binding:"required,oneof=disabled members anyone"`
+}
+
+// ReportCommentRequest represents comment report data
+type ReportCommentRequest struct {
+	Reason  string `json:"reason" binding:"required,oneof=spam abuse off_topic other"`
+	Details string `json:"details" binding:"max=1000"`
+}
+
+// ResolveReportRequest represents the decision on a report. remove deletes
+// the comment; either way every open report of the comment is closed.
+type ResolveReportRequest struct {
+	Action string `json:"action" binding:"required,oneof=dismiss remove"`
+	Note   string `json:"note" binding:"max=1000"`
+}
+
+// CreateRestrictionRequest represents mute or ban data
+type CreateRestrictionRequest struct {
+	UserID    uint            `json:"user_id" binding:"required"`
+	Kind      RestrictionKind `json:"kind" binding:"required,oneof=mute ban"`
+	Reason    string          `json:"reason" binding:"required,min=1,max=1000"`
+	ExpiresAt *time.Time      `json:"expires_at"`
+}
+
+// CommentReportResponse represents comment report response
+type CommentReportResponse struct {
+	ID         uint             `json:"id"`
+	CommentID  uint             `json:"comment_id"`
+	Comment    *CommentResponse `json:"comment,omitempty"`
+	ProjectID  uint             `json:"project_id"`
+	ReporterID uint             `json:"reporter_id"`
+	Reporter   UserResponse     `json:"reporter"`
+	Reason     string           `json:"reason"`
+	Details    string           `json:"details"`
+	Status     ReportStatus     `json:"status"`
+	ResolvedBy *uint            `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time       `json:"resolved_at,omitempty"`
+	Resolution string           `json:"resolution,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+// ProjectRestrictionResponse represents project restriction response
+type ProjectRestrictionResponse struct {
+	ID        uint            `json:"id"`
+	ProjectID uint            `json:"project_id"`
+	UserID    uint            `json:"user_id"`
+	User      UserResponse    `json:"user"`
+	Kind      RestrictionKind `json:"kind"`
+	Reason    string          `json:"reason"`
+	CreatedBy *uint           `json:"created_by"`
+	CreatedAt time.Time       `json:"created_at"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+	LiftedBy  *uint           `json:"lifted_by,omitempty"`
+	LiftedAt  *time.Time      `json:"lifted_at,omitempty"`
+	Active    bool            `json:"active"`
+}
+
+// TableName specifies the table name for CommentReport
+func (CommentReport) TableName() string {
+	return "comment_reports"
+}
+
+// TableName specifies the table name for ProjectRestriction
+func (ProjectRestriction) TableName() string {
+	return "project_restrictions"
+}
+
+// BeforeCreate runs before creating a comment report
+func (r *CommentReport) BeforeCreate(tx *gorm.DB) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+// BeforeCreate runs before creating a project restriction
+func (r *ProjectRestriction) BeforeCreate(tx *gorm.DB) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+// ToResponse converts comment report to CommentReportResponse
+func (r *CommentReport) ToResponse() CommentReportResponse {
+	response := CommentReportResponse{
+		ID:         r.ID,
+		CommentID:  r.CommentID,
+		ProjectID:  r.ProjectID,
+		ReporterID: r.ReporterID,
+		Reason:     r.Reason,
+		Details:    r.Details,
+		Status:     r.Status,
+		ResolvedBy: r.ResolvedBy,
+		ResolvedAt: r.ResolvedAt,
+		Resolution: r.Resolution,
+		CreatedAt:  r.CreatedAt,
+	}
+
+	// Include reporter if loaded
+	if r.Reporter.ID != 0 {
+		response.Reporter = r.Reporter.ToResponse()
+	}
+
+	// Include comment if loaded
+	if r.Comment.ID != 0 {
+		comment := r.Comment.ToResponse()
+		response.Comment = &comment
+	}
+
+	return response
+}
+
+// IsActive reports whether the restriction applies at now
+func (r *ProjectRestriction) IsActive(now time.Time) bool {
+	return r.LiftedAt == nil && (r.ExpiresAt == nil || r.ExpiresAt.After(now))
+}
+
+// ToResponse converts project restriction to ProjectRestrictionResponse
+func (r *ProjectRestriction) ToResponse() ProjectRestrictionResponse {
+	response := ProjectRestrictionResponse{
+		ID:        r.ID,
+		ProjectID: r.ProjectID,
+		UserID:    r.UserID,
+		Kind:      r.Kind,
+		Reason:    r.Reason,
+		CreatedBy: r.CreatedBy,
+		CreatedAt: r.CreatedAt,
+		ExpiresAt: r.ExpiresAt,
+		LiftedBy:  r.LiftedBy,
+		LiftedAt:  r.LiftedAt,
+		Active:    r.IsActive(time.Now()),
+	}
+
+	// Include user if loaded
+	if r.User.ID != 0 {
+		response.User = r.User.ToResponse()
+	}
+
+	return response
+}
+
+// activeRestrictionCondition matches the restrictions in force
+const activeRestrictionCondition = `lifted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())`
+
+// bannedProjectsSQL selects the IDs of the projects a user (@user) is
+// banned from
+const bannedProjectsSQL = `SELECT project_id FROM project_restrictions
+	WHERE user_id = @user AND kind = 'ban' AND ` + activeRestrictionCondition
+
+// ActiveRestriction returns the restriction in force for a user on a
+// project, or nil. A ban outweighs a mute.
+func ActiveRestriction(db *gorm.DB, projectID, userID uint) (*ProjectRestriction, error) {
+	var restriction ProjectRestriction
+	err := db.Where("project_id = ? AND user_id = ?", projectID, userID).
+		Where(activeRestrictionCondition).
+		Order("CASE kind WHEN 'ban' THEN 0 ELSE 1 END, created_at DESC").
+		Limit(1).Find(&restriction).Error
+	if err != nil || restriction.ID == 0 {
+		return nil, err
+	}
+	return &restriction, nil
+}
+
+// LiftRestrictions lifts the restrictions in force for a user on a project
+// and returns how many were lifted
+func LiftRestrictions(tx *gorm.DB, projectID, userID, liftedBy uint) (int64, error) {
+	result := tx.Model(&ProjectRestriction{}).
+		Where("project_id = ? AND user_id = ?", projectID, userID).
+		Where(activeRestrictionCondition).
+		Updates(map[string]interface{}{"lifted_at": time.Now(), "lifted_by": liftedBy})
+	return result.RowsAffected, result.Error
+}
+
+// CommentDenial returns why a user cannot write comments or reactions on a
+// project, or "" when they can. Project owners are never restricted, so
+// they can still moderate a closed discussion.
+func CommentDenial(db *gorm.DB, project *Project, user *User, access ProjectAccess) (string, error) {
+	if !access.CanView() {
+		return "Access denied", nil
+	}
+	if access.CanManage() {
+		return "", nil
+	}
+
+	switch CommentPolicy(project.CommentPolicy) {
+	case CommentsDisabled:
+		return "Comments are disabled on this project", nil
+	case CommentsMembers:
+		if !IsProjectMember(db, project, user) {
+			return "Only project members can comment on this project", nil
+		}
+	}
+
+	restriction, err := ActiveRestriction(db, project.ID, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if restriction != nil {
+		if restriction.Kind == RestrictionBan {
+			return "You are banned from this project", nil
+		}
+		return "You are muted on this project", nil
+	}
+	return "", nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCommentPolicyIsValid(t *testing.T) {
+	for _, policy := range []CommentPolicy{CommentsDisabled, CommentsMembers, CommentsAnyone} {
+		if !policy.IsValid() {
+			t.Errorf("%q should be valid", policy)
+		}
+	}
+	if CommentPolicy("everyone").IsValid() || CommentPolicy("").IsValid() {
+		t.Error("unknown policies should not be valid")
+	}
+}
+
+func TestProjectRestrictionIsActive(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	cases := []struct {
+		name        string
+		restriction ProjectRestriction
+		active      bool
+	}{
+		{"permanent", ProjectRestriction{}, true},
+		{"not expired", ProjectRestriction{ExpiresAt: &future}, true},
+		{"expired", ProjectRestriction{ExpiresAt: &past}, false},
+		{"lifted", ProjectRestriction{ExpiresAt: &future, LiftedAt: &past}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.restriction.IsActive(now); got != tc.active {
+			t.Errorf("%s: active = %v, want %v", tc.name, got, tc.active)
+		}
+	}
+}
+
+func TestCommentDenialWithoutDatabase(t *testing.T) {
+	project := &Project{ID: 1, CommentPolicy: string(CommentsDisabled)}
+	user := &User{ID: 2}
+
+	// Owners can still comment on a closed discussion
+	denial, err := CommentDenial(nil, project, user, ProjectAccess{Role: ProjectOwnerRole})
+	if err != nil || denial != "" {
+		t.Errorf("owner denied: %q, %v", denial, err)
+	}
+
+	denial, err = CommentDenial(nil, project, user, ProjectAccess{Role: ProjectEditorRole})
+	if err != nil || denial != "Comments are disabled on this project" {
+		t.Errorf("editor on a closed discussion: %q, %v", denial, err)
+	}
+
+	denial, _ = CommentDenial(nil, project, user, ProjectAccess{})
+	if denial != "Access denied" {
+		t.Errorf("user without access: %q", denial)
+	}
+}
@@ -0,0 +1,331 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationType represents why a user is notified
+type NotificationType string
+
+const (
+	NotificationMention        NotificationType = "mention"
+	NotificationReply          NotificationType = "reply"
+	NotificationServiceComment NotificationType = "service_comment"
+)
+
+// notificationExcerptLength is the number of characters of the comment
+// shown in the inbox
+const notificationExcerptLength = 140
+
+// Notification represents an inbox entry of a user about a comment
+type Notification struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	UserID    uint             `json:"user_id" gorm:"not null"`
+	User      User             `json:"-" gorm:"foreignKey:UserID"`
+	ActorID   uint             `json:"actor_id" gorm:"not null"`
+	Actor     User             `json:"-" gorm:"foreignKey:ActorID"`
+	Type      NotificationType `json:"type" gorm:"not null;size:30"`
+	ProjectID uint             `json:"project_id" gorm:"not null"`
+	Project   Project          `json:"-" gorm:"foreignKey:ProjectID"`
+	CommentID uint             `json:"comment_id" gorm:"not null"`
+	Comment   Comment          `json:"-" gorm:"foreignKey:CommentID"`
+	ServiceID *uint            `json:"service_id"`
+	Service   *Service         `json:"-" gorm:"foreignKey:ServiceID"`
+	ReadAt    *time.Time       `json:"read_at"`
+	CreatedAt time.Time        `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// CommentMention records a user mentioned in a comment
+type CommentMention struct {
+	CommentID uint `json:"comment_id" gorm:"primaryKey"`
+	UserID    uint `json:"user_id" gorm:"primaryKey"`
+}
+
+// NotificationResponse represents notification response
+type NotificationResponse struct {
+	ID          uint             `json:"id"`
+	Type        NotificationType `json:"type"`
+	ActorID     uint             `json:"actor_id"`
+	Actor       UserResponse     `json:"actor"`
+	ProjectID   uint             `json:"project_id"`
+	ProjectName string           `json:"project_name,omitempty"`
+	CommentID   uint             `json:"comment_id"`
+	ServiceID   *uint            `json:"service_id"`
+	ServiceName string           `json:"service_name,omitempty"`
+	Excerpt     string           `json:"excerpt"`
+	Read        bool             `json:"read"`
+	ReadAt      *time.Time       `json:"read_at"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// TableName specifies the table name for Notification
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// TableName specifies the table name for CommentMention
+func (CommentMention) TableName() string {
+	return "comment_mentions"
+}
+
+// ToResponse converts notification to NotificationResponse
+func (n *Notification) ToResponse() NotificationResponse {
+	response := NotificationResponse{
+		ID:        n.ID,
+		Type:      n.Type,
+		ActorID:   n.ActorID,
+		ProjectID: n.ProjectID,
+		CommentID: n.CommentID,
+		ServiceID: n.ServiceID,
+		Read:      n.ReadAt != nil,
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+	}
+
+	// Include relations if loaded
+	if n.Actor.ID != 0 {
+		response.Actor = n.Actor.ToResponse()
+	}
+	if n.Project.ID != 0 {
+		response.ProjectName = n.Project.Name
+	}
+	if n.Comment.ID != 0 {
+		response.Excerpt = excerpt(n.Comment.Content, notificationExcerptLength)
+	}
+	if n.Service != nil && n.Service.ID != 0 {
+		response.ServiceName = n.Service.Name
+	}
+
+	return response
+}
+
+// BeforeCreate runs before creating a notification
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+// excerpt shortens text to at most length characters on a word boundary
+func excerpt(text string, length int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	cut := string(runes[:length])
+	if i := strings.LastIndex(cut, " "); i > length/2 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+// mentionPattern matches @email and @name mentions. The @ must not follow a
+// word character so email addresses in the text are not taken as mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@.])@([\p{L}\p{N}][\p{L}\p{N}._%+\-]*(?:@[\p{L}\p{N}\-]+(?:\.[\p{L}\p{N}\-]+)+)?)`)
+
+// ParseMentions returns the lowercased handles mentioned in a comment, in
+// order of appearance and without duplicates
+func ParseMentions(content string) []string {
+	var handles []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		handle := strings.ToLower(strings.TrimRight(match[1], ".-_"))
+		if handle == "" || seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+	}
+	return handles
+}
+
+// normalizeName keeps the lowercased letters and digits of a name so
+// "@jane.doe" and "@JaneDoe" both match "Jane Doe"
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ResolveMentions matches the mentions of a comment against the members of
+// the project. A handle matches an email, a full name, or a first name no
+// other member shares. Handles that match nobody are ignored.
+func ResolveMentions(content string, members []User) []User {
+	handles := ParseMentions(content)
+	if len(handles) == 0 {
+		return nil
+	}
+
+	byEmail := make(map[string]*User, len(members))
+	byName := make(map[string]*User, len(members))
+	byFirstName := make(map[string][]*User, len(members))
+	for i := range members {
+		member := &members[i]
+		byEmail[strings.ToLower(member.Email)] = member
+		if name := normalizeName(member.Name); name != "" {
+			byName[name] = member
+		}
+		if fields := strings.Fields(member.Name); len(fields) > 0 {
+			first := normalizeName(fields[0])
+			byFirstName[first] = append(byFirstName[first], member)
+		}
+	}
+
+	var mentioned []User
+	seen := make(map[uint]bool)
+	for _, handle := range handles {
+		member := byEmail[handle]
+		if member == nil && !strings.Contains(handle, "@") {
+			name := normalizeName(handle)
+			if member = byName[name]; member == nil && len(byFirstName[name]) == 1 {
+				member = byFirstName[name][0]
+			}
+		}
+		if member != nil && !seen[member.ID] {
+			seen[member.ID] = true
+			mentioned = append(mentioned, *member)
+		}
+	}
+	return mentioned
+}
+
+// ProjectMemberIDsSQL selects the users granted access to a project (@project):
+// owner, active collaborators, members of granted teams and organization
+// admins. Visitors of public projects are not members.
+const ProjectMemberIDsSQL = `SELECT owner_id FROM projects WHERE id = @project
+	UNION SELECT user_id FROM project_collaborators WHERE project_id = @project AND state = 'active'
+	UNION SELECT tm.user_id FROM project_teams pt JOIN team_members tm ON tm.team_id = pt.team_id WHERE pt.project_id = @project
+	UNION SELECT om.user_id FROM organization_members om JOIN projects p ON p.organization_id = om.organization_id
+		WHERE p.id = @project AND om.role = 'admin'`
+
+// ProjectMembers returns the active members of a project
+func ProjectMembers(db *gorm.DB, projectID uint) ([]User, error) {
+	var members []User
+	err := db.Where("id IN ("+ProjectMemberIDsSQL+") AND status = @status",
+		map[string]interface{}{"project": projectID, "status": StatusActive}).
+		Order("id ASC").Find(&members).Error
+	return members, err
+}
+
+// SetCommentMentions replaces the mentions of a comment and returns the users
+// that were not mentioned before
+func SetCommentMentions(tx *gorm.DB, commentID uint, mentioned []User) ([]uint, error) {
+	var previous []uint
+	if err := tx.Model(&CommentMention{}).Where("comment_id = ?", commentID).
+		Pluck("user_id", &previous).Error; err != nil {
+		return nil, err
+	}
+
+	known := make(map[uint]bool, len(previous))
+	for _, userID := range previous {
+		known[userID] = true
+	}
+
+	ids := make([]uint, 0, len(mentioned))
+	var added []uint
+	for _, user := range mentioned {
+		ids = append(ids, user.ID)
+		if !known[user.ID] {
+			added = append(added, user.ID)
+		}
+	}
+
+	query := tx.Where("comment_id = ?", commentID)
+	if len(ids) > 0 {
+		query = query.Where("user_id NOT IN ?", ids)
+	}
+	if err := query.Delete(&CommentMention{}).Error; err != nil {
+		return nil, err
+	}
+
+	for _, userID := range added {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&CommentMention{CommentID: commentID, UserID: userID}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return added, nil
+}
+
+// NotifyComment creates the notifications of a comment: mentioned users,
+// then, for new comments, the author of the parent comment and the owners of
+// the service. Each user gets a single notification, the author none, and
+// only users who can see the project are notified.
+func NotifyComment(tx *gorm.DB, comment *Comment, project *Project, mentioned []uint, isNew bool) error {
+	recipients := make(map[uint]NotificationType)
+	var order []uint
+	add := func(userID uint, kind NotificationType) {
+		if _, ok := recipients[userID]; ok || userID == comment.UserID {
+			return
+		}
+		recipients[userID] = kind
+		order = append(order, userID)
+	}
+
+	for _, userID := range mentioned {
+		add(userID, NotificationMention)
+	}
+
+	if isNew && comment.ParentID != nil {
+		var parent Comment
+		if err := tx.Select("id", "user_id").First(&parent, *comment.ParentID).Error; err != nil {
+			return err
+		}
+		add(parent.UserID, NotificationReply)
+	}
+
+	if isNew && comment.ServiceID != nil {
+		owners, err := ServiceOwnerUserIDs(tx, *comment.ServiceID)
+		if err != nil {
+			return err
+		}
+		for _, userID := range owners {
+			add(userID, NotificationServiceComment)
+		}
+	}
+
+	if len(order) == 0 {
+		return nil
+	}
+
+	var users []User
+	if err := tx.Where("id IN ? AND status = ?", order, StatusActive).Find(&users).Error; err != nil {
+		return err
+	}
+	allowed := make(map[uint]bool, len(users))
+	for i := range users {
+		allowed[users[i].ID] = GetProjectAccess(tx, project, &users[i]).CanView()
+	}
+
+	for _, userID := range order {
+		if !allowed[userID] {
+			continue
+		}
+		if err := tx.Create(&Notification{
+			UserID:    userID,
+			ActorID:   comment.UserID,
+			Type:      recipients[userID],
+			ProjectID: comment.ProjectID,
+			CommentID: comment.ID,
+			ServiceID: comment.ServiceID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	content := "Thanks @Jane.Doe and @bob@example.com, see ops@example.com. cc @jane.doe @alice."
+	want := []string{"jane.doe", "bob@example.com", "alice"}
+	if got := ParseMentions(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseMentions() = %v, want %v", got, want)
+	}
+
+	if got := ParseMentions("no mentions here, email me at x@y.io"); got != nil {
+		t.Errorf("expected no mentions, got %v", got)
+	}
+}
+
+func TestResolveMentions(t *testing.T) {
+	members := []User{
+		{ID: 1, Name: "Jane Doe", Email: "jane@example.com"},
+		{ID: 2, Name: "Bob Martin", Email: "bob@example.com"},
+		{ID: 3, Name: "Alice Smith", Email: "alice@example.com"},
+		{ID: 4, Name: "Alice Jones", Email: "ajones@example.com"},
+	}
+
+	content := "@JaneDoe @BOB@example.com @bob @alice @alicejones @mallory @jane@example.com"
+	var ids []uint
+	for _, user := range ResolveMentions(content, members) {
+		ids = append(ids, user.ID)
+	}
+
+	// @alice is ambiguous, @mallory is not a member, Jane is mentioned twice
+	if want := []uint{1, 2, 4}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("resolved %v, want %v", ids, want)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	if got := excerpt("short  text\nhere", 140); got != "short text here" {
+		t.Errorf("unexpected excerpt %q", got)
+	}
+
+	long := strings.Repeat("word ", 50)
+	got := excerpt(long, 20)
+	if !strings.HasSuffix(got, "…") || len([]rune(got)) > 21 || strings.HasSuffix(got, " …") {
+		t.Errorf("unexpected excerpt %q", got)
+	}
+}
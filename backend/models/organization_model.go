@@ -0,0 +1,371 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrgRole represents the role of a user inside an organization. It is
+// unrelated to the global User.Role: an org admin manages the organization,
+// its teams and its projects, but has no platform-wide privileges.
+type OrgRole string
+
+const (
+	OrgAdminRole  OrgRole = "admin"
+	OrgMemberRole OrgRole = "member"
+)
+
+var validOrgRoles = map[OrgRole]bool{
+	OrgAdminRole:  true,
+	OrgMemberRole: true,
+}
+
+func (r OrgRole) IsValid() bool {
+	return validOrgRoles[r]
+}
+
+// Organization represents a group of users owning projects and teams
+type Organization struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null;size:100"`
+	Slug        string    `json:"slug" gorm:"unique;not null;size:100"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedBy   uint      `json:"created_by" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Relations
+	Members []OrganizationMember `json:"-" gorm:"foreignKey:OrganizationID"`
+	Teams   []Team               `json:"-" gorm:"foreignKey:OrganizationID"`
+}
+
+// OrganizationMember represents the membership of a user in an organization
+type OrganizationMember struct {
+	OrganizationID uint         `json:"organization_id" gorm:"primaryKey"`
+	UserID         uint         `json:"user_id" gorm:"primaryKey"`
+	Organization   Organization `json:"-" gorm:"foreignKey:OrganizationID"`
+	User           User         `json:"-" gorm:"foreignKey:UserID"`
+	Role           OrgRole      `json:"role" gorm:"default:member;size:20"`
+	JoinedAt       time.Time    `json:"joined_at" gorm:"default:now()"`
+}
+
+// Team represents a group of organization members that can be granted
+// access to projects
+type Team struct {
+	ID             uint         `json:"id" gorm:"primaryKey"`
+	OrganizationID uint         `json:"organization_id" gorm:"not null;uniqueIndex:idx_teams_org_slug"`
+	Organization   Organization `json:"-" gorm:"foreignKey:OrganizationID"`
+	Name           string       `json:"name" gorm:"not null;size:100"`
+	Slug           string       `json:"slug" gorm:"not null;size:100;uniqueIndex:idx_teams_org_slug"`
+	Description    string       `json:"description" gorm:"type:text"`
+	// ExternalID is the group ID at the identity provider for SCIM-managed teams
+	ExternalID *string   `json:"external_id" gorm:"size:255"`
+	CreatedAt  time.Time `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Relations
+	Members []TeamMember `json:"-" gorm:"foreignKey:TeamID"`
+}
+
+// TeamMember represents the membership of a user in a team
+type TeamMember struct {
+	TeamID   uint      `json:"team_id" gorm:"primaryKey"`
+	UserID   uint      `json:"user_id" gorm:"primaryKey"`
+	Team     Team      `json:"-" gorm:"foreignKey:TeamID"`
+	User     User      `json:"-" gorm:"foreignKey:UserID"`
+	JoinedAt time.Time `json:"joined_at" gorm:"default:now()"`
+}
+
+// ProjectTeam represents a team granted access to a project with a role
+type ProjectTeam struct {
+	ProjectID uint        `json:"project_id" gorm:"primaryKey"`
+	TeamID    uint        `json:"team_id" gorm:"primaryKey"`
+	Project   Project     `json:"-" gorm:"foreignKey:ProjectID"`
+	Team      Team        `json:"-" gorm:"foreignKey:TeamID"`
+	Role      ProjectRole `json:"role" gorm:"default:viewer;size:50"`
+	GrantedBy uint        `json:"granted_by" gorm:"not null"`
+	GrantedAt time.Time   `json:"granted_at" gorm:"default:now()"`
+}
+
+// CreateOrganizationRequest represents organization creation data
+type CreateOrganizationRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=100"`
+	Slug        string `json:"slug" binding:"required,min=2,max=100"`
+	Description string `json:"description"`
+}
+
+// UpdateOrganizationRequest represents organization update data
+type UpdateOrganizationRequest struct {
+	Name        string  `json:"name" binding:"omitempty,min=2,max=100"`
+	Description *string `json:"description"`
+}
+
+// AddOrganizationMemberRequest represents adding organization member data
+type AddOrganizationMemberRequest struct {
+	Email string  `json:"email" binding:"required,email"`
+	Role  OrgRole `json:"role" binding:"omitempty,oneof=admin member"`
+}
+
+// UpdateOrganizationMemberRequest represents organization member update data
+type UpdateOrganizationMemberRequest struct {
+	Role OrgRole `json:"role" binding:"required,oneof=admin member"`
+}
+
+// CreateTeamRequest represents team creation data
+type CreateTeamRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=100"`
+	Slug        string `json:"slug" binding:"required,min=2,max=100"`
+	Description string `json:"description"`
+}
+
+// UpdateTeamRequest represents team update data
+type UpdateTeamRequest struct {
+	Name        string  `json:"name" binding:"omitempty,min=2,max=100"`
+	Description *string `json:"description"`
+}
+
+// AddTeamMemberRequest represents adding team member data
+type AddTeamMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// GrantProjectTeamRequest represents granting a team access to a project
+type GrantProjectTeamRequest struct {
+	TeamID uint        `json:"team_id" binding:"required"`
+	Role   ProjectRole `json:"role" binding:"omitempty,oneof=owner editor viewer"`
+}
+
+// UpdateProjectTeamRequest represents a team grant update
+type UpdateProjectTeamRequest struct {
+	Role ProjectRole `json:"role" binding:"required,oneof=owner editor viewer"`
+}
+
+// OrganizationResponse represents organization response
+type OrganizationResponse struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	Description string    `json:"description"`
+	CreatedBy   uint      `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Role        OrgRole   `json:"role,omitempty"`
+}
+
+// OrganizationMemberResponse represents organization member response
+type OrganizationMemberResponse struct {
+	UserID   uint         `json:"user_id"`
+	User     UserResponse `json:"user"`
+	Role     OrgRole      `json:"role"`
+	JoinedAt time.Time    `json:"joined_at"`
+}
+
+// TeamResponse represents team response
+type TeamResponse struct {
+	ID             uint                 `json:"id"`
+	OrganizationID uint                 `json:"organization_id"`
+	Name           string               `json:"name"`
+	Slug           string               `json:"slug"`
+	Description    string               `json:"description"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+	Members        []TeamMemberResponse `json:"members,omitempty"`
+}
+
+// TeamMemberResponse represents team member response
+type TeamMemberResponse struct {
+	UserID   uint         `json:"user_id"`
+	User     UserResponse `json:"user"`
+	JoinedAt time.Time    `json:"joined_at"`
+}
+
+// ProjectTeamResponse represents a team grant on a project
+type ProjectTeamResponse struct {
+	TeamID    uint         `json:"team_id"`
+	Team      TeamResponse `json:"team"`
+	Role      ProjectRole  `json:"role"`
+	GrantedBy uint         `json:"granted_by"`
+	GrantedAt time.Time    `json:"granted_at"`
+}
+
+// ToResponse converts organization to OrganizationResponse
+func (o *Organization) ToResponse() OrganizationResponse {
+	return OrganizationResponse{
+		ID:          o.ID,
+		Name:        o.Name,
+		Slug:        o.Slug,
+		Description: o.Description,
+		CreatedBy:   o.CreatedBy,
+		CreatedAt:   o.CreatedAt,
+		UpdatedAt:   o.UpdatedAt,
+	}
+}
+
+// ToResponse converts organization member to OrganizationMemberResponse
+func (om *OrganizationMember) ToResponse() OrganizationMemberResponse {
+	response := OrganizationMemberResponse{
+		UserID:   om.UserID,
+		Role:     om.Role,
+		JoinedAt: om.JoinedAt,
+	}
+
+	// Include user if loaded
+	if om.User.ID != 0 {
+		response.User = om.User.ToResponse()
+	}
+
+	return response
+}
+
+// ToResponse converts team to TeamResponse
+func (t *Team) ToResponse() TeamResponse {
+	response := TeamResponse{
+		ID:             t.ID,
+		OrganizationID: t.OrganizationID,
+		Name:           t.Name,
+		Slug:           t.Slug,
+		Description:    t.Description,
+		CreatedAt:      t.CreatedAt,
+		UpdatedAt:      t.UpdatedAt,
+	}
+
+	// Convert members if loaded
+	for _, member := range t.Members {
+		response.Members = append(response.Members, member.ToResponse())
+	}
+
+	return response
+}
+
+// ToResponse converts team member to TeamMemberResponse
+func (tm *TeamMember) ToResponse() TeamMemberResponse {
+	response := TeamMemberResponse{
+		UserID:   tm.UserID,
+		JoinedAt: tm.JoinedAt,
+	}
+
+	// Include user if loaded
+	if tm.User.ID != 0 {
+		response.User = tm.User.ToResponse()
+	}
+
+	return response
+}
+
+// ToResponse converts project team grant to ProjectTeamResponse
+func (pt *ProjectTeam) ToResponse() ProjectTeamResponse {
+	response := ProjectTeamResponse{
+		TeamID:    pt.TeamID,
+		Role:      pt.Role,
+		GrantedBy: pt.GrantedBy,
+		GrantedAt: pt.GrantedAt,
+	}
+
+	// Include team if loaded
+	if pt.Team.ID != 0 {
+		response.Team = pt.Team.ToResponse()
+	}
+
+	return response
+}
+
+// TableName specifies the table name for Organization
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// TableName specifies the table name for OrganizationMember
+func (OrganizationMember) TableName() string {
+	return "organization_members"
+}
+
+// TableName specifies the table name for Team
+func (Team) TableName() string {
+	return "teams"
+}
+
+// TableName specifies the table name for TeamMember
+func (TeamMember) TableName() string {
+	return "team_members"
+}
+
+// TableName specifies the table name for ProjectTeam
+func (ProjectTeam) TableName() string {
+	return "project_teams"
+}
+
+// BeforeCreate runs before creating an organization
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = time.Now()
+	}
+	if o.UpdatedAt.IsZero() {
+		o.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// BeforeUpdate runs before updating an organization
+func (o *Organization) BeforeUpdate(tx *gorm.DB) error {
+	o.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeCreate runs before creating an organization member
+func (om *OrganizationMember) BeforeCreate(tx *gorm.DB) error {
+	if om.JoinedAt.IsZero() {
+		om.JoinedAt = time.Now()
+	}
+	return nil
+}
+
+// BeforeCreate runs before creating a team
+func (t *Team) BeforeCreate(tx *gorm.DB) error {
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	if t.UpdatedAt.IsZero() {
+		t.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// BeforeUpdate runs before updating a team
+func (t *Team) BeforeUpdate(tx *gorm.DB) error {
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeCreate runs before creating a team member
+func (tm *TeamMember) BeforeCreate(tx *gorm.DB) error {
+	if tm.JoinedAt.IsZero() {
+		tm.JoinedAt = time.Now()
+	}
+	return nil
+}
+
+// BeforeCreate runs before creating a project team grant
+func (pt *ProjectTeam) BeforeCreate(tx *gorm.DB) error {
+	if pt.GrantedAt.IsZero() {
+		pt.GrantedAt = time.Now()
+	}
+	return nil
+}
+
+// GetOrganizationRole returns the role of a user in an organization, or an
+// empty role when the user is not a member
+func GetOrganizationRole(db *gorm.DB, organizationID, userID uint) OrgRole {
+	var member OrganizationMember
+	if err := db.Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		First(&member).Error; err != nil {
+		return ""
+	}
+	return member.Role
+}
+
+// CanManageOrganization reports whether a user can manage an organization,
+// its members and its teams
+func CanManageOrganization(db *gorm.DB, organizationID uint, user *User) bool {
+	return user.Role == AdminRole || GetOrganizationRole(db, organizationID, user.ID) == OrgAdminRole
+}
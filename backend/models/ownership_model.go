@@ -0,0 +1,151 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"gorm.io/gorm"
+)
+
+// RunbookLink represents a link to an operational runbook of a service
+type RunbookLink struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// RunbookLinks is stored as a jsonb array on the service
+type RunbookLinks []RunbookLink
+
+// Value implements driver.Valuer so the links are stored as jsonb
+func (r RunbookLinks) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
+
+// Scan implements sql.Scanner so the links can be read from jsonb
+func (r *RunbookLinks) Scan(value interface{}) error {
+	if value == nil {
+		*r = nil
+		return nil
+	}
+
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported runbook links value: %T", value)
+	}
+
+	return json.Unmarshal(data, r)
+}
+
+// Check validates the runbook links
+func (r RunbookLinks) Check() []FieldError {
+	var errs []FieldError
+	for i, link := range r {
+		name := fmt.Sprintf("runbooks[%d]", i)
+		if link.Title == "" || len(link.Title) > 100 {
+			errs = append(errs, FieldError{Field: name + ".title", Message: "is required (max 100)"})
+		}
+		parsed, err := url.ParseRequestURI(link.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			errs = append(errs, FieldError{Field: name + ".url", Message: "must be an http or https URL"})
+		}
+	}
+	return errs
+}
+
+// UpdateServiceOwnershipRequest replaces the ownership and on-call data of a service
+type UpdateServiceOwnershipRequest struct {
+	OwnerTeamID       *uint        `json:"owner_team_id"`
+	OwnerIDs          []uint       `json:"owner_ids"`
+	EscalationContact string       `json:"escalation_contact" binding:"max=255"`
+	Runbooks          RunbookLinks `json:"runbooks"`
+}
+
+// ServiceOwnershipResponse represents the ownership and on-call data of a service
+type ServiceOwnershipResponse struct {
+	ServiceID         uint           `json:"service_id"`
+	OwnerTeamID       *uint          `json:"owner_team_id"`
+	OwnerTeam         *TeamResponse  `json:"owner_team,omitempty"`
+	Owners            []UserResponse `json:"owners"`
+	EscalationContact string         `json:"escalation_contact"`
+	Runbooks          RunbookLinks   `json:"runbooks"`
+}
+
+// IsOwned reports whether the service has an owning team or owner users.
+// Owners must be loaded.
+func (s *Service) IsOwned() bool {
+	return s.OwnerTeamID != nil || len(s.Owners) > 0
+}
+
+// ToOwnershipResponse converts the ownership data of a service. OwnerTeam and
+// Owners must be loaded.
+func (s *Service) ToOwnershipResponse() ServiceOwnershipResponse {
+	response := ServiceOwnershipResponse{
+		ServiceID:         s.ID,
+		OwnerTeamID:       s.OwnerTeamID,
+		Owners:            make([]UserResponse, 0, len(s.Owners)),
+		EscalationContact: s.EscalationContact,
+		Runbooks:          s.Runbooks,
+	}
+
+	if response.Runbooks == nil {
+		response.Runbooks = RunbookLinks{}
+	}
+
+	// Include owning team if loaded
+	if s.OwnerTeam != nil && s.OwnerTeam.ID != 0 {
+		team := s.OwnerTeam.ToResponse()
+		response.OwnerTeam = &team
+	}
+
+	for _, owner := range s.Owners {
+		response.Owners = append(response.Owners, owner.ToResponse())
+	}
+
+	return response
+}
+
+// OwnedServices restricts a services query to the services owned by a user,
+// directly or through one of their teams
+func OwnedServices(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Where(`(services.id IN (SELECT service_id FROM service_owners WHERE user_id = @user)
+		OR services.owner_team_id IN (SELECT team_id FROM team_members WHERE user_id = @user))`,
+		map[string]interface{}{"user": userID})
+}
+
+// DirectlyOwnedServices restricts a services query to the services a user
+// owns personally
+func DirectlyOwnedServices(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Where("services.id IN (SELECT service_id FROM service_owners WHERE user_id = ?)", userID)
+}
+
+// TeamOwnedServices restricts a services query to the services owned by the
+// teams the user belongs to
+func TeamOwnedServices(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Where("services.owner_team_id IN (SELECT team_id FROM team_members WHERE user_id = ?)", userID)
+}
+
+// UnownedServices restricts a services query to services without owners
+func UnownedServices(db *gorm.DB) *gorm.DB {
+	return db.Where("services.owner_team_id IS NULL AND NOT EXISTS (SELECT 1 FROM service_owners so WHERE so.service_id = services.id)")
+}
+
+// ServiceOwnerUserIDs resolves the users a notification about a service
+// should be routed to: its owner users and the members of its owning team
+func ServiceOwnerUserIDs(db *gorm.DB, serviceID uint) ([]uint, error) {
+	var userIDs []uint
+	err := db.Raw(`SELECT user_id FROM service_owners WHERE service_id = @service
+		UNION
+		SELECT tm.user_id FROM team_members tm JOIN services s ON s.owner_team_id = tm.team_id WHERE s.id = @service`,
+		map[string]interface{}{"service": serviceID}).Scan(&userIDs).Error
+	return userIDs, err
+}
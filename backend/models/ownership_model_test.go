@@ -0,0 +1,41 @@
+package models
+
+import "testing"
+
+func TestRunbookLinksCheck(t *testing.T) {
+	valid := RunbookLinks{
+		{Title: "On-call guide", URL: "https://wiki.example.com/payments/oncall"},
+		{Title: "Restart", URL: "http://runbooks.internal/restart"},
+	}
+	if errs := valid.Check(); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+
+	invalid := RunbookLinks{
+		{Title: "", URL: "https://wiki.example.com"},
+		{Title: "Script", URL: "javascript:alert(1)"},
+		{Title: "Relative", URL: "/runbooks/restart"},
+	}
+	errs := invalid.Check()
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+	if errs[0].Field != "runbooks[0].title" || errs[1].Field != "runbooks[1].url" || errs[2].Field != "runbooks[2].url" {
+		t.Errorf("unexpected fields: %v", errs)
+	}
+}
+
+func TestRunbookLinksScan(t *testing.T) {
+	var links RunbookLinks
+	if err := links.Scan([]byte(`[{"title":"Guide","url":"https://example.com"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 || links[0].Title != "Guide" {
+		t.Errorf("unexpected links: %v", links)
+	}
+
+	value, err := RunbookLinks{}.Value()
+	if err != nil || value != nil {
+		t.Errorf("expected empty links to be stored as NULL, got %v, %v", value, err)
+	}
+}
@@ -20,8 +20,24 @@ type Project struct {
 	CreatedAt   time.Time `json:"created_at" gorm:"not null;default:now()"`
 	UpdatedAt   time.Time `json:"updated_at" gorm:"not null;default:now()"`
 
+	// CommentPolicy decides who can comment, see CommentPolicy
+	CommentPolicy string `json:"comment_policy" gorm:"not null;default:anyone;size:20"`
+
+	// PublicView configures the anonymous view of a public project; nil
+	// uses the server defaults
+	PublicView *PublicView `json:"public_view" gorm:"type:jsonb"`
+
+	// OrganizationID scopes the project under an organization, if any
+	OrganizationID *uint         `json:"organization_id"`
+	Organization   *Organization `json:"-" gorm:"foreignKey:OrganizationID"`
+
+	// MetadataSchema defines the custom attributes allowed in Service.Metadata
+	MetadataSchema MetadataSchema `json:"metadata_schema" gorm:"type:jsonb"`
+
 	// Collaborators relationship
 	Collaborators []ProjectCollaborator `json:"-" gorm:"foreignKey:ProjectID"`
+	Teams         []ProjectTeam         `json:"-" gorm:"foreignKey:ProjectID"`
+	Tags          []Tag                 `json:"-" gorm:"many2many:project_tags"`
 }
 
 // ProjectCollaborator represents the project collaborators structure
@@ -37,10 +53,13 @@ type ProjectCollaborator struct {
 
 // CreateProjectRequest represents project creation data
 type CreateProjectRequest struct {
-	Name        string `json:"name" binding:"required,min=2,max=100"`
-	Slug        string `json:"slug" binding:"required,min=2,max=100"`
-	Description string `json:"description"`
-	Visibility  string `json:"visibility" binding:"omitempty,oneof=private public"`
+	Name        string   `json:"name" binding:"required,min=2,max=100"`
+	Slug        string   `json:"slug" binding:"required,min=2,max=100"`
+	Description string   `json:"description"`
+	Visibility  string   `json:"visibility" binding:"omitempty,oneof=private public"`
+	Tags        []string `json:"tags"`
+	// OrganizationID creates the project inside an organization the user belongs to
+	OrganizationID *uint `json:"organization_id"`
 }
 
 // UpdateProjectRequest represents project update data
@@ -49,6 +68,10 @@ type UpdateProjectRequest struct {
 	Description string `json:"description"`
 	Visibility  string `json:"visibility" binding:"omitempty,oneof=private public"`
 	Status      string `json:"status" binding:"omitempty,oneof=active archived"`
+	// Tags replaces the project tags when present; an empty list clears them
+	Tags []string `json:"tags"`
+	// OrganizationID moves the project to another organization; 0 detaches it
+	OrganizationID *uint `json:"organization_id"`
 }
 
 // AddCollaboratorRequest represents adding collaborator data
@@ -59,17 +82,23 @@ type AddCollaboratorRequest struct {
 
 // ProjectResponse represents project response
 type ProjectResponse struct {
-	ID            uint                   `json:"id"`
-	Name          string                 `json:"name"`
-	Slug          string                 `json:"slug"`
-	Description   string                 `json:"description"`
-	OwnerID       uint                   `json:"owner_id"`
-	Owner         UserResponse           `json:"owner"`
-	Visibility    string                 `json:"visibility"`
-	Status        string                 `json:"status"`
-	CreatedAt     time.Time              `json:"created_at"`
-	UpdatedAt     time.Time              `json:"updated_at"`
-	Collaborators []CollaboratorResponse `json:"collaborators,omitempty"`
+	ID             uint                   `json:"id"`
+	Name           string                 `json:"name"`
+	Slug           string                 `json:"slug"`
+	Description    string                 `json:"description"`
+	OwnerID        uint                   `json:"owner_id"`
+	Owner          UserResponse           `json:"owner"`
+	OrganizationID *uint                  `json:"organization_id"`
+	Visibility     string                 `json:"visibility"`
+	Status         string                 `json:"status"`
+	CommentPolicy  string                 `json:"comment_policy"`
+	PublicView     *PublicView            `json:"public_view"`
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+	Collaborators  []CollaboratorResponse `json:"collaborators,omitempty"`
+	Teams          []ProjectTeamResponse  `json:"teams,omitempty"`
+	MetadataSchema MetadataSchema         `json:"metadata_schema"`
+	Tags           []TagResponse          `json:"tags,omitempty"`
 }
 
 // CollaboratorResponse represents collaborator response
@@ -93,6 +122,11 @@ func (p *Project) ToResponse() ProjectResponse {
 		Status:      p.Status,
 		CreatedAt:   p.CreatedAt,
 		UpdatedAt:   p.UpdatedAt,
+
+		OrganizationID: p.OrganizationID,
+		CommentPolicy:  p.CommentPolicy,
+		PublicView:     p.PublicView,
+		MetadataSchema: p.MetadataSchema,
 	}
 
 	// Include owner if loaded
@@ -105,6 +139,16 @@ func (p *Project) ToResponse() ProjectResponse {
 		response.Collaborators = append(response.Collaborators, collab.ToResponse())
 	}
 
+	// Convert team grants if loaded
+	for _, team := range p.Teams {
+		response.Teams = append(response.Teams, team.ToResponse())
+	}
+
+	// Convert tags if loaded
+	for _, tag := range p.Tags {
+		response.Tags = append(response.Tags, tag.ToResponse())
+	}
+
 	return response
 }
 
@@ -168,6 +212,7 @@ type BulkSaveRequest struct {
 	UpdatedDependencies []UpdateDependencyRequest `json:"updated_dependencies"`
 	DeletedServices     []uint                    `json:"deleted_services"`
 	DeletedDependencies []uint                    `json:"deleted_dependencies"`
+	TagServices         []BulkTagRequest          `json:"tag_services"`
 }
 
 // BulkSaveResult represents the result of bulk save operations
@@ -178,6 +223,7 @@ type BulkSaveResult struct {
 	UpdatedDependencies      []DependencyResponse `json:"updated_dependencies"`
 	DeletedServicesCount     int                  `json:"deleted_services_count"`
 	DeletedDependenciesCount int                  `json:"deleted_dependencies_count"`
+	TaggedServicesCount      int                  `json:"tagged_services_count"`
 }
 
 // ExecuteBulkSave handles bulk save operations in a transaction
@@ -191,6 +237,21 @@ func ExecuteBulkSave(tx *gorm.DB, projectID uint, userID uint, req BulkSaveReque
 
 	// Step 1: Delete dependencies first (to avoid foreign key constraints)
 	if len(req.DeletedDependencies) > 0 {
+		var deleted []Dependency
+		if err := tx.Preload("SourceService").Preload("TargetService").
+			Where("id IN ?", req.DeletedDependencies).Find(&deleted).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch dependencies to delete: %v", err)
+		}
+		for i := range deleted {
+			if err := RecordDependencyHistory(tx, &deleted[i], userID, HistoryDeleteDependency); err != nil {
+				return nil, fmt.Errorf("failed to record history: %v", err)
+			}
+		}
+
+		if err := DeleteDependencyComments(tx, req.DeletedDependencies); err != nil {
+			return nil, fmt.Errorf("failed to delete dependency comments: %v", err)
+		}
+
 		deleteResult := tx.Where("id IN ?", req.DeletedDependencies).Delete(&Dependency{})
 		if deleteResult.Error != nil {
 			return nil, fmt.Errorf("failed to delete dependencies: %v", deleteResult.Error)
@@ -200,6 +261,20 @@ func ExecuteBulkSave(tx *gorm.DB, projectID uint, userID uint, req BulkSaveReque
 
 	// Step 2: Delete services
 	if len(req.DeletedServices) > 0 {
+		var deleted []Service
+		if err := tx.Where("id IN ?", req.DeletedServices).Find(&deleted).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch services to delete: %v", err)
+		}
+		for i := range deleted {
+			if err := RecordServiceHistory(tx, &deleted[i], userID, HistoryDeleteService); err != nil {
+				return nil, fmt.Errorf("failed to record history: %v", err)
+			}
+		}
+
+		if err := DeleteServiceDependencyComments(tx, req.DeletedServices); err != nil {
+			return nil, fmt.Errorf("failed to delete dependency comments: %v", err)
+		}
+
 		deleteResult := tx.Where("id IN ?", req.DeletedServices).Delete(&Service{})
 		if deleteResult.Error != nil {
 			return nil, fmt.Errorf("failed to delete services: %v", deleteResult.Error)
@@ -213,6 +288,18 @@ func ExecuteBulkSave(tx *gorm.DB, projectID uint, userID uint, req BulkSaveReque
 		if err := tx.Where("project_id = ?", projectID).First(&service, updateReq.ID).Error; err != nil {
 			return nil, fmt.Errorf("service not found for update: %v", err)
 		}
+		if err := ValidateServiceEnvironment(tx, projectID, updateReq.Environment); err != nil {
+			return nil, fmt.Errorf("invalid environment for service %d: %v", updateReq.ID, err)
+		}
+		if updateReq.Metadata != nil {
+			fieldErrors, err := ValidateServiceMetadata(tx, projectID, updateReq.Metadata)
+			if err != nil {
+				return nil, fmt.Errorf("failed to validate metadata: %v", err)
+			}
+			if len(fieldErrors) > 0 {
+				return nil, &MetadataValidationError{Service: service.Name, Fields: fieldErrors}
+			}
+		}
 
 		// Update fields only if they are provided and valid
 		if updateReq.Name != "" {
@@ -259,15 +346,41 @@ func ExecuteBulkSave(tx *gorm.DB, projectID uint, userID uint, req BulkSaveReque
 		service.PosY = updateReq.PosY
 		service.UpdatedBy = &userID
 
-		if err := tx.Save(&service).Error; err != nil {
+		if err := tx.Omit("Tags").Save(&service).Error; err != nil {
 			return nil, fmt.Errorf("failed to update service: %v", err)
 		}
 
+		if updateReq.Tags != nil {
+			tags, err := ResolveTags(tx, updateReq.Tags, userID)
+			if err != nil {
+				return nil, err
+			}
+			if err := tx.Model(&service).Association("Tags").Replace(tags); err != nil {
+				return nil, fmt.Errorf("failed to update service tags: %v", err)
+			}
+		}
+
 		result.UpdatedServices = append(result.UpdatedServices, service.ToResponse())
 	}
 
 	// Step 4: Create new services
 	for _, serviceReq := range req.Services {
+		if err := ValidateServiceEnvironment(tx, projectID, serviceReq.Environment); err != nil {
+			return nil, fmt.Errorf("invalid environment for service %q: %v", serviceReq.Name, err)
+		}
+		fieldErrors, err := ValidateServiceMetadata(tx, projectID, serviceReq.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate metadata: %v", err)
+		}
+		if len(fieldErrors) > 0 {
+			return nil, &MetadataValidationError{Service: serviceReq.Name, Fields: fieldErrors}
+		}
+
+		tags, err := ResolveTags(tx, serviceReq.Tags, userID)
+		if err != nil {
+			return nil, err
+		}
+
 		service := Service{
 			ProjectID:     projectID,
 			Name:          serviceReq.Name,
@@ -286,11 +399,15 @@ func ExecuteBulkSave(tx *gorm.DB, projectID uint, userID uint, req BulkSaveReque
 			PosY:          serviceReq.PosY,
 			Notes:         serviceReq.Notes,
 			CreatedBy:     userID,
+			Tags:          tags,
 		}
 
 		if err := tx.Create(&service).Error; err != nil {
 			return nil, fmt.Errorf("failed to create service: %v", err)
 		}
+		if err := RecordServiceHistory(tx, &service, userID, HistoryCreateService); err != nil {
+			return nil, fmt.Errorf("failed to record history: %v", err)
+		}
 
 		result.CreatedServices = append(result.CreatedServices, service.ToResponse())
 	}
@@ -348,9 +465,58 @@ func ExecuteBulkSave(tx *gorm.DB, projectID uint, userID uint, req BulkSaveReque
 		if err := tx.Create(&dependency).Error; err != nil {
 			return nil, fmt.Errorf("failed to create dependency: %v", err)
 		}
+		dependency.SourceService, dependency.TargetService = sourceService, targetService
+		if err := RecordDependencyHistory(tx, &dependency, userID, HistoryCreateDependency); err != nil {
+			return nil, fmt.Errorf("failed to record history: %v", err)
+		}
 
 		result.CreatedDependencies = append(result.CreatedDependencies, dependency.ToResponse())
 	}
 
+	// Step 7: Add and remove tags on existing services
+	for _, tagReq := range req.TagServices {
+		var services []Service
+		if err := tx.Where("project_id = ? AND id IN ?", projectID, tagReq.ServiceIDs).Find(&services).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch services to tag: %v", err)
+		}
+		if len(services) != len(uniqueIDs(tagReq.ServiceIDs)) {
+			return nil, fmt.Errorf("service not found for tagging")
+		}
+
+		add, err := ResolveTags(tx, tagReq.Add, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		var remove []Tag
+		if names := NormalizeTagNames(tagReq.Remove); len(names) > 0 {
+			if err := tx.Where("name IN ?", names).Find(&remove).Error; err != nil {
+				return nil, fmt.Errorf("failed to fetch tags to remove: %v", err)
+			}
+		}
+
+		for i := range services {
+			if len(add) > 0 {
+				if err := tx.Model(&services[i]).Association("Tags").Append(add); err != nil {
+					return nil, fmt.Errorf("failed to tag service: %v", err)
+				}
+			}
+			if len(remove) > 0 {
+				if err := tx.Model(&services[i]).Association("Tags").Delete(remove); err != nil {
+					return nil, fmt.Errorf("failed to untag service: %v", err)
+				}
+			}
+		}
+		result.TaggedServicesCount += len(services)
+	}
+
 	return result, nil
 }
+
+func uniqueIDs(ids []uint) map[uint]bool {
+	unique := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	return unique
+}
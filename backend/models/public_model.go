@@ -0,0 +1,357 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sami/pkg/markdown"
+)
+
+// PublicServiceFields lists the service fields that can be hidden from the
+// public view of a project. Name, type, status and position are always shown.
+var PublicServiceFields = []string{
+	"description",
+	"version",
+	"language",
+	"environment",
+	"deploy_url",
+	"domain",
+	"git_repo",
+	"health_metrics",
+	"metadata",
+	"notes",
+	"escalation_contact",
+	"runbooks",
+	"tags",
+}
+
+// PublicView configures what anonymous visitors see of a public project. A
+// project without one uses the server defaults.
+type PublicView struct {
+	// HiddenFields are the service fields left out, see PublicServiceFields
+	HiddenFields []string `json:"hidden_fields"`
+	// Comments publishes the project comments
+	Comments bool `json:"comments"`
+}
+
+// Value implements driver.Valuer so the view is stored as jsonb
+func (v PublicView) Value() (driver.Value, error) {
+	return json.Marshal(v)
+}
+
+// Scan implements sql.Scanner so the view can be read from jsonb
+func (v *PublicView) Scan(value interface{}) error {
+	if value == nil {
+		*v = PublicView{}
+		return nil
+	}
+
+	var data []byte
+	switch val := value.(type) {
+	case []byte:
+		data = val
+	case string:
+		data = []byte(val)
+	default:
+		return fmt.Errorf("unsupported public view value: %T", value)
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// Check validates the hidden field names
+func (v PublicView) Check() []FieldError {
+	var errs []FieldError
+	for i, field := range v.HiddenFields {
+		if !isPublicServiceField(field) {
+			errs = append(errs, FieldError{
+				Field:   fmt.Sprintf("hidden_fields[%d]", i),
+				Message: fmt.Sprintf("unknown service field %q", field),
+			})
+		}
+	}
+	return errs
+}
+
+// isPublicServiceField reports whether field can be hidden from the public view
+func isPublicServiceField(field string) bool {
+	for _, name := range PublicServiceFields {
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdatePublicViewRequest represents public view settings data. An empty
+// list of hidden fields shows every field.
+type UpdatePublicViewRequest struct {
+	HiddenFields []string `json:"hidden_fields" binding:"required"`
+	Comments     bool     `json:"comments"`
+}
+
+// PublicProjectResponse represents the anonymous view of a project
+type PublicProjectResponse struct {
+	ID          uint          `json:"id"`
+	Name        string        `json:"name"`
+	Slug        string        `json:"slug"`
+	Description string        `json:"description"`
+	Status      string        `json:"status"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Tags        []TagResponse `json:"tags,omitempty"`
+}
+
+// PublicServiceResponse represents the anonymous view of a service. Hidden
+// fields are left out.
+type PublicServiceResponse struct {
+	ID                uint          `json:"id"`
+	Name              string        `json:"name"`
+	Type              string        `json:"type"`
+	Status            string        `json:"status"`
+	PosX              int           `json:"pos_x"`
+	PosY              int           `json:"pos_y"`
+	Description       string        `json:"description,omitempty"`
+	Version           string        `json:"version,omitempty"`
+	Language          string        `json:"language,omitempty"`
+	Environment       string        `json:"environment,omitempty"`
+	DeployURL         string        `json:"deploy_url,omitempty"`
+	Domain            string        `json:"domain,omitempty"`
+	GitRepo           string        `json:"git_repo,omitempty"`
+	HealthMetrics     interface{}   `json:"health_metrics,omitempty"`
+	Metadata          interface{}   `json:"metadata,omitempty"`
+	Notes             string        `json:"notes,omitempty"`
+	EscalationContact string        `json:"escalation_contact,omitempty"`
+	Runbooks          RunbookLinks  `json:"runbooks,omitempty"`
+	Tags              []TagResponse `json:"tags,omitempty"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+}
+
+// PublicDependencyResponse represents the anonymous view of a dependency
+type PublicDependencyResponse struct {
+	ID          uint   `json:"id"`
+	SourceID    uint   `json:"source_id"`
+	TargetID    uint   `json:"target_id"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Protocol    string `json:"protocol"`
+	Method      string `json:"method"`
+}
+
+// PublicAuthorResponse names the author of a public comment, without
+// their email address
+type PublicAuthorResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"full_name"`
+}
+
+// PublicCommentResponse represents the anonymous view of a comment.
+// Deleted comments kept for their replies have no author or content.
+type PublicCommentResponse struct {
+	ID           uint                    `json:"id"`
+	ServiceID    *uint                   `json:"service_id,omitempty"`
+	DependencyID *uint                   `json:"dependency_id,omitempty"`
+	ParentID     *uint                   `json:"parent_id,omitempty"`
+	Author       *PublicAuthorResponse   `json:"author,omitempty"`
+	ContentHTML  string                  `json:"content_html"`
+	Status       string                  `json:"status"`
+	CreatedAt    time.Time               `json:"created_at"`
+	EditedAt     *time.Time              `json:"edited_at,omitempty"`
+	Replies      []PublicCommentResponse `json:"replies,omitempty"`
+}
+
+// PublicGraphResponse represents the anonymous view of a project graph
+type PublicGraphResponse struct {
+	Project      PublicProjectResponse      `json:"project"`
+	Services     []PublicServiceResponse    `json:"services"`
+	Dependencies []PublicDependencyResponse `json:"dependencies"`
+	Comments     []PublicCommentResponse    `json:"comments,omitempty"`
+}
+
+// PublicResponse converts project to PublicProjectResponse
+func (p *Project) PublicResponse() PublicProjectResponse {
+	response := PublicProjectResponse{
+		ID:          p.ID,
+		Name:        p.Name,
+		Slug:        p.Slug,
+		Description: p.Description,
+		Status:      p.Status,
+		UpdatedAt:   p.UpdatedAt,
+	}
+
+	// Convert tags if loaded
+	for _, tag := range p.Tags {
+		response.Tags = append(response.Tags, tag.ToResponse())
+	}
+
+	return response
+}
+
+// PublicResponse converts service to PublicServiceResponse, leaving out the
+// hidden fields
+func (s *Service) PublicResponse(hidden []string) PublicServiceResponse {
+	hide := make(map[string]bool, len(hidden))
+	for _, field := range hidden {
+		hide[field] = true
+	}
+	show := func(field string) bool { return !hide[field] }
+
+	response := PublicServiceResponse{
+		ID:        s.ID,
+		Name:      s.Name,
+		Type:      s.Type,
+		Status:    s.Status,
+		PosX:      s.PosX,
+		PosY:      s.PosY,
+		UpdatedAt: s.UpdatedAt,
+	}
+	if show("description") {
+		response.Description = s.Description
+	}
+	if show("version") {
+		response.Version = s.Version
+	}
+	if show("language") {
+		response.Language = s.Language
+	}
+	if show("environment") {
+		response.Environment = s.Environment
+	}
+	if show("deploy_url") {
+		response.DeployURL = s.DeployURL
+	}
+	if show("domain") {
+		response.Domain = s.Domain
+	}
+	if show("git_repo") {
+		response.GitRepo = s.GitRepo
+	}
+	if show("health_metrics") {
+		response.HealthMetrics = s.HealthMetrics
+	}
+	if show("metadata") {
+		response.Metadata = s.Metadata
+	}
+	if show("notes") {
+		response.Notes = s.Notes
+	}
+	if show("escalation_contact") {
+		response.EscalationContact = s.EscalationContact
+	}
+	if show("runbooks") {
+		response.Runbooks = s.Runbooks
+	}
+	if show("tags") {
+		for _, tag := range s.Tags {
+			response.Tags = append(response.Tags, tag.ToResponse())
+		}
+	}
+
+	return response
+}
+
+// PublicResponse converts dependency to PublicDependencyResponse
+func (d *Dependency) PublicResponse() PublicDependencyResponse {
+	return PublicDependencyResponse{
+		ID:          d.ID,
+		SourceID:    d.SourceID,
+		TargetID:    d.TargetID,
+		Type:        d.Type,
+		Description: d.Description,
+		Protocol:    d.Protocol,
+		Method:      d.Method,
+	}
+}
+
+// PublicResponse converts comment and its loaded replies to
+// PublicCommentResponse. The content is rendered again without references,
+// which could name services or projects hidden from visitors.
+func (c *Comment) PublicResponse() PublicCommentResponse {
+	response := PublicCommentResponse{
+		ID:           c.ID,
+		ServiceID:    c.ServiceID,
+		DependencyID: c.DependencyID,
+		ParentID:     c.ParentID,
+		Status:       c.Status,
+		CreatedAt:    c.CreatedAt,
+		EditedAt:     c.EditedAt,
+	}
+
+	if c.Status == "active" {
+		response.ContentHTML = markdown.RenderSafe(c.Content, nil).HTML
+		if c.User.ID != 0 {
+			response.Author = &PublicAuthorResponse{ID: c.User.ID, Name: c.User.Name}
+		}
+	}
+
+	// Convert replies if loaded
+	for _, reply := range c.Replies {
+		response.Replies = append(response.Replies, reply.PublicResponse())
+	}
+
+	return response
+}
+
+// BuildPublicGraph loads the anonymous view of the service graph of a
+// project. tags keeps only services carrying all of them, as in
+// BuildProjectGraph.
+func BuildPublicGraph(db *gorm.DB, project *Project, view PublicView, tags []string) (*PublicGraphResponse, error) {
+	var services []Service
+	servicesQuery := FilterByServiceTags(db.Where("project_id = ?", project.ID), "services.id", tags)
+	if err := servicesQuery.Preload("Tags").Order("id").Find(&services).Error; err != nil {
+		return nil, err
+	}
+
+	var dependencies []Dependency
+	dependenciesQuery := db.
+		Joins("JOIN services s1 ON dependencies.source_id = s1.id").
+		Joins("JOIN services s2 ON dependencies.target_id = s2.id").
+		Where("s1.project_id = ? AND s2.project_id = ?", project.ID, project.ID)
+	dependenciesQuery = FilterByServiceTags(dependenciesQuery, "s1.id", tags)
+	dependenciesQuery = FilterByServiceTags(dependenciesQuery, "s2.id", tags)
+	if err := dependenciesQuery.Order("dependencies.id").Find(&dependencies).Error; err != nil {
+		return nil, err
+	}
+
+	graph := &PublicGraphResponse{
+		Project:      project.PublicResponse(),
+		Services:     make([]PublicServiceResponse, 0, len(services)),
+		Dependencies: make([]PublicDependencyResponse, 0, len(dependencies)),
+	}
+	for _, service := range services {
+		graph.Services = append(graph.Services, service.PublicResponse(view.HiddenFields))
+	}
+	for _, dependency := range dependencies {
+		graph.Dependencies = append(graph.Dependencies, dependency.PublicResponse())
+	}
+
+	return graph, nil
+}
+
+// LoadPublicComments loads the latest limit threads of a project with their
+// replies, newest first
+func LoadPublicComments(db *gorm.DB, projectID uint, limit int) ([]PublicCommentResponse, error) {
+	var comments []Comment
+	if err := db.Preload("User").
+		Where("comments.project_id = ? AND comments.parent_id IS NULL", projectID).
+		Where(VisibleCommentSQL).
+		Order("comments.created_at DESC, comments.id DESC").
+		Limit(limit).Find(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	if err := LoadCommentTrees(db, comments, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("User")
+	}); err != nil {
+		return nil, err
+	}
+
+	responses := make([]PublicCommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		responses = append(responses, comment.PublicResponse())
+	}
+	return responses, nil
+}
@@ -0,0 +1,73 @@
+package models
+
+import "testing"
+
+func TestServicePublicResponseHidesFields(t *testing.T) {
+	service := Service{
+		ID:        1,
+		Name:      "api",
+		Type:      "backend",
+		DeployURL: "https://api.internal",
+		GitRepo:   "git@example.com:api.git",
+		Notes:     "secret",
+		Version:   "1.2.0",
+		Tags:      []Tag{{ID: 3, Name: "core"}},
+	}
+
+	response := service.PublicResponse([]string{"deploy_url", "git_repo", "notes", "tags"})
+	if response.DeployURL != "" || response.GitRepo != "" || response.Notes != "" || len(response.Tags) != 0 {
+		t.Errorf("hidden fields leaked: %+v", response)
+	}
+	if response.Name != "api" || response.Version != "1.2.0" {
+		t.Errorf("visible fields missing: %+v", response)
+	}
+
+	response = service.PublicResponse(nil)
+	if response.DeployURL != service.DeployURL || len(response.Tags) != 1 {
+		t.Errorf("fields hidden without settings: %+v", response)
+	}
+}
+
+func TestPublicViewCheck(t *testing.T) {
+	view := PublicView{HiddenFields: []string{"notes", "password", "git_repo"}}
+	errs := view.Check()
+	if len(errs) != 1 || errs[0].Field != "hidden_fields[1]" {
+		t.Errorf("unexpected errors %+v", errs)
+	}
+
+	if errs := (PublicView{HiddenFields: PublicServiceFields}).Check(); len(errs) != 0 {
+		t.Errorf("every public service field should be accepted, got %+v", errs)
+	}
+}
+
+func TestCommentPublicResponse(t *testing.T) {
+	parentID := uint(1)
+	comment := Comment{
+		ID:      1,
+		Status:  "deleted",
+		Content: "removed",
+		User:    User{ID: 2, Name: "Ada", Email: "ada@example.com"},
+		Replies: []Comment{{
+			ID:       2,
+			ParentID: &parentID,
+			Status:   "active",
+			Content:  "**hello** #api",
+			User:     User{ID: 3, Name: "Grace", Email: "grace@example.com"},
+		}},
+	}
+
+	response := comment.PublicResponse()
+	if response.Author != nil || response.ContentHTML != "" {
+		t.Errorf("deleted comment should have no author or content: %+v", response)
+	}
+	if len(response.Replies) != 1 {
+		t.Fatalf("expected one reply, got %+v", response.Replies)
+	}
+	reply := response.Replies[0]
+	if reply.Author == nil || reply.Author.Name != "Grace" {
+		t.Errorf("reply author missing: %+v", reply)
+	}
+	if reply.ContentHTML == "" {
+		t.Error("reply content not rendered")
+	}
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// maxEmojiLength bounds the bytes of a reaction, enough for flags and
+// ZWJ sequences such as family emoji
+const maxEmojiLength = 32
+
+// CommentReaction is an emoji reaction of a user to a comment. A user
+// reacts at most once with each emoji.
+type CommentReaction struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"comment_id" gorm:"not null;uniqueIndex:idx_comment_reactions_unique"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_comment_reactions_unique"`
+	User      User      `json:"-" gorm:"foreignKey:UserID"`
+	Emoji     string    `json:"emoji" gorm:"not null;size:32;uniqueIndex:idx_comment_reactions_unique"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// ReactionRequest represents reaction data
+type ReactionRequest struct {
+	Emoji string `json:"emoji" binding:"required"`
+}
+
+// ReactionCount counts the users who reacted to a comment with an emoji
+type ReactionCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+	// UserIDs lets clients show whether the current user reacted
+	UserIDs []uint `json:"user_ids"`
+}
+
+// ReactionResponse represents reaction response
+type ReactionResponse struct {
+	ID        uint         `json:"id"`
+	Emoji     string       `json:"emoji"`
+	UserID    uint         `json:"user_id"`
+	User      UserResponse `json:"user"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// TableName specifies the table name for CommentReaction
+func (CommentReaction) TableName() string {
+	return "comment_reactions"
+}
+
+// BeforeCreate runs before creating a reaction
+func (r *CommentReaction) BeforeCreate(tx *gorm.DB) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+// ToResponse converts reaction to ReactionResponse
+func (r *CommentReaction) ToResponse() ReactionResponse {
+	response := ReactionResponse{
+		ID:        r.ID,
+		Emoji:     r.Emoji,
+		UserID:    r.UserID,
+		CreatedAt: r.CreatedAt,
+	}
+
+	// Include user if loaded
+	if r.User.ID != 0 {
+		response.User = r.User.ToResponse()
+	}
+
+	return response
+}
+
+// NormalizeEmoji trims a reaction and reports whether it is a single emoji.
+// Emoji are symbols, possibly combined with modifiers, variation selectors,
+// zero width joiners or keycaps; text such as "+1" is rejected.
+func NormalizeEmoji(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || len(s) > maxEmojiLength || !utf8.ValidString(s) {
+		return s, false
+	}
+
+	hasSymbol, keycap := false, strings.ContainsRune(s, '⃣')
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.So, r):
+			hasSymbol = true
+		case r == '⃣':
+			hasSymbol = true
+		case unicode.Is(unicode.Sk, r), r == '‍', r == '️', r == '︎':
+		case r >= 0xE0020 && r <= 0xE007F: // tag sequences of subdivision flags
+		case keycap && (r == '#' || r == '*' || (r >= '0' && r <= '9')):
+		default:
+			return s, false
+		}
+	}
+	return s, hasSymbol
+}
+
+// SummarizeReactions counts reactions per emoji, in the order each emoji
+// was first used
+func SummarizeReactions(reactions []CommentReaction) []ReactionCount {
+	sorted := make([]CommentReaction, len(reactions))
+	copy(sorted, reactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	var counts []ReactionCount
+	index := map[string]int{}
+	for _, reaction := range sorted {
+		i, ok := index[reaction.Emoji]
+		if !ok {
+			i = len(counts)
+			index[reaction.Emoji] = i
+			counts = append(counts, ReactionCount{Emoji: reaction.Emoji})
+		}
+		counts[i].Count++
+		counts[i].UserIDs = append(counts[i].UserIDs, reaction.UserID)
+	}
+	return counts
+}
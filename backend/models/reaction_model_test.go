@@ -0,0 +1,54 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNormalizeEmoji(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		valid bool
+	}{
+		{"👍", "👍", true},
+		{" 🎉 ", "🎉", true},
+		{"👍🏽", "👍🏽", true},
+		{"❤️", "❤️", true},
+		{"👩‍💻", "👩‍💻", true},
+		{"🇫🇷", "🇫🇷", true},
+		{"1️⃣", "1️⃣", true},
+		{"+1", "+1", false},
+		{"1", "1", false},
+		{"👍 agreed", "👍 agreed", false},
+		{"", "", false},
+		{"<b>", "<b>", false},
+	}
+	for _, test := range tests {
+		got, valid := NormalizeEmoji(test.input)
+		if got != test.want || valid != test.valid {
+			t.Errorf("NormalizeEmoji(%q) = %q %v, want %q %v", test.input, got, valid, test.want, test.valid)
+		}
+	}
+}
+
+func TestSummarizeReactions(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	reactions := []CommentReaction{
+		{ID: 3, UserID: 2, Emoji: "👍", CreatedAt: now.Add(2 * time.Minute)},
+		{ID: 1, UserID: 1, Emoji: "🎉", CreatedAt: now},
+		{ID: 2, UserID: 1, Emoji: "👍", CreatedAt: now.Add(time.Minute)},
+	}
+
+	want := []ReactionCount{
+		{Emoji: "🎉", Count: 1, UserIDs: []uint{1}},
+		{Emoji: "👍", Count: 2, UserIDs: []uint{1, 2}},
+	}
+	if got := SummarizeReactions(reactions); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := SummarizeReactions(nil); got != nil {
+		t.Errorf("expected no counts, got %+v", got)
+	}
+}
@@ -0,0 +1,637 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SCIM 2.0 schema URNs (RFC 7643, RFC 7644)
+const (
+	SCIMUserSchema        = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMGroupSchema       = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SCIMListSchema        = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCIMPatchOpSchema     = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SCIMErrorSchema       = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SCIMServiceConfSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SCIMResourceSchema    = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+)
+
+// SCIMIssuer is the UserIdentity issuer under which SCIM external IDs are kept
+const SCIMIssuer = "scim"
+
+// provisioningTokenPrefix makes provisioning tokens recognizable in logs and
+// secret scanners
+const provisioningTokenPrefix = "sami_scim_"
+
+// ErrInvalidSCIMFilter is returned for filters the server does not support
+var ErrInvalidSCIMFilter = errors.New("unsupported filter")
+
+// ErrInvalidSCIMPatch is returned for patch operations that cannot be applied
+var ErrInvalidSCIMPatch = errors.New("invalid patch operation")
+
+// ProvisioningToken authenticates an identity provider on the SCIM API. Only
+// its SHA-256 is stored. Groups are synced into the teams of Organization.
+type ProvisioningToken struct {
+	ID             uint          `json:"id" gorm:"primaryKey"`
+	Name           string        `json:"name" gorm:"not null;size:100"`
+	TokenHash      string        `json:"-" gorm:"not null;unique;size:64"`
+	OrganizationID *uint         `json:"organization_id"`
+	Organization   *Organization `json:"-" gorm:"foreignKey:OrganizationID"`
+	CreatedBy      uint          `json:"created_by" gorm:"not null"`
+	CreatedAt      time.Time     `json:"created_at" gorm:"not null;default:now()"`
+	LastUsedAt     *time.Time    `json:"last_used_at"`
+	RevokedAt      *time.Time    `json:"revoked_at"`
+}
+
+// CreateProvisioningTokenRequest represents the data to create a token
+type CreateProvisioningTokenRequest struct {
+	Name           string `json:"name" binding:"required,min=2,max=100"`
+	OrganizationID *uint  `json:"organization_id"`
+}
+
+// ProvisioningTokenResponse represents a provisioning token response
+type ProvisioningTokenResponse struct {
+	ID               uint       `json:"id"`
+	Name             string     `json:"name"`
+	OrganizationID   *uint      `json:"organization_id"`
+	OrganizationName string     `json:"organization_name,omitempty"`
+	CreatedBy        uint       `json:"created_by"`
+	CreatedAt        time.Time  `json:"created_at"`
+	LastUsedAt       *time.Time `json:"last_used_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+}
+
+// TableName specifies the table name for ProvisioningToken
+func (ProvisioningToken) TableName() string {
+	return "provisioning_tokens"
+}
+
+// ToResponse converts token to ProvisioningTokenResponse
+func (t *ProvisioningToken) ToResponse() ProvisioningTokenResponse {
+	response := ProvisioningTokenResponse{
+		ID:             t.ID,
+		Name:           t.Name,
+		OrganizationID: t.OrganizationID,
+		CreatedBy:      t.CreatedBy,
+		CreatedAt:      t.CreatedAt,
+		LastUsedAt:     t.LastUsedAt,
+		RevokedAt:      t.RevokedAt,
+	}
+
+	// Include relations if loaded
+	if t.Organization != nil {
+		response.OrganizationName = t.Organization.Name
+	}
+
+	return response
+}
+
+// NewProvisioningToken generates a token and the hash stored for it
+func NewProvisioningToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = provisioningTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// SCIMMeta holds resource metadata
+type SCIMMeta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+}
+
+// SCIMName is the name of a SCIM user
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// SCIMEmail is an email address of a SCIM user
+type SCIMEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// SCIMMemberRef references a user from a group or a group from a user
+type SCIMMemberRef struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// SCIMUser is the SCIM representation of a user
+type SCIMUser struct {
+	Schemas     []string        `json:"schemas"`
+	ID          string          `json:"id,omitempty"`
+	ExternalID  string          `json:"externalId,omitempty"`
+	UserName    string          `json:"userName"`
+	Name        *SCIMName       `json:"name,omitempty"`
+	DisplayName string          `json:"displayName,omitempty"`
+	Emails      []SCIMEmail     `json:"emails,omitempty"`
+	Active      *bool           `json:"active,omitempty"`
+	Groups      []SCIMMemberRef `json:"groups,omitempty"`
+	Meta        *SCIMMeta       `json:"meta,omitempty"`
+}
+
+// SCIMGroup is the SCIM representation of a team
+type SCIMGroup struct {
+	Schemas     []string        `json:"schemas"`
+	ID          string          `json:"id,omitempty"`
+	ExternalID  string          `json:"externalId,omitempty"`
+	DisplayName string          `json:"displayName"`
+	Members     []SCIMMemberRef `json:"members,omitempty"`
+	Meta        *SCIMMeta       `json:"meta,omitempty"`
+}
+
+// SCIMListResponse is a page of resources
+type SCIMListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int64         `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+// SCIMError is an error response
+type SCIMError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+// SCIMPatchRequest is a PATCH request body
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations"`
+}
+
+// SCIMPatchOperation is a single add, remove or replace operation
+type SCIMPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// SCIMFilter is an attribute equality filter, the only kind identity
+// providers need to look resources up
+type SCIMFilter struct {
+	Attribute string
+	Value     string
+}
+
+var scimFilterPattern = regexp.MustCompile(`^\s*([A-Za-z][A-Za-z0-9.]*)\s+(?i:eq)\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+// ParseSCIMFilter parses a filter of the form `attribute eq "value"`
+func ParseSCIMFilter(filter string) (*SCIMFilter, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil
+	}
+
+	match := scimFilterPattern.FindStringSubmatch(filter)
+	if match == nil {
+		return nil, ErrInvalidSCIMFilter
+	}
+
+	value, err := strconv.Unquote(`"` + match[2] + `"`)
+	if err != nil {
+		return nil, ErrInvalidSCIMFilter
+	}
+
+	return &SCIMFilter{Attribute: strings.ToLower(match[1]), Value: value}, nil
+}
+
+// Email returns the address a SCIM user maps to: the user name when it is
+// an email, else the primary email
+func (u *SCIMUser) Email() string {
+	if strings.Contains(u.UserName, "@") {
+		return strings.TrimSpace(u.UserName)
+	}
+	for _, email := range u.Emails {
+		if email.Primary {
+			return strings.TrimSpace(email.Value)
+		}
+	}
+	if len(u.Emails) > 0 {
+		return strings.TrimSpace(u.Emails[0].Value)
+	}
+	return ""
+}
+
+// FullName returns the display name of a SCIM user
+func (u *SCIMUser) FullName() string {
+	if u.Name != nil {
+		if u.Name.Formatted != "" {
+			return strings.TrimSpace(u.Name.Formatted)
+		}
+		if name := strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName); name != "" {
+			return name
+		}
+	}
+	return strings.TrimSpace(u.DisplayName)
+}
+
+// ToSCIM converts a user to its SCIM representation
+func (u *User) ToSCIM(externalID string, teams []Team, baseURL string) SCIMUser {
+	active := u.Status == StatusActive
+	created, updated := u.CreatedAt, u.UpdatedAt
+	id := strconv.FormatUint(uint64(u.ID), 10)
+
+	user := SCIMUser{
+		Schemas:     []string{SCIMUserSchema},
+		ID:          id,
+		ExternalID:  externalID,
+		UserName:    u.Email,
+		Name:        &SCIMName{Formatted: u.Name},
+		DisplayName: u.Name,
+		Emails:      []SCIMEmail{{Value: u.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &SCIMMeta{
+			ResourceType: "User",
+			Created:      &created,
+			LastModified: &updated,
+			Location:     baseURL + "/Users/" + id,
+		},
+	}
+
+	for _, team := range teams {
+		teamID := strconv.FormatUint(uint64(team.ID), 10)
+		user.Groups = append(user.Groups, SCIMMemberRef{
+			Value:   teamID,
+			Display: team.Name,
+			Ref:     baseURL + "/Groups/" + teamID,
+		})
+	}
+
+	return user
+}
+
+// ToSCIM converts a team to its SCIM representation
+func (t *Team) ToSCIM(members []User, baseURL string) SCIMGroup {
+	created, updated := t.CreatedAt, t.UpdatedAt
+	id := strconv.FormatUint(uint64(t.ID), 10)
+
+	group := SCIMGroup{
+		Schemas:     []string{SCIMGroupSchema},
+		ID:          id,
+		DisplayName: t.Name,
+		Meta: &SCIMMeta{
+			ResourceType: "Group",
+			Created:      &created,
+			LastModified: &updated,
+			Location:     baseURL + "/Groups/" + id,
+		},
+	}
+	if t.ExternalID != nil {
+		group.ExternalID = *t.ExternalID
+	}
+
+	for _, member := range members {
+		memberID := strconv.FormatUint(uint64(member.ID), 10)
+		group.Members = append(group.Members, SCIMMemberRef{
+			Value:   memberID,
+			Display: member.Name,
+			Ref:     baseURL + "/Users/" + memberID,
+		})
+	}
+
+	return group
+}
+
+// ApplyPatch applies PATCH operations to a SCIM user. Operations without a
+// path carry an object of attributes, as Azure AD sends them.
+func (u *SCIMUser) ApplyPatch(operations []SCIMPatchOperation) error {
+	for _, op := range operations {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+		default:
+			// Removing attributes of a user is not supported
+			return fmt.Errorf("%w: %s", ErrInvalidSCIMPatch, op.Op)
+		}
+
+		path := strings.ToLower(strings.TrimSpace(op.Path))
+		if path == "" {
+			var values map[string]json.RawMessage
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				return fmt.Errorf("%w: value must be an object", ErrInvalidSCIMPatch)
+			}
+			for key, value := range values {
+				if err := u.setAttribute(strings.ToLower(key), value); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err := u.setAttribute(path, op.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setAttribute sets a single attribute from a PATCH value
+func (u *SCIMUser) setAttribute(path string, value json.RawMessage) error {
+	if u.Name == nil {
+		u.Name = &SCIMName{}
+	}
+
+	var err error
+	switch path {
+	case "active":
+		var active bool
+		active, err = patchBool(value)
+		u.Active = &active
+	case "username":
+		err = json.Unmarshal(value, &u.UserName)
+	case "externalid":
+		err = json.Unmarshal(value, &u.ExternalID)
+	case "displayname":
+		err = json.Unmarshal(value, &u.DisplayName)
+	case "name":
+		err = json.Unmarshal(value, u.Name)
+	case "name.formatted":
+		err = json.Unmarshal(value, &u.Name.Formatted)
+	case "name.givenname":
+		u.Name.Formatted = ""
+		err = json.Unmarshal(value, &u.Name.GivenName)
+	case "name.familyname":
+		u.Name.Formatted = ""
+		err = json.Unmarshal(value, &u.Name.FamilyName)
+	case "emails":
+		err = json.Unmarshal(value, &u.Emails)
+	case `emails[type eq "work"].value`, `emails[primary eq true].value`:
+		var email string
+		if err = json.Unmarshal(value, &email); err == nil {
+			u.Emails = []SCIMEmail{{Value: email, Type: "work", Primary: true}}
+		}
+	default:
+		// Attributes SAMI does not store are ignored, as RFC 7644 allows
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: bad value for %s", ErrInvalidSCIMPatch, path)
+	}
+	return nil
+}
+
+// patchBool reads a boolean, accepting the "True"/"False" strings some
+// identity providers send
+func patchBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(strings.ToLower(s))
+}
+
+// SCIMGroupPatch is the effect of PATCH operations on a group
+type SCIMGroupPatch struct {
+	DisplayName *string
+	ExternalID  *string
+	// ReplaceMembers is set when the member list is replaced by Members
+	ReplaceMembers bool
+	Members        []uint
+	Add            []uint
+	Remove         []uint
+}
+
+var scimMemberPathPattern = regexp.MustCompile(`^members\[value eq "([^"]*)"\]$`)
+
+// ParseSCIMGroupPatch reads PATCH operations on a group
+func ParseSCIMGroupPatch(operations []SCIMPatchOperation) (*SCIMGroupPatch, error) {
+	patch := &SCIMGroupPatch{}
+
+	for _, op := range operations {
+		kind := strings.ToLower(op.Op)
+		path := strings.ToLower(strings.TrimSpace(op.Path))
+
+		// Operations without a path carry an object of attributes
+		if path == "" && kind != "remove" {
+			var values map[string]json.RawMessage
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				return nil, fmt.Errorf("%w: value must be an object", ErrInvalidSCIMPatch)
+			}
+			for key, value := range values {
+				if err := patch.apply(kind, strings.ToLower(key), value); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		if err := patch.apply(kind, path, op.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	return patch, nil
+}
+
+// apply records a single operation
+func (p *SCIMGroupPatch) apply(kind, path string, value json.RawMessage) error {
+	switch {
+	case path == "displayname" && kind != "remove":
+		var name string
+		if err := json.Unmarshal(value, &name); err != nil || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("%w: bad displayName", ErrInvalidSCIMPatch)
+		}
+		p.DisplayName = &name
+	case path == "externalid" && kind != "remove":
+		var externalID string
+		if err := json.Unmarshal(value, &externalID); err != nil {
+			return fmt.Errorf("%w: bad externalId", ErrInvalidSCIMPatch)
+		}
+		p.ExternalID = &externalID
+	case path == "members":
+		var ids []uint
+		if len(value) > 0 && string(value) != "null" {
+			var err error
+			if ids, err = memberIDs(value); err != nil {
+				return err
+			}
+		}
+		switch kind {
+		case "add":
+			p.Add = append(p.Add, ids...)
+		case "remove":
+			if ids == nil {
+				// Removing the attribute removes every member
+				p.ReplaceMembers, p.Members, p.Add = true, nil, nil
+			}
+			p.Remove = append(p.Remove, ids...)
+		case "replace":
+			p.ReplaceMembers, p.Members, p.Add, p.Remove = true, ids, nil, nil
+		default:
+			return fmt.Errorf("%w: %s", ErrInvalidSCIMPatch, kind)
+		}
+	case kind == "remove" && scimMemberPathPattern.MatchString(path):
+		id, err := strconv.ParseUint(scimMemberPathPattern.FindStringSubmatch(path)[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: bad member ID", ErrInvalidSCIMPatch)
+		}
+		p.Remove = append(p.Remove, uint(id))
+	default:
+		return fmt.Errorf("%w: unsupported path %q", ErrInvalidSCIMPatch, path)
+	}
+	return nil
+}
+
+// memberIDs reads a list of member references
+func memberIDs(value json.RawMessage) ([]uint, error) {
+	var refs []SCIMMemberRef
+	if err := json.Unmarshal(value, &refs); err != nil {
+		return nil, fmt.Errorf("%w: bad members", ErrInvalidSCIMPatch)
+	}
+
+	ids := make([]uint, 0, len(refs))
+	for _, ref := range refs {
+		id, err := strconv.ParseUint(ref.Value, 10, 64)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("%w: bad member ID %q", ErrInvalidSCIMPatch, ref.Value)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// Slugify turns a name into a slug: lowercase letters and digits separated
+// by dashes
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// UniqueTeamSlug returns a slug for name that is free in the organization
+func UniqueTeamSlug(db *gorm.DB, organizationID uint, name string) (string, error) {
+	base := Slugify(name)
+	if len(base) < 2 {
+		base = "team"
+	}
+	if len(base) > 90 {
+		base = strings.TrimSuffix(base[:90], "-")
+	}
+
+	slug := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := db.Model(&Team{}).Where("organization_id = ? AND slug = ?", organizationID, slug).
+			Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// SCIMExternalID returns the external ID the identity provider gave a user
+func SCIMExternalID(db *gorm.DB, userID uint) (string, error) {
+	var identity UserIdentity
+	if err := db.Where("issuer = ? AND user_id = ?", SCIMIssuer, userID).
+		Limit(1).Find(&identity).Error; err != nil {
+		return "", err
+	}
+	return identity.Subject, nil
+}
+
+// SetSCIMExternalID records the external ID of a user, replacing the
+// previous one. An empty ID removes it.
+func SetSCIMExternalID(tx *gorm.DB, user *User, externalID string) error {
+	if err := tx.Where("issuer = ? AND user_id = ?", SCIMIssuer, user.ID).
+		Delete(&UserIdentity{}).Error; err != nil {
+		return err
+	}
+	if externalID == "" {
+		return nil
+	}
+	return tx.Create(&UserIdentity{
+		UserID:  user.ID,
+		Issuer:  SCIMIssuer,
+		Subject: externalID,
+		Email:   user.Email,
+	}).Error
+}
+
+// AddTeamMembers adds users to a team, and to its organization as members
+// when they are not in it yet
+func AddTeamMembers(tx *gorm.DB, team *Team, userIDs []uint) error {
+	for _, userID := range userIDs {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&OrganizationMember{
+			OrganizationID: team.OrganizationID,
+			UserID:         userID,
+			Role:           OrgMemberRole,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&TeamMember{
+			TeamID: team.ID,
+			UserID: userID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplySCIMGroupPatch applies the member changes of a patch to a team
+func ApplySCIMGroupPatch(tx *gorm.DB, team *Team, patch *SCIMGroupPatch) error {
+	if patch.ReplaceMembers {
+		query := tx.Where("team_id = ?", team.ID)
+		if len(patch.Members) > 0 {
+			query = query.Where("user_id NOT IN ?", patch.Members)
+		}
+		if err := query.Delete(&TeamMember{}).Error; err != nil {
+			return err
+		}
+		if err := AddTeamMembers(tx, team, patch.Members); err != nil {
+			return err
+		}
+	}
+
+	if err := AddTeamMembers(tx, team, patch.Add); err != nil {
+		return err
+	}
+
+	if len(patch.Remove) > 0 {
+		if err := tx.Where("team_id = ? AND user_id IN ?", team.ID, patch.Remove).
+			Delete(&TeamMember{}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
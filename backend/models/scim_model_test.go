@@ -0,0 +1,118 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseSCIMFilter(t *testing.T) {
+	filter, err := ParseSCIMFilter(`userName eq "jane@example.com"`)
+	if err != nil || filter == nil || filter.Attribute != "username" || filter.Value != "jane@example.com" {
+		t.Fatalf("unexpected parse result: %+v, %v", filter, err)
+	}
+
+	filter, err = ParseSCIMFilter(`externalId EQ "a\"b"`)
+	if err != nil || filter.Attribute != "externalid" || filter.Value != `a"b` {
+		t.Errorf("unexpected parse result: %+v, %v", filter, err)
+	}
+
+	if filter, err := ParseSCIMFilter(""); filter != nil || err != nil {
+		t.Errorf("expected no filter, got %+v, %v", filter, err)
+	}
+
+	for _, invalid := range []string{`userName co "jane"`, `userName eq jane`, `a eq "b" and c eq "d"`} {
+		if _, err := ParseSCIMFilter(invalid); err != ErrInvalidSCIMFilter {
+			t.Errorf("%q: expected unsupported filter error, got %v", invalid, err)
+		}
+	}
+}
+
+func TestSCIMUserApplyPatch(t *testing.T) {
+	active := true
+	user := SCIMUser{UserName: "jane@example.com", Name: &SCIMName{Formatted: "Jane"}, Active: &active}
+
+	operations := []SCIMPatchOperation{
+		{Op: "Replace", Path: "active", Value: json.RawMessage(`"False"`)},
+		{Op: "replace", Value: json.RawMessage(`{"name.givenName": "Janet", "name.familyName": "Doe", "title": "SRE"}`)},
+		{Op: "add", Path: `emails[type eq "work"].value`, Value: json.RawMessage(`"janet@example.com"`)},
+	}
+	if err := user.ApplyPatch(operations); err != nil {
+		t.Fatal(err)
+	}
+
+	if *user.Active {
+		t.Error("expected user to be deactivated")
+	}
+	if user.FullName() != "Janet Doe" {
+		t.Errorf("unexpected name %q", user.FullName())
+	}
+	// The user name holds an email, it wins over the emails attribute
+	if user.Email() != "jane@example.com" {
+		t.Errorf("unexpected email %q", user.Email())
+	}
+
+	user.UserName = "jane"
+	if user.Email() != "janet@example.com" {
+		t.Errorf("unexpected email %q", user.Email())
+	}
+
+	err := user.ApplyPatch([]SCIMPatchOperation{{Op: "remove", Path: "active"}})
+	if !errors.Is(err, ErrInvalidSCIMPatch) {
+		t.Errorf("expected invalid patch error, got %v", err)
+	}
+}
+
+func TestParseSCIMGroupPatch(t *testing.T) {
+	patch, err := ParseSCIMGroupPatch([]SCIMPatchOperation{
+		{Op: "add", Path: "members", Value: json.RawMessage(`[{"value": "1"}, {"value": "2"}]`)},
+		{Op: "remove", Path: `members[value eq "3"]`},
+		{Op: "remove", Path: "members", Value: json.RawMessage(`[{"value": "4"}]`)},
+		{Op: "replace", Value: json.RawMessage(`{"displayName": "SRE", "externalId": "grp-1"}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(patch.Add, []uint{1, 2}) || !reflect.DeepEqual(patch.Remove, []uint{3, 4}) || patch.ReplaceMembers {
+		t.Errorf("unexpected member changes: %+v", patch)
+	}
+	if *patch.DisplayName != "SRE" || *patch.ExternalID != "grp-1" {
+		t.Errorf("unexpected attributes: %q, %q", *patch.DisplayName, *patch.ExternalID)
+	}
+
+	patch, err = ParseSCIMGroupPatch([]SCIMPatchOperation{
+		{Op: "add", Path: "members", Value: json.RawMessage(`[{"value": "1"}]`)},
+		{Op: "replace", Path: "members", Value: json.RawMessage(`[{"value": "5"}]`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !patch.ReplaceMembers || !reflect.DeepEqual(patch.Members, []uint{5}) || patch.Add != nil {
+		t.Errorf("expected members to be replaced: %+v", patch)
+	}
+
+	for _, invalid := range []SCIMPatchOperation{
+		{Op: "add", Path: "members", Value: json.RawMessage(`[{"value": "abc"}]`)},
+		{Op: "replace", Path: "owner", Value: json.RawMessage(`"x"`)},
+		{Op: "replace", Path: "displayName", Value: json.RawMessage(`""`)},
+	} {
+		if _, err := ParseSCIMGroupPatch([]SCIMPatchOperation{invalid}); !errors.Is(err, ErrInvalidSCIMPatch) {
+			t.Errorf("%+v: expected invalid patch error, got %v", invalid, err)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Site Reliability":   "site-reliability",
+		"  Platform / Core ": "platform-core",
+		"--":                 "",
+	}
+	for name, want := range tests {
+		if got := Slugify(name); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"html"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SearchType represents the kind of entity returned by a search
+type SearchType string
+
+const (
+	SearchProject    SearchType = "project"
+	SearchService    SearchType = "service"
+	SearchDependency SearchType = "dependency"
+	SearchComment    SearchType = "comment"
+)
+
+var validSearchTypes = map[SearchType]bool{
+	SearchProject:    true,
+	SearchService:    true,
+	SearchDependency: true,
+	SearchComment:    true,
+}
+
+func (t SearchType) IsValid() bool {
+	return validSearchTypes[t]
+}
+
+// Highlight markers used by ts_headline; they are replaced with <mark> tags
+// after the snippet has been HTML-escaped. They are control characters, and
+// the queries strip them from the searched text (@markers), so user content
+// cannot produce them.
+const (
+	highlightStart = "\x01"
+	highlightStop  = "\x02"
+)
+
+const headlineOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", MaxWords=25, MinWords=8, MaxFragments=2, FragmentDelimiter=\" … \""
+
+// SearchOptions represents the parameters of a full-text search
+type SearchOptions struct {
+	Query     string
+	Types     []SearchType
+	ProjectID *uint
+	Limit     int
+	Offset    int
+}
+
+// SearchResult represents a single ranked search hit
+type SearchResult struct {
+	Type      SearchType `json:"type"`
+	ID        uint       `json:"id"`
+	ProjectID uint       `json:"project_id"`
+	ServiceID *uint      `json:"service_id,omitempty"`
+	Title     string     `json:"title"`
+	Snippet   string     `json:"snippet"`
+	Rank      float64    `json:"rank"`
+	Total     int64      `json:"-"`
+}
+
+// searchQueries holds the SQL used for each searchable entity. Every query
+// selects the same columns so they can be combined with UNION ALL.
+var searchQueries = map[SearchType]string{
+	SearchProject: `
+		SELECT 'project' AS type, p.id, p.id AS project_id, NULL::integer AS service_id,
+			p.name AS title,
+			ts_headline('english', translate(coalesce(p.name, '') || ' ' || coalesce(p.description, ''), @markers, ''), q.query, @headline) AS snippet,
+			ts_rank(p.search_vector, q.query) AS rank
+		FROM projects p, q
+		WHERE p.search_vector @@ q.query AND p.id IN (SELECT id FROM accessible)`,
+	SearchService: `
+		SELECT 'service' AS type, s.id, s.project_id, s.id AS service_id,
+			s.name AS title,
+			ts_headline('english', translate(concat_ws(' ', s.name, s.description, s.notes, s.language, s.domain), @markers, ''), q.query, @headline) AS snippet,
+			ts_rank(s.search_vector, q.query) AS rank
+		FROM services s, q
+		WHERE s.search_vector @@ q.query AND s.project_id IN (SELECT id FROM accessible)`,
+	SearchDependency: `
+		SELECT 'dependency' AS type, d.id, src.project_id, d.source_id AS service_id,
+			src.name || ' → ' || dst.name AS title,
+			ts_headline('english', translate(coalesce(d.description, ''), @markers, ''), q.query, @headline) AS snippet,
+			ts_rank(d.search_vector, q.query) AS rank
+		FROM dependencies d
+		JOIN services src ON src.id = d.source_id
+		JOIN services dst ON dst.id = d.target_id, q
+		WHERE d.search_vector @@ q.query AND src.project_id IN (SELECT id FROM accessible)`,
+	SearchComment: `
+		SELECT 'comment' AS type, c.id, c.project_id, c.service_id,
+			left(c.content, 80) AS title,
+			ts_headline('english', translate(c.content, @markers, ''), q.query, @headline) AS snippet,
+			ts_rank(c.search_vector, q.query) AS rank
+		FROM comments c, q
+		WHERE c.search_vector @@ q.query AND c.status = 'active' AND c.project_id IN (SELECT id FROM accessible)`,
+}
+
+// searchOrder is the order in which entity queries are combined
+var searchOrder = []SearchType{SearchProject, SearchService, SearchDependency, SearchComment}
+
+// Search runs a ranked full-text search over the projects the user can access
+func Search(db *gorm.DB, userID uint, opts SearchOptions) ([]SearchResult, int64, error) {
+	sql, args := searchSQL(userID, opts)
+
+	var results []SearchResult
+	if err := db.Raw(sql, args).Scan(&results).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	for i := range results {
+		results[i].Snippet = highlightSnippet(results[i].Snippet)
+		total = results[i].Total
+	}
+
+	return results, total, nil
+}
+
+// searchSQL builds the search query for the wanted types and its named
+// arguments
+func searchSQL(userID uint, opts SearchOptions) (string, map[string]interface{}) {
+	types := opts.Types
+	if len(types) == 0 {
+		types = searchOrder
+	}
+
+	wanted := make(map[SearchType]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
+	}
+
+	parts := make([]string, 0, len(wanted))
+	for _, t := range searchOrder {
+		if wanted[t] {
+			parts = append(parts, searchQueries[t])
+		}
+	}
+
+	accessible := AccessibleProjectsSQL
+	if opts.ProjectID != nil {
+		accessible = "SELECT id FROM (" + accessible + ") a WHERE id = @project"
+	}
+
+	sql := `WITH q AS (SELECT websearch_to_tsquery('english', @query) AS query),
+		accessible AS (` + accessible + `)
+		SELECT r.*, COUNT(*) OVER() AS total FROM (` + strings.Join(parts, "\nUNION ALL") + `) r
+		ORDER BY r.rank DESC, r.type, r.id
+		LIMIT @limit OFFSET @offset`
+
+	args := map[string]interface{}{
+		"query":    opts.Query,
+		"user":     userID,
+		"headline": headlineOptions,
+		"markers":  highlightStart + highlightStop,
+		"limit":    opts.Limit,
+		"offset":   opts.Offset,
+	}
+	if opts.ProjectID != nil {
+		args["project"] = *opts.ProjectID
+	}
+
+	return sql, args
+}
+
+// highlightSnippet escapes a ts_headline snippet and turns its highlight
+// markers into <mark> tags
+func highlightSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, highlightStop, "</mark>")
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlightSnippet(t *testing.T) {
+	cases := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{"marks matches", "the \x01payments\x02 api", "the <mark>payments</mark> api"},
+		{"escapes html", "<script>\x01alert\x02</script>", "&lt;script&gt;<mark>alert</mark>&lt;/script&gt;"},
+		{"keeps template-like text", "{{hl}}not a match{{/hl}}", "{{hl}}not a match{{/hl}}"},
+		{"escapes tags in user text", "<mark>fake</mark>", "&lt;mark&gt;fake&lt;/mark&gt;"},
+	}
+	for _, tc := range cases {
+		if got := highlightSnippet(tc.snippet); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestSearchSQL(t *testing.T) {
+	sql, args := searchSQL(4, SearchOptions{Query: "payments", Limit: 20, Offset: 40})
+	for _, part := range []string{"'project' AS type", "'service' AS type", "'dependency' AS type", "'comment' AS type"} {
+		if !strings.Contains(sql, part) {
+			t.Errorf("expected every type to be searched, missing %s", part)
+		}
+	}
+	if strings.Contains(sql, "@project") {
+		t.Error("unscoped search should not filter on a project")
+	}
+	if args["query"] != "payments" || args["user"] != uint(4) || args["limit"] != 20 || args["offset"] != 40 {
+		t.Errorf("unexpected arguments %v", args)
+	}
+	if args["markers"] != highlightStart+highlightStop {
+		t.Errorf("highlight markers are not stripped from the searched text: %v", args["markers"])
+	}
+
+	projectID := uint(9)
+	sql, args = searchSQL(4, SearchOptions{Query: "db", Types: []SearchType{SearchComment, SearchService}, ProjectID: &projectID})
+	if strings.Contains(sql, "'project' AS type") || strings.Contains(sql, "'dependency' AS type") {
+		t.Error("only the wanted types should be searched")
+	}
+	if strings.Index(sql, "'service' AS type") > strings.Index(sql, "'comment' AS type") {
+		t.Error("types should be combined in search order")
+	}
+	if !strings.Contains(sql, "WHERE id = @project") || args["project"] != projectID {
+		t.Errorf("search should be scoped to project %d", projectID)
+	}
+	if strings.Count(sql, "ts_headline(") != strings.Count(sql, "translate(") {
+		t.Error("every headline should strip the highlight markers")
+	}
+}
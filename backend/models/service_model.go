@@ -17,7 +17,7 @@ type Service struct {
 	Status        string      `json:"status" gorm:"default:active;size:20"`
 	Version       string      `json:"version" gorm:"size:50"`
 	Language      string      `json:"language" gorm:"size:50"`
-	Environment   string      `json:"environment" gorm:"default:production;size:20"`
+	Environment   string      `json:"environment" gorm:"default:production;size:50"`
 	DeployURL     string      `json:"deploy_url" gorm:"size:255"`
 	Domain        string      `json:"domain" gorm:"size:255"`
 	GitRepo       string      `json:"git_repo" gorm:"size:255"`
@@ -26,14 +26,24 @@ type Service struct {
 	PosX          int         `json:"pos_x" gorm:"default:0"`
 	PosY          int         `json:"pos_y" gorm:"default:0"`
 	Notes         string      `json:"notes" gorm:"type:text"`
-	CreatedBy     uint        `json:"created_by" gorm:"not null"`
-	UpdatedBy     *uint       `json:"updated_by"`
-	CreatedAt     time.Time   `json:"created_at" gorm:"not null;default:now()"`
-	UpdatedAt     time.Time   `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Ownership and on-call data
+	OwnerTeamID       *uint        `json:"owner_team_id"`
+	EscalationContact string       `json:"escalation_contact" gorm:"size:255"`
+	Runbooks          RunbookLinks `json:"runbooks" gorm:"type:jsonb"`
+
+	CreatedBy uint      `json:"created_by" gorm:"not null"`
+	UpdatedBy *uint     `json:"updated_by"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null;default:now()"`
 
 	// Relations
-	Creator User `json:"-" gorm:"foreignKey:CreatedBy"`
-	Updater User `json:"-" gorm:"foreignKey:UpdatedBy"`
+	Creator   User              `json:"-" gorm:"foreignKey:CreatedBy"`
+	Updater   User              `json:"-" gorm:"foreignKey:UpdatedBy"`
+	Instances []ServiceInstance `json:"-" gorm:"foreignKey:ServiceID"`
+	Tags      []Tag             `json:"-" gorm:"many2many:service_tags"`
+	OwnerTeam *Team             `json:"-" gorm:"foreignKey:OwnerTeamID"`
+	Owners    []User            `json:"-" gorm:"many2many:service_owners"`
 }
 
 // CreateServiceRequest represents service creation data
@@ -44,7 +54,7 @@ type CreateServiceRequest struct {
 	Status        string      `json:"status" binding:"omitempty,oneof=active inactive"`
 	Version       string      `json:"version"`
 	Language      string      `json:"language"`
-	Environment   string      `json:"environment" binding:"omitempty,oneof=production development"`
+	Environment   string      `json:"environment" binding:"omitempty,max=50"`
 	DeployURL     string      `json:"deploy_url"`
 	Domain        string      `json:"domain"`
 	GitRepo       string      `json:"git_repo"`
@@ -53,6 +63,7 @@ type CreateServiceRequest struct {
 	PosX          int         `json:"pos_x"`
 	PosY          int         `json:"pos_y"`
 	Notes         string      `json:"notes"`
+	Tags          []string    `json:"tags"`
 }
 
 // UpdateServiceRequest represents service update data
@@ -64,7 +75,7 @@ type UpdateServiceRequest struct {
 	Status        string      `json:"status" binding:"omitempty,oneof=active inactive"`
 	Version       string      `json:"version"`
 	Language      string      `json:"language"`
-	Environment   string      `json:"environment" binding:"omitempty,oneof=production development"`
+	Environment   string      `json:"environment" binding:"omitempty,max=50"`
 	DeployURL     string      `json:"deploy_url"`
 	Domain        string      `json:"domain"`
 	GitRepo       string      `json:"git_repo"`
@@ -73,33 +84,43 @@ type UpdateServiceRequest struct {
 	PosX          int         `json:"pos_x"`
 	PosY          int         `json:"pos_y"`
 	Notes         string      `json:"notes"`
+	// Tags replaces the service tags when present; an empty list clears them
+	Tags []string `json:"tags"`
 }
 
 // ServiceResponse represents service response
 type ServiceResponse struct {
-	ID            uint         `json:"id"`
-	ProjectID     uint         `json:"project_id"`
-	Name          string       `json:"name"`
-	Description   string       `json:"description"`
-	Type          string       `json:"type"`
-	Status        string       `json:"status"`
-	Version       string       `json:"version"`
-	Language      string       `json:"language"`
-	Environment   string       `json:"environment"`
-	DeployURL     string       `json:"deploy_url"`
-	Domain        string       `json:"domain"`
-	GitRepo       string       `json:"git_repo"`
-	HealthMetrics interface{}  `json:"health_metrics"`
-	Metadata      interface{}  `json:"metadata"`
-	PosX          int          `json:"pos_x"`
-	PosY          int          `json:"pos_y"`
-	Notes         string       `json:"notes"`
-	CreatedBy     uint         `json:"created_by"`
-	UpdatedBy     *uint        `json:"updated_by"`
-	CreatedAt     time.Time    `json:"created_at"`
-	UpdatedAt     time.Time    `json:"updated_at"`
-	Creator       UserResponse `json:"creator,omitempty"`
-	Updater       UserResponse `json:"updater,omitempty"`
+	ID            uint                      `json:"id"`
+	ProjectID     uint                      `json:"project_id"`
+	Name          string                    `json:"name"`
+	Description   string                    `json:"description"`
+	Type          string                    `json:"type"`
+	Status        string                    `json:"status"`
+	Version       string                    `json:"version"`
+	Language      string                    `json:"language"`
+	Environment   string                    `json:"environment"`
+	DeployURL     string                    `json:"deploy_url"`
+	Domain        string                    `json:"domain"`
+	GitRepo       string                    `json:"git_repo"`
+	HealthMetrics interface{}               `json:"health_metrics"`
+	Metadata      interface{}               `json:"metadata"`
+	PosX          int                       `json:"pos_x"`
+	PosY          int                       `json:"pos_y"`
+	Notes         string                    `json:"notes"`
+	CreatedBy     uint                      `json:"created_by"`
+	UpdatedBy     *uint                     `json:"updated_by"`
+	CreatedAt     time.Time                 `json:"created_at"`
+	UpdatedAt     time.Time                 `json:"updated_at"`
+	Creator       UserResponse              `json:"creator,omitempty"`
+	Updater       UserResponse              `json:"updater,omitempty"`
+	Instances     []ServiceInstanceResponse `json:"instances,omitempty"`
+	Tags          []TagResponse             `json:"tags,omitempty"`
+
+	OwnerTeamID       *uint          `json:"owner_team_id"`
+	OwnerTeam         *TeamResponse  `json:"owner_team,omitempty"`
+	Owners            []UserResponse `json:"owners,omitempty"`
+	EscalationContact string         `json:"escalation_contact"`
+	Runbooks          RunbookLinks   `json:"runbooks,omitempty"`
 }
 
 // ToResponse converts service to ServiceResponse
@@ -126,6 +147,10 @@ func (s *Service) ToResponse() ServiceResponse {
 		UpdatedBy:     s.UpdatedBy,
 		CreatedAt:     s.CreatedAt,
 		UpdatedAt:     s.UpdatedAt,
+
+		OwnerTeamID:       s.OwnerTeamID,
+		EscalationContact: s.EscalationContact,
+		Runbooks:          s.Runbooks,
 	}
 
 	// Include creator if loaded
@@ -138,6 +163,25 @@ func (s *Service) ToResponse() ServiceResponse {
 		response.Updater = s.Updater.ToResponse()
 	}
 
+	// Convert environment instances if loaded
+	for _, instance := range s.Instances {
+		response.Instances = append(response.Instances, instance.ToResponse())
+	}
+
+	// Convert tags if loaded
+	for _, tag := range s.Tags {
+		response.Tags = append(response.Tags, tag.ToResponse())
+	}
+
+	// Include owners if loaded
+	if s.OwnerTeam != nil && s.OwnerTeam.ID != 0 {
+		team := s.OwnerTeam.ToResponse()
+		response.OwnerTeam = &team
+	}
+	for _, owner := range s.Owners {
+		response.Owners = append(response.Owners, owner.ToResponse())
+	}
+
 	return response
 }
 
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Setting keys changed at runtime by admins
+const (
+	SettingRequireAdminTwoFactor = "security.require_admin_2fa"
+)
+
+// Setting represents an instance-wide setting managed by admins
+type Setting struct {
+	Key       string    `json:"key" gorm:"primaryKey;size:100"`
+	Value     string    `json:"value" gorm:"type:text"`
+	UpdatedBy *uint     `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// SecuritySettings represents the security settings admins can change
+type SecuritySettings struct {
+	RequireAdminTwoFactor bool `json:"require_admin_2fa"`
+}
+
+// UpdateSecuritySettingsRequest represents a change of security settings
+type UpdateSecuritySettingsRequest struct {
+	RequireAdminTwoFactor *bool `json:"require_admin_2fa"`
+}
+
+// TableName specifies the table name for Setting
+func (Setting) TableName() string {
+	return "settings"
+}
+
+// GetBoolSetting reads a boolean setting, falling back to def when it is
+// unset or cannot be read
+func GetBoolSetting(db *gorm.DB, key string, def bool) bool {
+	var setting Setting
+	if err := db.Where("key = ?", key).Limit(1).Find(&setting).Error; err != nil || setting.Key == "" {
+		return def
+	}
+
+	value, err := strconv.ParseBool(setting.Value)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// SetSetting creates or updates a setting
+func SetSetting(db *gorm.DB, key, value string, updatedBy uint) error {
+	return db.Save(&Setting{
+		Key:       key,
+		Value:     value,
+		UpdatedBy: &updatedBy,
+		UpdatedAt: time.Now(),
+	}).Error
+}
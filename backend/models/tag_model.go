@@ -0,0 +1,196 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultTagColor is used for tags created without an explicit color
+const DefaultTagColor = "#6b7280"
+
+var (
+	tagNamePattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9._:-]{0,49}$`)
+	tagColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+// Tag represents a label that can be attached to projects and services
+type Tag struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"unique;not null;size:50"`
+	Color       string    `json:"color" gorm:"not null;size:7"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedBy   uint      `json:"created_by" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// CreateTagRequest represents tag creation data
+type CreateTagRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=50"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+// UpdateTagRequest represents tag update data
+type UpdateTagRequest struct {
+	Name        string  `json:"name" binding:"omitempty,min=1,max=50"`
+	Color       string  `json:"color"`
+	Description *string `json:"description"`
+}
+
+// BulkTagRequest adds and removes tags on a set of services
+type BulkTagRequest struct {
+	ServiceIDs []uint   `json:"service_ids" binding:"required"`
+	Add        []string `json:"add"`
+	Remove     []string `json:"remove"`
+}
+
+// TagResponse represents tag response
+type TagResponse struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description,omitempty"`
+}
+
+// ToResponse converts tag to TagResponse
+func (t *Tag) ToResponse() TagResponse {
+	return TagResponse{
+		ID:          t.ID,
+		Name:        t.Name,
+		Color:       t.Color,
+		Description: t.Description,
+	}
+}
+
+// TableName specifies the table name for Tag
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// BeforeCreate runs before creating a tag
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	if t.UpdatedAt.IsZero() {
+		t.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// BeforeUpdate runs before updating a tag
+func (t *Tag) BeforeUpdate(tx *gorm.DB) error {
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// NormalizeTagName returns the canonical form of a tag name
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// IsValidTagName reports whether a normalized name can be used as a tag
+func IsValidTagName(name string) bool {
+	return tagNamePattern.MatchString(name)
+}
+
+// IsValidTagColor reports whether color is a #rrggbb hex color
+func IsValidTagColor(color string) bool {
+	return tagColorPattern.MatchString(color)
+}
+
+// NormalizeTagNames normalizes a list of tag names, dropping empty ones
+func NormalizeTagNames(raw []string) []string {
+	var names []string
+	for _, part := range raw {
+		if name := NormalizeTagName(part); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ParseTagFilter splits a comma separated ?tag= parameter into normalized names
+func ParseTagFilter(raw string) []string {
+	return NormalizeTagNames(strings.Split(raw, ","))
+}
+
+// ResolveTags returns the tags with the given names, creating the missing
+// ones with the default color
+func ResolveTags(db *gorm.DB, names []string, userID uint) ([]Tag, error) {
+	tags := make([]Tag, 0, len(names))
+	seen := make(map[string]bool, len(names))
+
+	for _, raw := range names {
+		name := NormalizeTagName(raw)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if !IsValidTagName(name) {
+			return nil, fmt.Errorf("invalid tag name %q", raw)
+		}
+
+		tag := Tag{Name: name}
+		if err := db.Where(Tag{Name: name}).
+			Attrs(Tag{Color: DefaultTagColor, CreatedBy: userID}).
+			FirstOrCreate(&tag).Error; err != nil {
+			return nil, fmt.Errorf("failed to resolve tag %q: %v", name, err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// FilterByServiceTags keeps rows whose service (identified by column) carries
+// every one of the given tags
+func FilterByServiceTags(db *gorm.DB, column string, names []string) *gorm.DB {
+	return filterByTags(db, column, "service_tags", "service_id", names)
+}
+
+// FilterByProjectTags keeps rows whose project (identified by column) carries
+// every one of the given tags
+func FilterByProjectTags(db *gorm.DB, column string, names []string) *gorm.DB {
+	return filterByTags(db, column, "project_tags", "project_id", names)
+}
+
+func filterByTags(db *gorm.DB, column, joinTable, joinColumn string, names []string) *gorm.DB {
+	if len(names) == 0 {
+		return db
+	}
+
+	subquery := fmt.Sprintf(`%s IN (SELECT jt.%s FROM %s jt JOIN tags t ON t.id = jt.tag_id
+		WHERE t.name IN ? GROUP BY jt.%s HAVING COUNT(DISTINCT t.id) = ?)`, column, joinColumn, joinTable, joinColumn)
+
+	return db.Where(subquery, names, len(uniqueStrings(names)))
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// HasTag reports whether the service carries the named tag. Tags must be loaded.
+func (s *Service) HasTag(name string) bool {
+	name = NormalizeTagName(name)
+	for _, tag := range s.Tags {
+		if tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
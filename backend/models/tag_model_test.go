@@ -0,0 +1,35 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTagFilter(t *testing.T) {
+	got := ParseTagFilter(" PCI, tier-1,,team-payments ")
+	want := []string{"pci", "tier-1", "team-payments"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if got := ParseTagFilter(""); len(got) != 0 {
+		t.Errorf("expected no tags, got %v", got)
+	}
+}
+
+func TestTagValidation(t *testing.T) {
+	for _, name := range []string{"pci", "tier-1", "team:payments", "v2.legacy"} {
+		if !IsValidTagName(name) {
+			t.Errorf("expected %q to be a valid tag name", name)
+		}
+	}
+	for _, name := range []string{"", "-pci", "Tier 1", "a/b"} {
+		if IsValidTagName(name) {
+			t.Errorf("expected %q to be an invalid tag name", name)
+		}
+	}
+
+	if !IsValidTagColor("#1F2937") || IsValidTagColor("red") || IsValidTagColor("#fff") {
+		t.Error("unexpected tag color validation result")
+	}
+}
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// A thread is a top-level comment and its replies at any depth. Every
+// comment records the root of its thread, its depth below the root and its
+// path: the zero-padded IDs from the root down to itself, separated by
+// slashes. Ordering by path lists a thread depth first, replies in the order
+// they were written.
+//
+// Deleting a comment that has active replies keeps it in the thread as a
+// placeholder without content, so the replies keep their context. Deleted
+// comments without active replies are left out.
+
+// pathSegmentWidth pads IDs so paths sort like the tree
+const pathSegmentWidth = 10
+
+// ThreadPath returns the path of a comment below the path of its parent,
+// or the path of a root comment when parentPath is empty
+func ThreadPath(parentPath string, id uint) string {
+	segment := fmt.Sprintf("%0*d", pathSegmentWidth, id)
+	if parentPath == "" {
+		return segment
+	}
+	return parentPath + "/" + segment
+}
+
+// LockThreadRequest represents thread lock data
+type LockThreadRequest struct {
+	Locked *bool `json:"locked" binding:"required"`
+}
+
+// ResolveThreadRequest represents thread resolution data
+type ResolveThreadRequest struct {
+	Resolved *bool `json:"resolved" binding:"required"`
+}
+
+// MoveThreadRequest represents the new subject of a thread: a service, a
+// dependency, or the project itself when both are empty
+type MoveThreadRequest struct {
+	ServiceID    *uint `json:"service_id"`
+	DependencyID *uint `json:"dependency_id"`
+}
+
+// ThreadResponse represents the state of a thread, on its root comment
+type ThreadResponse struct {
+	Locked     bool       `json:"locked"`
+	LockedAt   *time.Time `json:"locked_at,omitempty"`
+	LockedBy   *uint      `json:"locked_by,omitempty"`
+	Resolved   bool       `json:"resolved"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy *uint      `json:"resolved_by,omitempty"`
+}
+
+// AfterCreate places a new comment in its thread
+func (c *Comment) AfterCreate(tx *gorm.DB) error {
+	threadID, depth, path := c.ID, 0, ThreadPath("", c.ID)
+	if c.ParentID != nil {
+		var parent Comment
+		if err := tx.Select("id", "thread_id", "depth", "path").First(&parent, *c.ParentID).Error; err != nil {
+			return err
+		}
+		if parent.ThreadID != nil {
+			threadID = *parent.ThreadID
+		}
+		depth, path = parent.Depth+1, ThreadPath(parent.Path, c.ID)
+	}
+
+	c.ThreadID, c.Depth, c.Path = &threadID, depth, path
+	return tx.Model(c).UpdateColumns(map[string]interface{}{
+		"thread_id": threadID,
+		"depth":     depth,
+		"path":      path,
+	}).Error
+}
+
+// IsLocked reports whether the thread of a root comment is locked
+func (c *Comment) IsLocked() bool {
+	return c.LockedAt != nil
+}
+
+// ThreadResponse returns the state of the thread of a root comment, or nil
+// for replies
+func (c *Comment) ThreadResponse() *ThreadResponse {
+	if c.ParentID != nil {
+		return nil
+	}
+	return &ThreadResponse{
+		Locked:     c.LockedAt != nil,
+		LockedAt:   c.LockedAt,
+		LockedBy:   c.LockedBy,
+		Resolved:   c.ThreadResolvedAt != nil,
+		ResolvedAt: c.ThreadResolvedAt,
+		ResolvedBy: c.ThreadResolvedBy,
+	}
+}
+
+// ThreadRoot returns the root comment of the thread of a comment, which is
+// the comment itself for top-level comments
+func ThreadRoot(db *gorm.DB, comment *Comment) (*Comment, error) {
+	if comment.ParentID == nil || comment.ThreadID == nil {
+		return comment, nil
+	}
+	var root Comment
+	if err := db.First(&root, *comment.ThreadID).Error; err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// VisibleCommentSQL selects active comments and the deleted comments kept as
+// placeholders for their active replies
+const VisibleCommentSQL = `comments.status = 'active' OR (comments.status = 'deleted' AND EXISTS (
+	SELECT 1 FROM comments AS replies
+	WHERE replies.thread_id = comments.thread_id AND replies.status = 'active'
+	AND replies.path LIKE comments.path || '/%'))`
+
+// LoadCommentTrees fills the Replies of comments with their replies at any
+// depth. load adds the relationships to preload on replies.
+func LoadCommentTrees(db *gorm.DB, comments []Comment, load func(*gorm.DB) *gorm.DB) error {
+	threadIDs := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		if comment.ThreadID != nil {
+			threadIDs = append(threadIDs, *comment.ThreadID)
+		}
+	}
+	if len(threadIDs) == 0 {
+		return nil
+	}
+
+	var replies []Comment
+	query := db.Where("thread_id IN ? AND parent_id IS NOT NULL AND status IN ?", threadIDs, []string{"active", "deleted"})
+	if load != nil {
+		query = load(query)
+	}
+	if err := query.Order("path ASC").Find(&replies).Error; err != nil {
+		return err
+	}
+
+	children := make(map[uint][]int)
+	for i, reply := range replies {
+		children[*reply.ParentID] = append(children[*reply.ParentID], i)
+	}
+	for i := range comments {
+		comments[i].Replies = buildReplies(comments[i].ID, replies, children)
+	}
+	return nil
+}
+
+// buildReplies nests the replies to a comment, leaving out deleted replies
+// that have no active replies themselves
+func buildReplies(parentID uint, replies []Comment, children map[uint][]int) []Comment {
+	var nested []Comment
+	for _, i := range children[parentID] {
+		reply := replies[i]
+		reply.Replies = buildReplies(reply.ID, replies, children)
+		if reply.Status == "active" || len(reply.Replies) > 0 {
+			nested = append(nested, reply)
+		}
+	}
+	return nested
+}
+
+// MoveThread attaches every comment of the thread of root to a service, a
+// dependency, or the project itself when both are nil
+func MoveThread(tx *gorm.DB, root *Comment, serviceID, dependencyID *uint) error {
+	return tx.Model(&Comment{}).Where("thread_id = ?", root.ID).UpdateColumns(map[string]interface{}{
+		"service_id":    serviceID,
+		"dependency_id": dependencyID,
+	}).Error
+}
@@ -0,0 +1,51 @@
+package models
+
+import "testing"
+
+func TestThreadPath(t *testing.T) {
+	root := ThreadPath("", 12)
+	if root != "0000000012" {
+		t.Errorf("root path %q", root)
+	}
+	reply := ThreadPath(root, 345)
+	if reply != "0000000012/0000000345" {
+		t.Errorf("reply path %q", reply)
+	}
+	// Paths sort like the tree: a reply comes before the next sibling of
+	// its parent, even when that sibling has a smaller ID
+	if !(reply < ThreadPath("", 13)) || !(ThreadPath(root, 9) < reply) {
+		t.Error("paths do not sort depth first")
+	}
+}
+
+func TestBuildReplies(t *testing.T) {
+	id := func(v uint) *uint { return &v }
+	// 1 root
+	// ├── 2 deleted, with an active reply 3
+	// │   └── 3
+	// │       └── 4 deleted, without replies
+	// └── 5 deleted, without replies
+	replies := []Comment{
+		{ID: 2, ParentID: id(1), Status: "deleted", Content: "removed"},
+		{ID: 3, ParentID: id(2), Status: "active", Content: "reply"},
+		{ID: 4, ParentID: id(3), Status: "deleted"},
+		{ID: 5, ParentID: id(1), Status: "deleted"},
+	}
+	children := map[uint][]int{1: {0, 3}, 2: {1}, 3: {2}}
+
+	tree := buildReplies(1, replies, children)
+	if len(tree) != 1 || tree[0].ID != 2 {
+		t.Fatalf("expected only the placeholder 2 under the root, got %+v", tree)
+	}
+	if len(tree[0].Replies) != 1 || tree[0].Replies[0].ID != 3 || len(tree[0].Replies[0].Replies) != 0 {
+		t.Fatalf("unexpected replies %+v", tree[0].Replies)
+	}
+
+	response := tree[0].ToResponse()
+	if response.Content != "" || response.ContentHTML != "" || len(response.Replies) != 1 {
+		t.Errorf("placeholder shows content or lost replies: %+v", response)
+	}
+	if response.Thread != nil {
+		t.Error("replies carry no thread state")
+	}
+}
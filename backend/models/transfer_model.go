@@ -0,0 +1,209 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TransferStatus represents the state of a project ownership transfer
+type TransferStatus string
+
+const (
+	TransferPending   TransferStatus = "pending"
+	TransferAccepted  TransferStatus = "accepted"
+	TransferDeclined  TransferStatus = "declined"
+	TransferCancelled TransferStatus = "cancelled"
+	TransferForced    TransferStatus = "forced"
+)
+
+// TransferExpiry is how long a transfer waits for the recipient to answer
+const TransferExpiry = 14 * 24 * time.Hour
+
+// ProjectTransfer represents a request to hand the primary ownership of a
+// project to another user
+type ProjectTransfer struct {
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	ProjectID         uint           `json:"project_id" gorm:"not null"`
+	Project           Project        `json:"-" gorm:"foreignKey:ProjectID"`
+	FromUserID        uint           `json:"from_user_id" gorm:"not null"`
+	FromUser          User           `json:"-" gorm:"foreignKey:FromUserID"`
+	ToUserID          uint           `json:"to_user_id" gorm:"not null"`
+	ToUser            User           `json:"-" gorm:"foreignKey:ToUserID"`
+	PreviousOwnerRole string         `json:"previous_owner_role" gorm:"size:50"`
+	Status            TransferStatus `json:"status" gorm:"default:pending;size:20"`
+	RequestedBy       uint           `json:"requested_by" gorm:"not null"`
+	CreatedAt         time.Time      `json:"created_at" gorm:"not null;default:now()"`
+	ExpiresAt         time.Time      `json:"expires_at" gorm:"not null"`
+	RespondedAt       *time.Time     `json:"responded_at"`
+}
+
+// CreateTransferRequest represents the data to start an ownership transfer.
+// PreviousOwnerRole is the collaborator role the current owner keeps once
+// the transfer is accepted; "none" removes them from the project.
+type CreateTransferRequest struct {
+	Email             string `json:"email" binding:"required,email"`
+	PreviousOwnerRole string `json:"previous_owner_role" binding:"omitempty,oneof=owner editor viewer none"`
+}
+
+// TransferResponse represents an ownership transfer response
+type TransferResponse struct {
+	ID                uint           `json:"id"`
+	ProjectID         uint           `json:"project_id"`
+	ProjectName       string         `json:"project_name,omitempty"`
+	FromUser          UserResponse   `json:"from_user"`
+	ToUser            UserResponse   `json:"to_user"`
+	PreviousOwnerRole string         `json:"previous_owner_role"`
+	Status            TransferStatus `json:"status"`
+	RequestedBy       uint           `json:"requested_by"`
+	CreatedAt         time.Time      `json:"created_at"`
+	ExpiresAt         time.Time      `json:"expires_at"`
+	RespondedAt       *time.Time     `json:"responded_at"`
+}
+
+// ToResponse converts transfer to TransferResponse
+func (t *ProjectTransfer) ToResponse() TransferResponse {
+	response := TransferResponse{
+		ID:                t.ID,
+		ProjectID:         t.ProjectID,
+		ProjectName:       t.Project.Name,
+		PreviousOwnerRole: t.PreviousOwnerRole,
+		Status:            t.Status,
+		RequestedBy:       t.RequestedBy,
+		CreatedAt:         t.CreatedAt,
+		ExpiresAt:         t.ExpiresAt,
+		RespondedAt:       t.RespondedAt,
+	}
+
+	// Include users if loaded
+	if t.FromUser.ID != 0 {
+		response.FromUser = t.FromUser.ToResponse()
+	}
+	if t.ToUser.ID != 0 {
+		response.ToUser = t.ToUser.ToResponse()
+	}
+
+	return response
+}
+
+// IsExpired reports whether a pending transfer can no longer be accepted
+func (t *ProjectTransfer) IsExpired(now time.Time) bool {
+	return t.Status == TransferPending && now.After(t.ExpiresAt)
+}
+
+// TableName specifies the table name for ProjectTransfer
+func (ProjectTransfer) TableName() string {
+	return "project_transfers"
+}
+
+// TransferProjectOwnership makes newOwnerID the primary owner of a project.
+// The new owner's collaborator grant is dropped since ownership supersedes it,
+// and the previous owner keeps previousOwnerRole as collaborator ("" or "none"
+// removes them). Must run inside a transaction.
+func TransferProjectOwnership(tx *gorm.DB, project *Project, newOwnerID uint, previousOwnerRole string) error {
+	previousOwnerID := project.OwnerID
+
+	if err := tx.Model(project).Update("owner_id", newOwnerID).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("project_id = ? AND user_id = ?", project.ID, newOwnerID).
+		Delete(&ProjectCollaborator{}).Error; err != nil {
+		return err
+	}
+
+	if previousOwnerRole != "" && previousOwnerRole != "none" && previousOwnerID != newOwnerID {
+		collaborator := ProjectCollaborator{
+			ProjectID: project.ID,
+			UserID:    previousOwnerID,
+			Role:      previousOwnerRole,
+			State:     "active",
+			JoinedAt:  time.Now(),
+		}
+		if err := tx.Save(&collaborator).Error; err != nil {
+			return err
+		}
+	}
+
+	// Any other pending transfer of this project is now stale
+	return tx.Model(&ProjectTransfer{}).
+		Where("project_id = ? AND status = ?", project.ID, TransferPending).
+		Updates(map[string]interface{}{"status": TransferCancelled, "responded_at": time.Now()}).Error
+}
+
+// SuccessorOwnerID picks the collaborator that inherits a project when its
+// owner leaves: the longest-standing active collaborator with the owner role.
+// Returns 0 when the project has no co-owner.
+func SuccessorOwnerID(db *gorm.DB, projectID, leavingUserID uint) (uint, error) {
+	var collaborators []ProjectCollaborator
+	err := db.Where("project_id = ? AND user_id <> ? AND role = ? AND state = ?",
+		projectID, leavingUserID, string(ProjectOwnerRole), "active").
+		Order("joined_at, user_id").Limit(1).Find(&collaborators).Error
+	if err != nil || len(collaborators) == 0 {
+		return 0, err
+	}
+	return collaborators[0].UserID, nil
+}
+
+// PlanOwnershipSuccession decides who inherits each project a leaving user
+// owns: transferTo when set, otherwise the co-owner picked by
+// SuccessorOwnerID. Projects nobody can inherit are returned as orphaned.
+func PlanOwnershipSuccession(db *gorm.DB, userID, transferTo uint) (owned []Project, successors map[uint]uint, orphaned []Project, err error) {
+	if err := db.Where("owner_id = ?", userID).Find(&owned).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	successors = make(map[uint]uint, len(owned))
+	for _, project := range owned {
+		successorID := transferTo
+		if successorID == 0 {
+			if successorID, err = SuccessorOwnerID(db, project.ID, userID); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		if successorID == 0 {
+			orphaned = append(orphaned, project)
+			continue
+		}
+		successors[project.ID] = successorID
+	}
+
+	return owned, successors, orphaned, nil
+}
+
+// DeleteUserWithSuccession forces the transfers planned by
+// PlanOwnershipSuccession, records them, cancels the transfers offered to the
+// user and deletes the user. Must run inside a transaction.
+func DeleteUserWithSuccession(tx *gorm.DB, user *User, owned []Project, successors map[uint]uint, requestedBy uint) error {
+	now := time.Now()
+	for i := range owned {
+		project := &owned[i]
+		if err := TransferProjectOwnership(tx, project, successors[project.ID], "none"); err != nil {
+			return err
+		}
+
+		// Keep a record of the forced transfer
+		if err := tx.Create(&ProjectTransfer{
+			ProjectID:         project.ID,
+			FromUserID:        user.ID,
+			ToUserID:          successors[project.ID],
+			PreviousOwnerRole: "none",
+			Status:            TransferForced,
+			RequestedBy:       requestedBy,
+			CreatedAt:         now,
+			ExpiresAt:         now,
+			RespondedAt:       &now,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	// Transfers offered to the user can no longer be answered
+	if err := tx.Model(&ProjectTransfer{}).
+		Where("to_user_id = ? AND status = ?", user.ID, TransferPending).
+		Updates(map[string]interface{}{"status": TransferCancelled, "responded_at": now}).Error; err != nil {
+		return err
+	}
+
+	return tx.Delete(user).Error
+}
@@ -0,0 +1,278 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeCount is the number of recovery codes issued at once
+const RecoveryCodeCount = 10
+
+// recoveryCodeAlphabet avoids characters that are easy to confuse
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// UserTwoFactor holds the TOTP secret of a user. It is created when the user
+// starts enrollment and only enforced once EnabledAt is set.
+type UserTwoFactor struct {
+	UserID    uint       `json:"user_id" gorm:"primaryKey"`
+	Secret    string     `json:"-" gorm:"not null;size:64"`
+	EnabledAt *time.Time `json:"enabled_at"`
+	// LastUsedStep is the time step of the last accepted code, so a code
+	// cannot be replayed within its validity window
+	LastUsedStep int64 `json:"-" gorm:"not null;default:0"`
+	// FailedAttempts counts the wrong codes given at login since the last
+	// accepted one; LockedUntil is set once there are too many
+	FailedAttempts int        `json:"-" gorm:"not null;default:0"`
+	LockedUntil    *time.Time `json:"-"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// RecoveryCode represents a single-use code to log in without the
+// authenticator. Only its SHA-256 is stored.
+type RecoveryCode struct {
+	ID       uint       `json:"id" gorm:"primaryKey"`
+	UserID   uint       `json:"user_id" gorm:"not null"`
+	CodeHash string     `json:"-" gorm:"not null;size:64"`
+	UsedAt   *time.Time `json:"used_at"`
+}
+
+// TwoFactorCodeRequest carries a TOTP code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTwoFactorRequest represents the data to turn 2FA off
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// TwoFactorLoginRequest completes a login started with a password. Code is
+// either a TOTP code or a recovery code.
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// TwoFactorChallengeResponse is returned by Login when a second factor is needed
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"`
+}
+
+// TwoFactorSetupResponse represents a pending enrollment
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorStatusResponse represents the 2FA state of a user
+type TwoFactorStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+	Required               bool       `json:"required"`
+}
+
+// TableName specifies the table name for UserTwoFactor
+func (UserTwoFactor) TableName() string {
+	return "user_two_factor"
+}
+
+// TableName specifies the table name for RecoveryCode
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+// IsEnabled reports whether the second factor is enforced at login
+func (t *UserTwoFactor) IsEnabled() bool {
+	return t.EnabledAt != nil
+}
+
+// GetUserTwoFactor loads the 2FA settings of a user. It returns nil when the
+// user never started enrollment.
+func GetUserTwoFactor(db *gorm.DB, userID uint) (*UserTwoFactor, error) {
+	var twoFactor UserTwoFactor
+	if err := db.Where("user_id = ?", userID).Limit(1).Find(&twoFactor).Error; err != nil {
+		return nil, err
+	}
+	if twoFactor.UserID == 0 {
+		return nil, nil
+	}
+	return &twoFactor, nil
+}
+
+// HasTwoFactorEnabled reports whether the user has completed 2FA enrollment
+func HasTwoFactorEnabled(db *gorm.DB, userID uint) (bool, error) {
+	var count int64
+	err := db.Model(&UserTwoFactor{}).Where("user_id = ? AND enabled_at IS NOT NULL", userID).Count(&count).Error
+	return count > 0, err
+}
+
+// IsTwoFactorRequired reports whether the user must use 2FA: admins when the
+// instance requires it for the admin role. def is the configured default.
+func IsTwoFactorRequired(db *gorm.DB, user *User, def bool) bool {
+	return user.Role == AdminRole && GetBoolSetting(db, SettingRequireAdminTwoFactor, def)
+}
+
+// GenerateRecoveryCodes replaces the recovery codes of a user and returns
+// the new ones in clear text. They are never retrievable again.
+func GenerateRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, RecoveryCodeCount)
+	rows := make([]RecoveryCode, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		rows = append(rows, RecoveryCode{UserID: userID, CodeHash: HashToken(normalizeRecoveryCode(code))})
+	}
+
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// UseRecoveryCode consumes a recovery code of the user. It reports false when
+// the code is unknown or already used.
+func UseRecoveryCode(tx *gorm.DB, userID uint, code string) (bool, error) {
+	hash := HashToken(normalizeRecoveryCode(code))
+
+	var candidates []RecoveryCode
+	if err := tx.Where("user_id = ? AND used_at IS NULL", userID).Find(&candidates).Error; err != nil {
+		return false, err
+	}
+
+	for _, candidate := range candidates {
+		if subtle.ConstantTimeCompare([]byte(candidate.CodeHash), []byte(hash)) != 1 {
+			continue
+		}
+		result := tx.Model(&RecoveryCode{}).
+			Where("id = ? AND used_at IS NULL", candidate.ID).
+			Update("used_at", time.Now())
+		return result.RowsAffected == 1, result.Error
+	}
+
+	return false, nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has left
+func CountRecoveryCodes(db *gorm.DB, userID uint) (int64, error) {
+	var count int64
+	err := db.Model(&RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// DeleteUserTwoFactor removes the 2FA secret and recovery codes of a user
+func DeleteUserTwoFactor(tx *gorm.DB, userID uint) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&UserTwoFactor{}).Error
+}
+
+// newRecoveryCode returns a code formatted as xxxxx-xxxxx
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = recoveryCodeAlphabet[int(b[i])%len(recoveryCodeAlphabet)]
+	}
+	return string(b[:5]) + "-" + string(b[5:]), nil
+}
+
+// normalizeRecoveryCode ignores case, spaces and dashes
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}
+
+// twoFactorAudience identifies challenge tokens so they cannot be used as
+// session tokens or the other way around
+const twoFactorAudience = "2fa-challenge"
+
+// ErrInvalidChallengeToken is returned for challenge tokens that are
+// malformed, expired or not signed by us
+var ErrInvalidChallengeToken = errors.New("invalid two-factor challenge token")
+
+// SignTwoFactorChallenge creates the short-lived token Login returns once the
+// password is verified. It only proves the first factor.
+func SignTwoFactorChallenge(secret []byte, user *User, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.FormatUint(uint64(user.ID), 10),
+		Audience:  jwt.ClaimStrings{twoFactorAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+}
+
+// ParseTwoFactorChallenge verifies a challenge token and returns the user ID
+func ParseTwoFactorChallenge(secret []byte, token string) (uint, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(twoFactorAudience))
+	if err != nil {
+		return 0, ErrInvalidChallengeToken
+	}
+
+	id, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || id == 0 {
+		return 0, ErrInvalidChallengeToken
+	}
+
+	return uint(id), nil
+}
+
+// LockedFor returns how long login codes are still refused at now, or zero
+func (t *UserTwoFactor) LockedFor(now time.Time) time.Duration {
+	if t.LockedUntil == nil || !now.Before(*t.LockedUntil) {
+		return 0
+	}
+	return t.LockedUntil.Sub(now)
+}
+
+// RecordTwoFactorFailure counts a wrong login code. Once maxAttempts codes
+// have failed in a row, codes are refused for lockout; every further failure
+// restarts it.
+func RecordTwoFactorFailure(db *gorm.DB, userID uint, maxAttempts int, lockout time.Duration) error {
+	return db.Model(&UserTwoFactor{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+		"failed_attempts": gorm.Expr("failed_attempts + 1"),
+		"locked_until":    gorm.Expr("CASE WHEN failed_attempts + 1 >= ? THEN ? ELSE locked_until END", maxAttempts, time.Now().Add(lockout)),
+	}).Error
+}
+
+// ResetTwoFactorFailures clears the failed attempts after an accepted code
+func ResetTwoFactorFailures(db *gorm.DB, userID uint) error {
+	return db.Model(&UserTwoFactor{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+		"failed_attempts": 0,
+		"locked_until":    nil,
+	}).Error
+}
+
+// UseTOTPStep records the time step of an accepted code. It reports false
+// when a code of the same or a later step was already used.
+func UseTOTPStep(tx *gorm.DB, userID uint, step int64) (bool, error) {
+	result := tx.Model(&UserTwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return result.RowsAffected == 1, result.Error
+}
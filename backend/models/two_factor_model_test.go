@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTwoFactorChallenge(t *testing.T) {
+	secret := []byte("test-secret")
+	user := &User{ID: 7}
+
+	token, err := SignTwoFactorChallenge(secret, user, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := ParseTwoFactorChallenge(secret, token)
+	if err != nil || id != 7 {
+		t.Fatalf("unexpected parse result: %d, %v", id, err)
+	}
+
+	// Tokens signed with another key are rejected
+	if _, err := ParseTwoFactorChallenge([]byte("other-secret"), token); err != ErrInvalidChallengeToken {
+		t.Errorf("expected invalid token error, got %v", err)
+	}
+
+	// Expired challenges are rejected
+	expired, _ := SignTwoFactorChallenge(secret, user, -time.Minute)
+	if _, err := ParseTwoFactorChallenge(secret, expired); err != ErrInvalidChallengeToken {
+		t.Errorf("expected expired token to be rejected, got %v", err)
+	}
+
+	// Invitation tokens cannot be used as challenges
+	invitation := &Invitation{ID: 7, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	other, _ := SignInvitationToken(secret, invitation, "nonce")
+	if _, err := ParseTwoFactorChallenge(secret, other); err != ErrInvalidChallengeToken {
+		t.Errorf("expected token for another audience to be rejected, got %v", err)
+	}
+}
+
+func TestRecoveryCodeFormat(t *testing.T) {
+	code, err := newRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 11 || code[5] != '-' {
+		t.Errorf("unexpected recovery code format %q", code)
+	}
+
+	// Codes are accepted regardless of case, spaces and dashes
+	if normalizeRecoveryCode(" ABCDE-fghjk ") != normalizeRecoveryCode("abcdefghjk") {
+		t.Error("expected normalized codes to match")
+	}
+}
+
+func TestUserTwoFactorLockedFor(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) *time.Time { v := now.Add(d); return &v }
+	cases := []struct {
+		name        string
+		lockedUntil *time.Time
+		want        time.Duration
+	}{
+		{"never locked", nil, 0},
+		{"locked", at(time.Minute), time.Minute},
+		{"lock ends now", at(0), 0},
+		{"lock ended", at(-time.Minute), 0},
+	}
+
+	for _, tc := range cases {
+		twoFactor := UserTwoFactor{FailedAttempts: 5, LockedUntil: tc.lockedUntil}
+		if got := twoFactor.LockedFor(now); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TokenPurpose represents what a user token can be used for
+type TokenPurpose string
+
+const (
+	PasswordResetToken     TokenPurpose = "password_reset"
+	EmailVerificationToken TokenPurpose = "email_verification"
+)
+
+// ErrInvalidUserToken is returned for tokens that are unknown, expired or
+// already used
+var ErrInvalidUserToken = errors.New("invalid or expired token")
+
+// UserToken represents a single-use token emailed to a user. Only the
+// SHA-256 of the token is stored.
+type UserToken struct {
+	ID        uint         `json:"id" gorm:"primaryKey"`
+	UserID    uint         `json:"user_id" gorm:"not null"`
+	User      User         `json:"-" gorm:"foreignKey:UserID"`
+	Purpose   TokenPurpose `json:"purpose" gorm:"not null;size:30"`
+	TokenHash string       `json:"-" gorm:"not null;unique;size:64"`
+	// Email is the address the token was sent to; verification only applies
+	// while it is still the user's address
+	Email     string     `json:"email" gorm:"not null;size:255"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// ForgotPasswordRequest represents a password reset request
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest represents the confirmation of a password reset
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// VerifyEmailRequest represents the confirmation of an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ResendVerificationRequest asks for a new verification email
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// TableName specifies the table name for UserToken
+func (UserToken) TableName() string {
+	return "user_tokens"
+}
+
+// newUserToken builds the token issued to the user at now and returns it
+// with its clear value
+func newUserToken(user *User, purpose TokenPurpose, ttl time.Duration, now time.Time) (*UserToken, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return &UserToken{
+		UserID:    user.ID,
+		User:      *user,
+		Purpose:   purpose,
+		TokenHash: HashToken(token),
+		Email:     user.Email,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}, token, nil
+}
+
+// IssueUserToken creates a token for the user and returns its clear value.
+// Unused tokens previously issued for the same purpose are invalidated.
+func IssueUserToken(db *gorm.DB, user *User, purpose TokenPurpose, ttl time.Duration) (string, error) {
+	now := time.Now()
+	userToken, token, err := newUserToken(user, purpose, ttl, now)
+	if err != nil {
+		return "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, purpose).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Omit("User").Create(userToken).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// IsUsable reports whether the token can still be used at now: it is unused,
+// not expired, and its user (which must be loaded) still has the address it
+// was sent to
+func (t *UserToken) IsUsable(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt) && t.User.Email == t.Email
+}
+
+// ConsumeUserToken marks a token as used and returns it with its user. It
+// fails with ErrInvalidUserToken when the token is unknown, expired, already
+// used or was sent to an address the user no longer has. Must run inside a
+// transaction so the token stays unused if the caller fails.
+func ConsumeUserToken(tx *gorm.DB, token string, purpose TokenPurpose) (*UserToken, error) {
+	var userToken UserToken
+	if err := tx.Preload("User").
+		Where("token_hash = ? AND purpose = ?", HashToken(token), purpose).
+		First(&userToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidUserToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if !userToken.IsUsable(now) {
+		return nil, ErrInvalidUserToken
+	}
+
+	// Guard against concurrent use of the same token
+	result := tx.Model(&UserToken{}).
+		Where("id = ? AND used_at IS NULL", userToken.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidUserToken
+	}
+
+	userToken.UsedAt = &now
+	return &userToken, nil
+}
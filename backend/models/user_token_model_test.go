@@ -0,0 +1,78 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserTokenIsUsable(t *testing.T) {
+	now := time.Now()
+	used := now.Add(-time.Minute)
+
+	cases := []struct {
+		name   string
+		token  UserToken
+		usable bool
+	}{
+		{"fresh", UserToken{Email: "ada@example.com", ExpiresAt: now.Add(time.Hour), User: User{Email: "ada@example.com"}}, true},
+		{"already used", UserToken{Email: "ada@example.com", ExpiresAt: now.Add(time.Hour), UsedAt: &used, User: User{Email: "ada@example.com"}}, false},
+		{"expired", UserToken{Email: "ada@example.com", ExpiresAt: now.Add(-time.Second), User: User{Email: "ada@example.com"}}, false},
+		{"expires now", UserToken{Email: "ada@example.com", ExpiresAt: now, User: User{Email: "ada@example.com"}}, false},
+		{"email changed since", UserToken{Email: "ada@example.com", ExpiresAt: now.Add(time.Hour), User: User{Email: "ada@new.example.com"}}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.token.IsUsable(now); got != tc.usable {
+			t.Errorf("%s: expected usable=%v, got %v", tc.name, tc.usable, got)
+		}
+	}
+}
+
+func TestUserTokenLifecycle(t *testing.T) {
+	issuedAt := time.Now()
+	user := &User{ID: 7, Email: "ada@example.com"}
+
+	userToken, token, err := newUserToken(user, PasswordResetToken, time.Hour, issuedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userToken.TokenHash != HashToken(token) || userToken.TokenHash == token {
+		t.Error("expected only the hash of the token to be stored")
+	}
+	if userToken.UserID != 7 || userToken.Purpose != PasswordResetToken || userToken.Email != "ada@example.com" {
+		t.Errorf("unexpected token %+v", userToken)
+	}
+	if _, other, _ := newUserToken(user, PasswordResetToken, time.Hour, issuedAt); other == token {
+		t.Error("expected every token to be different")
+	}
+
+	used := issuedAt.Add(time.Minute)
+	cases := []struct {
+		name   string
+		change func(*UserToken)
+		at     time.Time
+		usable bool
+	}{
+		{"just issued", func(*UserToken) {}, issuedAt, true},
+		{"before expiry", func(*UserToken) {}, issuedAt.Add(59 * time.Minute), true},
+		{"at expiry", func(*UserToken) {}, issuedAt.Add(time.Hour), false},
+		{"used once", func(ut *UserToken) { ut.UsedAt = &used }, used.Add(time.Second), false},
+		{"sent to a previous address", func(ut *UserToken) { ut.User.Email = "ada@new.example.com" }, issuedAt, false},
+	}
+	for _, tc := range cases {
+		copied := *userToken
+		tc.change(&copied)
+		if got := copied.IsUsable(tc.at); got != tc.usable {
+			t.Errorf("%s: expected usable=%v, got %v", tc.name, tc.usable, got)
+		}
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	hash := HashToken("token-a")
+	if len(hash) != 64 || hash != HashToken("token-a") {
+		t.Errorf("expected a stable SHA-256 hex digest, got %q", hash)
+	}
+	if hash == HashToken("token-b") {
+		t.Error("different tokens should not share a hash")
+	}
+}
@@ -2,19 +2,34 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"sami/pkg/env"
 )
 
 type Config struct {
-	App      AppConfig
-	Server   ServerConfig
-	Database DatabaseConfig
+	App         AppConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Mail        MailConfig
+	Auth        AuthConfig
+	OIDC        OIDCConfig
+	Redis       RedisConfig
+	RateLimit   RateLimitConfig
+	Password    PasswordConfig
+	SCIM        SCIMConfig
+	Digest      DigestConfig
+	Storage     StorageConfig
+	Attachments AttachmentConfig
+	Public      PublicConfig
 }
 
 type AppConfig struct {
 	Name string `env:"APP_NAME" default:"Sami"`
+	// BaseURL is the address of the frontend, used to build links in emails
+	BaseURL       string        `env:"APP_BASE_URL" default:"http://localhost:3000"`
+	InvitationTTL time.Duration `env:"INVITATION_TTL" default:"168h"`
 }
 
 type ServerConfig struct {
@@ -23,12 +38,209 @@ type ServerConfig struct {
 	WriteTimeout   time.Duration // TODO
 	AllowedOrigin  string        `env:"SERVER_ALLOWED_ORIGIN" default:"*"`
 	AllowedMethods string        `env:"SERVER_ALLOWED_METHODS" default:"POST,GET,PUT,PATCH,DELETE,OPTIONS"`
+	// TrustedProxies are the proxies whose X-Forwarded-For is believed when
+	// finding the client IP rate limits apply to
+	TrustedProxies string `env:"SERVER_TRUSTED_PROXIES" default:"127.0.0.1,::1,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16"`
+}
+
+// TrustedProxyList returns the trusted proxies
+func (c ServerConfig) TrustedProxyList() []string {
+	return splitList(c.TrustedProxies)
 }
 
 type DatabaseConfig struct {
 	URL string `env:"DATABASE_URL"`
 }
 
+type MailConfig struct {
+	Driver       string `env:"MAIL_DRIVER" default:"memory"` // smtp | file | memory
+	From         string `env:"MAIL_FROM" default:"Sami <no-reply@localhost>"`
+	SMTPHost     string `env:"MAIL_SMTP_HOST"`
+	SMTPPort     int    `env:"MAIL_SMTP_PORT" default:"587"`
+	SMTPUsername string `env:"MAIL_SMTP_USERNAME"`
+	SMTPPassword string `env:"MAIL_SMTP_PASSWORD"`
+	OutboxDir    string `env:"MAIL_OUTBOX_DIR" default:"outbox"`
+}
+
+type AuthConfig struct {
+	// RequireEmailVerification blocks login until the user confirms their
+	// email address. Existing accounts must be marked verified beforehand.
+	RequireEmailVerification bool          `env:"AUTH_REQUIRE_EMAIL_VERIFICATION" default:"false"`
+	EmailVerificationTTL     time.Duration `env:"AUTH_EMAIL_VERIFICATION_TTL" default:"48h"`
+	PasswordResetTTL         time.Duration `env:"AUTH_PASSWORD_RESET_TTL" default:"1h"`
+	// RequireAdminTwoFactor is the default of the admin 2FA policy until an
+	// admin changes it from the security settings
+	RequireAdminTwoFactor bool          `env:"AUTH_REQUIRE_ADMIN_2FA" default:"false"`
+	TwoFactorIssuer       string        `env:"AUTH_2FA_ISSUER" default:"Sami"`
+	TwoFactorChallengeTTL time.Duration `env:"AUTH_2FA_CHALLENGE_TTL" default:"5m"`
+	// TwoFactorMaxAttempts wrong login codes in a row lock the second factor
+	// for TwoFactorLockout, whether or not rate limiting is enabled
+	TwoFactorMaxAttempts int           `env:"AUTH_2FA_MAX_ATTEMPTS" default:"5"`
+	TwoFactorLockout     time.Duration `env:"AUTH_2FA_LOCKOUT" default:"15m"`
+}
+
+// PasswordConfig configures the policy new passwords must satisfy
+type PasswordConfig struct {
+	MinLength          int  `env:"PASSWORD_MIN_LENGTH" default:"8"`
+	RequireUpper       bool `env:"PASSWORD_REQUIRE_UPPER" default:"true"`
+	RequireLower       bool `env:"PASSWORD_REQUIRE_LOWER" default:"true"`
+	RequireDigit       bool `env:"PASSWORD_REQUIRE_DIGIT" default:"true"`
+	RequireSymbol      bool `env:"PASSWORD_REQUIRE_SYMBOL" default:"false"`
+	RejectPersonalInfo bool `env:"PASSWORD_REJECT_PERSONAL_INFO" default:"true"`
+	RejectCommon       bool `env:"PASSWORD_REJECT_COMMON" default:"true"`
+	// CommonListFile replaces the bundled list of common passwords
+	CommonListFile string `env:"PASSWORD_COMMON_LIST_FILE"`
+}
+
+// SCIMConfig configures provisioning from an identity provider
+type SCIMConfig struct {
+	// DeactivatedStatus is given to users the provider marks inactive:
+	// inactive or suspended
+	DeactivatedStatus string `env:"SCIM_DEACTIVATED_STATUS" default:"inactive"`
+	MaxResults        int    `env:"SCIM_MAX_RESULTS" default:"200"`
+}
+
+// DigestConfig configures the activity digest emails
+type DigestConfig struct {
+	Enabled bool `env:"DIGEST_ENABLED" default:"true"`
+	// Digests are sent every day at Hour, weekly ones on Weekday only
+	Hour    int    `env:"DIGEST_HOUR" default:"8"`
+	Weekday string `env:"DIGEST_WEEKDAY" default:"monday"`
+	// DefaultFrequency applies to users who never chose one: never, daily
+	// or weekly
+	DefaultFrequency string        `env:"DIGEST_DEFAULT_FREQUENCY" default:"weekly"`
+	CheckInterval    time.Duration `env:"DIGEST_CHECK_INTERVAL" default:"10m"`
+}
+
+// StorageConfig configures where uploaded files are kept
+type StorageConfig struct {
+	Driver   string `env:"STORAGE_DRIVER" default:"local"` // local | s3
+	LocalDir string `env:"STORAGE_LOCAL_DIR" default:"uploads"`
+	// S3-compatible object store, such as AWS S3 or MinIO
+	S3Endpoint  string `env:"STORAGE_S3_ENDPOINT"`
+	S3Region    string `env:"STORAGE_S3_REGION" default:"us-east-1"`
+	S3Bucket    string `env:"STORAGE_S3_BUCKET"`
+	S3AccessKey string `env:"STORAGE_S3_ACCESS_KEY"`
+	S3SecretKey string `env:"STORAGE_S3_SECRET_KEY"`
+	S3PathStyle bool   `env:"STORAGE_S3_PATH_STYLE" default:"true"`
+}
+
+// AttachmentConfig limits the files attached to comments and services
+type AttachmentConfig struct {
+	MaxSizeMB      int64  `env:"ATTACHMENT_MAX_SIZE_MB" default:"10"`
+	ProjectQuotaMB int64  `env:"ATTACHMENT_PROJECT_QUOTA_MB" default:"500"`
+	AllowedTypes   string `env:"ATTACHMENT_ALLOWED_TYPES" default:"image/png,image/jpeg,image/gif,image/webp,application/pdf,text/plain,text/markdown,text/csv,application/json,application/zip"`
+	// URLTTL is how long signed download links stay valid
+	URLTTL time.Duration `env:"ATTACHMENT_URL_TTL" default:"15m"`
+	// CleanupInterval is how often files of deleted comments, services and
+	// projects are removed
+	CleanupInterval time.Duration `env:"ATTACHMENT_CLEANUP_INTERVAL" default:"1h"`
+}
+
+// AllowedTypeList returns the MIME types that may be uploaded
+func (c AttachmentConfig) AllowedTypeList() []string {
+	return splitList(c.AllowedTypes)
+}
+
+// PublicConfig sets up the anonymous view of public projects
+type PublicConfig struct {
+	// CacheMaxAge is how long browsers and proxies may reuse a response
+	CacheMaxAge time.Duration `env:"PUBLIC_CACHE_MAX_AGE" default:"1m"`
+	// HiddenFields are the service fields hidden on projects that do not
+	// configure their own, comma-separated
+	HiddenFields string `env:"PUBLIC_HIDDEN_FIELDS" default:"deploy_url,git_repo,notes,escalation_contact,runbooks,metadata"`
+	// CommentLimit caps the threads included in the view
+	CommentLimit int `env:"PUBLIC_COMMENT_LIMIT" default:"100"`
+}
+
+// HiddenFieldList returns the service fields hidden by default
+func (c PublicConfig) HiddenFieldList() []string {
+	return splitList(c.HiddenFields)
+}
+
+type RedisConfig struct {
+	Host     string `env:"REDIS_HOST" default:"localhost"`
+	Port     int    `env:"REDIS_PORT" default:"6379"`
+	Password string `env:"REDIS_PASSWORD"`
+	DB       int    `env:"REDIS_DB" default:"0"`
+}
+
+// RateLimitConfig configures request limits and account lockout. Limits are
+// written as "requests/window", such as "20/1m"; "0" disables one.
+type RateLimitConfig struct {
+	Enabled bool   `env:"RATE_LIMIT_ENABLED" default:"true"`
+	Backend string `env:"RATE_LIMIT_BACKEND" default:"memory"` // memory | redis
+	// Per client IP, before authentication
+	AuthPerIP string `env:"RATE_LIMIT_AUTH_IP" default:"20/1m"`
+	APIPerIP  string `env:"RATE_LIMIT_API_IP" default:"1200/1m"`
+	// Per authenticated user
+	APIPerUser      string `env:"RATE_LIMIT_API_USER" default:"600/1m"`
+	BulkSavePerUser string `env:"RATE_LIMIT_BULK_SAVE_USER" default:"10/1m"`
+	// Accounts are locked after LockoutThreshold failed logins, for
+	// LockoutBase doubled on each further failure up to LockoutMax
+	LockoutThreshold int           `env:"AUTH_LOCKOUT_THRESHOLD" default:"5"`
+	LockoutBase      time.Duration `env:"AUTH_LOCKOUT_BASE" default:"1m"`
+	LockoutMax       time.Duration `env:"AUTH_LOCKOUT_MAX" default:"1h"`
+	LockoutWindow    time.Duration `env:"AUTH_LOCKOUT_WINDOW" default:"24h"`
+}
+
+// OIDCConfig configures single sign-on with an OpenID Connect provider
+type OIDCConfig struct {
+	Enabled      bool   `env:"OIDC_ENABLED" default:"false"`
+	IssuerURL    string `env:"OIDC_ISSUER_URL"`
+	ClientID     string `env:"OIDC_CLIENT_ID"`
+	ClientSecret string `env:"OIDC_CLIENT_SECRET"`
+	// RedirectURL is the frontend page the provider sends the user back to
+	RedirectURL string `env:"OIDC_REDIRECT_URL" default:"http://localhost:3000/auth/oidc/callback"`
+	Scopes      string `env:"OIDC_SCOPES" default:"openid,email,profile,groups"`
+	GroupsClaim string `env:"OIDC_GROUPS_CLAIM" default:"groups"`
+	// AdminGroups lists the groups granted the admin role. When set, the role
+	// of SSO users is synced on every login.
+	AdminGroups string `env:"OIDC_ADMIN_GROUPS"`
+	// TeamMappings maps groups to teams: "group=org-slug/team-slug,..."
+	TeamMappings  string        `env:"OIDC_TEAM_MAPPINGS"`
+	AutoProvision bool          `env:"OIDC_AUTO_PROVISION" default:"true"`
+	CacheTTL      time.Duration `env:"OIDC_CACHE_TTL" default:"1h"`
+	FlowTTL       time.Duration `env:"OIDC_FLOW_TTL" default:"10m"`
+	// TrustProviderMFA skips the TOTP challenge after a provider login. Only
+	// set it when the provider itself enforces a second factor.
+	TrustProviderMFA bool `env:"OIDC_TRUST_PROVIDER_MFA" default:"false"`
+}
+
+// ScopeList returns the configured scopes
+func (c OIDCConfig) ScopeList() []string {
+	return splitList(c.Scopes)
+}
+
+// AdminGroupList returns the groups granted the admin role
+func (c OIDCConfig) AdminGroupList() []string {
+	return splitList(c.AdminGroups)
+}
+
+// TeamMappingList returns the team ("org-slug/team-slug") of each mapped group
+func (c OIDCConfig) TeamMappingList() map[string]string {
+	mappings := make(map[string]string)
+	for _, item := range splitList(c.TeamMappings) {
+		group, team, ok := strings.Cut(item, "=")
+		if !ok || strings.TrimSpace(group) == "" || !strings.Contains(team, "/") {
+			continue
+		}
+		mappings[strings.TrimSpace(group)] = strings.TrimSpace(team)
+	}
+	return mappings
+}
+
+// splitList splits a comma-separated value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func Load() (*Config, error) {
 	cfg := &Config{}
 
@@ -44,5 +256,49 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("error parsing Database config: %v", err)
 	}
 
+	if err := env.ParseEnv(&cfg.Mail); err != nil {
+		return nil, fmt.Errorf("error parsing Mail config: %v", err)
+	}
+
+	if err := env.ParseEnv(&cfg.Auth); err != nil {
+		return nil, fmt.Errorf("error parsing Auth config: %v", err)
+	}
+
+	if err := env.ParseEnv(&cfg.OIDC); err != nil {
+		return nil, fmt.Errorf("error parsing OIDC config: %v", err)
+	}
+
+	if err := env.ParseEnv(&cfg.Password); err != nil {
+		return nil, fmt.Errorf("error parsing Password config: %v", err)
+	}
+
+	if err := env.ParseEnv(&cfg.Redis); err != nil {
+		return nil, fmt.Errorf("error parsing Redis config: %v", err)
+	}
+
+	if err := env.ParseEnv(&cfg.RateLimit); err != nil {
+		return nil, fmt.Errorf("error parsing RateLimit config: %v", err)
+	}
+
+	if err := env.ParseEnv(&cfg.SCIM); err != nil {
+		return nil, fmt.Errorf("error parsing SCIM config: %v", err)
+	}
+
+	if err := env.ParseEnv(&cfg.Digest); err != nil {
+		return nil, fmt.Errorf("error parsing Digest config: %v", err)
+	}
+
+	if err := env.ParseEnv(&cfg.Storage); err != nil {
+		return nil, fmt.Errorf("error parsing Storage config: %v", err)
+	}
+
+	if err := env.ParseEnv(&cfg.Attachments); err != nil {
+		return nil, fmt.Errorf("error parsing Attachments config: %v", err)
+	}
+
+	if err := env.ParseEnv(&cfg.Public); err != nil {
+		return nil, fmt.Errorf("error parsing Public config: %v", err)
+	}
+
 	return cfg, nil
 }
@@ -0,0 +1,93 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sami/pkg/config"
+)
+
+func TestBuildMultipart(t *testing.T) {
+	body, err := Build("Sami <no-reply@example.com>", Message{
+		To:      []string{"ana@example.com"},
+		Subject: "Invitation\r\nBcc: eve@example.com",
+		Text:    "Hello",
+		HTML:    "<p>Hello</p>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := string(body)
+	if strings.Contains(raw, "\r\nBcc:") {
+		t.Errorf("header injection not prevented:\n%s", raw)
+	}
+	for _, want := range []string{"To: ana@example.com", "multipart/alternative", "text/plain", "text/html", "<p>Hello</p>"} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("expected message to contain %q:\n%s", want, raw)
+		}
+	}
+}
+
+func TestBuildRejectsInvalidRecipients(t *testing.T) {
+	if _, err := Build("no-reply@example.com", Message{}); err == nil {
+		t.Error("expected error for message without recipients")
+	}
+	if _, err := Build("no-reply@example.com", Message{To: []string{"not an address"}}); err == nil {
+		t.Error("expected error for invalid recipient")
+	}
+}
+
+func TestMemoryOutbox(t *testing.T) {
+	outbox := NewMemoryOutbox()
+	if _, ok := outbox.Last(); ok {
+		t.Fatal("expected empty outbox")
+	}
+
+	if err := outbox.Send(context.Background(), Message{To: []string{"ana@example.com"}, Subject: "Hi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, ok := outbox.Last()
+	if !ok || msg.Subject != "Hi" || len(outbox.Messages()) != 1 {
+		t.Errorf("unexpected outbox content: %+v", outbox.Messages())
+	}
+
+	outbox.Reset()
+	if len(outbox.Messages()) != 0 {
+		t.Error("expected outbox to be empty after reset")
+	}
+}
+
+func TestFileOutbox(t *testing.T) {
+	dir := t.TempDir()
+	m, err := New(config.MailConfig{Driver: "file", OutboxDir: dir, From: "no-reply@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Send(context.Background(), Message{To: []string{"ana@example.com"}, Subject: "Hi", Text: "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("expected one message file, got %v", files)
+	}
+	content, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(content), "Subject: Hi") {
+		t.Errorf("unexpected message file:\n%s", content)
+	}
+}
+
+func TestNewRejectsUnknownDriver(t *testing.T) {
+	if _, err := New(config.MailConfig{Driver: "pigeon"}); err == nil {
+		t.Error("expected error for unknown driver")
+	}
+	if _, err := New(config.MailConfig{Driver: "smtp"}); err == nil {
+		t.Error("expected error for smtp driver without host")
+	}
+}
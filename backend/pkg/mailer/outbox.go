@@ -0,0 +1,100 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MemoryOutbox keeps sent messages in memory. It is meant for development
+// and tests.
+type MemoryOutbox struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemoryOutbox creates an empty in-memory outbox
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{}
+}
+
+// Send implements Mailer
+func (o *MemoryOutbox) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(msg.To) == 0 {
+		return fmt.Errorf("message has no recipients")
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of the messages sent so far
+func (o *MemoryOutbox) Messages() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Message(nil), o.messages...)
+}
+
+// Last returns the last message sent, if any
+func (o *MemoryOutbox) Last() (Message, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.messages) == 0 {
+		return Message{}, false
+	}
+	return o.messages[len(o.messages)-1], true
+}
+
+// Reset discards the messages sent so far
+func (o *MemoryOutbox) Reset() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = nil
+}
+
+// FileOutbox writes every message as an .eml file in Dir so it can be opened
+// with a mail client during development
+type FileOutbox struct {
+	Dir  string
+	From string
+
+	mu sync.Mutex
+}
+
+// Send implements Mailer
+func (o *FileOutbox) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	body, err := Build(o.From, msg)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := os.MkdirAll(o.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	suffix, err := randomBoundary()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), suffix[:8])
+	if err := os.WriteFile(filepath.Join(o.Dir, name), body, 0o600); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,762 @@
+// Package markdown renders the Markdown used in comments to HTML.
+//
+// It supports the common subset of CommonMark: paragraphs, ATX headings,
+// fenced code blocks, block quotes, lists, thematic breaks, emphasis, code
+// spans, links and autolinks, plus GitHub's strikethrough and bare URLs. As in
+// GitHub comments, a newline inside a paragraph is a line break. Raw HTML is
+// never passed through, and the output is meant to go through Sanitize.
+//
+// References to other entities, #service-name and !project-slug, are turned
+// into links when the Resolver knows them.
+package markdown
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// ReferenceKind is the kind of entity a reference points to
+type ReferenceKind string
+
+const (
+	ServiceReference ReferenceKind = "service" // #service-name
+	ProjectReference ReferenceKind = "project" // !project-slug
+)
+
+// Reference is a resolved reference to an entity
+type Reference struct {
+	Kind ReferenceKind `json:"kind"`
+	Name string        `json:"name"`
+	ID   uint          `json:"id"`
+	URL  string        `json:"url"`
+}
+
+// Resolver looks up a reference by the name written after its sigil. It
+// returns nil for unknown entities, which are left as plain text.
+type Resolver func(kind ReferenceKind, name string) *Reference
+
+// Result is a rendered document
+type Result struct {
+	HTML       string
+	References []Reference
+}
+
+// Render converts Markdown to HTML, resolving references with resolve when
+// it is not nil
+func Render(src string, resolve Resolver) Result {
+	r := &renderer{resolve: resolve, seen: make(map[string]bool)}
+
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+
+	var b strings.Builder
+	r.blocks(&b, strings.Split(src, "\n"), false)
+	return Result{HTML: b.String(), References: r.refs}
+}
+
+type renderer struct {
+	resolve Resolver
+	refs    []Reference
+	seen    map[string]bool
+	inLink  bool
+}
+
+// blocks renders a sequence of lines. In tight lists paragraphs are not
+// wrapped in <p>.
+func (r *renderer) blocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+
+		if strings.TrimSpace(line) == "" {
+			i++
+			continue
+		}
+		if indent >= 4 {
+			i = r.paragraph(b, lines, i, tight)
+			continue
+		}
+
+		if fence, ok := openingFence(trimmed); ok {
+			i = r.codeBlock(b, lines, i, indent, fence)
+			continue
+		}
+		if level, text, ok := heading(trimmed); ok {
+			tag := "h" + strconv.Itoa(level)
+			b.WriteString("<" + tag + ">" + r.inline(text) + "</" + tag + ">\n")
+			i++
+			continue
+		}
+		if isThematicBreak(trimmed) {
+			b.WriteString("<hr>\n")
+			i++
+			continue
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			i = r.blockquote(b, lines, i)
+			continue
+		}
+		if _, ok := parseListMarker(line); ok {
+			i = r.list(b, lines, i)
+			continue
+		}
+
+		i = r.paragraph(b, lines, i, tight)
+	}
+}
+
+// startsBlock reports whether a line interrupts a paragraph
+func startsBlock(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) >= 4 {
+		return false
+	}
+	if _, ok := openingFence(trimmed); ok {
+		return true
+	}
+	if _, _, ok := heading(trimmed); ok {
+		return true
+	}
+	if _, ok := parseListMarker(line); ok {
+		return true
+	}
+	return isThematicBreak(trimmed) || strings.HasPrefix(trimmed, ">")
+}
+
+func (r *renderer) paragraph(b *strings.Builder, lines []string, i int, tight bool) int {
+	var text []string
+	for ; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" || (len(text) > 0 && startsBlock(lines[i])) {
+			break
+		}
+		text = append(text, strings.TrimSpace(lines[i]))
+	}
+
+	content := r.inline(strings.Join(text, "\n"))
+	if tight {
+		b.WriteString(content + "\n")
+	} else {
+		b.WriteString("<p>" + content + "</p>\n")
+	}
+	return i
+}
+
+// fence is the opening line of a fenced code block
+type fence struct {
+	char     byte
+	length   int
+	language string
+}
+
+func openingFence(trimmed string) (fence, bool) {
+	if len(trimmed) < 3 || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return fence{}, false
+	}
+	f := fence{char: trimmed[0]}
+	for f.length < len(trimmed) && trimmed[f.length] == f.char {
+		f.length++
+	}
+	if f.length < 3 {
+		return fence{}, false
+	}
+
+	info := strings.TrimSpace(trimmed[f.length:])
+	if f.char == '`' && strings.Contains(info, "`") {
+		return fence{}, false
+	}
+	if fields := strings.Fields(info); len(fields) > 0 {
+		f.language = fields[0]
+	}
+	return f, true
+}
+
+func (r *renderer) codeBlock(b *strings.Builder, lines []string, i, indent int, f fence) int {
+	var code []string
+	for i++; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		if len(lines[i])-len(trimmed) < 4 && strings.Trim(trimmed, " ") != "" &&
+			strings.TrimRight(strings.TrimLeft(trimmed, string(f.char)), " ") == "" &&
+			len(strings.TrimRight(trimmed, " ")) >= f.length {
+			i++
+			break
+		}
+
+		// Remove the indentation of the opening fence
+		line := lines[i]
+		for n := 0; n < indent && strings.HasPrefix(line, " "); n++ {
+			line = line[1:]
+		}
+		code = append(code, line)
+	}
+
+	b.WriteString("<pre><code")
+	if f.language != "" {
+		b.WriteString(` class="language-` + html.EscapeString(f.language) + `"`)
+	}
+	b.WriteString(">")
+	for _, line := range code {
+		b.WriteString(html.EscapeString(line) + "\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+func heading(trimmed string) (int, string, bool) {
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(trimmed) && trimmed[level] != ' ') {
+		return 0, "", false
+	}
+
+	text := strings.TrimSpace(trimmed[level:])
+	// Drop the optional closing sequence
+	if closing := strings.TrimRight(text, "#"); closing == "" || strings.HasSuffix(closing, " ") {
+		text = strings.TrimSpace(closing)
+	}
+	return level, text, true
+}
+
+func isThematicBreak(trimmed string) bool {
+	if trimmed == "" || (trimmed[0] != '-' && trimmed[0] != '*' && trimmed[0] != '_') {
+		return false
+	}
+	count := 0
+	for _, c := range trimmed {
+		switch {
+		case byte(c) == trimmed[0]:
+			count++
+		case c != ' ':
+			return false
+		}
+	}
+	return count >= 3
+}
+
+func (r *renderer) blockquote(b *strings.Builder, lines []string, i int) int {
+	var quoted []string
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		if len(lines[i])-len(trimmed) >= 4 || !strings.HasPrefix(trimmed, ">") {
+			break
+		}
+		trimmed = strings.TrimPrefix(trimmed, ">")
+		quoted = append(quoted, strings.TrimPrefix(trimmed, " "))
+	}
+
+	b.WriteString("<blockquote>\n")
+	r.blocks(b, quoted, false)
+	b.WriteString("</blockquote>\n")
+	return i
+}
+
+// listMarker is the marker starting a list item
+type listMarker struct {
+	ordered bool
+	char    byte // bullet, or delimiter of ordered items
+	start   int
+	indent  int // column where the item content starts
+}
+
+func parseListMarker(line string) (listMarker, bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	offset := len(line) - len(trimmed)
+	if offset >= 4 || trimmed == "" {
+		return listMarker{}, false
+	}
+
+	var m listMarker
+	width := 0
+	switch c := trimmed[0]; {
+	case c == '-' || c == '*' || c == '+':
+		if isThematicBreak(trimmed) {
+			return listMarker{}, false
+		}
+		m.char, width = c, 1
+	case c >= '0' && c <= '9':
+		for width < len(trimmed) && width < 9 && trimmed[width] >= '0' && trimmed[width] <= '9' {
+			width++
+		}
+		if width == len(trimmed) || (trimmed[width] != '.' && trimmed[width] != ')') {
+			return listMarker{}, false
+		}
+		m.ordered, m.char = true, trimmed[width]
+		m.start, _ = strconv.Atoi(trimmed[:width])
+		width++
+	default:
+		return listMarker{}, false
+	}
+
+	rest := trimmed[width:]
+	if rest != "" && rest[0] != ' ' {
+		return listMarker{}, false
+	}
+	spaces := len(rest) - len(strings.TrimLeft(rest, " "))
+	if spaces == 0 || spaces > 4 || strings.TrimSpace(rest) == "" {
+		spaces = 1
+	}
+	m.indent = offset + width + spaces
+	return m, true
+}
+
+func (r *renderer) list(b *strings.Builder, lines []string, i int) int {
+	first, _ := parseListMarker(lines[i])
+
+	var items [][]string
+	loose := false
+	for i < len(lines) {
+		marker, ok := parseListMarker(lines[i])
+		if !ok || marker.ordered != first.ordered || marker.char != first.char {
+			break
+		}
+
+		item := []string{contentAfter(lines[i], marker.indent)}
+		blank := false
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				blank = true
+				item = append(item, "")
+				continue
+			}
+
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			if indent >= marker.indent {
+				if blank && strings.TrimSpace(strings.Join(item, "")) != "" {
+					loose = true
+				}
+				item = append(item, contentAfter(line, marker.indent))
+				blank = false
+				continue
+			}
+
+			// A paragraph may continue without indentation
+			if !blank && !startsBlock(line) {
+				item = append(item, strings.TrimSpace(line))
+				continue
+			}
+			break
+		}
+
+		// Blank lines between items make the list loose
+		for len(item) > 0 && item[len(item)-1] == "" {
+			item = item[:len(item)-1]
+		}
+		if blank && i < len(lines) {
+			if next, ok := parseListMarker(lines[i]); ok && next.ordered == first.ordered && next.char == first.char {
+				loose = true
+			}
+		}
+		items = append(items, item)
+	}
+
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if first.ordered && first.start != 1 {
+		b.WriteString(` start="` + strconv.Itoa(first.start) + `"`)
+	}
+	b.WriteString(">\n")
+	for _, item := range items {
+		var content strings.Builder
+		r.blocks(&content, item, !loose)
+		b.WriteString("<li>" + strings.TrimSuffix(content.String(), "\n") + "</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// contentAfter removes the first n columns of a list item line
+func contentAfter(line string, n int) string {
+	if len(line) <= n {
+		return strings.TrimLeft(line, " ")
+	}
+	if strings.TrimSpace(line[:n]) == "" {
+		return line[n:]
+	}
+	// First line of the item: skip the marker
+	return strings.TrimLeft(line[n:], " ")
+}
+
+// inline renders the inline content of a block
+func (r *renderer) inline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			b.WriteString("<br>\n")
+			i += 2
+			continue
+		case c == '\n':
+			b.WriteString("<br>\n")
+			i++
+			continue
+		case c == '`':
+			if end, code, ok := codeSpan(s, i); ok {
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i = end
+				continue
+			}
+			n := runLength(s, i)
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		case c == '*' || c == '_' || c == '~':
+			if end, out, ok := r.emphasis(s, i); ok {
+				b.WriteString(out)
+				i = end
+				continue
+			}
+			n := runLength(s, i)
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		case c == '[' && !r.inLink:
+			if end, out, ok := r.link(s, i); ok {
+				b.WriteString(out)
+				i = end
+				continue
+			}
+		case c == '<':
+			if end, out, ok := r.autolink(s, i); ok {
+				b.WriteString(out)
+				i = end
+				continue
+			}
+		case (c == 'h' || c == 'H') && !r.inLink && atBoundary(s, i):
+			if end, out, ok := r.bareURL(s, i); ok {
+				b.WriteString(out)
+				i = end
+				continue
+			}
+		case (c == '#' || c == '!') && !r.inLink && atBoundary(s, i):
+			if end, out, ok := r.reference(s, i); ok {
+				b.WriteString(out)
+				i = end
+				continue
+			}
+		}
+
+		b.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+	return b.String()
+}
+
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n'
+}
+
+// atBoundary reports whether position i starts a word
+func atBoundary(s string, i int) bool {
+	return i == 0 || isSpace(s[i-1]) || strings.IndexByte("([{\"'*_~", s[i-1]) >= 0
+}
+
+// runLength counts the repetitions of the character at i
+func runLength(s string, i int) int {
+	n := 1
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+	return n
+}
+
+// codeSpan parses a code span opened by the backticks at i
+func codeSpan(s string, i int) (int, string, bool) {
+	n := runLength(s, i)
+	for j := i + n; j < len(s); {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		m := runLength(s, j)
+		if m == n {
+			code := strings.ReplaceAll(s[i+n:j], "\n", " ")
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+				code = code[1 : len(code)-1]
+			}
+			return j + m, code, true
+		}
+		j += m
+	}
+	return 0, "", false
+}
+
+// findClosing finds the closing delimiter of emphasis opened before start.
+// Code spans are skipped, and runs of another length are not taken as the
+// closing delimiter of single emphasis.
+func findClosing(s string, start int, delim string) int {
+	for j := start; j < len(s); {
+		switch {
+		case s[j] == '\\':
+			j += 2
+			continue
+		case s[j] == '`':
+			if end, _, ok := codeSpan(s, j); ok {
+				j = end
+				continue
+			}
+		case s[j] == delim[0]:
+			n := runLength(s, j)
+			if (len(delim) == 1 && n == 2) || (len(delim) == 2 && n == 1) || n < len(delim) {
+				j += n
+				continue
+			}
+			pos := j + n - len(delim)
+			if !isSpace(s[pos-1]) && (delim[0] != '_' || pos+len(delim) == len(s) || !isAlnum(s[pos+len(delim)])) {
+				return pos
+			}
+			j += n
+			continue
+		}
+		j++
+	}
+	return -1
+}
+
+func (r *renderer) emphasis(s string, i int) (int, string, bool) {
+	c := s[i]
+	n := runLength(s, i)
+	if c == '_' && i > 0 && isAlnum(s[i-1]) {
+		return 0, "", false
+	}
+
+	try := func(delim, tag string) (int, string, bool) {
+		open := i + len(delim)
+		if open >= len(s) || isSpace(s[open]) {
+			return 0, "", false
+		}
+		closing := findClosing(s, open, delim)
+		if closing <= open {
+			return 0, "", false
+		}
+		return closing + len(delim), "<" + tag + ">" + r.inline(s[open:closing]) + "</" + tag + ">", true
+	}
+
+	if c == '~' {
+		if n != 2 {
+			return 0, "", false
+		}
+		return try("~~", "del")
+	}
+	if n >= 2 {
+		if end, out, ok := try(s[i:i+2], "strong"); ok {
+			return end, out, true
+		}
+	}
+	if n == 1 || n == 3 {
+		return try(s[i:i+1], "em")
+	}
+	return 0, "", false
+}
+
+// link parses an inline link [text](destination "title")
+func (r *renderer) link(s string, i int) (int, string, bool) {
+	depth := 0
+	closeText := -1
+	for j := i; j < len(s) && closeText < 0; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '`':
+			if end, _, ok := codeSpan(s, j); ok {
+				j = end - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closeText = j
+			}
+		}
+	}
+	if closeText < 0 || closeText+1 >= len(s) || s[closeText+1] != '(' {
+		return 0, "", false
+	}
+
+	// Destination, optionally in angle brackets, then an optional title
+	j := closeText + 2
+	for j < len(s) && s[j] == ' ' {
+		j++
+	}
+	var dest string
+	if j < len(s) && s[j] == '<' {
+		end := strings.IndexAny(s[j:], ">\n")
+		if end < 0 || s[j+end] != '>' {
+			return 0, "", false
+		}
+		dest = s[j+1 : j+end]
+		j += end + 1
+	} else {
+		start, parens := j, 0
+		for ; j < len(s) && s[j] > ' '; j++ {
+			if s[j] == '(' {
+				parens++
+			} else if s[j] == ')' {
+				if parens == 0 {
+					break
+				}
+				parens--
+			}
+		}
+		dest = s[start:j]
+	}
+
+	for j < len(s) && s[j] == ' ' {
+		j++
+	}
+	var title string
+	if j < len(s) && (s[j] == '"' || s[j] == '\'') {
+		end := strings.IndexByte(s[j+1:], s[j])
+		if end < 0 {
+			return 0, "", false
+		}
+		title = s[j+1 : j+1+end]
+		j += end + 2
+		for j < len(s) && s[j] == ' ' {
+			j++
+		}
+	}
+	if j >= len(s) || s[j] != ')' {
+		return 0, "", false
+	}
+
+	r.inLink = true
+	text := r.inline(s[i+1 : closeText])
+	r.inLink = false
+
+	out := `<a href="` + html.EscapeString(unescapePunct(dest)) + `"`
+	if title != "" {
+		out += ` title="` + html.EscapeString(unescapePunct(title)) + `"`
+	}
+	return j + 1, out + ">" + text + "</a>", true
+}
+
+// unescapePunct removes backslash escapes
+func unescapePunct(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// autolink parses <https://example.com> and <name@example.com>
+func (r *renderer) autolink(s string, i int) (int, string, bool) {
+	end := strings.IndexAny(s[i+1:], "<> \n")
+	if end <= 0 || s[i+1+end] != '>' {
+		return 0, "", false
+	}
+	target := s[i+1 : i+1+end]
+
+	lower := strings.ToLower(target)
+	var href string
+	switch {
+	case strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "mailto:"):
+		href = target
+	case strings.Count(target, "@") == 1 && !strings.ContainsAny(target, ":/") && strings.Contains(target[strings.Index(target, "@"):], "."):
+		href = "mailto:" + target
+	default:
+		return 0, "", false
+	}
+	if r.inLink {
+		return i + end + 2, html.EscapeString(target), true
+	}
+	return i + end + 2, `<a href="` + html.EscapeString(href) + `">` + html.EscapeString(target) + `</a>`, true
+}
+
+// bareURL links http and https URLs written as text
+func (r *renderer) bareURL(s string, i int) (int, string, bool) {
+	lower := strings.ToLower(s[i:min(len(s), i+8)])
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return 0, "", false
+	}
+
+	end := i
+	for end < len(s) && s[end] > ' ' && s[end] != '<' {
+		end++
+	}
+
+	// Trailing punctuation and unbalanced parentheses end the sentence
+	for end > i {
+		last := s[end-1]
+		if strings.IndexByte(".,:;!?\"'*_~", last) >= 0 {
+			end--
+			continue
+		}
+		if last == ')' && strings.Count(s[i:end], "(") < strings.Count(s[i:end], ")") {
+			end--
+			continue
+		}
+		break
+	}
+
+	url := s[i:end]
+	if strings.Index(url, "://")+3 >= len(url) {
+		return 0, "", false
+	}
+	return end, `<a href="` + html.EscapeString(url) + `">` + html.EscapeString(url) + `</a>`, true
+}
+
+// reference resolves #service-name and !project-slug
+func (r *renderer) reference(s string, i int) (int, string, bool) {
+	if r.resolve == nil {
+		return 0, "", false
+	}
+
+	end := i + 1
+	for end < len(s) && (isAlnum(s[end]) || s[end] == '-' || s[end] == '_' || s[end] == '.') {
+		end++
+	}
+	for end > i+1 && strings.IndexByte("-_.", s[end-1]) >= 0 {
+		end--
+	}
+	if end == i+1 || !isAlnum(s[i+1]) {
+		return 0, "", false
+	}
+
+	kind := ServiceReference
+	if s[i] == '!' {
+		kind = ProjectReference
+	}
+	name := s[i+1 : end]
+	ref := r.resolve(kind, name)
+	if ref == nil {
+		return 0, "", false
+	}
+
+	key := string(kind) + ":" + strconv.FormatUint(uint64(ref.ID), 10)
+	if !r.seen[key] {
+		r.seen[key] = true
+		r.refs = append(r.refs, *ref)
+	}
+
+	return end, `<a href="` + html.EscapeString(ref.URL) + `" class="reference" data-reference="` + string(kind) +
+		`" data-id="` + strconv.FormatUint(uint64(ref.ID), 10) + `">` + html.EscapeString(s[i:end]) + `</a>`, true
+}
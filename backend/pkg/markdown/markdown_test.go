@@ -0,0 +1,81 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"paragraph", "Hello\nworld", "<p>Hello<br>\nworld</p>\n"},
+		{"emphasis", "**bold** *it* _it_ ***both*** ~~gone~~", "<p><strong>bold</strong> <em>it</em> <em>it</em> <strong><em>both</em></strong> <del>gone</del></p>\n"},
+		{"intraword underscore", "snake_case_name", "<p>snake_case_name</p>\n"},
+		{"code span", "use `a < b` here", "<p>use <code>a &lt; b</code> here</p>\n"},
+		{"escapes", `\*not em\* & <b>`, "<p>*not em* &amp; &lt;b&gt;</p>\n"},
+		{"heading", "## Title ##", "<h2>Title</h2>\n"},
+		{"fenced code", "```go\nx := `*`\n```", "<pre><code class=\"language-go\">x := `*`\n</code></pre>\n"},
+		{"quote", "> quoted\n> *text*", "<blockquote>\n<p>quoted<br>\n<em>text</em></p>\n</blockquote>\n"},
+		{"tight list", "- one\n- two\n  - nested", "<ul>\n<li>one</li>\n<li>two\n<ul>\n<li>nested</li>\n</ul></li>\n</ul>\n"},
+		{"loose ordered list", "3. one\n\n4. two", "<ol start=\"3\">\n<li><p>one</p></li>\n<li><p>two</p></li>\n</ol>\n"},
+		{"rule", "a\n\n---", "<p>a</p>\n<hr>\n"},
+		{"link", `[the *docs*](https://example.com/a_(b) "Docs")`, "<p><a href=\"https://example.com/a_(b)\" title=\"Docs\">the <em>docs</em></a></p>\n"},
+		{"autolinks", "<https://a.io> and https://b.io/x.", "<p><a href=\"https://a.io\">https://a.io</a> and <a href=\"https://b.io/x\">https://b.io/x</a>.</p>\n"},
+	}
+	for _, test := range tests {
+		if got := Render(test.src, nil).HTML; got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRenderReferences(t *testing.T) {
+	resolve := func(kind ReferenceKind, name string) *Reference {
+		switch {
+		case kind == ServiceReference && name == "payments-api":
+			return &Reference{Kind: kind, Name: name, ID: 7, URL: "/dashboard/projects/1/services?service=7"}
+		case kind == ProjectReference && name == "billing":
+			return &Reference{Kind: kind, Name: name, ID: 2, URL: "/dashboard/projects/2"}
+		}
+		return nil
+	}
+
+	result := Render("Ask #payments-api, then !billing. Not #unknown, issue#3 or `#payments-api`; #payments-api again.", resolve)
+	for _, want := range []string{
+		`<a href="/dashboard/projects/1/services?service=7" class="reference" data-reference="service" data-id="7">#payments-api</a>,`,
+		`<a href="/dashboard/projects/2" class="reference" data-reference="project" data-id="2">!billing</a>.`,
+		"Not #unknown, issue#3 or <code>#payments-api</code>",
+	} {
+		if !strings.Contains(result.HTML, want) {
+			t.Errorf("missing %q in %s", want, result.HTML)
+		}
+	}
+	if len(result.References) != 2 || result.References[0].ID != 7 || result.References[1].ID != 2 {
+		t.Errorf("unexpected references %+v", result.References)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{`<p onclick="x()">hi <script>alert(1)</script><b>there</b></p>`, "<p>hi there</p>"},
+		{`<a href="javascript:alert(1)" class="evil">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{`<a href="//evil.com">x</a><a href="/dashboard/projects/1" class="reference">y</a>`,
+			`<a rel="nofollow noopener noreferrer">x</a><a href="/dashboard/projects/1" class="reference" rel="nofollow noopener noreferrer">y</a>`},
+		{`<pre><code class="language-go">a &lt; b</code></pre>`, `<pre><code class="language-go">a &lt; b</code></pre>`},
+		{`<ul><li><em>open`, "<ul><li><em>open</em></li></ul>"},
+		{`<img src=x onerror=alert(1)><iframe src="https://a.io">t</iframe>done`, "done"},
+	}
+	for _, test := range tests {
+		if got := Sanitize(test.src); got != test.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", test.src, got, test.want)
+		}
+	}
+
+	// Links written in Markdown go through the same checks
+	if got := RenderSafe("[x](javascript:alert(1))", nil).HTML; strings.Contains(got, "javascript") {
+		t.Errorf("unsafe link kept: %s", got)
+	}
+}
@@ -0,0 +1,155 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// allowedTags lists the elements kept by Sanitize with their allowed
+// attributes. Other elements are removed but their text is kept.
+var allowedTags = map[string]map[string]func(string) bool{
+	"a": {
+		"href":           safeURL,
+		"title":          anyValue,
+		"class":          oneOf("reference"),
+		"data-reference": oneOf(string(ServiceReference), string(ProjectReference)),
+		"data-id":        digitsPattern.MatchString,
+	},
+	"p": nil, "br": nil, "hr": nil,
+	"strong": nil, "em": nil, "del": nil,
+	"code": {"class": languagePattern.MatchString},
+	"pre":  nil, "blockquote": nil,
+	"ul": nil, "ol": {"start": digitsPattern.MatchString}, "li": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+}
+
+// droppedTags are removed along with their content
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"textarea": true, "title": true, "noscript": true, "template": true, "svg": true, "math": true,
+}
+
+var voidTags = map[string]bool{"br": true, "hr": true}
+
+var (
+	digitsPattern   = regexp.MustCompile(`^[0-9]{1,9}$`)
+	languagePattern = regexp.MustCompile(`^language-[A-Za-z0-9_+#.-]{1,30}$`)
+)
+
+func anyValue(string) bool { return true }
+
+func oneOf(values ...string) func(string) bool {
+	return func(value string) bool {
+		for _, v := range values {
+			if value == v {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// safeURL allows http, https and mailto links, and paths on this site
+func safeURL(value string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return true
+	case "":
+		return u.Host == "" && (strings.HasPrefix(u.Path, "/") && !strings.HasPrefix(value, "//") || u.Path == "" && u.Fragment != "")
+	}
+	return false
+}
+
+// Sanitize keeps the allowlisted elements and attributes of an HTML
+// fragment. Links get rel="nofollow noopener noreferrer".
+func Sanitize(fragment string) string {
+	var b strings.Builder
+	var open []string
+	dropped := 0
+
+	z := nethtml.NewTokenizer(strings.NewReader(fragment))
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			// io.EOF, or input the tokenizer gives up on
+			break
+		}
+
+		token := z.Token()
+		switch tt {
+		case nethtml.TextToken:
+			if dropped == 0 {
+				b.WriteString(html.EscapeString(token.Data))
+			}
+
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			if droppedTags[token.Data] {
+				if tt == nethtml.StartTagToken {
+					dropped++
+				}
+				continue
+			}
+			attrs, ok := allowedTags[token.Data]
+			if dropped > 0 || !ok {
+				continue
+			}
+
+			b.WriteString("<" + token.Data)
+			for _, attr := range token.Attr {
+				if check, ok := attrs[attr.Key]; ok && attr.Namespace == "" && check(attr.Val) {
+					b.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+				}
+			}
+			if token.Data == "a" {
+				b.WriteString(` rel="nofollow noopener noreferrer"`)
+			}
+			b.WriteString(">")
+			if !voidTags[token.Data] {
+				open = append(open, token.Data)
+			}
+
+		case nethtml.EndTagToken:
+			if droppedTags[token.Data] {
+				if dropped > 0 {
+					dropped--
+				}
+				continue
+			}
+			if dropped > 0 {
+				continue
+			}
+
+			// Close the element and any left open inside it
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != token.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+// RenderSafe renders Markdown and sanitizes the result
+func RenderSafe(src string, resolve Resolver) Result {
+	result := Render(src, resolve)
+	result.HTML = Sanitize(result.HTML)
+	return result
+}
@@ -0,0 +1,292 @@
+// Package oidc implements the parts of OpenID Connect needed to log users in
+// with the authorization code flow and PKCE: discovery, the token exchange
+// and ID token verification against the provider's cached JWKS.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultCacheTTL is how long discovery documents and keys are cached when
+// the client has no CacheTTL
+const DefaultCacheTTL = time.Hour
+
+// ErrInvalidIDToken is returned for ID tokens that fail verification
+var ErrInvalidIDToken = errors.New("invalid ID token")
+
+// Config configures a Client
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// CacheTTL is how long the discovery document and JWKS are kept
+	CacheTTL   time.Duration
+	HTTPClient *http.Client
+}
+
+// Provider is the subset of the discovery document the client uses
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Token is the response of the token endpoint
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Claims are the verified claims of an ID token
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Nonce         string
+	// Raw holds every claim, for provider specific ones such as groups
+	Raw map[string]interface{}
+}
+
+// Client talks to a single OpenID provider. It is safe for concurrent use.
+type Client struct {
+	cfg Config
+
+	mu         sync.Mutex
+	provider   *Provider
+	providerAt time.Time
+	keys       *keySet
+}
+
+// NewClient creates a client. Nothing is fetched until it is first used.
+func NewClient(cfg Config) *Client {
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = DefaultCacheTTL
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &Client{cfg: cfg}
+}
+
+// Discover returns the provider metadata, fetching it from the well-known
+// endpoint when it is not cached
+func (c *Client) Discover(ctx context.Context) (*Provider, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.provider != nil && time.Since(c.providerAt) < c.cfg.CacheTTL {
+		return c.provider, nil
+	}
+
+	issuer := strings.TrimSuffix(c.cfg.IssuerURL, "/")
+	var provider Provider
+	if err := c.getJSON(ctx, issuer+"/.well-known/openid-configuration", &provider); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+
+	// The issuer must be the one we were configured with (OIDC Discovery 4.3)
+	if strings.TrimSuffix(provider.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", provider.Issuer, c.cfg.IssuerURL)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery: incomplete provider metadata")
+	}
+
+	if c.keys == nil || c.keys.uri != provider.JWKSURI {
+		c.keys = &keySet{uri: provider.JWKSURI}
+	}
+	c.provider = &provider
+	c.providerAt = time.Now()
+	return c.provider, nil
+}
+
+// AuthCodeURL returns the URL to send the user to. The verifier stays with
+// the caller; only its S256 challenge is sent.
+func (c *Client) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	provider, err := c.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(provider.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("oidc: invalid authorization endpoint: %w", err)
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", c.cfg.ClientID)
+	query.Set("redirect_uri", c.cfg.RedirectURL)
+	query.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", Challenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+// Exchange trades an authorization code for tokens
+func (c *Client) Exchange(ctx context.Context, code, verifier string) (*Token, error) {
+	provider, err := c.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"client_id":     {c.cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	}
+
+	resp, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc token exchange: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var token Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("oidc token exchange: no id_token in response")
+	}
+
+	return &token, nil
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of
+// an ID token and returns its claims
+func (c *Client) VerifyIDToken(ctx context.Context, rawToken, nonce string) (*Claims, error) {
+	provider, err := c.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	keys := c.keys
+	c.mu.Unlock()
+
+	mapClaims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawToken, mapClaims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return keys.get(ctx, c, kid, c.cfg.CacheTTL)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(provider.Issuer),
+		jwt.WithAudience(c.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	claims := &Claims{Raw: mapClaims}
+	claims.Subject, _ = mapClaims["sub"].(string)
+	claims.Email, _ = mapClaims["email"].(string)
+	claims.Name, _ = mapClaims["name"].(string)
+	claims.Nonce, _ = mapClaims["nonce"].(string)
+	claims.EmailVerified = boolClaim(mapClaims["email_verified"])
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+	if nonce != "" && claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// Strings returns a claim holding a list of strings, such as groups. A single
+// string is returned as a list of one.
+func (c *Claims) Strings(name string) []string {
+	switch value := c.Raw[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				items = append(items, s)
+			}
+		}
+		return items
+	}
+	return nil
+}
+
+// boolClaim reads a boolean claim. Some providers send "true" as a string.
+func boolClaim(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// getJSON fetches url and decodes its JSON body into target
+func (c *Client) getJSON(ctx context.Context, url string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(target)
+}
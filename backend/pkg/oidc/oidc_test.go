@@ -0,0 +1,163 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"sami/pkg/oidc/oidctest"
+)
+
+func newClient(provider *oidctest.Provider) *Client {
+	return NewClient(Config{
+		IssuerURL:    provider.Issuer(),
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		RedirectURL:  "http://localhost:3000/auth/oidc/callback",
+		Scopes:       []string{"openid", "email", "groups"},
+	})
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	provider := oidctest.NewProvider("sami", "secret")
+	defer provider.Close()
+	provider.SetClaims(map[string]interface{}{
+		"sub":            "user-1",
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"name":           "Jane",
+		"groups":         []string{"sre", "admins"},
+	})
+
+	client := newClient(provider)
+	ctx := context.Background()
+
+	verifier, _ := RandomString()
+	authURL, err := client.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, state, err := provider.Authorize(authURL)
+	if err != nil || state != "state-1" {
+		t.Fatalf("unexpected authorize result: %q, %q, %v", code, state, err)
+	}
+
+	// A wrong verifier fails PKCE
+	if _, err := client.Exchange(ctx, code, "wrong-verifier"); err == nil {
+		t.Fatal("expected exchange with wrong verifier to fail")
+	}
+
+	code, _, _ = provider.Authorize(authURL)
+	token, err := client.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := client.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user-1" || claims.Email != "jane@example.com" || !claims.EmailVerified || claims.Name != "Jane" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+	if groups := claims.Strings("groups"); !reflect.DeepEqual(groups, []string{"sre", "admins"}) {
+		t.Errorf("unexpected groups: %v", groups)
+	}
+
+	// The nonce of another login is rejected
+	if _, err := client.VerifyIDToken(ctx, token.IDToken, "nonce-2"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("expected nonce mismatch, got %v", err)
+	}
+}
+
+func TestVerifyIDTokenRejectsInvalidTokens(t *testing.T) {
+	provider := oidctest.NewProvider("sami", "")
+	defer provider.Close()
+	client := newClient(provider)
+	ctx := context.Background()
+
+	valid := jwt.MapClaims{
+		"iss": provider.Issuer(),
+		"aud": "sami",
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}
+
+	tests := map[string]func(jwt.MapClaims){
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "other-client" },
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no subject":     func(c jwt.MapClaims) { delete(c, "sub") },
+	}
+
+	for name, mutate := range tests {
+		claims := jwt.MapClaims{}
+		for k, v := range valid {
+			claims[k] = v
+		}
+		mutate(claims)
+
+		token, err := provider.SignIDToken(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.VerifyIDToken(ctx, token, ""); !errors.Is(err, ErrInvalidIDToken) {
+			t.Errorf("%s: expected invalid token, got %v", name, err)
+		}
+	}
+
+	// Unsigned tokens are rejected
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, valid).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if _, err := client.VerifyIDToken(ctx, unsigned, ""); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("expected unsigned token to be rejected, got %v", err)
+	}
+}
+
+func TestKeysAreCachedAndRefreshedOnRotation(t *testing.T) {
+	provider := oidctest.NewProvider("sami", "")
+	defer provider.Close()
+	client := newClient(provider)
+	ctx := context.Background()
+
+	sign := func() string {
+		token, err := provider.SignIDToken(jwt.MapClaims{
+			"iss": provider.Issuer(),
+			"aud": "sami",
+			"sub": "user-1",
+			"exp": time.Now().Add(time.Minute).Unix(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.VerifyIDToken(ctx, sign(), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := provider.JWKSRequests(); n != 1 {
+		t.Errorf("expected keys to be fetched once, got %d", n)
+	}
+
+	// A token signed with an unknown key only triggers a refresh once the
+	// minimum interval has passed
+	provider.RotateKey()
+	if _, err := client.VerifyIDToken(ctx, sign(), ""); err == nil {
+		t.Error("expected unknown key to be rejected within the refresh interval")
+	}
+
+	client.keys.fetchedAt = time.Now().Add(-minRefreshInterval)
+	if _, err := client.VerifyIDToken(ctx, sign(), ""); err != nil {
+		t.Errorf("expected rotated key to be fetched, got %v", err)
+	}
+	if n := provider.JWKSRequests(); n != 2 {
+		t.Errorf("expected keys to be fetched twice, got %d", n)
+	}
+}
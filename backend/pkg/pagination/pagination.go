@@ -0,0 +1,368 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMaxLimit = 200
+	dateLayout      = "2006-01-02"
+)
+
+// Kind represents the type of a sortable or filterable column
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindInt
+	KindTime
+)
+
+// Sort describes a sort key exposed through the ?sort= parameter
+type Sort[T any] struct {
+	// Column is the SQL expression to order by; it must never be NULL
+	Column string
+	Kind   Kind
+	// Value extracts the sort value from a row to build cursors
+	Value func(row T) interface{}
+}
+
+// Filter describes a field filter exposed as a query parameter. Values may be
+// comma separated to match any of them.
+type Filter struct {
+	Column string
+	Kind   Kind
+}
+
+// Spec describes how a list endpoint can be paginated, sorted and filtered
+type Spec[T any] struct {
+	Sorts       map[string]Sort[T]
+	DefaultSort string
+	DefaultDesc bool
+
+	// IDColumn and ID are used as tie-breaker so cursors are stable
+	IDColumn string
+	ID       func(row T) uint
+
+	Filters map[string]Filter
+	// DateRanges maps a prefix to a timestamp column, exposing
+	// <prefix>_after and <prefix>_before parameters
+	DateRanges map[string]string
+
+	// DefaultLimit is used when no ?limit= is given; 0 returns every row
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// Params represents the parsed pagination, sort and filter parameters
+type Params struct {
+	Limit  int
+	Sort   string
+	Desc   bool
+	cursor *cursor
+	where  []condition
+}
+
+type condition struct {
+	sql  string
+	args []interface{}
+}
+
+// cursor is the decoded form of an opaque next/prev cursor
+type cursor struct {
+	Sort     string          `json:"s"`
+	Value    json.RawMessage `json:"v"`
+	ID       uint            `json:"id"`
+	Backward bool            `json:"b,omitempty"`
+}
+
+// Info represents the pagination block returned with every list
+type Info struct {
+	Limit      int     `json:"limit"`
+	Total      int64   `json:"total"`
+	Sort       string  `json:"sort"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+}
+
+// Page represents one page of results
+type Page[T any] struct {
+	Items []T
+	Info  Info
+}
+
+// Parse reads limit, cursor, sort and filter parameters from a query string
+func Parse[T any](values url.Values, spec Spec[T]) (Params, error) {
+	params := Params{
+		Limit: spec.DefaultLimit,
+		Sort:  spec.DefaultSort,
+		Desc:  spec.DefaultDesc,
+	}
+
+	maxLimit := spec.MaxLimit
+	if maxLimit == 0 {
+		maxLimit = defaultMaxLimit
+	}
+
+	if l := values.Get("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit <= 0 {
+			return params, fmt.Errorf("invalid limit %q", l)
+		}
+		if limit > maxLimit {
+			limit = maxLimit
+		}
+		params.Limit = limit
+	}
+
+	if s := values.Get("sort"); s != "" {
+		name := strings.TrimPrefix(s, "-")
+		if _, ok := spec.Sorts[name]; !ok {
+			return params, fmt.Errorf("invalid sort %q, must be one of: %s", name, strings.Join(sortNames(spec), ", "))
+		}
+		params.Sort = name
+		params.Desc = strings.HasPrefix(s, "-")
+	}
+
+	if cur := values.Get("cursor"); cur != "" {
+		decoded, err := decodeCursor(cur)
+		if err != nil || decoded.Sort != sortParam(params.Sort, params.Desc) {
+			return params, fmt.Errorf("invalid cursor")
+		}
+		if params.Limit == 0 {
+			params.Limit = maxLimit
+		}
+		params.cursor = decoded
+	}
+
+	for name, filter := range spec.Filters {
+		raw := values.Get(name)
+		if raw == "" {
+			continue
+		}
+
+		parts := strings.Split(raw, ",")
+		args := make([]interface{}, 0, len(parts))
+		for _, part := range parts {
+			value, err := parseValue(strings.TrimSpace(part), filter.Kind)
+			if err != nil {
+				return params, fmt.Errorf("invalid value for %s: %v", name, err)
+			}
+			args = append(args, value)
+		}
+		params.where = append(params.where, condition{sql: filter.Column + " IN ?", args: []interface{}{args}})
+	}
+
+	for prefix, column := range spec.DateRanges {
+		if raw := values.Get(prefix + "_after"); raw != "" {
+			t, err := parseTime(raw)
+			if err != nil {
+				return params, fmt.Errorf("invalid value for %s_after: %v", prefix, err)
+			}
+			params.where = append(params.where, condition{sql: column + " >= ?", args: []interface{}{t}})
+		}
+		if raw := values.Get(prefix + "_before"); raw != "" {
+			t, err := parseTime(raw)
+			if err != nil {
+				return params, fmt.Errorf("invalid value for %s_before: %v", prefix, err)
+			}
+			params.where = append(params.where, condition{sql: column + " < ?", args: []interface{}{t}})
+		}
+	}
+
+	return params, nil
+}
+
+// Filter applies the parsed field filters to a query
+func (p Params) Filter(db *gorm.DB) *gorm.DB {
+	for _, cond := range p.where {
+		db = db.Where(cond.sql, cond.args...)
+	}
+	return db
+}
+
+// Find runs a filtered query and returns one page of results. load is applied
+// to the data query only and is the place for Preload calls.
+func Find[T any](db *gorm.DB, spec Spec[T], params Params, load func(*gorm.DB) *gorm.DB) (Page[T], error) {
+	page := Page[T]{
+		Items: make([]T, 0),
+		Info: Info{
+			Limit: params.Limit,
+			Sort:  sortParam(params.Sort, params.Desc),
+		},
+	}
+
+	base := params.Filter(db)
+
+	if err := base.Session(&gorm.Session{}).Model(new(T)).Count(&page.Info.Total).Error; err != nil {
+		return page, err
+	}
+
+	sort := spec.Sorts[params.Sort]
+	backward := params.cursor != nil && params.cursor.Backward
+	desc := params.Desc != backward
+
+	query := base.Session(&gorm.Session{})
+	if load != nil {
+		query = load(query)
+	}
+
+	if params.cursor != nil {
+		value, err := decodeValue(params.cursor.Value, sort.Kind)
+		if err != nil {
+			return page, err
+		}
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		query = query.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", sort.Column, spec.IDColumn, op), value, params.cursor.ID)
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	query = query.Order(fmt.Sprintf("%s %s, %s %s", sort.Column, direction, spec.IDColumn, direction))
+
+	if params.Limit > 0 {
+		query = query.Limit(params.Limit + 1)
+	}
+
+	var rows []T
+	if err := query.Find(&rows).Error; err != nil {
+		return page, err
+	}
+
+	hasMore := params.Limit > 0 && len(rows) > params.Limit
+	if hasMore {
+		rows = rows[:params.Limit]
+	}
+
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	page.Items = rows
+	if len(rows) == 0 {
+		return page, nil
+	}
+
+	// There is a next page when more rows follow going forward, or when we
+	// came back from one; symmetrically for the previous page
+	hasNext := (!backward && hasMore) || (backward && params.cursor != nil)
+	hasPrev := (backward && hasMore) || (!backward && params.cursor != nil)
+
+	if hasNext {
+		next, err := encodeCursor(page.Info.Sort, sort.Value(rows[len(rows)-1]), spec.ID(rows[len(rows)-1]), false)
+		if err != nil {
+			return page, err
+		}
+		page.Info.NextCursor = &next
+	}
+
+	if hasPrev {
+		prev, err := encodeCursor(page.Info.Sort, sort.Value(rows[0]), spec.ID(rows[0]), true)
+		if err != nil {
+			return page, err
+		}
+		page.Info.PrevCursor = &prev
+	}
+
+	return page, nil
+}
+
+func sortParam(name string, desc bool) string {
+	if desc {
+		return "-" + name
+	}
+	return name
+}
+
+func sortNames[T any](spec Spec[T]) []string {
+	names := make([]string, 0, len(spec.Sorts))
+	for name := range spec.Sorts {
+		names = append(names, name)
+	}
+	return names
+}
+
+func encodeCursor(sort string, value interface{}, id uint, backward bool) (string, error) {
+	if t, ok := value.(time.Time); ok {
+		value = t.Format(time.RFC3339Nano)
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(cursor{Sort: sort, Value: raw, ID: id, Backward: backward})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(encoded string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func decodeValue(raw json.RawMessage, kind Kind) (interface{}, error) {
+	switch kind {
+	case KindInt:
+		var v int64
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case KindTime:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	default:
+		var v string
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	}
+}
+
+func parseValue(raw string, kind Kind) (interface{}, error) {
+	switch kind {
+	case KindInt:
+		return strconv.ParseInt(raw, 10, 64)
+	case KindTime:
+		return parseTime(raw)
+	default:
+		return raw, nil
+	}
+}
+
+// parseTime accepts RFC 3339 timestamps or plain dates
+func parseTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse(dateLayout, raw)
+}
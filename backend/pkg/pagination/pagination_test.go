@@ -0,0 +1,83 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+type row struct {
+	ID        uint
+	Name      string
+	CreatedAt time.Time
+}
+
+var testSpec = Spec[row]{
+	Sorts: map[string]Sort[row]{
+		"name":       {Column: "name", Kind: KindString, Value: func(r row) interface{} { return r.Name }},
+		"created_at": {Column: "created_at", Kind: KindTime, Value: func(r row) interface{} { return r.CreatedAt }},
+	},
+	DefaultSort:  "created_at",
+	DefaultDesc:  true,
+	IDColumn:     "id",
+	ID:           func(r row) uint { return r.ID },
+	Filters:      map[string]Filter{"status": {Column: "status"}, "owner_id": {Column: "owner_id", Kind: KindInt}},
+	DateRanges:   map[string]string{"created": "created_at"},
+	DefaultLimit: 50,
+	MaxLimit:     100,
+}
+
+func TestParse(t *testing.T) {
+	params, err := Parse(url.Values{"sort": {"-name"}, "limit": {"500"}, "status": {"active,archived"}, "created_after": {"2024-01-01"}}, testSpec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params.Sort != "name" || !params.Desc {
+		t.Errorf("expected sort -name, got %s desc=%v", params.Sort, params.Desc)
+	}
+	if params.Limit != 100 {
+		t.Errorf("expected limit to be capped at 100, got %d", params.Limit)
+	}
+	if len(params.where) != 2 {
+		t.Errorf("expected 2 conditions, got %d", len(params.where))
+	}
+
+	invalid := []url.Values{
+		{"sort": {"password"}},
+		{"limit": {"-1"}},
+		{"owner_id": {"abc"}},
+		{"created_before": {"yesterday"}},
+		{"cursor": {"not-a-cursor"}},
+	}
+	for _, values := range invalid {
+		if _, err := Parse(values, testSpec); err == nil {
+			t.Errorf("expected error for %v", values)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 30, 0, 123, time.UTC)
+	encoded, err := encodeCursor("-created_at", created, 42, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params, err := Parse(url.Values{"cursor": {encoded}}, testSpec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params.cursor.ID != 42 || !params.cursor.Backward {
+		t.Errorf("unexpected cursor %+v", params.cursor)
+	}
+
+	value, err := decodeValue(params.cursor.Value, KindTime)
+	if err != nil || !value.(time.Time).Equal(created) {
+		t.Errorf("expected %v, got %v (%v)", created, value, err)
+	}
+
+	// A cursor is only valid for the sort it was issued for
+	if _, err := Parse(url.Values{"cursor": {encoded}, "sort": {"name"}}, testSpec); err == nil {
+		t.Error("expected error for cursor with a different sort")
+	}
+}
@@ -0,0 +1,160 @@
+// Package password checks new passwords against a configurable policy:
+// length, character classes, personal information and a list of common
+// passwords.
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"sami/pkg/config"
+)
+
+// MaxBytes is the longest password bcrypt can hash
+const MaxBytes = 72
+
+//go:embed common-passwords.txt
+var bundledCommonPasswords string
+
+// Policy describes what a new password must satisfy
+type Policy struct {
+	MinLength      int  `json:"min_length"`
+	RequireUpper   bool `json:"require_upper"`
+	RequireLower   bool `json:"require_lower"`
+	RequireDigit   bool `json:"require_digit"`
+	RequireSymbol  bool `json:"require_symbol"`
+	RejectPersonal bool `json:"reject_personal_info"`
+	RejectCommon   bool `json:"reject_common"`
+
+	common map[string]bool
+}
+
+// New creates the policy configured by cfg. The common password list is read
+// from cfg.CommonListFile, or the bundled list when it is not set.
+func New(cfg config.PasswordConfig) (*Policy, error) {
+	policy := &Policy{
+		MinLength:      cfg.MinLength,
+		RequireUpper:   cfg.RequireUpper,
+		RequireLower:   cfg.RequireLower,
+		RequireDigit:   cfg.RequireDigit,
+		RequireSymbol:  cfg.RequireSymbol,
+		RejectPersonal: cfg.RejectPersonalInfo,
+		RejectCommon:   cfg.RejectCommon,
+	}
+
+	if !policy.RejectCommon {
+		return policy, nil
+	}
+
+	var list io.Reader = strings.NewReader(bundledCommonPasswords)
+	if cfg.CommonListFile != "" {
+		file, err := os.Open(cfg.CommonListFile)
+		if err != nil {
+			return nil, fmt.Errorf("common password list: %w", err)
+		}
+		defer file.Close()
+		list = file
+	}
+
+	common, err := LoadList(list)
+	if err != nil {
+		return nil, fmt.Errorf("common password list: %w", err)
+	}
+	policy.common = common
+
+	return policy, nil
+}
+
+// LoadList reads a password list with one password per line. Blank lines
+// and lines starting with # are skipped.
+func LoadList(r io.Reader) (map[string]bool, error) {
+	list := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list[strings.ToLower(line)] = true
+	}
+	return list, scanner.Err()
+}
+
+// Check returns the requirements password does not meet, empty when it is
+// acceptable. personal holds the user's name and email, which must not
+// appear in the password.
+func (p *Policy) Check(password string, personal ...string) []string {
+	var problems []string
+
+	if n := len([]rune(password)); n < p.MinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters long", p.MinLength))
+	}
+	if len(password) > MaxBytes {
+		problems = append(problems, fmt.Sprintf("must be at most %d bytes long", MaxBytes))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		problems = append(problems, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		problems = append(problems, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		problems = append(problems, "must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		problems = append(problems, "must contain a symbol")
+	}
+
+	lowered := strings.ToLower(password)
+	if p.RejectPersonal && containsPersonal(lowered, personal) {
+		problems = append(problems, "must not contain your name or email")
+	}
+	if p.RejectCommon && p.common[lowered] {
+		problems = append(problems, "is too common")
+	}
+
+	return problems
+}
+
+// containsPersonal reports whether password contains a meaningful part of
+// the user's name or email: each name word and the email's local part
+func containsPersonal(password string, personal []string) bool {
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if local, _, ok := strings.Cut(value, "@"); ok {
+			value = local
+		}
+
+		parts := strings.FieldsFunc(value, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		parts = append(parts, value)
+
+		for _, part := range parts {
+			// Short fragments such as initials would reject too much
+			if len([]rune(part)) >= 3 && strings.Contains(password, part) {
+				return true
+			}
+		}
+	}
+	return false
+}
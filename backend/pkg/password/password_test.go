@@ -0,0 +1,91 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sami/pkg/config"
+)
+
+func defaultPolicy(t *testing.T) *Policy {
+	policy, err := New(config.PasswordConfig{
+		MinLength:          8,
+		RequireUpper:       true,
+		RequireLower:       true,
+		RequireDigit:       true,
+		RejectPersonalInfo: true,
+		RejectCommon:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return policy
+}
+
+func TestCheck(t *testing.T) {
+	policy := defaultPolicy(t)
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"Tr0ub4dor&3", nil},
+		{"Ab1", []string{"must be at least 8 characters long"}},
+		{"lowercase123", []string{"must contain an uppercase letter"}},
+		{"UPPERCASE123", []string{"must contain a lowercase letter"}},
+		{"NoDigitsHere", []string{"must contain a digit"}},
+		{"Password1", []string{"is too common"}},
+		{"JaneDoe2024", []string{"must not contain your name or email"}},
+		{"xJsmith99x", []string{"must not contain your name or email"}},
+		{strings.Repeat("Aa1", 25), []string{"must be at most 72 bytes long"}},
+	}
+
+	for _, tt := range tests {
+		got := policy.Check(tt.password, "Jane Doe", "jsmith99@example.com")
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Check(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestSymbolsAndShortNames(t *testing.T) {
+	policy := defaultPolicy(t)
+	policy.RequireSymbol = true
+
+	if got := policy.Check("Abcdefg1"); !reflect.DeepEqual(got, []string{"must contain a symbol"}) {
+		t.Errorf("unexpected problems: %v", got)
+	}
+	if got := policy.Check("Abcdefg1!"); got != nil {
+		t.Errorf("unexpected problems: %v", got)
+	}
+
+	// Names shorter than three characters are ignored
+	if got := policy.Check("Abcdefg1!", "Al", "al@example.com"); got != nil {
+		t.Errorf("unexpected problems: %v", got)
+	}
+}
+
+func TestCommonListFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "common.txt")
+	if err := os.WriteFile(path, []byte("# comment\n\nCorrectHorse9\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := New(config.PasswordConfig{RejectCommon: true, CommonListFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := policy.Check("correcthorse9"); !reflect.DeepEqual(got, []string{"is too common"}) {
+		t.Errorf("expected password from the file to be rejected, got %v", got)
+	}
+	if got := policy.Check("password"); got != nil {
+		t.Errorf("expected the file to replace the bundled list, got %v", got)
+	}
+
+	if _, err := New(config.PasswordConfig{RejectCommon: true, CommonListFile: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("expected a missing list to fail")
+	}
+}
@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Lockout locks an account after too many failed logins. Callers key it with
+// AccountKey so failures from one client do not lock out the others. Each failure past
+// the threshold doubles the lock, up to Max. Failures are forgotten Window
+// after the first one or on a successful login.
+type Lockout struct {
+	Store     Store
+	Threshold int64
+	Base      time.Duration
+	Max       time.Duration
+	Window    time.Duration
+}
+
+// Check returns how long the account is still locked, zero when it is not
+func (l *Lockout) Check(ctx context.Context, account string) (time.Duration, error) {
+	if !l.enabled() {
+		return 0, nil
+	}
+
+	locked, ttl, err := l.Store.Get(ctx, l.key("lock", account))
+	if err != nil || locked == 0 {
+		return 0, err
+	}
+	return ttl, nil
+}
+
+// Fail records a failed login and returns the lock it caused, if any
+func (l *Lockout) Fail(ctx context.Context, account string) (time.Duration, error) {
+	if !l.enabled() {
+		return 0, nil
+	}
+
+	window := l.Window
+	if window <= 0 {
+		window = 24 * time.Hour
+	}
+
+	failures, _, err := l.Store.Increment(ctx, l.key("fail", account), window)
+	if err != nil || failures < l.Threshold {
+		return 0, err
+	}
+
+	duration := l.Backoff(failures)
+	if err := l.Store.Set(ctx, l.key("lock", account), 1, duration); err != nil {
+		return 0, err
+	}
+	return duration, nil
+}
+
+// Reset forgets the failures of an account after a successful login
+func (l *Lockout) Reset(ctx context.Context, account string) error {
+	if !l.enabled() {
+		return nil
+	}
+
+	if err := l.Store.Delete(ctx, l.key("fail", account)); err != nil {
+		return err
+	}
+	return l.Store.Delete(ctx, l.key("lock", account))
+}
+
+// Backoff returns the lock duration after the given number of failures
+func (l *Lockout) Backoff(failures int64) time.Duration {
+	if failures < l.Threshold {
+		return 0
+	}
+
+	duration := l.Base
+	for i := l.Threshold; i < failures; i++ {
+		duration *= 2
+		if l.Max > 0 && duration >= l.Max {
+			return l.Max
+		}
+	}
+	if l.Max > 0 && duration > l.Max {
+		return l.Max
+	}
+	return duration
+}
+
+func (l *Lockout) enabled() bool {
+	return l != nil && l.Store != nil && l.Threshold > 0 && l.Base > 0
+}
+
+// AccountKey identifies the logins to an account from one client address.
+// Keying on the email alone would let anyone lock any account out.
+func AccountKey(email, clientIP string) string {
+	return strings.TrimSpace(email) + "|" + clientIP
+}
+
+// key builds the store key of an account. Emails are case-insensitive.
+func (l *Lockout) key(kind, account string) string {
+	return "lockout:" + kind + ":" + strings.ToLower(strings.TrimSpace(account))
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often expired counters are dropped
+const sweepInterval = time.Minute
+
+type memoryEntry struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// MemoryStore keeps counters in process memory. Limits are per instance, so
+// it suits single instance deployments and tests.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates an empty store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry), now: time.Now}
+}
+
+// Increment implements Store
+func (s *MemoryStore) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		entry = memoryEntry{expiresAt: now.Add(window)}
+	}
+	entry.count++
+	s.entries[key] = entry
+
+	return entry.count, entry.expiresAt.Sub(now), nil
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(ctx context.Context, key string) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		return 0, 0, nil
+	}
+	return entry.count, entry.expiresAt.Sub(now), nil
+}
+
+// Set implements Store
+func (s *MemoryStore) Set(ctx context.Context, key string, count int64, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryEntry{count: count, expiresAt: s.now().Add(ttl)}
+	return nil
+}
+
+// Delete implements Store
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// sweep drops expired entries so keys of past clients do not pile up
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,144 @@
+// Package ratelimit counts requests and failed logins in fixed windows. The
+// counters live in a Store so several API instances can share them.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Store keeps counters that expire
+type Store interface {
+	// Increment adds one to key and returns the new count and the time left
+	// before the key expires. The expiry is set when the key is created.
+	Increment(ctx context.Context, key string, window time.Duration) (count int64, ttl time.Duration, err error)
+	// Get returns the count of key and its time to live, zero when unset
+	Get(ctx context.Context, key string) (count int64, ttl time.Duration, err error)
+	// Set stores count under key for ttl
+	Set(ctx context.Context, key string, count int64, ttl time.Duration) error
+	// Delete removes key
+	Delete(ctx context.Context, key string) error
+}
+
+// Limit allows Requests per Window
+type Limit struct {
+	Requests int64
+	Window   time.Duration
+}
+
+// ParseLimit parses a limit written as "requests/window", such as "20/1m".
+// An empty value or "0" disables the limit.
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return Limit{}, nil
+	}
+
+	requests, window, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected requests/window", value)
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(requests), 10, 64)
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad request count", value)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(window))
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad window", value)
+	}
+
+	return Limit{Requests: n, Window: d}, nil
+}
+
+// Enabled reports whether the limit restricts anything
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Window > 0
+}
+
+// String formats the limit the way ParseLimit reads it
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "0"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Window)
+}
+
+// Result is the outcome of a rate limited request
+type Result struct {
+	Limit     Limit
+	Allowed   bool
+	Remaining int64
+	// Reset is the time left until the window starts over
+	Reset time.Duration
+}
+
+// Allow counts a request on key against limit
+func Allow(ctx context.Context, store Store, key string, limit Limit) (Result, error) {
+	if !limit.Enabled() {
+		return Result{Limit: limit, Allowed: true}, nil
+	}
+
+	count, ttl, err := store.Increment(ctx, "rl:"+key, limit.Window)
+	if err != nil {
+		return Result{}, err
+	}
+	if ttl <= 0 {
+		ttl = limit.Window
+	}
+
+	remaining := limit.Requests - count
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return Result{
+		Limit:     limit,
+		Allowed:   count <= limit.Requests,
+		Remaining: remaining,
+		Reset:     ttl,
+	}, nil
+}
+
+// Rule applies a limit to routes starting with Prefix
+type Rule struct {
+	Name   string
+	Prefix string
+	Limit  Limit
+}
+
+// Match returns the first rule whose prefix matches path
+func Match(rules []Rule, path string) (Rule, bool) {
+	for _, rule := range rules {
+		if strings.HasPrefix(path, rule.Prefix) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// SetHeaders writes the RateLimit headers of the IETF draft, and Retry-After
+// when the request is refused
+func SetHeaders(h http.Header, result Result) {
+	reset := Seconds(result.Reset)
+
+	h.Set("RateLimit-Limit", strconv.FormatInt(result.Limit.Requests, 10))
+	h.Set("RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+	h.Set("RateLimit-Reset", strconv.Itoa(reset))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.Limit.Requests, Seconds(result.Limit.Window)))
+	if !result.Allowed {
+		h.Set("Retry-After", strconv.Itoa(reset))
+	}
+}
+
+// Seconds rounds a duration up to whole seconds, as headers expect
+func Seconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
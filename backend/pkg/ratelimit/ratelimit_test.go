@@ -0,0 +1,187 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("20/1m")
+	if err != nil || limit != (Limit{Requests: 20, Window: time.Minute}) {
+		t.Fatalf("unexpected limit: %+v, %v", limit, err)
+	}
+
+	if limit, err := ParseLimit(""); err != nil || limit.Enabled() {
+		t.Errorf("expected empty value to disable the limit, got %+v, %v", limit, err)
+	}
+
+	for _, value := range []string{"20", "x/1m", "20/soon", "20/-1m"} {
+		if _, err := ParseLimit(value); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestAllowWithMemoryStore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	limit := Limit{Requests: 2, Window: time.Minute}
+
+	for i, want := range []bool{true, true, false} {
+		result, err := Allow(ctx, store, "ip:1.2.3.4", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != want {
+			t.Errorf("request %d: expected allowed=%v", i+1, want)
+		}
+	}
+
+	// Other keys have their own counter
+	if result, _ := Allow(ctx, store, "ip:5.6.7.8", limit); !result.Allowed || result.Remaining != 1 {
+		t.Errorf("unexpected result for another key: %+v", result)
+	}
+
+	// The window starts over once it expires
+	now = now.Add(30 * time.Second)
+	if result, _ := Allow(ctx, store, "ip:1.2.3.4", limit); result.Allowed || result.Reset != 30*time.Second {
+		t.Errorf("expected to stay limited until the window ends, got %+v", result)
+	}
+	now = now.Add(30 * time.Second)
+	if result, _ := Allow(ctx, store, "ip:1.2.3.4", limit); !result.Allowed || result.Remaining != 1 {
+		t.Errorf("expected a new window, got %+v", result)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	rules := []Rule{
+		{Name: "bulk", Prefix: "/projects/:id/bulk-save"},
+		{Name: "api", Prefix: "/"},
+	}
+
+	if rule, _ := Match(rules, "/projects/:id/bulk-save"); rule.Name != "bulk" {
+		t.Errorf("expected bulk rule, got %q", rule.Name)
+	}
+	if rule, _ := Match(rules, "/projects/:id"); rule.Name != "api" {
+		t.Errorf("expected api rule, got %q", rule.Name)
+	}
+	if _, ok := Match(rules[:1], "/auth/login"); ok {
+		t.Error("expected no rule to match")
+	}
+}
+
+func TestLockout(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	lockout := &Lockout{Store: store, Threshold: 3, Base: time.Minute, Max: 10 * time.Minute}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if d, _ := lockout.Fail(ctx, "Jane@example.com"); d != 0 {
+			t.Fatalf("failure %d: unexpected lock %s", i+1, d)
+		}
+	}
+
+	// The third failure locks the account, emails are case-insensitive
+	if d, _ := lockout.Fail(ctx, "jane@example.com"); d != time.Minute {
+		t.Fatalf("expected a one minute lock, got %s", d)
+	}
+	if d, _ := lockout.Check(ctx, "JANE@example.com"); d != time.Minute {
+		t.Errorf("expected account to be locked, got %s", d)
+	}
+
+	// The lock doubles with each failure, up to the maximum
+	want := []time.Duration{2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+	for i, failures := range []int64{4, 5, 6, 7, 20} {
+		if d := lockout.Backoff(failures); d != want[i] {
+			t.Errorf("backoff after %d failures: expected %s, got %s", failures, want[i], d)
+		}
+	}
+
+	// Failures from one client do not lock the account for the others
+	if d, _ := lockout.Check(ctx, AccountKey("jane@example.com", "192.0.2.1")); d != 0 {
+		t.Errorf("expected account to be open from another client, got %s", d)
+	}
+	for i := 0; i < 3; i++ {
+		lockout.Fail(ctx, AccountKey("Jane@example.com", "192.0.2.1"))
+	}
+	if d, _ := lockout.Check(ctx, AccountKey("jane@example.com", "192.0.2.1")); d != time.Minute {
+		t.Errorf("expected account to be locked for the failing client, got %s", d)
+	}
+	if d, _ := lockout.Check(ctx, AccountKey("jane@example.com", "192.0.2.2")); d != 0 {
+		t.Errorf("expected account to be open from another client, got %s", d)
+	}
+
+	// A successful login clears the failures
+	if err := lockout.Reset(ctx, "jane@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := lockout.Check(ctx, "jane@example.com"); d != 0 {
+		t.Errorf("expected lock to be cleared, got %s", d)
+	}
+}
+
+// fakeRedis answers each command read from conn with the next canned reply
+func fakeRedis(conn net.Conn, replies []string) <-chan []string {
+	commands := make(chan []string, len(replies))
+	go func() {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for _, reply := range replies {
+			command, err := readReply(r)
+			if err != nil {
+				return
+			}
+			args := make([]string, 0)
+			for _, arg := range command.([]interface{}) {
+				args = append(args, arg.(string))
+			}
+			commands <- args
+			conn.Write([]byte(reply))
+		}
+		close(commands)
+	}()
+	return commands
+}
+
+func TestRedisConn(t *testing.T) {
+	client, server := net.Pipe()
+	commands := fakeRedis(server, []string{
+		"*2\r\n:3\r\n:59000\r\n",
+		"-ERR wrong number of arguments\r\n",
+		"$5\r\nhello\r\n",
+	})
+	conn := &redisConn{Conn: client, r: bufio.NewReader(client)}
+	defer conn.Close()
+
+	reply, err := conn.do("EVAL", incrementScript, "1", "rl:key", "60000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, ttl, err := countAndTTL(reply)
+	if err != nil || count != 3 || ttl != 59*time.Second {
+		t.Errorf("unexpected increment result: %d, %s, %v", count, ttl, err)
+	}
+	if command := <-commands; command[0] != "EVAL" || !reflect.DeepEqual(command[2:], []string{"1", "rl:key", "60000"}) {
+		t.Errorf("unexpected command: %v", command)
+	}
+
+	if _, err := conn.do("DEL"); err == nil || !strings.Contains(err.Error(), "wrong number") {
+		t.Errorf("expected error reply, got %v", err)
+	}
+	<-commands
+
+	if reply, err := conn.do("GET", "greeting"); err != nil || reply != "hello" {
+		t.Errorf("unexpected bulk reply: %v, %v", reply, err)
+	}
+}
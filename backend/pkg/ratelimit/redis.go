@@ -0,0 +1,278 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// incrementScript increments a key and sets its expiry when it is created,
+// atomically so a crash between the two cannot leave a counter forever
+const incrementScript = `
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return {count, redis.call('PTTL', KEYS[1])}
+`
+
+// getScript returns the count and time to live of a key in one round trip
+const getScript = `
+local count = redis.call('GET', KEYS[1])
+if not count then
+	return {0, 0}
+end
+return {tonumber(count), redis.call('PTTL', KEYS[1])}
+`
+
+// RedisConfig configures a RedisStore
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+	// PoolSize is the number of idle connections kept open
+	PoolSize int
+	Timeout  time.Duration
+}
+
+// RedisStore keeps counters in Redis so limits are shared between instances.
+// It speaks the small subset of RESP it needs over a pool of connections.
+type RedisStore struct {
+	cfg  RedisConfig
+	pool chan *redisConn
+}
+
+// NewRedisStore creates a store. Connections are opened on first use.
+func NewRedisStore(cfg RedisConfig) *RedisStore {
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 10
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	return &RedisStore{cfg: cfg, pool: make(chan *redisConn, cfg.PoolSize)}
+}
+
+// Ping checks that Redis is reachable
+func (s *RedisStore) Ping(ctx context.Context) error {
+	_, err := s.do(ctx, "PING")
+	return err
+}
+
+// Increment implements Store
+func (s *RedisStore) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	reply, err := s.do(ctx, "EVAL", incrementScript, "1", key, strconv.FormatInt(window.Milliseconds(), 10))
+	if err != nil {
+		return 0, 0, err
+	}
+	return countAndTTL(reply)
+}
+
+// Get implements Store
+func (s *RedisStore) Get(ctx context.Context, key string) (int64, time.Duration, error) {
+	reply, err := s.do(ctx, "EVAL", getScript, "1", key)
+	if err != nil {
+		return 0, 0, err
+	}
+	return countAndTTL(reply)
+}
+
+// Set implements Store
+func (s *RedisStore) Set(ctx context.Context, key string, count int64, ttl time.Duration) error {
+	_, err := s.do(ctx, "SET", key, strconv.FormatInt(count, 10), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// Delete implements Store
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	_, err := s.do(ctx, "DEL", key)
+	return err
+}
+
+// Close closes the idle connections
+func (s *RedisStore) Close() error {
+	for {
+		select {
+		case conn := <-s.pool:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// countAndTTL reads the {count, pttl} reply of the scripts. A negative PTTL
+// means the key has no expiry or does not exist.
+func countAndTTL(reply interface{}) (int64, time.Duration, error) {
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, 0, fmt.Errorf("redis: unexpected reply %v", reply)
+	}
+	count, ok1 := values[0].(int64)
+	pttl, ok2 := values[1].(int64)
+	if !ok1 || !ok2 {
+		return 0, 0, fmt.Errorf("redis: unexpected reply %v", reply)
+	}
+	if pttl < 0 {
+		pttl = 0
+	}
+	return count, time.Duration(pttl) * time.Millisecond, nil
+}
+
+// do runs a command on a pooled connection
+func (s *RedisStore) do(ctx context.Context, args ...string) (interface{}, error) {
+	conn, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(s.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	reply, err := conn.do(args...)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		// The connection state is unknown after an I/O error
+		conn.Close()
+		return nil, err
+	}
+
+	s.put(conn)
+	return reply, err
+}
+
+// get takes an idle connection or dials a new one
+func (s *RedisStore) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-s.pool:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: s.cfg.Timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", s.cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+
+	conn := &redisConn{Conn: netConn, r: bufio.NewReader(netConn)}
+	conn.SetDeadline(time.Now().Add(s.cfg.Timeout))
+	if s.cfg.Password != "" {
+		if _, err := conn.do("AUTH", s.cfg.Password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if s.cfg.DB != 0 {
+		if _, err := conn.do("SELECT", strconv.Itoa(s.cfg.DB)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+// put returns a connection to the pool, closing it when the pool is full
+func (s *RedisStore) put(conn *redisConn) {
+	select {
+	case s.pool <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+// redisError is an error reply from the server. The connection stays usable.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// do writes a command as an array of bulk strings and reads the reply
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+
+	if _, err := c.Write(buf); err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	return readReply(c.r)
+}
+
+// readReply reads a RESP2 reply
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, redisError(payload)
+	case ':':
+		n, err := strconv.ParseInt(payload, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed integer %q", payload)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", payload)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		return string(b[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array length %q", payload)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+	}
+}
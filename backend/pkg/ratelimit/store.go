@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"sami/pkg/config"
+)
+
+// NewStore creates the store selected by cfg.Backend: "memory" or "redis"
+func NewStore(cfg config.RateLimitConfig, redis config.RedisConfig) (Store, error) {
+	switch cfg.Backend {
+	case "memory", "":
+		return NewMemoryStore(), nil
+	case "redis":
+		return NewRedisStore(RedisConfig{
+			Addr:     net.JoinHostPort(redis.Host, strconv.Itoa(redis.Port)),
+			Password: redis.Password,
+			DB:       redis.DB,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend: %s", cfg.Backend)
+	}
+}
+
+// NewLockout creates the login lockout configured by cfg
+func NewLockout(cfg config.RateLimitConfig, store Store) *Lockout {
+	return &Lockout{
+		Store:     store,
+		Threshold: int64(cfg.LockoutThreshold),
+		Base:      cfg.LockoutBase,
+		Max:       cfg.LockoutMax,
+		Window:    cfg.LockoutWindow,
+	}
+}
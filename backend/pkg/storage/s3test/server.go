@@ -0,0 +1,145 @@
+// Package s3test provides a local S3-compatible object store for tests and
+// local development, in the spirit of MinIO. It keeps objects in memory,
+// serves path-style requests for a single bucket and rejects requests not
+// signed with its access key.
+package s3test
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// object is a stored object
+type object struct {
+	data        []byte
+	contentType string
+}
+
+// Server is a mock S3 service served by an httptest.Server
+type Server struct {
+	Server    *httptest.Server
+	Bucket    string
+	AccessKey string
+	SecretKey string
+
+	mu      sync.Mutex
+	objects map[string]object
+}
+
+// NewServer starts a store with one bucket
+func NewServer(bucket, accessKey, secretKey string) *Server {
+	s := &Server{
+		Bucket:    bucket,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		objects:   map[string]object{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// URL returns the endpoint of the store
+func (s *Server) URL() string {
+	return s.Server.URL
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.Server.Close()
+}
+
+// Keys returns the keys of the stored objects, sorted
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Object returns the content and type of a stored object
+func (s *Server) Object(key string) ([]byte, string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	obj, ok := s.objects[key]
+	return obj.data, obj.contentType, ok
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential="+s.AccessKey+"/") ||
+		!strings.Contains(auth, "Signature=") || r.Header.Get("X-Amz-Date") == "" {
+		writeError(w, http.StatusForbidden, "AccessDenied", "Access Denied")
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != s.Bucket {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+	if key == "" {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", "Bucket operations are not supported")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		s.mu.Lock()
+		s.objects[key] = object{data: data, contentType: r.Header.Get("Content-Type")}
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+
+	case http.MethodGet, http.MethodHead:
+		s.mu.Lock()
+		obj, ok := s.objects[key]
+		s.mu.Unlock()
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		if obj.contentType != "" {
+			w.Header().Set("Content-Type", obj.contentType)
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+
+	case http.MethodDelete:
+		s.mu.Lock()
+		delete(s.objects, key)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: code, Message: message})
+}
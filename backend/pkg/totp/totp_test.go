@@ -0,0 +1,69 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 key of the RFC 6238 test vectors ("12345678901234567890")
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeAtRFC6238(t *testing.T) {
+	// RFC 6238 appendix B, truncated to 6 digits
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, expected := range vectors {
+		code, err := CodeAt(rfcSecret, Step(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != expected {
+			t.Errorf("code at %d = %s, expected %s", unix, code, expected)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, _ := CodeAt(rfcSecret, Step(now))
+
+	if step := Validate(rfcSecret, code, now); step != Step(now) {
+		t.Errorf("expected current code to validate, got step %d", step)
+	}
+
+	// One step of clock drift is tolerated, two are not
+	if step := Validate(rfcSecret, code, now.Add(Period)); step != Step(now) {
+		t.Errorf("expected code from previous step to validate, got %d", step)
+	}
+	if step := Validate(rfcSecret, code, now.Add(2*Period)); step != -1 {
+		t.Errorf("expected code two steps old to be rejected, got %d", step)
+	}
+
+	if step := Validate(rfcSecret, "12345", now); step != -1 {
+		t.Errorf("expected short code to be rejected")
+	}
+}
+
+func TestGenerateSecretAndURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("expected 32 character secret, got %q", secret)
+	}
+
+	uri := URI("Sami", "ana@example.com", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/Sami:ana@example.com?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("unexpected URI: %s", uri)
+	}
+}
@@ -26,5 +26,10 @@ func SetupAdminRoutes(r *gin.Engine, adminController *controller.AdminController
 		admin.GET("/users/stats", adminController.GetUserStats) // GET /admin/users/stats
 		admin.PUT("/users/:id", adminController.UpdateUser)     // PUT /admin/users/:id
 		admin.DELETE("/users/:id", adminController.DeleteUser)  // DELETE /admin/users/:id
+
+		// Two-factor authentication
+		admin.DELETE("/users/:id/2fa", adminController.ResetUserTwoFactor)      // DELETE /admin/users/:id/2fa
+		admin.GET("/settings/security", adminController.GetSecuritySettings)    // GET /admin/settings/security
+		admin.PUT("/settings/security", adminController.UpdateSecuritySettings) // PUT /admin/settings/security
 	}
 }
@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"sami/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAttachmentRoutes configures file attachment routes
+func SetupAttachmentRoutes(r *gin.Engine, attachmentController *controller.AttachmentController, authController *controller.AuthController) {
+	// Signed download links work without the Authorization header
+	r.GET("/attachments/:id/content", attachmentController.DownloadSignedAttachment) // GET /attachments/:id/content?user=&expires=&signature=
+
+	// Attachment routes - all protected by authentication middleware
+	attachments := r.Group("/attachments")
+	attachments.Use(authController.AuthMiddleware())
+	{
+		attachments.GET("/:id", attachmentController.GetAttachment)               // GET /attachments/:id
+		attachments.GET("/:id/download", attachmentController.DownloadAttachment) // GET /attachments/:id/download
+		attachments.DELETE("/:id", attachmentController.DeleteAttachment)         // DELETE /attachments/:id
+	}
+
+	comments := r.Group("/comments")
+	comments.Use(authController.AuthMiddleware())
+	{
+		comments.GET("/:id/attachments", attachmentController.GetCommentAttachments)    // GET /comments/:id/attachments
+		comments.POST("/:id/attachments", attachmentController.UploadCommentAttachment) // POST /comments/:id/attachments
+	}
+
+	services := r.Group("/services")
+	services.Use(authController.AuthMiddleware())
+	{
+		services.GET("/:id/attachments", attachmentController.GetServiceAttachments)    // GET /services/:id/attachments
+		services.POST("/:id/attachments", attachmentController.UploadServiceAttachment) // POST /services/:id/attachments
+	}
+
+	projects := r.Group("/projects")
+	projects.Use(authController.AuthMiddleware())
+	{
+		projects.GET("/:id/attachments/usage", attachmentController.GetProjectAttachmentUsage) // GET /projects/:id/attachments/usage
+	}
+}
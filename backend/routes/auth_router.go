@@ -14,6 +14,18 @@ func SetupAuthRoutes(r *gin.Engine, authController *controller.AuthController) {
 		// Public routes (no authentication required)
 		auth.POST("/register", authController.Register)
 		auth.POST("/login", authController.Login)
+		auth.POST("/login/2fa", authController.LoginTwoFactor)
+
+		// Single sign-on with the OpenID provider
+		auth.GET("/oidc/login", authController.StartOIDCLogin)
+		auth.POST("/oidc/callback", authController.OIDCCallback)
+
+		// Password reset and email verification (token sent by email)
+		auth.GET("/password/policy", authController.GetPasswordPolicy)
+		auth.POST("/password/forgot", authController.ForgotPassword)
+		auth.POST("/password/reset", authController.ResetPassword)
+		auth.POST("/email/verify", authController.VerifyEmail)
+		auth.POST("/email/resend", authController.ResendVerification)
 
 		// Protected routes (authentication required)
 		protected := auth.Group("/")
@@ -24,6 +36,13 @@ func SetupAuthRoutes(r *gin.Engine, authController *controller.AuthController) {
 			protected.PUT("/profile", authController.UpdateProfile)
 			protected.POST("/change-password", authController.ChangePassword)
 			protected.POST("/logout", authController.Logout)
+
+			// Two-factor authentication
+			protected.GET("/2fa", authController.GetTwoFactorStatus)
+			protected.POST("/2fa/setup", authController.SetupTwoFactor)
+			protected.POST("/2fa/enable", authController.EnableTwoFactor)
+			protected.POST("/2fa/disable", authController.DisableTwoFactor)
+			protected.POST("/2fa/recovery-codes", authController.RegenerateRecoveryCodes)
 		}
 	}
 
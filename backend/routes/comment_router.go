@@ -14,6 +14,17 @@ func SetupCommentRoutes(r *gin.Engine, commentController *controller.CommentCont
 	{
 		projects.GET("/:id/comments", commentController.GetProjectComments)    // GET /projects/:id/comments
 		projects.POST("/:id/comments", commentController.CreateProjectComment) // POST /projects/:id/comments
+
+		// Issue boards
+		projects.GET("/:id/issues", commentController.GetProjectIssueBoard)             // GET /projects/:id/issues
+		projects.GET("/:id/issues/hotspots", commentController.GetProjectIssueHotspots) // GET /projects/:id/issues/hotspots
+	}
+
+	// Service issue board - protected by authentication middleware
+	services := r.Group("/services")
+	services.Use(authController.AuthMiddleware())
+	{
+		services.GET("/:id/issues", commentController.GetServiceIssueBoard) // GET /services/:id/issues
 	}
 
 	// Individual comment routes - all protected by authentication middleware
@@ -24,5 +35,21 @@ func SetupCommentRoutes(r *gin.Engine, commentController *controller.CommentCont
 		comments.GET("/:id", commentController.GetComment)       // GET /comments/:id
 		comments.PUT("/:id", commentController.UpdateComment)    // PUT /comments/:id
 		comments.DELETE("/:id", commentController.DeleteComment) // DELETE /comments/:id
+
+		// Issue workflow
+		comments.PUT("/:id/issue", commentController.UpdateIssue)                     // PUT /comments/:id/issue
+		comments.GET("/:id/issue/transitions", commentController.GetIssueTransitions) // GET /comments/:id/issue/transitions
+
+		// Threads
+		comments.GET("/:id/thread", commentController.GetCommentThread)      // GET /comments/:id/thread
+		comments.PUT("/:id/thread/lock", commentController.LockThread)       // PUT /comments/:id/thread/lock
+		comments.PUT("/:id/thread/resolve", commentController.ResolveThread) // PUT /comments/:id/thread/resolve
+		comments.PUT("/:id/thread/move", commentController.MoveThread)       // PUT /comments/:id/thread/move
+
+		// Edit history and reactions
+		comments.GET("/:id/revisions", commentController.GetCommentRevisions)             // GET /comments/:id/revisions
+		comments.GET("/:id/reactions", commentController.GetCommentReactions)             // GET /comments/:id/reactions
+		comments.POST("/:id/reactions", commentController.AddCommentReaction)             // POST /comments/:id/reactions
+		comments.DELETE("/:id/reactions/:emoji", commentController.RemoveCommentReaction) // DELETE /comments/:id/reactions/:emoji
 	}
 }
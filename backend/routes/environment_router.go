@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"sami/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupEnvironmentRoutes configures environment and service instance routes
+func SetupEnvironmentRoutes(r *gin.Engine, environmentController *controller.EnvironmentController, authController *controller.AuthController) {
+	// Project environments routes - nested under projects
+	projects := r.Group("/projects")
+	projects.Use(authController.AuthMiddleware())
+	{
+		projects.GET("/:id/environments", environmentController.GetProjectEnvironments)    // GET /projects/:id/environments
+		projects.POST("/:id/environments", environmentController.CreateProjectEnvironment) // POST /projects/:id/environments
+	}
+
+	// Individual environment routes
+	environments := r.Group("/environments")
+	environments.Use(authController.AuthMiddleware())
+	{
+		environments.PUT("/:id", environmentController.UpdateEnvironment)    // PUT /environments/:id
+		environments.DELETE("/:id", environmentController.DeleteEnvironment) // DELETE /environments/:id
+	}
+
+	// Service instances routes - nested under services
+	services := r.Group("/services")
+	services.Use(authController.AuthMiddleware())
+	{
+		services.GET("/:id/instances", environmentController.GetServiceInstances)                      // GET /services/:id/instances
+		services.PUT("/:id/instances/:environment_id", environmentController.UpsertServiceInstance)    // PUT /services/:id/instances/:environment_id
+		services.DELETE("/:id/instances/:environment_id", environmentController.DeleteServiceInstance) // DELETE /services/:id/instances/:environment_id
+	}
+}
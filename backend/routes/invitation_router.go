@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"sami/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupInvitationRoutes configures invitation routes
+func SetupInvitationRoutes(r *gin.Engine, invitationController *controller.InvitationController, authController *controller.AuthController) {
+	// Public routes (no authentication required), the token is the credential
+	invitations := r.Group("/invitations")
+	{
+		invitations.GET("/:token", invitationController.GetInvitation)     // GET /invitations/:token
+		invitations.POST("/accept", invitationController.AcceptInvitation) // POST /invitations/accept
+	}
+
+	// Project invitations - pending invites sent by AddProjectCollaborator
+	projects := r.Group("/projects")
+	projects.Use(authController.AuthMiddleware())
+	{
+		projects.GET("/:id/invitations", invitationController.GetProjectInvitations)                    // GET /projects/:id/invitations
+		projects.DELETE("/:id/invitations/:invitationId", invitationController.RevokeProjectInvitation) // DELETE /projects/:id/invitations/:invitationId
+	}
+
+	// Admin invitations - every pending invite
+	admin := r.Group("/admin")
+	admin.Use(authController.AuthMiddleware())
+	{
+		admin.GET("/invitations", invitationController.GetInvitations)                    // GET /admin/invitations
+		admin.DELETE("/invitations/:invitationId", invitationController.RevokeInvitation) // DELETE /admin/invitations/:invitationId
+	}
+}
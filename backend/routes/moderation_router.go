@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"sami/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupModerationRoutes configures comment moderation routes
+func SetupModerationRoutes(r *gin.Engine, moderationController *controller.ModerationController, authController *controller.AuthController) {
+	// Project moderation routes - all protected by authentication middleware
+	projects := r.Group("/projects")
+	projects.Use(authController.AuthMiddleware())
+	{
+		projects.PUT("/:id/comment-settings", moderationController.UpdateCommentSettings)              // PUT /projects/:id/comment-settings
+		projects.GET("/:id/moderation/reports", moderationController.GetModerationReports)             // GET /projects/:id/moderation/reports
+		projects.GET("/:id/moderation/restrictions", moderationController.GetRestrictions)             // GET /projects/:id/moderation/restrictions
+		projects.POST("/:id/moderation/restrictions", moderationController.CreateRestriction)          // POST /projects/:id/moderation/restrictions
+		projects.DELETE("/:id/moderation/restrictions/:user_id", moderationController.LiftRestriction) // DELETE /projects/:id/moderation/restrictions/:user_id
+	}
+
+	// Report routes - all protected by authentication middleware
+	comments := r.Group("/comments")
+	comments.Use(authController.AuthMiddleware())
+	{
+		comments.POST("/:id/report", moderationController.ReportComment) // POST /comments/:id/report
+	}
+
+	reports := r.Group("/moderation/reports")
+	reports.Use(authController.AuthMiddleware())
+	{
+		reports.PUT("/:id", moderationController.ResolveReport) // PUT /moderation/reports/:id
+	}
+}
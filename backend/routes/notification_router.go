@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"sami/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupNotificationRoutes configures notification inbox routes
+func SetupNotificationRoutes(r *gin.Engine, notificationController *controller.NotificationController, authController *controller.AuthController) {
+	// Notification routes - all protected by authentication middleware
+	notifications := r.Group("/notifications")
+	notifications.Use(authController.AuthMiddleware())
+	{
+		notifications.GET("", notificationController.GetNotifications)                          // GET /notifications
+		notifications.GET("/unread-count", notificationController.GetUnreadCount)               // GET /notifications/unread-count
+		notifications.GET("/preferences", notificationController.GetNotificationPreferences)    // GET /notifications/preferences
+		notifications.PUT("/preferences", notificationController.UpdateNotificationPreferences) // PUT /notifications/preferences
+		notifications.PUT("/read-all", notificationController.MarkAllNotificationsRead)         // PUT /notifications/read-all
+		notifications.PUT("/:id/read", notificationController.MarkNotificationRead)             // PUT /notifications/:id/read
+	}
+}
@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"sami/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupOrganizationRoutes configures organization, team and team grant routes
+func SetupOrganizationRoutes(r *gin.Engine, organizationController *controller.OrganizationController, teamController *controller.TeamController, authController *controller.AuthController) {
+	// Organization routes - protected by authentication middleware
+	organizations := r.Group("/organizations")
+	organizations.Use(authController.AuthMiddleware())
+	{
+		organizations.GET("", organizationController.GetOrganizations)          // GET /organizations
+		organizations.POST("", organizationController.CreateOrganization)       // POST /organizations
+		organizations.GET("/:id", organizationController.GetOrganization)       // GET /organizations/:id
+		organizations.PUT("/:id", organizationController.UpdateOrganization)    // PUT /organizations/:id
+		organizations.DELETE("/:id", organizationController.DeleteOrganization) // DELETE /organizations/:id
+
+		// Organization members routes
+		organizations.GET("/:id/members", organizationController.GetOrganizationMembers)               // GET /organizations/:id/members
+		organizations.POST("/:id/members", organizationController.AddOrganizationMember)               // POST /organizations/:id/members
+		organizations.PUT("/:id/members/:user_id", organizationController.UpdateOrganizationMember)    // PUT /organizations/:id/members/:user_id
+		organizations.DELETE("/:id/members/:user_id", organizationController.RemoveOrganizationMember) // DELETE /organizations/:id/members/:user_id
+
+		// Organization teams routes
+		organizations.GET("/:id/teams", teamController.GetOrganizationTeams) // GET /organizations/:id/teams
+		organizations.POST("/:id/teams", teamController.CreateTeam)          // POST /organizations/:id/teams
+	}
+
+	// Individual team routes
+	teams := r.Group("/teams")
+	teams.Use(authController.AuthMiddleware())
+	{
+		teams.GET("/:id", teamController.GetTeam)                              // GET /teams/:id
+		teams.PUT("/:id", teamController.UpdateTeam)                           // PUT /teams/:id
+		teams.DELETE("/:id", teamController.DeleteTeam)                        // DELETE /teams/:id
+		teams.POST("/:id/members", teamController.AddTeamMember)               // POST /teams/:id/members
+		teams.DELETE("/:id/members/:user_id", teamController.RemoveTeamMember) // DELETE /teams/:id/members/:user_id
+	}
+
+	// Project team grants routes - nested under projects
+	projects := r.Group("/projects")
+	projects.Use(authController.AuthMiddleware())
+	{
+		projects.GET("/:id/teams", teamController.GetProjectTeams)               // GET /projects/:id/teams
+		projects.POST("/:id/teams", teamController.GrantProjectTeam)             // POST /projects/:id/teams
+		projects.PUT("/:id/teams/:team_id", teamController.UpdateProjectTeam)    // PUT /projects/:id/teams/:team_id
+		projects.DELETE("/:id/teams/:team_id", teamController.RevokeProjectTeam) // DELETE /projects/:id/teams/:team_id
+	}
+}
@@ -25,9 +25,30 @@ func SetupProjectRoutes(r *gin.Engine, projectController *controller.ProjectCont
 		// Bulk operations
 		projects.POST("/:id/bulk-save", projectController.BulkSaveProjectData)
 
+		// Service metadata schema operations
+		projects.GET("/:id/metadata-schema", projectController.GetProjectMetadataSchema)    // GET /projects/:id/metadata-schema
+		projects.PUT("/:id/metadata-schema", projectController.UpdateProjectMetadataSchema) // PUT /projects/:id/metadata-schema
+
+		// Export operations
+		projects.GET("/:id/export", projectController.ExportProjectGraph) // GET /projects/:id/export
+
 		// Project collaborators operations
 		projects.GET("/:id/collaborators", projectController.GetProjectCollaborators)             // GET /projects/:id/collaborators
 		projects.POST("/:id/collaborators", projectController.AddProjectCollaborator)             // POST /projects/:id/collaborators
 		projects.DELETE("/:id/collaborators/:email", projectController.RemoveProjectCollaborator) // DELETE /projects/:id/collaborators/:email
+
+		// Ownership transfer operations
+		projects.GET("/:id/transfer", projectController.GetProjectTransfer)       // GET /projects/:id/transfer
+		projects.POST("/:id/transfer", projectController.CreateProjectTransfer)   // POST /projects/:id/transfer
+		projects.DELETE("/:id/transfer", projectController.CancelProjectTransfer) // DELETE /projects/:id/transfer
+	}
+
+	// Transfers offered to the authenticated user
+	transfers := r.Group("/transfers")
+	transfers.Use(authController.AuthMiddleware())
+	{
+		transfers.GET("", projectController.GetIncomingTransfers)         // GET /transfers
+		transfers.POST("/:id/accept", projectController.AcceptTransfer)   // POST /transfers/:id/accept
+		transfers.POST("/:id/decline", projectController.DeclineTransfer) // POST /transfers/:id/decline
 	}
 }
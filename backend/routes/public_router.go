@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"sami/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupPublicRoutes configures the anonymous view of public projects
+func SetupPublicRoutes(r *gin.Engine, publicController *controller.PublicController, authController *controller.AuthController) {
+	// Public routes (no authentication required)
+	r.GET("/projects/public/:slug/graph", publicController.GetPublicGraph) // GET /projects/public/:slug/graph
+
+	// Public view settings - protected by authentication middleware
+	projects := r.Group("/projects")
+	projects.Use(authController.AuthMiddleware())
+	{
+		projects.PUT("/:id/public-view", publicController.UpdatePublicView) // PUT /projects/:id/public-view
+	}
+}
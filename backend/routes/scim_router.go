@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"sami/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupSCIMRoutes configures the SCIM provisioning routes
+func SetupSCIMRoutes(r *gin.Engine, scimController *controller.SCIMController, authController *controller.AuthController) {
+	// SCIM routes - authenticated by a provisioning token
+	scim := r.Group("/scim/v2")
+	scim.Use(scimController.TokenMiddleware())
+	{
+		// Discovery
+		scim.GET("/ServiceProviderConfig", scimController.GetServiceProviderConfig) // GET /scim/v2/ServiceProviderConfig
+		scim.GET("/ResourceTypes", scimController.GetResourceTypes)                 // GET /scim/v2/ResourceTypes
+
+		// Users
+		scim.GET("/Users", scimController.ListUsers)         // GET /scim/v2/Users
+		scim.POST("/Users", scimController.CreateUser)       // POST /scim/v2/Users
+		scim.GET("/Users/:id", scimController.GetUser)       // GET /scim/v2/Users/:id
+		scim.PUT("/Users/:id", scimController.ReplaceUser)   // PUT /scim/v2/Users/:id
+		scim.PATCH("/Users/:id", scimController.PatchUser)   // PATCH /scim/v2/Users/:id
+		scim.DELETE("/Users/:id", scimController.DeleteUser) // DELETE /scim/v2/Users/:id
+
+		// Groups, synced into the teams of the token organization
+		scim.GET("/Groups", scimController.ListGroups)         // GET /scim/v2/Groups
+		scim.POST("/Groups", scimController.CreateGroup)       // POST /scim/v2/Groups
+		scim.GET("/Groups/:id", scimController.GetGroup)       // GET /scim/v2/Groups/:id
+		scim.PUT("/Groups/:id", scimController.ReplaceGroup)   // PUT /scim/v2/Groups/:id
+		scim.PATCH("/Groups/:id", scimController.PatchGroup)   // PATCH /scim/v2/Groups/:id
+		scim.DELETE("/Groups/:id", scimController.DeleteGroup) // DELETE /scim/v2/Groups/:id
+	}
+
+	// Provisioning token management - admin only
+	admin := r.Group("/admin/scim")
+	admin.Use(authController.AuthMiddleware())
+	{
+		admin.GET("/tokens", scimController.GetProvisioningTokens)          // GET /admin/scim/tokens
+		admin.POST("/tokens", scimController.CreateProvisioningToken)       // POST /admin/scim/tokens
+		admin.DELETE("/tokens/:id", scimController.RevokeProvisioningToken) // DELETE /admin/scim/tokens/:id
+	}
+}
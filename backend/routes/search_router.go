@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"sami/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupSearchRoutes configures search routes
+func SetupSearchRoutes(r *gin.Engine, searchController *controller.SearchController, authController *controller.AuthController) {
+	// Search routes - protected by authentication middleware
+	search := r.Group("/search")
+	search.Use(authController.AuthMiddleware())
+	{
+		search.GET("", searchController.Search) // GET /search?q=
+	}
+}
@@ -21,9 +21,17 @@ func SetupServiceRoutes(r *gin.Engine, serviceController *controller.ServiceCont
 	services := r.Group("/services")
 	services.Use(authController.AuthMiddleware())
 	{
+		// Ownership reports across projects
+		services.GET("/owned", serviceController.GetOwnedServices)     // GET /services/owned?scope=all|me|team
+		services.GET("/unowned", serviceController.GetUnownedServices) // GET /services/unowned
+
 		// Service CRUD operations
 		services.GET("/:id", serviceController.GetService)       // GET /services/:id
 		services.PUT("/:id", serviceController.UpdateService)    // PUT /services/:id
 		services.DELETE("/:id", serviceController.DeleteService) // DELETE /services/:id
+
+		// Service ownership and on-call data
+		services.GET("/:id/ownership", serviceController.GetServiceOwnership)    // GET /services/:id/ownership
+		services.PUT("/:id/ownership", serviceController.UpdateServiceOwnership) // PUT /services/:id/ownership
 	}
 }
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"sami/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupTagRoutes configures tag routes
+func SetupTagRoutes(r *gin.Engine, tagController *controller.TagController, authController *controller.AuthController) {
+	tags := r.Group("/tags")
+	tags.Use(authController.AuthMiddleware())
+	{
+		tags.GET("", tagController.GetTags)          // GET /tags
+		tags.POST("", tagController.CreateTag)       // POST /tags
+		tags.PUT("/:id", tagController.UpdateTag)    // PUT /tags/:id (admin only)
+		tags.DELETE("/:id", tagController.DeleteTag) // DELETE /tags/:id (admin only)
+	}
+}
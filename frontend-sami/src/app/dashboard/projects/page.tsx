@@ -25,6 +25,7 @@ import { DropdownMenu, DropdownMenuContent, DropdownMenuItem, DropdownMenuTrigge
 import { Alert, AlertDescription } from '@/components/ui/alert';
 import { Tabs, TabsContent, TabsList, TabsTrigger } from '@/components/ui/tabs';
 import Layout from '@/components/Layout';
+import { fetchAllPages } from '@/lib/api';
 
 const API_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080';
 
@@ -100,35 +101,30 @@ export default function ProjectsPage() {
 
     setError(null);
     try {
-      const response = await fetch(`${API_URL}/projects`, {
-        headers: {
-          'Authorization': `Bearer ${token}`,
-          'Content-Type': 'application/json',
-        },
+      // Status of the page that failed, if any
+      const failure = { status: 0 };
+      const projects = await fetchAllPages<Project>(`${API_URL}/projects`, 'projects', async (url) => {
+        const response = await fetch(url, {
+          headers: {
+            'Authorization': `Bearer ${token}`,
+            'Content-Type': 'application/json',
+          },
+        });
+        if (!response.ok) {
+          failure.status = response.status;
+          console.error('Failed to fetch projects:', response.status, await response.text());
+          return {};
+        }
+        return response.json();
       });
 
-      if (response.ok) {
-        const data = await response.json();
-        console.log('API Response:', data);
-        
-        if (Array.isArray(data)) {
-          setProjects(data);
-        } else if (data && Array.isArray(data.projects)) {
-          setProjects(data.projects);
-        } else if (data && Array.isArray(data.data)) {
-          setProjects(data.data);
-        } else {
-          console.warn('Unexpected API response format:', data);
-          setProjects([]);
-          setError('Received unexpected response format from server');
-        }
-      } else if (response.status === 401) {
+      if (failure.status === 401) {
         router.push('/login');
-      } else {
-        const errorText = await response.text();
-        console.error('Failed to fetch projects:', response.status, errorText);
-        setError(`Failed to fetch projects: ${response.status}`);
+      } else if (failure.status !== 0) {
+        setError(`Failed to fetch projects: ${failure.status}`);
         setProjects([]);
+      } else {
+        setProjects(projects);
       }
     } catch (error) {
       console.error('Error fetching projects:', error);
@@ -165,19 +165,45 @@ const handleResponse = async (response: Response) => {
   return response.json();
 };
 
+// List endpoints are paginated; pages are requested at the maximum size
+const PAGE_LIMIT = 200;
+
+// Loads every page of a list endpoint by following next_cursor. load fetches
+// one page and returns its parsed body, whose items are under key.
+export const fetchAllPages = async <T>(
+  url: string,
+  key: string,
+  load: (pageUrl: string) => Promise<Record<string, unknown>>,
+): Promise<T[]> => {
+  const items: T[] = [];
+  let cursor: string | null = null;
+  do {
+    const pageUrl = new URL(url);
+    pageUrl.searchParams.set('limit', PAGE_LIMIT.toString());
+    if (cursor) {
+      pageUrl.searchParams.set('cursor', cursor);
+    }
+
+    const data = await load(pageUrl.toString());
+    items.push(...((data[key] as T[] | undefined) || []));
+    cursor = (data.pagination as { next_cursor?: string | null } | undefined)?.next_cursor ?? null;
+  } while (cursor);
+  return items;
+};
+
 export const projectApi = {
   // Get all projects
   async getProjects(): Promise<Project[]> {
     const token = getAuthToken();
-    const response = await fetch(`${API_URL}/projects`, {
-      headers: {
-        'Authorization': `Bearer ${token}`,
-        'Content-Type': 'application/json',
-      },
+    return fetchAllPages<Project>(`${API_URL}/projects`, 'projects', async (url) => {
+      const response = await fetch(url, {
+        headers: {
+          'Authorization': `Bearer ${token}`,
+          'Content-Type': 'application/json',
+        },
+      });
+      return handleResponse(response);
     });
-    
-    const data = await handleResponse(response);
-    return data.projects;
   },
 
   // Get project by ID
@@ -252,15 +278,15 @@ export const collaboratorApi = {
   // Get project collaborators
   async getProjectCollaborators(projectId: number): Promise<Collaborator[]> {
     const token = getAuthToken();
-    const response = await fetch(`${API_URL}/projects/${projectId}/collaborators`, {
-      headers: {
-        'Authorization': `Bearer ${token}`,
-        'Content-Type': 'application/json',
-      },
+    return fetchAllPages<Collaborator>(`${API_URL}/projects/${projectId}/collaborators`, 'collaborators', async (url) => {
+      const response = await fetch(url, {
+        headers: {
+          'Authorization': `Bearer ${token}`,
+          'Content-Type': 'application/json',
+        },
+      });
+      return handleResponse(response);
     });
-    
-    const data = await handleResponse(response);
-    return data.collaborators || [];
   },
 
   // Add collaborator to project
@@ -331,15 +357,15 @@ export const commentApi = {
     }
     
     const url = `${API_URL}/comments${searchParams.toString() ? `?${searchParams.toString()}` : ''}`;
-    const response = await fetch(url, {
-      headers: {
-        'Authorization': `Bearer ${token}`,
-        'Content-Type': 'application/json',
-      },
+    return fetchAllPages<Comment>(url, 'comments', async (pageUrl) => {
+      const response = await fetch(pageUrl, {
+        headers: {
+          'Authorization': `Bearer ${token}`,
+          'Content-Type': 'application/json',
+        },
+      });
+      return handleResponse(response);
     });
-    
-    const data = await handleResponse(response);
-    return data.comments || [];
   },
 
   // Get project comments with optional filters
@@ -355,15 +381,15 @@ export const commentApi = {
     }
     
     const url = `${API_URL}/projects/${projectId}/comments${searchParams.toString() ? `?${searchParams.toString()}` : ''}`;
-    const response = await fetch(url, {
-      headers: {
-        'Authorization': `Bearer ${token}`,
-        'Content-Type': 'application/json',
-      },
+    return fetchAllPages<Comment>(url, 'comments', async (pageUrl) => {
+      const response = await fetch(pageUrl, {
+        headers: {
+          'Authorization': `Bearer ${token}`,
+          'Content-Type': 'application/json',
+        },
+      });
+      return handleResponse(response);
     });
-    
-    const data = await handleResponse(response);
-    return data.comments || [];
   },
 
   // Create a new comment
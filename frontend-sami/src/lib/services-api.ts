@@ -1,4 +1,6 @@
 // API functions for services and dependencies
+import { fetchAllPages } from '@/lib/api'
+
 const API_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080'
 
 export interface HealthMetrics {
@@ -124,15 +126,15 @@ const handleResponse = async (response: Response) => {
 export const servicesApi = {
   // Get all services for a project
   async getServices(projectId: number, token: string): Promise<Service[]> {
-    const response = await fetch(`${API_URL}/projects/${projectId}/services`, {
-      headers: {
-        'Authorization': `Bearer ${token}`,
-        'Content-Type': 'application/json',
-      },
+    return fetchAllPages<Service>(`${API_URL}/projects/${projectId}/services`, 'services', async (url) => {
+      const response = await fetch(url, {
+        headers: {
+          'Authorization': `Bearer ${token}`,
+          'Content-Type': 'application/json',
+        },
+      })
+      return handleResponse(response)
     })
-    
-    const data = await handleResponse(response)
-    return data.services || []
   },
 
   // Get a specific service
@@ -211,15 +213,15 @@ export const servicesApi = {
 export const dependenciesApi = {
   // Get all dependencies for a project
   async getDependencies(projectId: number, token: string): Promise<Dependency[]> {
-    const response = await fetch(`${API_URL}/projects/${projectId}/dependencies`, {
-      headers: {
-        'Authorization': `Bearer ${token}`,
-        'Content-Type': 'application/json',
-      },
+    return fetchAllPages<Dependency>(`${API_URL}/projects/${projectId}/dependencies`, 'dependencies', async (url) => {
+      const response = await fetch(url, {
+        headers: {
+          'Authorization': `Bearer ${token}`,
+          'Content-Type': 'application/json',
+        },
+      })
+      return handleResponse(response)
     })
-    
-    const data = await handleResponse(response)
-    return data.dependencies || []
   },
 
   // Get a specific dependency
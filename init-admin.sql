@@ -10,13 +10,14 @@ BEGIN
     SELECT COUNT(*) INTO existing_count FROM users WHERE email = 'admin@sami.local';
     
     IF existing_count = 0 THEN
-        INSERT INTO users (name, email, password_hash, role, status)
+        INSERT INTO users (name, email, password_hash, role, status, email_verified_at)
         VALUES (
             'Administrator',
             'admin@sami.local',
             crypt('admin123', gen_salt('bf')),
             'admin',
-            'active'
+            'active',
+            NOW()
         );
         RAISE NOTICE 'Usuario administrador creado: admin@sami.local / admin123';
     ELSE